@@ -0,0 +1,185 @@
+package gocbcore
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// adaptiveCompressionBuckets is the number of power of two size buckets that compression results are tracked in,
+	// this covers values up to the maximum document size.
+	adaptiveCompressionBuckets = 32
+	// adaptiveCompressionMinSamples is the number of samples that a bucket must hold before it is trusted.
+	adaptiveCompressionMinSamples = 16
+	// adaptiveCompressionWindow is the number of samples after which a bucket is decayed, so that old results age out.
+	adaptiveCompressionWindow = 1024
+	// adaptiveCompressionRecalcInterval is how many samples are recorded between each recalculation of the threshold.
+	adaptiveCompressionRecalcInterval = 64
+	// adaptiveCompressionProbeInterval controls how often a value below the effective threshold is compressed anyway,
+	// so that the threshold can move back down if data becomes more compressible.
+	adaptiveCompressionProbeInterval = 64
+	// adaptiveCompressionMaxNanosPerSavedByte is the most time that compressing a value may take for each byte that it
+	// saves for compression to be worthwhile. This is the time taken to send a byte at 1Gbit/s, so compression which
+	// is slower than this costs more latency than it saves on the network.
+	adaptiveCompressionMaxNanosPerSavedByte = 8
+)
+
+type adaptiveCompressionBucket struct {
+	samples         uint64
+	originalBytes   uint64
+	compressedBytes uint64
+	compressNanos   uint64
+}
+
+// adaptiveCompressor tracks the compression ratio achieved, and the time taken to achieve it, for values of different
+// sizes and uses them to determine the smallest value size which is worth compressing. Compression is worthwhile for
+// a size when it meets the minimum ratio and saves more time on the network than it costs to compress. Until enough
+// data has been gathered the static minimum size is used.
+type adaptiveCompressor struct {
+	staticMinSize int
+	minRatio      float64
+	lowerBound    int
+	upperBound    int
+
+	buckets [adaptiveCompressionBuckets]adaptiveCompressionBucket
+
+	effectiveMinSize int64
+	recorded         uint64
+	probes           uint64
+	recalculating    uint32
+
+	logger *scopedLogger
+}
+
+func newAdaptiveCompressor(staticMinSize int, minRatio float64, lowerBound, upperBound int,
+	logger *scopedLogger) *adaptiveCompressor {
+	if upperBound < lowerBound {
+		upperBound = lowerBound
+	}
+
+	return &adaptiveCompressor{
+		staticMinSize:    staticMinSize,
+		minRatio:         minRatio,
+		lowerBound:       lowerBound,
+		upperBound:       upperBound,
+		effectiveMinSize: int64(staticMinSize),
+		logger:           logger,
+	}
+}
+
+func adaptiveCompressionBucketIdx(size int) int {
+	idx := bits.Len(uint(size)) - 1
+	if idx < 0 {
+		return 0
+	}
+	if idx >= adaptiveCompressionBuckets {
+		return adaptiveCompressionBuckets - 1
+	}
+	return idx
+}
+
+// MinSize returns the current effective minimum size for compression.
+func (ac *adaptiveCompressor) MinSize() int {
+	return int(atomic.LoadInt64(&ac.effectiveMinSize))
+}
+
+// ShouldCompress returns whether a value of the given size should be compressed.
+func (ac *adaptiveCompressor) ShouldCompress(size int) bool {
+	if size > ac.MinSize() {
+		return true
+	}
+	if size <= ac.lowerBound {
+		return false
+	}
+
+	return atomic.AddUint64(&ac.probes, 1)%adaptiveCompressionProbeInterval == 0
+}
+
+// Record records the result of compressing a value, and how long the compression took.
+func (ac *adaptiveCompressor) Record(size, compressedSize int, took time.Duration) {
+	bucket := &ac.buckets[adaptiveCompressionBucketIdx(size)]
+	atomic.AddUint64(&bucket.originalBytes, uint64(size))
+	atomic.AddUint64(&bucket.compressedBytes, uint64(compressedSize))
+	if took > 0 {
+		atomic.AddUint64(&bucket.compressNanos, uint64(took))
+	}
+	atomic.AddUint64(&bucket.samples, 1)
+
+	if atomic.AddUint64(&ac.recorded, 1)%adaptiveCompressionRecalcInterval == 0 {
+		ac.recalculate()
+	}
+}
+
+func (ac *adaptiveCompressor) recalculate() {
+	if !atomic.CompareAndSwapUint32(&ac.recalculating, 0, 1) {
+		return
+	}
+	defer atomic.StoreUint32(&ac.recalculating, 0)
+
+	minSize := -1
+	haveData := false
+	for i := adaptiveCompressionBuckets - 1; i >= 0; i-- {
+		bucket := &ac.buckets[i]
+		samples := atomic.LoadUint64(&bucket.samples)
+		originalBytes := atomic.LoadUint64(&bucket.originalBytes)
+		compressedBytes := atomic.LoadUint64(&bucket.compressedBytes)
+		compressNanos := atomic.LoadUint64(&bucket.compressNanos)
+
+		if samples > adaptiveCompressionWindow {
+			// Decaying here can lose a concurrently recorded sample, this is fine as we only need an approximation.
+			atomic.StoreUint64(&bucket.samples, samples/2)
+			atomic.StoreUint64(&bucket.originalBytes, originalBytes/2)
+			atomic.StoreUint64(&bucket.compressedBytes, compressedBytes/2)
+			atomic.StoreUint64(&bucket.compressNanos, compressNanos/2)
+		}
+
+		if samples < adaptiveCompressionMinSamples || originalBytes == 0 {
+			continue
+		}
+		haveData = true
+
+		// Walk down from the largest bucket, the threshold is the bottom of the smallest contiguous bucket
+		// in which compression is worthwhile.
+		if !ac.worthwhile(originalBytes, compressedBytes, compressNanos) {
+			if minSize >= 0 {
+				break
+			}
+			continue
+		}
+		minSize = (1 << uint(i)) - 1
+	}
+
+	if !haveData {
+		atomic.StoreInt64(&ac.effectiveMinSize, int64(ac.staticMinSize))
+		return
+	}
+
+	if minSize < 0 {
+		// Nothing we've seen has compressed well enough.
+		minSize = ac.upperBound
+	}
+	if minSize < ac.lowerBound {
+		minSize = ac.lowerBound
+	}
+	if minSize > ac.upperBound {
+		minSize = ac.upperBound
+	}
+
+	if int64(minSize) != atomic.SwapInt64(&ac.effectiveMinSize, int64(minSize)) {
+		ac.logger.debugf("Adaptive compression minimum size changed to %d", minSize)
+	}
+}
+
+// worthwhile returns whether compression achieved the minimum ratio, and saved more time sending the bytes which it
+// saved than it took to compress them.
+func (ac *adaptiveCompressor) worthwhile(originalBytes, compressedBytes, compressNanos uint64) bool {
+	if float64(compressedBytes)/float64(originalBytes) > ac.minRatio {
+		return false
+	}
+	if compressedBytes >= originalBytes {
+		return false
+	}
+
+	return compressNanos <= (originalBytes-compressedBytes)*adaptiveCompressionMaxNanosPerSavedByte
+}
@@ -0,0 +1,63 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestAdaptiveCompressorInsufficientData() {
+	ac := newAdaptiveCompressor(32, 0.83, 16, 16*1024, nil)
+
+	for i := 0; i < adaptiveCompressionRecalcInterval; i++ {
+		// Spread the samples across buckets so that none have enough data.
+		ac.Record(1<<uint(5+i%8), 1, 0)
+	}
+
+	suite.Assert().Equal(32, ac.MinSize())
+	suite.Assert().True(ac.ShouldCompress(33))
+}
+
+func (suite *UnitTestSuite) TestAdaptiveCompressorRaisesThreshold() {
+	ac := newAdaptiveCompressor(32, 0.83, 16, 16*1024, nil)
+
+	for i := 0; i < adaptiveCompressionRecalcInterval*4; i++ {
+		// Small values barely compress, large values compress well.
+		ac.Record(100, 95, 0)
+		ac.Record(5000, 1000, 0)
+	}
+
+	suite.Assert().Equal(4095, ac.MinSize())
+	suite.Assert().True(ac.ShouldCompress(5000))
+
+	// Values below the threshold should only occasionally be compressed, to probe the achieved ratio.
+	var probes int
+	for i := 0; i < adaptiveCompressionProbeInterval*2; i++ {
+		if ac.ShouldCompress(1000) {
+			probes++
+		}
+	}
+	suite.Assert().Equal(2, probes)
+}
+
+func (suite *UnitTestSuite) TestAdaptiveCompressorClampsToBounds() {
+	ac := newAdaptiveCompressor(32, 0.83, 64, 2048, nil)
+
+	for i := 0; i < adaptiveCompressionRecalcInterval*2; i++ {
+		ac.Record(40, 10, 0)
+	}
+	suite.Assert().Equal(64, ac.MinSize())
+
+	ac = newAdaptiveCompressor(32, 0.83, 64, 2048, nil)
+	for i := 0; i < adaptiveCompressionRecalcInterval*2; i++ {
+		ac.Record(8000, 7900, 0)
+	}
+	suite.Assert().Equal(2048, ac.MinSize())
+}
+
+func (suite *UnitTestSuite) TestAdaptiveCompressorAccountsForLatency() {
+	ac := newAdaptiveCompressor(32, 0.83, 16, 16*1024, nil)
+
+	for i := 0; i < adaptiveCompressionRecalcInterval*4; i++ {
+		// Both sizes compress well, but compressing the smaller values takes longer than sending the bytes saved.
+		ac.Record(100, 50, 50*adaptiveCompressionMaxNanosPerSavedByte*2)
+		ac.Record(5000, 1000, 4000*adaptiveCompressionMaxNanosPerSavedByte/2)
+	}
+
+	suite.Assert().Equal(4095, ac.MinSize())
+	suite.Assert().True(ac.ShouldCompress(5000))
+}
@@ -3,6 +3,7 @@
 package gocbcore
 
 import (
+	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
@@ -11,20 +12,26 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
 )
 
 // Agent represents the base client handling connections to a Couchbase Server.
 // This is used internally by the higher level classes for communicating with the cluster,
 // it can also be used to perform more advanced operations with a cluster.
 type Agent struct {
+	logger               *scopedLogger
 	clientID             string
 	bucketName           string
 	defaultRetryStrategy RetryStrategy
 
 	pollerController configPollerController
-	kvMux            *kvMux
-	httpMux          *httpMux
-	dialer           *memdClientDialerComponent
+	// httpPoller is used to fetch configs over HTTP when they cannot be fetched using CCCP, it is nil when there is
+	// no bucket.
+	httpPoller *httpConfigController
+	kvMux      *kvMux
+	httpMux    *httpMux
+	dialer     *memdClientDialerComponent
 
 	cfgManager   *configManagementComponent
 	errMap       *errMapComponent
@@ -40,12 +47,15 @@ type Agent struct {
 	search       *searchQueryComponent
 	views        *viewQueryComponent
 	zombieLogger *zombieLoggerComponent
+	clockSkew    *clockSkewComponent
+	cfgUpdates   *configUpdateComponent
 
 	// These connection settings are only ever changed when ForceReconnect or ReconfigureSecurity are called.
 	connectionSettingsLock sync.Mutex
 	auth                   AuthProvider
 	authMechanisms         []AuthMechanism
 	tlsConfig              *dynTLSConfig
+	tlsSettings            tlsSettings
 
 	srvDetails  *srvDetails
 	shutdownSig chan struct{}
@@ -69,10 +79,15 @@ func CreateAgent(config *AgentConfig) (*Agent, error) {
 }
 
 func createAgent(config *AgentConfig) (*Agent, error) {
-	logInfof("SDK Version: gocbcore/%s", goCbCoreVersionStr)
-	logInfof("Creating new agent: %+v", config)
+	logger := newScopedLogger(config.Logger)
+	logger.infof("SDK Version: gocbcore/%s", goCbCoreVersionStr)
+	// The seed cluster config can be large, so is left out of the logged config.
+	logConfig := *config
+	logConfig.SeedConfig.ClusterConfig = nil
+	logger.infof("Creating new agent: %+v", &logConfig)
 
 	c := &Agent{
+		logger:     logger,
 		clientID:   formatCbUID(randomCbUID()),
 		bucketName: config.BucketName,
 
@@ -84,11 +99,16 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 		shutdownSig: make(chan struct{}),
 	}
 
-	tlsConfig, err := setupTLSConfig(config.SeedConfig.MemdAddrs, config.SecurityConfig)
+	if err := validateHTTPRetryJitter(config.ConfigPollerConfig.HTTPRetryJitter); err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := setupTLSConfig(config.SeedConfig.MemdAddrs, config.SecurityConfig, logger)
 	if err != nil {
 		return nil, err
 	}
 	c.tlsConfig = tlsConfig
+	c.tlsSettings = config.SecurityConfig.tlsSettings()
 
 	httpIdleConnTimeout := 1000 * time.Millisecond
 	if config.HTTPConfig.IdleConnectionTimeout > 0 {
@@ -166,6 +186,11 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 		confCccpPollPeriod = config.ConfigPollerConfig.CccpPollPeriod
 	}
 
+	cccpRetryBackoff := config.CccpRetryBackoff
+	if cccpRetryBackoff == nil {
+		cccpRetryBackoff = defaultCCCPRetryBackoff(confCccpPollPeriod, confCccpMaxWait)
+	}
+
 	if config.CompressionConfig.MinSize > 0 {
 		compressionMinSize = config.CompressionConfig.MinSize
 	}
@@ -175,11 +200,23 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 			compressionMinRatio = 1.0
 		}
 	}
+	var compressor *adaptiveCompressor
+	if config.CompressionConfig.Adaptive {
+		lowerBound := 16
+		if config.CompressionConfig.AdaptiveMinSizeLowerBound > 0 {
+			lowerBound = config.CompressionConfig.AdaptiveMinSizeLowerBound
+		}
+		upperBound := 16 * 1024
+		if config.CompressionConfig.AdaptiveMinSizeUpperBound > 0 {
+			upperBound = config.CompressionConfig.AdaptiveMinSizeUpperBound
+		}
+		compressor = newAdaptiveCompressor(compressionMinSize, compressionMinRatio, lowerBound, upperBound, logger)
+	}
 	if c.defaultRetryStrategy == nil {
 		c.defaultRetryStrategy = newFailFastRetryStrategy()
 	}
 
-	c.authMechanisms = authMechanismsFromConfig(config.SecurityConfig.AuthMechanisms, tlsConfig != nil)
+	c.authMechanisms = authMechanismsFromConfig(config.SecurityConfig.AuthMechanisms, tlsConfig != nil, logger)
 
 	httpEpList := routeEndpoints{}
 	var srcHTTPAddrs []routeEndpoint
@@ -211,7 +248,8 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 			zombieLoggerSampleSize = config.OrphanReporterConfig.SampleSize
 		}
 
-		c.zombieLogger = newZombieLoggerComponent(zombieLoggerInterval, zombieLoggerSampleSize)
+		c.zombieLogger = newZombieLoggerComponent(zombieLoggerInterval, zombieLoggerSampleSize,
+			config.OrphanReporterConfig.Callback, c.logger)
 		go c.zombieLogger.Start()
 	}
 
@@ -256,6 +294,9 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 			SrcHTTPAddrs: srcHTTPAddrs,
 			UseTLS:       tlsConfig != nil,
 			SeedNodeAddr: seedNodeAddr,
+
+			AddressResolver: config.AddressResolver,
+			Logger:          c.logger,
 		},
 	)
 
@@ -266,11 +307,17 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 			ServerWaitTimeout:    serverWaitTimeout,
 			KVConnectTimeout:     kvConnectTimeout,
 			ClientID:             c.clientID,
+			Logger:               c.logger,
 			CompressionMinSize:   compressionMinSize,
 			CompressionMinRatio:  compressionMinRatio,
+			Compressor:           compressor,
+			CompressionFilter:    config.CompressionConfig.Filter,
 			DisableDecompression: disableDecompression,
 			NoTLSSeedNode:        config.SecurityConfig.NoTLSSeedNode,
 			ConnBufSize:          kvBufferSize,
+			HealthChecker:        config.HealthChecker,
+			Dialer:               config.MemdDialer,
+			ConnStateNotifier:    newConnectionStateNotifier(config.OnConnectionStateChange),
 		},
 		bootstrapProps{
 			HelloProps: helloProps{
@@ -298,9 +345,13 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 	c.kvMux = newKVMux(
 		kvMuxProps{
 			QueueSize:          maxQueueSize,
+			NodeQueueSize:      config.KVConfig.MaxNodeQueueSize,
+			Logger:             c.logger,
 			PoolSize:           kvPoolSize,
+			ReservedPoolSize:   config.KVConfig.HighPriorityPoolSize,
 			CollectionsEnabled: useCollections,
 			NoTLSSeedNode:      config.SecurityConfig.NoTLSSeedNode,
+			Interceptors:       config.KVInterceptors,
 		},
 		c.cfgManager,
 		c.errMap,
@@ -317,6 +368,10 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 		collectionIDProps{
 			MaxQueueSize:         config.KVConfig.MaxQueueSize,
 			DefaultRetryStrategy: c.defaultRetryStrategy,
+			MaxKeyLength:         config.KVConfig.MaxKeyLength,
+			OnManifestUpdate:     config.OnCollectionManifestUpdate,
+			DefaultTimeout:       config.KVConfig.Timeout,
+			Logger:               c.logger,
 		},
 		c.kvMux,
 		c.tracer,
@@ -327,17 +382,22 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 		c.cfgManager,
 		&httpClientMux{tlsConfig: tlsConfig, auth: config.SecurityConfig.Auth},
 		config.SecurityConfig.NoTLSSeedNode,
+		c.logger,
 	)
 	c.http = newHTTPComponent(
 		httpComponentProps{
 			UserAgent:            userAgent,
 			DefaultRetryStrategy: c.defaultRetryStrategy,
+			HealthChecker:        config.HealthChecker,
+			ManagementTimeout:    config.HTTPConfig.ManagementTimeout,
+			Logger:               c.logger,
 		},
 		httpClientProps{
 			maxIdleConns:        config.HTTPConfig.MaxIdleConns,
 			maxIdleConnsPerHost: config.HTTPConfig.MaxIdleConnsPerHost,
 			idleTimeout:         httpIdleConnTimeout,
 			connectTimeout:      httpConnectTimeout,
+			transportFactory:    config.HTTPTransportFactory,
 			maxConnsPerHost:     config.HTTPConfig.MaxConnsPerHost,
 		},
 		c.httpMux,
@@ -348,16 +408,19 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 	if len(config.SeedConfig.MemdAddrs) == 0 && config.BucketName == "" {
 		// The http poller can't run without a bucket. We don't trigger an error for this case
 		// because AgentGroup users who use memcached buckets on non-default ports will end up here.
-		logDebugf("No bucket name specified and only http addresses specified, not running config poller")
-		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy, nil)
+		c.logger.debugf("No bucket name specified and only http addresses specified, not running config poller")
+		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy, nil, c.logger)
 	} else {
 		if config.SecurityConfig.NoTLSSeedNode {
 			poller = newSeedConfigController(srcHTTPAddrs[0].Address, c.bucketName,
 				httpPollerProperties{
 					httpComponent:        c.http,
 					confHTTPRetryDelay:   confHTTPRetryDelay,
+					confHTTPRetryJitter:  config.ConfigPollerConfig.HTTPRetryJitter,
 					confHTTPRedialPeriod: confHTTPRedialPeriod,
 					confHTTPMaxWait:      confHTTPMaxWait,
+					confHTTPMaxFailures:  config.ConfigPollerConfig.HTTPMaxConsecutiveFailures,
+					logger:               c.logger,
 				}, c.cfgManager)
 		} else {
 			var httpPoller *httpConfigController
@@ -367,19 +430,25 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 					httpPollerProperties{
 						httpComponent:        c.http,
 						confHTTPRetryDelay:   confHTTPRetryDelay,
+						confHTTPRetryJitter:  config.ConfigPollerConfig.HTTPRetryJitter,
 						confHTTPRedialPeriod: confHTTPRedialPeriod,
 						confHTTPMaxWait:      confHTTPMaxWait,
+						confHTTPMaxFailures:  config.ConfigPollerConfig.HTTPMaxConsecutiveFailures,
+						logger:               c.logger,
 					},
 					c.httpMux,
 					c.cfgManager,
 				)
 			}
+			c.httpPoller = httpPoller
 			cccpFetcher := newCCCPConfigFetcher(confCccpMaxWait)
 			poller = newPollerController(
 				newCCCPConfigController(
 					cccpPollerProperties{
-						confCccpPollPeriod: confCccpPollPeriod,
-						cccpConfigFetcher:  cccpFetcher,
+						confCccpPollPeriod:   confCccpPollPeriod,
+						confCccpRetryBackoff: cccpRetryBackoff,
+						cccpConfigFetcher:    cccpFetcher,
+						logger:               c.logger,
 					},
 					c.kvMux,
 					c.cfgManager,
@@ -389,23 +458,40 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 				httpPoller,
 				c.cfgManager,
 				c.isPollingFallbackError,
+				c.logger,
 			)
 			c.cfgManager.SetConfigFetcher(cccpFetcher)
 		}
 		c.pollerController = poller
-		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy, c.pollerController)
+		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy, c.pollerController, c.logger)
 	}
 	c.dialer.AddBootstrapFailHandler(c.diagnostics)
 	c.dialer.AddCCCPUnsupportedHandler(c)
 	c.cfgManager.AddConfigWatcher(c.dialer)
+	if config.OnConfigUpdate != nil {
+		// This must be added after the kv mux so that the config is in use for routing by the time we see it.
+		c.cfgUpdates = newConfigUpdateComponent(config.OnConfigUpdate, c.cfgManager, c.logger)
+		go c.cfgUpdates.Start()
+	}
 
-	c.observe = newObserveComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.kvMux)
-	c.crud = newCRUDComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.errMap, c.kvMux, c.kvMux, disableDecompression, c.kvMux)
-	c.stats = newStatsComponent(c.kvMux, c.defaultRetryStrategy, c.tracer)
-	c.n1ql = newN1QLQueryComponent(c.http, c.cfgManager, c.tracer)
-	c.analytics = newAnalyticsQueryComponent(c.http, c.tracer)
-	c.search = newSearchQueryComponent(c.http, c.cfgManager, c.tracer)
-	c.views = newViewQueryComponent(c.http, c.tracer)
+	c.observe = newObserveComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.kvMux, c.kvMux,
+		config.KVConfig.Timeout, c.logger)
+	c.stats = newStatsComponent(c.kvMux, c.defaultRetryStrategy, c.tracer, config.KVConfig.Timeout, c.logger)
+	if config.KVConfig.ClockSkewCheckInterval > 0 {
+		clockSkewThreshold := 5 * time.Second
+		if config.KVConfig.ClockSkewThreshold > 0 {
+			clockSkewThreshold = config.KVConfig.ClockSkewThreshold
+		}
+		c.clockSkew = newClockSkewComponent(c.stats, c.cfgManager, config.KVConfig.ClockSkewCheckInterval, clockSkewThreshold,
+			c.logger)
+		go c.clockSkew.Start()
+	}
+	c.crud = newCRUDComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.errMap, c.kvMux, c.kvMux, disableDecompression,
+		c.kvMux, c.clockSkew, config.KVConfig.Timeout, c.logger)
+	c.n1ql = newN1QLQueryComponent(c.http, c.cfgManager, c.tracer, config.HTTPConfig.QueryTimeout, c.logger)
+	c.analytics = newAnalyticsQueryComponent(c.http, c.tracer, config.HTTPConfig.AnalyticsTimeout, c.logger)
+	c.search = newSearchQueryComponent(c.http, c.cfgManager, c.tracer, config.HTTPConfig.SearchTimeout, c.logger)
+	c.views = newViewQueryComponent(c.http, c.tracer, config.HTTPConfig.ViewTimeout, c.logger)
 
 	// Kick everything off.
 	cfg := &routeConfig{
@@ -417,6 +503,10 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 	c.httpMux.OnNewRouteConfig(cfg)
 	c.kvMux.OnNewRouteConfig(cfg)
 
+	if len(config.SeedConfig.ClusterConfig) > 0 {
+		c.applySeedClusterConfig(config.SeedConfig.ClusterConfig)
+	}
+
 	if c.pollerController != nil {
 		go c.pollerController.Run()
 	}
@@ -424,10 +514,32 @@ func createAgent(config *AgentConfig) (*Agent, error) {
 	return c, nil
 }
 
+// applySeedClusterConfig applies a config previously exported by an agent, falling back to waiting for a config from
+// the cluster if it cannot be used.
+func (agent *Agent) applySeedClusterConfig(data []byte) {
+	cfg, err := parseConfig(data, "")
+	if err != nil {
+		agent.logger.warnf("Failed to parse seed cluster config, ignoring: %v", err)
+		return
+	}
+
+	if cfg.Name != agent.bucketName {
+		agent.logger.warnf("Seed cluster config is for bucket %q rather than %q, ignoring", cfg.Name, agent.bucketName)
+		return
+	}
+
+	if !agent.cfgManager.OnSeedConfig(cfg) {
+		agent.logger.infof("Seed cluster config could not be applied, ignoring")
+		return
+	}
+
+	agent.logger.debugf("Applied seed cluster config with revision %d", cfg.Rev)
+}
+
 // Close shuts down the agent, disconnecting from all servers and failing
 // any outstanding operations with ErrShutdown.
 func (agent *Agent) Close() error {
-	logInfof("Agent closing")
+	agent.logger.infof("Agent closing")
 	poller := agent.pollerController
 	if poller != nil {
 		poller.Stop()
@@ -439,15 +551,76 @@ func (agent *Agent) Close() error {
 		agent.zombieLogger.Stop()
 	}
 
+	if agent.clockSkew != nil {
+		agent.clockSkew.Stop()
+	}
+
+	if agent.cfgUpdates != nil {
+		agent.cfgUpdates.Stop()
+	}
+
 	// Close the transports so that they don't hold open goroutines.
 	agent.http.Close()
 	close(agent.shutdownSig)
 
-	logInfof("Agent close complete")
+	agent.logger.infof("Agent close complete")
 
 	return routeCloseErr
 }
 
+// DrainOrphanResponses returns, and clears, the orphaned responses currently buffered by the orphan reporter.
+// Responses returned from this function will not be included in the next periodic report.
+// If the orphan reporter is not enabled then this will always return nil.
+func (agent *Agent) DrainOrphanResponses() []ZombieLogEntry {
+	if agent.zombieLogger == nil {
+		return nil
+	}
+
+	return agent.zombieLogger.Drain()
+}
+
+// ReconfigureZombieLogger updates the interval and sample size used by the orphan reporter whilst the agent is running.
+// A zero interval disables reporting until the orphan reporter is reconfigured with a non-zero interval.
+// If the orphan reporter is not enabled then this will return ErrFeatureNotAvailable.
+func (agent *Agent) ReconfigureZombieLogger(interval time.Duration, sampleSize int) error {
+	if interval < 0 {
+		return wrapError(errInvalidArgument, "interval cannot be negative")
+	}
+	if sampleSize <= 0 {
+		return wrapError(errInvalidArgument, "sample size must be greater than zero")
+	}
+	if agent.zombieLogger == nil {
+		return wrapError(errFeatureNotAvailable, "orphan reporter is not enabled")
+	}
+
+	agent.zombieLogger.Reconfigure(interval, sampleSize)
+	return nil
+}
+
+// networkTypeWaitTimeout is the maximum period of time that NetworkType will wait for the first config to be applied.
+const networkTypeWaitTimeout = 2 * time.Second
+
+// NetworkType returns the network type used by the agent. When the configured network type is empty or auto this is
+// resolved from the first config received, if that has not yet been applied then this will wait for up to
+// networkTypeWaitTimeout for it to be.
+func (agent *Agent) NetworkType() (string, error) {
+	select {
+	case <-agent.shutdownSig:
+		return "", errShutdown
+	default:
+	}
+
+	select {
+	case <-agent.cfgManager.FirstConfigApplied():
+	case <-agent.shutdownSig:
+		return "", errShutdown
+	case <-time.After(networkTypeWaitTimeout):
+		return "", wrapError(errUnambiguousTimeout, "timed out waiting for the network type to be resolved")
+	}
+
+	return agent.cfgManager.NetworkType(), nil
+}
+
 // ClientID returns the unique id for this agent
 func (agent *Agent) ClientID() string {
 	return agent.clientID
@@ -513,6 +686,14 @@ func (agent *Agent) HasCollectionsSupport() bool {
 	return agent.kvMux.SupportsCollections()
 }
 
+// HasFeature returns whether the feature was negotiated with the server on all of the current KV connections, so
+// that callers can check whether functionality such as durations or sync replication is available. It returns
+// false if there are no connections, such as before the agent has connected.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) HasFeature(feature memd.HelloFeature) bool {
+	return agent.kvMux.SupportsFeature(feature)
+}
+
 // IsSecure returns whether this client is connected via SSL.
 func (agent *Agent) IsSecure() bool {
 	return agent.kvMux.IsSecure()
@@ -523,6 +704,13 @@ func (agent *Agent) UsingGCCCP() bool {
 	return agent.kvMux.SupportsGCCCP()
 }
 
+// ConfigRev returns the revision and revision epoch of the cluster config currently being used to route requests.
+// ok is false if no config has been applied yet, or the agent has been shut down.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ConfigRev() (rev int64, epoch int64, ok bool) {
+	return agent.kvMux.ConfigRevAndEpoch()
+}
+
 // HasSeenConfig returns whether or not the Agent has seen a valid cluster config. This does not mean that the agent
 // currently has active connections.
 // Volatile: This API is subject to change at any time.
@@ -557,6 +745,29 @@ func (agent *Agent) ConfigSnapshot() (*ConfigSnapshot, error) {
 	return agent.kvMux.ConfigSnapshot()
 }
 
+// KeyToVbucket returns the vbucket that the key belongs to, using the most recently applied config. It returns an
+// error if no config has been applied yet or the bucket does not use vbuckets.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) KeyToVbucket(key []byte) (uint16, error) {
+	return agent.kvMux.KeyToVbucket(key)
+}
+
+// VbucketToServer returns the address of the node hosting the vbucket, using the most recently applied config. A
+// replicaIdx of 0 returns the node hosting the active copy, otherwise the node hosting that replica. It returns
+// ErrInvalidReplica if replicaIdx exceeds the number of replicas configured for the bucket.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) VbucketToServer(vbID uint16, replicaIdx uint32) (string, error) {
+	return agent.kvMux.VbucketToServer(vbID, replicaIdx)
+}
+
+// OperationStats returns a snapshot of the counters of KV operations performed by the agent since it was created.
+// Snapshots are copies and so can be compared over time to find the rates of operations.
+// This is distinct from Stats, which fetches statistics from the server.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) OperationStats() AgentStats {
+	return agent.kvMux.Stats()
+}
+
 // WaitForConfigSnapshot returns a snapshot of the underlying configuration currently in use, once one is available.
 // Volatile: This API is subject to change at any time.
 func (agent *Agent) WaitForConfigSnapshot(deadline time.Time, opts WaitForConfigSnapshotOptions, cb WaitForConfigSnapshotCallback) (PendingOp, error) {
@@ -582,6 +793,71 @@ func (agent *Agent) ForceReconnect() {
 	agent.kvMux.ForceReconnect(tlsConfig, mechs, auth, true)
 }
 
+// ForceConfigRefreshCallback is invoked upon completion of a ForceConfigRefresh operation.
+type ForceConfigRefreshCallback func(error)
+
+// ForceConfigRefresh immediately fetches the current config from the cluster and applies it, replacing the config
+// in use by the agent regardless of revision ordering. This is useful for recovering when the agent is known to be
+// out of sync with the cluster, such as after a network partition heals. The config is fetched using CCCP, falling
+// back to the HTTP management endpoints if that fails. If deadline is reached before a config is applied then the
+// callback is invoked with a timeout error.
+func (agent *Agent) ForceConfigRefresh(deadline time.Time, cb ForceConfigRefreshCallback) {
+	go func() {
+		cb(agent.refreshConfig(deadline, true))
+	}()
+}
+
+// ForceReconfigure triggers an immediate fetch of the config from the cluster, outside of the usual polling, in the
+// same way as ForceConfigRefresh. Unlike ForceConfigRefresh the config is only applied if it is newer than the current
+// config, and the fetch is fire and forget. This is useful when the cluster is known to have changed, such as after a
+// rebalance completes. The config is fetched using CCCP (or HTTP, if CCCP is not in use or fails). Calls made whilst a
+// fetch is in progress, or within a second of a previous call, are ignored.
+func (agent *Agent) ForceReconfigure() {
+	if !agent.cfgManager.BeginReconfigure() {
+		return
+	}
+
+	go func() {
+		err := agent.refreshConfig(time.Time{}, false)
+		if err != nil {
+			agent.logger.debugf("Failed to reconfigure: %v", err)
+		}
+	}()
+}
+
+// refreshConfig fetches the current config from the cluster using CCCP, falling back to HTTP, and applies it. If force
+// is set the config is applied regardless of revision ordering, otherwise only if it is newer than the current config.
+func (agent *Agent) refreshConfig(deadline time.Time, force bool) error {
+	snapshot, err := agent.kvMux.PipelineSnapshot()
+	if err == nil {
+		err = agent.cfgManager.RefreshConfigNow(snapshot, deadline, force)
+		if err == nil {
+			return nil
+		}
+	}
+
+	if agent.httpPoller == nil || errors.Is(err, ErrTimeout) || errors.Is(err, ErrShutdown) {
+		return err
+	}
+
+	agent.logger.debugf("Failed to refresh config using CCCP, falling back to HTTP: %v", err)
+	cfg, err := agent.httpPoller.FetchConfig(deadline)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		agent.cfgManager.OnNewConfig(cfg)
+		return nil
+	}
+
+	if !agent.cfgManager.ForceApplyConfig(cfg) {
+		return wrapError(errCliInternalError, "fetched config was not valid so could not be applied")
+	}
+
+	return nil
+}
+
 // ReconfigureSecurityOptions are the options available to the ReconfigureSecurity function.
 type ReconfigureSecurityOptions struct {
 	UseTLS bool
@@ -631,7 +907,7 @@ func (agent *Agent) ReconfigureSecurity(opts ReconfigureSecurityOptions) error {
 		if opts.TLSRootCAProvider == nil {
 			return wrapError(errInvalidArgument, "must provide TLSRootCAProvider when UseTLS is true")
 		}
-		tlsConfig = createTLSConfig(auth, opts.TLSRootCAProvider)
+		tlsConfig = createTLSConfig(auth, opts.TLSRootCAProvider, agent.tlsSettings)
 	}
 
 	agent.auth = auth
@@ -688,7 +964,7 @@ func (agent *Agent) resetConfig() {
 }
 
 func (agent *Agent) onCCCPNoConfigFromAnyNode(err error) {
-	onCCCPNoConfigFromAnyNode(agent, err)
+	onCCCPNoConfigFromAnyNode(agent, agent.logger, err)
 }
 
 func (agent *Agent) stopped() <-chan struct{} {
@@ -699,7 +975,7 @@ func (agent *Agent) stopped() <-chan struct{} {
 // for checking if we need to try refresh the DNS SRV record that we used to initially connect.
 // Note that we don't need locking around of this because there is only one poller active at any given time
 // and we're blocking it here.
-func onCCCPNoConfigFromAnyNode(agent srvAgent, err error) {
+func onCCCPNoConfigFromAnyNode(agent srvAgent, logger *scopedLogger, err error) {
 	srvDetails := agent.srv()
 	if srvDetails == nil {
 		return
@@ -711,16 +987,16 @@ func onCCCPNoConfigFromAnyNode(agent srvAgent, err error) {
 		return
 	}
 
-	logInfof("Refreshing SRV record: %s", srvDetails.Record)
+	logger.infof("Refreshing SRV record: %s", srvDetails.Record)
 
 	var addrs []*net.SRV
 	for {
 		_, addrs, err = net.LookupSRV(srvDetails.Record.Scheme, srvDetails.Record.Proto, srvDetails.Record.Host)
 		if err != nil {
 			if isLogRedactionLevelFull() {
-				logInfof("Failed to lookup SRV record: %s", redactSystemData(err))
+				logger.infof("Failed to lookup SRV record: %s", redactSystemData(err))
 			} else {
-				logInfof("Failed to lookup SRV record: %s", err)
+				logger.infof("Failed to lookup SRV record: %s", err)
 			}
 		}
 
@@ -751,18 +1027,18 @@ func onCCCPNoConfigFromAnyNode(agent srvAgent, err error) {
 			logAddrs[i].Address = redactSystemData(addr)
 		}
 	}
-	logInfof("Found new addrs for SRV record: %v", logAddrs)
+	logger.infof("Found new addrs for SRV record: %v", logAddrs)
 
 	for _, addr := range addrs {
 		host := fmt.Sprintf("%s:%d", strings.TrimSuffix(addr.Target, "."), addr.Port)
 		for _, seed := range memdAddrs {
 			if host == seed.Address {
-				logInfof("Found already known matching address, not refreshing system")
+				logger.infof("Found already known matching address, not refreshing system")
 				return
 			}
 		}
 	}
-	logInfof("No matching address known, refreshing system")
+	logger.infof("No matching address known, refreshing system")
 
 	agent.resetConfig()
 
@@ -799,7 +1075,7 @@ func onCCCPNoConfigFromAnyNode(agent srvAgent, err error) {
 	agent.setSRVAddrs(kvServerList)
 }
 
-func authMechanismsFromConfig(authMechanisms []AuthMechanism, useTLS bool) []AuthMechanism {
+func authMechanismsFromConfig(authMechanisms []AuthMechanism, useTLS bool, logger *scopedLogger) []AuthMechanism {
 	if len(authMechanisms) == 0 {
 		if useTLS {
 			authMechanisms = []AuthMechanism{PlainAuthMechanism}
@@ -814,18 +1090,22 @@ func authMechanismsFromConfig(authMechanisms []AuthMechanism, useTLS bool) []Aut
 		// The user has specified their own mechanisms and not using TLS so we check if they've set PLAIN.
 		for _, mech := range authMechanisms {
 			if mech == PlainAuthMechanism {
-				logWarnf("PLAIN sends credentials in plaintext, this will cause credential leakage on the network")
+				logger.warnf("PLAIN sends credentials in plaintext, this will cause credential leakage on the network")
 			}
 		}
 	}
 	return authMechanisms
 }
 
-func setupTLSConfig(addrs []string, config SecurityConfig) (*dynTLSConfig, error) {
+func setupTLSConfig(addrs []string, config SecurityConfig, logger *scopedLogger) (*dynTLSConfig, error) {
+	if config.TLSMinVersion != 0 && config.TLSMinVersion < tls.VersionTLS12 {
+		return nil, wrapError(errInvalidArgument, "tls min version must be at least TLS 1.2")
+	}
+
 	var tlsConfig *dynTLSConfig
 	if config.UseTLS {
 		if config.TLSRootCAProvider == nil {
-			logDebugf("TLS enabled with no root ca provider - trusting system cert pool and Capella root CA")
+			logger.debugf("TLS enabled with no root ca provider - trusting system cert pool and Capella root CA")
 
 			pool, err := x509.SystemCertPool()
 			if err != nil {
@@ -837,7 +1117,7 @@ func setupTLSConfig(addrs []string, config SecurityConfig) (*dynTLSConfig, error
 				return pool
 			}
 		}
-		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider)
+		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider, config.tlsSettings())
 	} else {
 		var endsInCloud bool
 		for _, host := range addrs {
@@ -847,7 +1127,7 @@ func setupTLSConfig(addrs []string, config SecurityConfig) (*dynTLSConfig, error
 			}
 		}
 		if endsInCloud {
-			logWarnf("TLS is required when connecting to Couchbase Capella. Please enable TLS by prefixing " +
+			logger.warnf("TLS is required when connecting to Couchbase Capella. Please enable TLS by prefixing " +
 				"the connection string with \"couchbases://\" (note the final 's').")
 		}
 	}
@@ -1,11 +1,17 @@
 package gocbcore
 
 import (
+	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/couchbase/gocbcore/v10/connstr"
@@ -44,10 +50,65 @@ type AgentConfig struct {
 
 	HTTPConfig HTTPConfig
 
+	// HTTPTransportFactory, if set, is used to create a template for the transport used by the HTTP client, allowing
+	// settings such as a Proxy to be applied. The proxy, dialer, connection limits, timeouts and buffer sizes of the
+	// returned transport are used, with any connection limits set in HTTPConfig taking precedence. TLS settings and
+	// TLS dialers on the returned transport are ignored, the TLS configuration of the agent is always used instead,
+	// including for connections tunnelled through an http proxy. Only http proxies are supported for TLS connections.
+	// Volatile: This API is subject to change at any time.
+	HTTPTransportFactory func() *http.Transport
+
+	// MemdDialer, if set, is used to establish the connections to the KV service in place of the default dialer. When
+	// TLS is in use the returned connection is wrapped in TLS by the agent, so it must be a plain connection.
+	// Volatile: This API is subject to change at any time.
+	MemdDialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Logger, if set, is used for the log output of this agent in place of the logger set by SetLogger. Some
+	// messages which are not specific to an agent are always sent to the global logger.
+	// Volatile: This API is subject to change at any time.
+	Logger Logger
+
+	// OnConnectionStateChange, if set, is invoked whenever a KV connection to an endpoint is connected, disconnected or
+	// reconnected, with the address of the endpoint. It is invoked from a separate goroutine to the connection, in the
+	// order that the changes occurred, so a slow callback delays later notifications but not the connection itself.
+	// Volatile: This API is subject to change at any time.
+	OnConnectionStateChange func(endpoint string, state ConnectionState)
+
+	// KVInterceptors are invoked, in order, as each KV request is dispatched and in reverse order as it completes.
+	// Volatile: This API is subject to change at any time.
+	KVInterceptors []KVInterceptor
+
+	// AddressResolver, if set, is invoked with the host and port of each service endpoint extracted from a cluster
+	// config, and the returned host and port are used in their place. This allows addresses which are unreachable, such
+	// as in some NAT environments, to be remapped. IPv6 hosts are passed without enclosing brackets. It is invoked for
+	// every endpoint each time a config is applied so must be cheap.
+	// Volatile: This API is subject to change at any time.
+	AddressResolver func(host string, port int, service ServiceType) (string, int)
+
+	// CccpRetryBackoff, if set, calculates how long the CCCP poller waits before polling again after it has failed to
+	// fetch a config from any node, in place of ConfigPollerConfig.CccpPollPeriod. It is passed the number of
+	// consecutive failed polls. The poller never waits less than CccpPollPeriod, whatever is returned. Defaults to an
+	// exponential backoff starting from CccpPollPeriod and capped at ConfigPollerConfig.CccpMaxWait.
+	CccpRetryBackoff BackoffCalculator
+
 	DefaultRetryStrategy RetryStrategy
 
 	CircuitBreakerConfig CircuitBreakerConfig
 
+	// HealthChecker, if set, is consulted before routing requests to an endpoint.
+	HealthChecker HealthChecker
+
+	// OnConfigUpdate, if set, is invoked for each cluster config applied by the agent, after the config has been
+	// installed for routing. Configs with a stale revision are discarded before this is invoked. The callback is
+	// invoked from its own goroutine so does not hold up config application, but if it falls too far behind then
+	// updates will be dropped.
+	OnConfigUpdate ConfigUpdateCallback
+
+	// OnCollectionManifestUpdate, if set, is invoked whenever a cluster config shows that the collections manifest has
+	// changed. Cached collection IDs from older manifests are invalidated before it is invoked. The callback is invoked
+	// from its own goroutine.
+	OnCollectionManifestUpdate CollectionManifestUpdateCallback
+
 	OrphanReporterConfig OrphanReporterConfig
 
 	TracerConfig TracerConfig
@@ -66,6 +127,8 @@ type OrphanReporterConfig struct {
 	ReportInterval time.Duration
 	// SampleSize is the number of requests which will be reported.
 	SampleSize int
+	// Callback, if set, receives each sampled batch of orphaned responses instead of them being logged.
+	Callback ZombieLoggerCallback
 }
 
 func (config OrphanReporterConfig) fromSpec(spec connstr.ResolvedConnSpec) (OrphanReporterConfig, error) {
@@ -102,6 +165,19 @@ type SecurityConfig struct {
 	UseTLS            bool
 	TLSRootCAProvider func() *x509.CertPool
 
+	// TLSCipherSuites restricts the cipher suites which can be negotiated for TLS 1.2 connections, for both KV and
+	// HTTP. If empty then Go's default cipher suites are used. TLS 1.3 cipher suites are not configurable.
+	TLSCipherSuites []uint16
+
+	// TLSMinVersion is the minimum TLS version which will be negotiated, such as tls.VersionTLS13. If zero then
+	// TLS 1.2 is used, lower versions are not supported.
+	TLSMinVersion uint16
+
+	// TLSPinnedCertFingerprints is the set of SHA-256 fingerprints of the leaf certificates which servers may
+	// present. If not empty then any server presenting a leaf certificate not in the set is rejected, this is in
+	// addition to the usual verification against TLSRootCAProvider.
+	TLSPinnedCertFingerprints [][32]byte
+
 	// NoTLSSeedNode indicates that, even with UseTLS set to true, the SDK should always connect to the seed node
 	// over a non TLS connection. This means that the seed node should ALWAYS be localhost.
 	// This option must be used with the ConfigPollerConfig UseSeedPoller set to true.
@@ -144,6 +220,25 @@ func (config SecurityConfig) fromSpec(spec connstr.ResolvedConnSpec) (SecurityCo
 		config.UseTLS = true
 	}
 
+	if valStr, ok := fetchOption(spec, "tls_cipher_suites"); ok {
+		suites, err := parseTLSCipherSuites(valStr)
+		if err != nil {
+			return SecurityConfig{}, err
+		}
+		config.TLSCipherSuites = suites
+	}
+
+	if valStr, ok := fetchOption(spec, "tls_min_version"); ok {
+		switch valStr {
+		case "1.2":
+			config.TLSMinVersion = tls.VersionTLS12
+		case "1.3":
+			config.TLSMinVersion = tls.VersionTLS13
+		default:
+			return SecurityConfig{}, fmt.Errorf("tls_min_version option must be one of 1.2 or 1.3")
+		}
+	}
+
 	if spec.NSServerHost != nil {
 		config.NoTLSSeedNode = true
 	}
@@ -151,14 +246,70 @@ func (config SecurityConfig) fromSpec(spec connstr.ResolvedConnSpec) (SecurityCo
 	return config, nil
 }
 
+func (config SecurityConfig) tlsSettings() tlsSettings {
+	return tlsSettings{
+		CipherSuites: config.TLSCipherSuites,
+		MinVersion:   config.TLSMinVersion,
+
+		PinnedCertFingerprints: config.TLSPinnedCertFingerprints,
+	}
+}
+
+// parseTLSCipherSuites parses a comma separated list of IANA cipher suite names, only the suites which Go considers
+// secure are accepted.
+func parseTLSCipherSuites(names string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	var suites []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("tls_cipher_suites option contains unknown or insecure cipher suite %s", name)
+		}
+		suites = append(suites, id)
+	}
+
+	if len(suites) == 0 {
+		return nil, fmt.Errorf("tls_cipher_suites option must contain at least one cipher suite")
+	}
+
+	return suites, nil
+}
+
 // CompressionConfig specifies options for controlling compression applied to documents using KV.
 type CompressionConfig struct {
 	Enabled              bool
 	DisableDecompression bool
 	MinSize              int
 	MinRatio             float64
+
+	// Adaptive enables adaptive compression. When enabled the compression ratio achieved for values of different
+	// sizes, and the time taken to compress them, is tracked and used to adjust the minimum size at which values are
+	// compressed, between AdaptiveMinSizeLowerBound and AdaptiveMinSizeUpperBound. Values of a size are only
+	// compressed when doing so meets MinRatio and saves more time sending the value than it costs to compress it.
+	// Until enough data has been gathered MinSize is used.
+	Adaptive bool
+	// AdaptiveMinSizeLowerBound is the smallest minimum size that adaptive compression will use, defaults to 16 bytes.
+	AdaptiveMinSizeLowerBound int
+	// AdaptiveMinSizeUpperBound is the largest minimum size that adaptive compression will use, defaults to 16KiB.
+	AdaptiveMinSizeUpperBound int
+
+	// Filter, if set, is called before a value is compressed and can prevent compression of that value by returning
+	// false, regardless of MinSize and MinRatio. This is useful for values which are already compressed.
+	Filter CompressionFilter
 }
 
+// CompressionFilter is used to decide whether a value with the given datatype may be compressed.
+type CompressionFilter func(value []byte, datatype uint8) bool
+
 func (config CompressionConfig) fromSpec(spec connstr.ResolvedConnSpec) (CompressionConfig, error) {
 	if valStr, ok := fetchOption(spec, "compression"); ok {
 		val, err := strconv.ParseBool(valStr)
@@ -184,6 +335,30 @@ func (config CompressionConfig) fromSpec(spec connstr.ResolvedConnSpec) (Compres
 		config.MinRatio = val
 	}
 
+	if valStr, ok := fetchOption(spec, "compression_adaptive"); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return CompressionConfig{}, fmt.Errorf("compression_adaptive option must be a boolean")
+		}
+		config.Adaptive = val
+	}
+
+	if valStr, ok := fetchOption(spec, "compression_adaptive_min_size_lower_bound"); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil || val < 0 {
+			return CompressionConfig{}, fmt.Errorf("compression_adaptive_min_size_lower_bound option must be a non-negative int")
+		}
+		config.AdaptiveMinSizeLowerBound = int(val)
+	}
+
+	if valStr, ok := fetchOption(spec, "compression_adaptive_min_size_upper_bound"); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil || val < 0 {
+			return CompressionConfig{}, fmt.Errorf("compression_adaptive_min_size_upper_bound option must be a non-negative int")
+		}
+		config.AdaptiveMinSizeUpperBound = int(val)
+	}
+
 	return config, nil
 }
 
@@ -194,6 +369,15 @@ type ConfigPollerConfig struct {
 	HTTPMaxWait      time.Duration
 	CccpMaxWait      time.Duration
 	CccpPollPeriod   time.Duration
+
+	// HTTPMaxConsecutiveFailures is the number of consecutive times that the HTTP poller can fail to connect to an
+	// endpoint before it abandons that endpoint in favour of the others available. Abandoned endpoints become
+	// eligible again once every known endpoint has been abandoned. A value of 0 disables this behaviour.
+	HTTPMaxConsecutiveFailures uint32
+
+	// HTTPRetryJitter is the fraction, between 0 and 1, by which HTTPRetryDelay is randomly varied in either direction
+	// so that pollers across many agents do not retry in lockstep. A value of 0 disables this behaviour.
+	HTTPRetryJitter float64
 }
 
 func (config ConfigPollerConfig) fromSpec(spec connstr.ResolvedConnSpec) (ConfigPollerConfig, error) {
@@ -231,6 +415,18 @@ func (config ConfigPollerConfig) fromSpec(spec connstr.ResolvedConnSpec) (Config
 		config.HTTPRetryDelay = val
 	}
 
+	// This option is experimental
+	if valStr, ok := fetchOption(spec, "http_retry_jitter"); ok {
+		val, err := strconv.ParseFloat(valStr, 64)
+		if err != nil {
+			return ConfigPollerConfig{}, fmt.Errorf("http_retry_jitter option must be a number")
+		}
+		if err := validateHTTPRetryJitter(val); err != nil {
+			return ConfigPollerConfig{}, fmt.Errorf("http_retry_jitter option must be between 0 and 1")
+		}
+		config.HTTPRetryJitter = val
+	}
+
 	if valStr, ok := fetchOption(spec, "http_config_poll_timeout"); ok {
 		val, err := parseDurationOrInt(valStr)
 		if err != nil {
@@ -239,6 +435,15 @@ func (config ConfigPollerConfig) fromSpec(spec connstr.ResolvedConnSpec) (Config
 		config.HTTPMaxWait = val
 	}
 
+	// This option is experimental
+	if valStr, ok := fetchOption(spec, "http_max_consecutive_failures"); ok {
+		val, err := strconv.ParseUint(valStr, 10, 32)
+		if err != nil {
+			return ConfigPollerConfig{}, fmt.Errorf("http_max_consecutive_failures option must be a number")
+		}
+		config.HTTPMaxConsecutiveFailures = uint32(val)
+	}
+
 	return config, nil
 }
 
@@ -261,6 +466,11 @@ type IoConfig struct {
 
 func (config IoConfig) fromSpec(spec connstr.ResolvedConnSpec) (IoConfig, error) {
 	if valStr, ok := fetchOption(spec, "network"); ok {
+		switch valStr {
+		case "default", "external", "auto":
+		default:
+			return IoConfig{}, fmt.Errorf("network option must be one of default, external or auto")
+		}
 		config.NetworkType = valStr
 	}
 
@@ -302,7 +512,9 @@ func (config IoConfig) fromSpec(spec connstr.ResolvedConnSpec) (IoConfig, error)
 
 // TracerConfig specifies tracer related configuration options.
 type TracerConfig struct {
-	Tracer           RequestTracer
+	Tracer RequestTracer
+	// NoRootTraceSpans disables the creation of root spans for all operations, root spans can also be disabled for
+	// individual operations by setting NoRootSpan in their options.
 	NoRootTraceSpans bool
 }
 
@@ -323,6 +535,19 @@ type HTTPConfig struct {
 	// IdleConnTimeout is the maximum amount of time an idle (keep-alive) connection will remain idle before closing
 	// itself.
 	IdleConnectionTimeout time.Duration
+
+	// QueryTimeout is the default timeout applied to query requests which specify neither a Deadline nor a Timeout.
+	QueryTimeout time.Duration
+	// AnalyticsTimeout is the default timeout applied to analytics requests which specify neither a Deadline nor
+	// a Timeout.
+	AnalyticsTimeout time.Duration
+	// SearchTimeout is the default timeout applied to search requests which specify neither a Deadline nor a Timeout.
+	SearchTimeout time.Duration
+	// ViewTimeout is the default timeout applied to view requests which specify neither a Deadline nor a Timeout.
+	ViewTimeout time.Duration
+	// ManagementTimeout is the default timeout applied to HTTP requests made to the management service using
+	// DoHTTPRequest which specify neither a Deadline nor a Timeout.
+	ManagementTimeout time.Duration
 }
 
 func (config HTTPConfig) fromSpec(spec connstr.ResolvedConnSpec) (HTTPConfig, error) {
@@ -366,6 +591,46 @@ func (config HTTPConfig) fromSpec(spec connstr.ResolvedConnSpec) (HTTPConfig, er
 		config.ConnectTimeout = val
 	}
 
+	if valStr, ok := fetchOption(spec, "query_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("query_timeout option must be a duration or a number")
+		}
+		config.QueryTimeout = val
+	}
+
+	if valStr, ok := fetchOption(spec, "analytics_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("analytics_timeout option must be a duration or a number")
+		}
+		config.AnalyticsTimeout = val
+	}
+
+	if valStr, ok := fetchOption(spec, "search_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("search_timeout option must be a duration or a number")
+		}
+		config.SearchTimeout = val
+	}
+
+	if valStr, ok := fetchOption(spec, "view_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("view_timeout option must be a duration or a number")
+		}
+		config.ViewTimeout = val
+	}
+
+	if valStr, ok := fetchOption(spec, "management_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("management_timeout option must be a duration or a number")
+		}
+		config.ManagementTimeout = val
+	}
+
 	return config, nil
 }
 
@@ -379,12 +644,34 @@ type KVConfig struct {
 
 	// The number of connections to create to each node.
 	PoolSize int
+	// HighPriorityPoolSize is the number of connections, out of PoolSize, to each node which are reserved for
+	// operations using OperationPriorityHigh. At least one connection is always left available to all operations.
+	HighPriorityPoolSize int
 	// The maximum number of requests that can be queued waiting to be sent to a node.
 	MaxQueueSize int
+	// MaxNodeQueueSize is the maximum number of requests that can be pending against a node, including those which
+	// have been sent and are awaiting a response. Requests dispatched once it is reached fail immediately with
+	// ErrOverload rather than being queued. A value of 0 disables this limit.
+	MaxNodeQueueSize int
 
 	// Note: if you create multiple agents with different buffer sizes within the same environment then you will
 	// get indeterminate behaviour, the connections may not even use the provided buffer size.
 	ConnectionBufferSize uint
+
+	// ClockSkewCheckInterval is how often the SDK compares the client clock against the clock of each node.
+	// The first check is performed once the agent has bootstrapped. If zero then clock skew detection is disabled.
+	ClockSkewCheckInterval time.Duration
+	// ClockSkewThreshold is the amount of clock skew above which a warning is logged. Expiry values above 30 days
+	// are sent as absolute timestamps based on the client clock so are affected by skew. Defaults to 5 seconds.
+	ClockSkewThreshold time.Duration
+
+	// MaxKeyLength is the maximum length of a document key, including the encoded collection ID, above which
+	// operations are rejected before being sent. Defaults to, and cannot exceed, the server limit of 250 bytes.
+	MaxKeyLength int
+
+	// Timeout is the default timeout applied to KV operations, including observe, range scans, stats and collection
+	// manifest requests, which specify neither a Deadline nor a Timeout.
+	Timeout time.Duration
 }
 
 func (config KVConfig) fromSpec(spec connstr.ResolvedConnSpec) (KVConfig, error) {
@@ -397,6 +684,14 @@ func (config KVConfig) fromSpec(spec connstr.ResolvedConnSpec) (KVConfig, error)
 		config.ConnectTimeout = val
 	}
 
+	if valStr, ok := fetchOption(spec, "kv_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return KVConfig{}, fmt.Errorf("kv_timeout option must be a duration or a number")
+		}
+		config.Timeout = val
+	}
+
 	// This option is experimental
 	if valStr, ok := fetchOption(spec, "kv_pool_size"); ok {
 		val, err := strconv.ParseInt(valStr, 10, 64)
@@ -406,6 +701,15 @@ func (config KVConfig) fromSpec(spec connstr.ResolvedConnSpec) (KVConfig, error)
 		config.PoolSize = int(val)
 	}
 
+	// This option is experimental
+	if valStr, ok := fetchOption(spec, "kv_high_priority_pool_size"); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil {
+			return KVConfig{}, fmt.Errorf("kv high priority pool size option must be a number")
+		}
+		config.HighPriorityPoolSize = int(val)
+	}
+
 	// This option is experimental
 	if valStr, ok := fetchOption(spec, "max_queue_size"); ok {
 		val, err := strconv.ParseInt(valStr, 10, 64)
@@ -415,6 +719,15 @@ func (config KVConfig) fromSpec(spec connstr.ResolvedConnSpec) (KVConfig, error)
 		config.MaxQueueSize = int(val)
 	}
 
+	// This option is experimental
+	if valStr, ok := fetchOption(spec, "max_node_queue_size"); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil {
+			return KVConfig{}, fmt.Errorf("max node queue size option must be a number")
+		}
+		config.MaxNodeQueueSize = int(val)
+	}
+
 	// This option is experimental
 	if valStr, ok := fetchOption(spec, "kv_buffer_size"); ok {
 		val, err := strconv.ParseInt(valStr, 10, 64)
@@ -432,6 +745,22 @@ func (config KVConfig) fromSpec(spec connstr.ResolvedConnSpec) (KVConfig, error)
 		config.ServerWaitBackoff = time.Duration(val) * time.Millisecond
 	}
 
+	if valStr, ok := fetchOption(spec, "clock_skew_check_interval"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return KVConfig{}, fmt.Errorf("clock_skew_check_interval option must be a duration or a number")
+		}
+		config.ClockSkewCheckInterval = val
+	}
+
+	if valStr, ok := fetchOption(spec, "clock_skew_threshold"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return KVConfig{}, fmt.Errorf("clock_skew_threshold option must be a duration or a number")
+		}
+		config.ClockSkewThreshold = val
+	}
+
 	return config, nil
 }
 
@@ -451,23 +780,39 @@ type SeedConfig struct {
 	HTTPAddrs []string
 	MemdAddrs []string
 	SRVRecord *SRVRecord
+
+	// ClusterConfig, if set, is a cluster config previously returned by Agent.ExportClusterConfig. The agent applies
+	// it before fetching a config from the cluster so that requests can be routed immediately. It is ignored if it is
+	// invalid, is for a different bucket or does not contain any of the seed addresses, and is replaced by the first
+	// config fetched from the cluster regardless of revision.
+	// Volatile: This API is subject to change at any time.
+	ClusterConfig []byte
+}
+
+// formatSeedAddress joins host and port, wrapping IPv6 literals in brackets if they are not already.
+func formatSeedAddress(host string, port int) string {
+	if strings.HasPrefix(host, "[") {
+		return fmt.Sprintf("%s:%d", host, port)
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
 }
 
 func (config SeedConfig) fromSpec(spec connstr.ResolvedConnSpec) (SeedConfig, error) {
 	// Grab the resolved hostnames into a set of string arrays
 	var httpHosts []string
 	for _, specHost := range spec.HttpHosts {
-		httpHosts = append(httpHosts, fmt.Sprintf("%s:%d", specHost.Host, specHost.Port))
+		httpHosts = append(httpHosts, formatSeedAddress(specHost.Host, specHost.Port))
 	}
 
 	var memdHosts []string
 	for _, specHost := range spec.MemdHosts {
-		memdHosts = append(memdHosts, fmt.Sprintf("%s:%d", specHost.Host, specHost.Port))
+		memdHosts = append(memdHosts, formatSeedAddress(specHost.Host, specHost.Port))
 	}
 
 	var nsServerHost string
 	if spec.NSServerHost != nil {
-		nsServerHost = fmt.Sprintf("%s:%d", spec.NSServerHost.Host, spec.NSServerHost.Port)
+		nsServerHost = formatSeedAddress(spec.NSServerHost.Host, spec.NSServerHost.Port)
 	}
 
 	if nsServerHost != "" {
@@ -560,6 +905,10 @@ func (config *AgentConfig) redacted() interface{} {
 		if newConfig.BucketName != "" {
 			newConfig.BucketName = redactMetaData(newConfig.BucketName)
 		}
+
+		if newConfig.UserAgent != "" {
+			newConfig.UserAgent = redactMetaData(newConfig.UserAgent)
+		}
 	}
 
 	return newConfig
@@ -579,17 +928,28 @@ func fetchOption(spec connstr.ResolvedConnSpec, name string) (string, bool) {
 //
 //		bootstrap_on (bool) - Specifies what protocol to bootstrap on (cccp, http).
 //		ca_cert_path (string) - Specifies the path to a CA certificate.
-//		network (string) - The network type to use.
+//		tls_cipher_suites (string) - A comma separated list of IANA names of the TLS 1.2 cipher suites to allow.
+//		tls_min_version (string) - The minimum TLS version to negotiate (1.2, 1.3).
+//		network (string) - The network type to use (default, external, auto), auto picks the network the seed nodes are reachable on.
 //		kv_connect_timeout (duration) - Maximum period to attempt to connect to cluster in ms.
+//		kv_timeout (duration) - The default timeout for KV document operations which do not specify one.
 //		config_poll_interval (duration) - Period to wait between CCCP config polling in ms.
 //		config_poll_timeout (duration) - Maximum period of time to wait for a CCCP request.
 //		compression (bool) - Whether to enable network-wise compression of documents.
 //		compression_min_size (int) - The minimal size of the document in bytes to consider compression.
 //		compression_min_ratio (float64) - The minimal compress ratio (compressed / original) for the document to be sent compressed.
+//		compression_adaptive (bool) - Whether to dynamically adjust the minimal size for compression based on the ratios and latency achieved.
+//		compression_adaptive_min_size_lower_bound (int) - The smallest minimal size that adaptive compression will use.
+//		compression_adaptive_min_size_upper_bound (int) - The largest minimal size that adaptive compression will use.
 //		enable_server_durations (bool) - Whether to enable fetching server operation durations.
 //		max_idle_http_connections (int) - Maximum number of idle http connections in the pool.
 //		max_perhost_idle_http_connections (int) - Maximum number of idle http connections in the pool per host.
 //		idle_http_connection_timeout (duration) - Maximum length of time for an idle connection to stay in the pool in ms.
+//		query_timeout (duration) - The default timeout for query requests which do not specify one.
+//		analytics_timeout (duration) - The default timeout for analytics requests which do not specify one.
+//		search_timeout (duration) - The default timeout for search requests which do not specify one.
+//		view_timeout (duration) - The default timeout for view requests which do not specify one.
+//		management_timeout (duration) - The default timeout for management requests which do not specify one.
 //		orphaned_response_logging (bool) - Whether to enable orphaned response logging.
 //		orphaned_response_logging_interval (duration) - How often to print the orphan log records.
 //		orphaned_response_logging_sample_size (int) - The maximum number of orphan log records to track.
@@ -597,10 +957,16 @@ func fetchOption(spec connstr.ResolvedConnSpec, name string) (string, bool) {
 //		enable_dcp_expiry (bool) - Whether to enable the feature to distinguish between explicit delete and expired delete on DCP.
 //		http_redial_period (duration) - The maximum length of time for the HTTP poller to stay connected before reconnecting.
 //		http_retry_delay (duration) - The length of time to wait between HTTP poller retries if connecting fails.
+//		http_retry_jitter (float) - The fraction, between 0 and 1, by which to randomly vary the HTTP poller retry delay.
+//		http_max_consecutive_failures (int) - The number of consecutive failures before the HTTP poller abandons an endpoint.
 //		kv_pool_size (int) - The number of connections to create to each kv node.
+//		kv_high_priority_pool_size (int) - The number of kv connections to each node reserved for high priority operations.
 //		max_queue_size (int) - The maximum number of requests that can be queued for sending per connection.
+//		max_node_queue_size (int) - The maximum number of requests that can be pending against each node.
 //		unordered_execution_enabled (bool) - Whether to enabled the "out of order responses" feature.
 //	 server_wait_backoff (duration) -The period of time waited between kv reconnect attmepts to a node after connection failure
+//		clock_skew_check_interval (duration) - How often to check for clock skew between the client and nodes, disabled if unset.
+//		clock_skew_threshold (duration) - The amount of clock skew above which a warning is logged.
 func (config *AgentConfig) FromConnStr(connStr string) error {
 	baseSpec, err := connstr.Parse(connStr)
 	if err != nil {
@@ -626,6 +992,12 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 		return err
 	}
 
+	return config.optionsFromSpec(spec)
+}
+
+// optionsFromSpec applies the options from the spec which are not related to seed hosts or security.
+func (config *AgentConfig) optionsFromSpec(spec connstr.ResolvedConnSpec) error {
+	var err error
 	config.OrphanReporterConfig, err = config.OrphanReporterConfig.fromSpec(spec)
 	if err != nil {
 		return err
@@ -663,3 +1035,165 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 
 	return nil
 }
+
+// FromEnvironment populates the AgentConfig from environment variables with the given prefix, on top of any values
+// already present. If <prefix>_CONNSTR is set then it is first applied using FromConnStr.
+// Supported variables are:
+//
+//	<prefix>_CONNSTR - A connection string to apply.
+//	<prefix>_BUCKET - The name of the bucket to connect to.
+//	<prefix>_USER - The username to authenticate with.
+//	<prefix>_PASS - The password to authenticate with.
+//	<prefix>_<OPTION> - Any option supported by FromConnStr, e.g. <prefix>_KV_POOL_SIZE for kv_pool_size.
+//	                    These override the value from the connection string. Options relating to seed hosts
+//	                    or security, such as bootstrap_on and ca_cert_path, can only be set in the connection string.
+func (config *AgentConfig) FromEnvironment(prefix string) error {
+	envPrefix := prefix + "_"
+
+	if connStr, ok := os.LookupEnv(envPrefix + "CONNSTR"); ok {
+		if err := config.FromConnStr(connStr); err != nil {
+			return err
+		}
+	}
+
+	spec := connstr.ResolvedConnSpec{
+		Options: make(map[string][]string),
+	}
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], envPrefix) {
+			continue
+		}
+
+		name := parts[0][len(envPrefix):]
+		switch name {
+		case "CONNSTR", "BUCKET", "USER", "PASS":
+			continue
+		}
+
+		optName := strings.ToLower(name)
+		spec.Options[optName] = append(spec.Options[optName], parts[1])
+	}
+
+	if len(spec.Options) > 0 {
+		if err := config.optionsFromSpec(spec); err != nil {
+			return err
+		}
+	}
+
+	if bucket, ok := os.LookupEnv(envPrefix + "BUCKET"); ok {
+		config.BucketName = bucket
+	}
+
+	username, hasUsername := os.LookupEnv(envPrefix + "USER")
+	password, hasPassword := os.LookupEnv(envPrefix + "PASS")
+	if hasUsername || hasPassword {
+		auth, _ := config.SecurityConfig.Auth.(PasswordAuthProvider)
+		if hasUsername {
+			auth.Username = username
+		}
+		if hasPassword {
+			auth.Password = password
+		}
+		config.SecurityConfig.Auth = auth
+	}
+
+	return nil
+}
+
+// Validate checks the AgentConfig for settings which would prevent an Agent from being created or operating
+// correctly. Zero values are treated as "use the default" and are always valid. Certificate trust settings, such as a
+// TLSRootCAProvider or pinned certificates, are rejected unless UseTLS is set. The returned error can be
+// compared against ErrNoSeedAddresses, ErrInvalidTimeout, ErrInvalidCompressionRatio, ErrInvalidPoolSize,
+// ErrAuthTransportMismatch and ErrInvalidArgument using errors.Is.
+func (config *AgentConfig) Validate() error {
+	if len(config.SeedConfig.MemdAddrs) == 0 && len(config.SeedConfig.HTTPAddrs) == 0 {
+		return errNoSeedAddresses
+	}
+
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"KVConfig.ConnectTimeout", config.KVConfig.ConnectTimeout},
+		{"KVConfig.ServerWaitBackoff", config.KVConfig.ServerWaitBackoff},
+		{"KVConfig.ClockSkewCheckInterval", config.KVConfig.ClockSkewCheckInterval},
+		{"KVConfig.ClockSkewThreshold", config.KVConfig.ClockSkewThreshold},
+		{"KVConfig.Timeout", config.KVConfig.Timeout},
+		{"HTTPConfig.ConnectTimeout", config.HTTPConfig.ConnectTimeout},
+		{"HTTPConfig.IdleConnectionTimeout", config.HTTPConfig.IdleConnectionTimeout},
+		{"HTTPConfig.QueryTimeout", config.HTTPConfig.QueryTimeout},
+		{"HTTPConfig.AnalyticsTimeout", config.HTTPConfig.AnalyticsTimeout},
+		{"HTTPConfig.SearchTimeout", config.HTTPConfig.SearchTimeout},
+		{"HTTPConfig.ViewTimeout", config.HTTPConfig.ViewTimeout},
+		{"HTTPConfig.ManagementTimeout", config.HTTPConfig.ManagementTimeout},
+		{"ConfigPollerConfig.HTTPRedialPeriod", config.ConfigPollerConfig.HTTPRedialPeriod},
+		{"ConfigPollerConfig.HTTPRetryDelay", config.ConfigPollerConfig.HTTPRetryDelay},
+		{"ConfigPollerConfig.HTTPMaxWait", config.ConfigPollerConfig.HTTPMaxWait},
+		{"ConfigPollerConfig.CccpMaxWait", config.ConfigPollerConfig.CccpMaxWait},
+		{"ConfigPollerConfig.CccpPollPeriod", config.ConfigPollerConfig.CccpPollPeriod},
+		{"OrphanReporterConfig.ReportInterval", config.OrphanReporterConfig.ReportInterval},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			return wrapError(errInvalidTimeout, fmt.Sprintf("%s must not be negative", timeout.name))
+		}
+	}
+
+	if config.CompressionConfig.MinRatio < 0 || config.CompressionConfig.MinRatio > 1 {
+		return wrapError(errInvalidCompressionRatio, "CompressionConfig.MinRatio must be within [0,1]")
+	}
+
+	if err := validateHTTPRetryJitter(config.ConfigPollerConfig.HTTPRetryJitter); err != nil {
+		return err
+	}
+
+	if config.KVConfig.PoolSize < 0 {
+		return wrapError(errInvalidPoolSize, "KVConfig.PoolSize must not be negative")
+	}
+	if config.KVConfig.HighPriorityPoolSize < 0 {
+		return wrapError(errInvalidPoolSize, "KVConfig.HighPriorityPoolSize must not be negative")
+	}
+	if config.KVConfig.MaxQueueSize < 0 {
+		return wrapError(errInvalidArgument, "KVConfig.MaxQueueSize must not be negative")
+	}
+	if config.KVConfig.MaxNodeQueueSize < 0 {
+		return wrapError(errInvalidArgument, "KVConfig.MaxNodeQueueSize must not be negative")
+	}
+
+	// Trust settings for the server certificate only make sense if the connections are secured.
+	if !config.SecurityConfig.UseTLS {
+		if config.SecurityConfig.TLSRootCAProvider != nil {
+			return wrapError(errInvalidArgument, "SecurityConfig.TLSRootCAProvider requires SecurityConfig.UseTLS")
+		}
+		if len(config.SecurityConfig.TLSPinnedCertFingerprints) > 0 {
+			return wrapError(errInvalidArgument, "SecurityConfig.TLSPinnedCertFingerprints requires SecurityConfig.UseTLS")
+		}
+	}
+	if config.SecurityConfig.TLSMinVersion != 0 && config.SecurityConfig.TLSMinVersion < tls.VersionTLS12 {
+		return wrapError(errInvalidArgument, "SecurityConfig.TLSMinVersion must be at least TLS 1.2")
+	}
+
+	auth := config.SecurityConfig.Auth
+	if auth == nil {
+		return wrapError(errInvalidArgument, "SecurityConfig.Auth must be provided")
+	}
+	if config.SecurityConfig.UseTLS && !auth.SupportsTLS() {
+		return wrapError(errAuthTransportMismatch, "authenticator does not support TLS connections")
+	}
+	if !config.SecurityConfig.UseTLS && !auth.SupportsNonTLS() {
+		return wrapError(errAuthTransportMismatch, "authenticator does not support non-TLS connections")
+	}
+
+	return nil
+}
+
+// validateHTTPRetryJitter checks that the HTTP poller retry jitter is a fraction, a larger jitter could produce zero
+// or negative retry delays.
+func validateHTTPRetryJitter(jitter float64) error {
+	if jitter < 0 || jitter > 1 {
+		return wrapError(errInvalidArgument, "ConfigPollerConfig.HTTPRetryJitter must be within [0,1]")
+	}
+
+	return nil
+}
@@ -1,8 +1,19 @@
 package gocbcore
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
+
+	"github.com/couchbase/gocbcore/v10/connstr"
 )
 
 func (suite *StandardTestSuite) TestAgentConfig_FromConnStr() {
@@ -45,6 +56,155 @@ func (suite *StandardTestSuite) TestAgentConfig_Couchbase1() {
 	}
 }
 
+func (suite *UnitTestSuite) TestAgentConfig_IPv6Hosts() {
+	tests := []struct {
+		name      string
+		connStr   string
+		memdAddrs []string
+		httpAddrs []string
+	}{
+		{
+			name:      "bracketed ipv6",
+			connStr:   "couchbase://[fe80::1]",
+			memdAddrs: []string{"[fe80::1]:11210"},
+			httpAddrs: []string{"[fe80::1]:8091"},
+		},
+		{
+			name:      "bracketed ipv6 with port",
+			connStr:   "couchbase://[fe80::1]:11207",
+			memdAddrs: []string{"[fe80::1]:11207"},
+		},
+		{
+			name:      "ipv4",
+			connStr:   "couchbase://10.112.192.101",
+			memdAddrs: []string{"10.112.192.101:11210"},
+			httpAddrs: []string{"10.112.192.101:8091"},
+		},
+		{
+			name:      "hostname",
+			connStr:   "couchbase://cb.example.com,cb2.example.com",
+			memdAddrs: []string{"cb.example.com:11210", "cb2.example.com:11210"},
+			httpAddrs: []string{"cb.example.com:8091", "cb2.example.com:8091"},
+		},
+	}
+	for _, tt := range tests {
+		suite.T().Run(tt.name, func(t *testing.T) {
+			config := &AgentConfig{}
+			if err := config.FromConnStr(tt.connStr); err != nil {
+				t.Fatalf("Failed to execute FromConnStr: %v", err)
+			}
+
+			suite.Assert().Equal(tt.memdAddrs, config.SeedConfig.MemdAddrs)
+			suite.Assert().Equal(tt.httpAddrs, config.SeedConfig.HTTPAddrs)
+		})
+	}
+
+	// Bare IPv6 literals can be supplied by a resolved connection spec and must be bracketed.
+	seedConfig, err := SeedConfig{}.fromSpec(connstr.ResolvedConnSpec{
+		MemdHosts: []connstr.Address{{Host: "fe80::1", Port: 11210}},
+		HttpHosts: []connstr.Address{{Host: "fe80::1", Port: 8091}},
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal([]string{"[fe80::1]:11210"}, seedConfig.MemdAddrs)
+	suite.Assert().Equal([]string{"[fe80::1]:8091"}, seedConfig.HTTPAddrs)
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_TLSCipherSuites() {
+	config := &AgentConfig{}
+	err := config.FromConnStr("couchbases://10.112.192.101?tls_cipher_suites=" +
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,%20TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal([]uint16{
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	}, config.SecurityConfig.TLSCipherSuites)
+
+	tlsConfig := createTLSConfig(nil, nil, config.SecurityConfig.tlsSettings())
+	hostConfig, err := tlsConfig.MakeForHost("10.112.192.101")
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(config.SecurityConfig.TLSCipherSuites, hostConfig.CipherSuites)
+
+	for _, connStr := range []string{
+		"couchbases://10.112.192.101?tls_cipher_suites=TLS_NOT_A_SUITE",
+		"couchbases://10.112.192.101?tls_cipher_suites=TLS_RSA_WITH_RC4_128_SHA",
+		"couchbases://10.112.192.101?tls_cipher_suites=,",
+	} {
+		config := &AgentConfig{}
+		suite.Assert().NotNil(config.FromConnStr(connStr), connStr)
+	}
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_TLSMinVersion() {
+	config := &AgentConfig{}
+	err := config.FromConnStr("couchbases://10.112.192.101?tls_min_version=1.3")
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(uint16(tls.VersionTLS13), config.SecurityConfig.TLSMinVersion)
+
+	suite.Assert().NotNil(config.FromConnStr("couchbases://10.112.192.101?tls_min_version=1.1"))
+
+	_, err = setupTLSConfig(nil, SecurityConfig{UseTLS: true, TLSMinVersion: tls.VersionTLS11}, nil)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+
+	handshake := func(serverMaxVersion uint16, settings tlsSettings) error {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+		srv.TLS = &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: serverMaxVersion}
+		srv.StartTLS()
+		defer srv.Close()
+
+		pool := x509.NewCertPool()
+		pool.AddCert(srv.Certificate())
+		hostConfig, err := createTLSConfig(nil, func() *x509.CertPool {
+			return pool
+		}, settings).MakeForHost("127.0.0.1")
+		suite.Require().Nil(err, err)
+
+		conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), hostConfig)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	suite.Assert().Nil(handshake(tls.VersionTLS12, tlsSettings{}))
+	suite.Assert().NotNil(handshake(tls.VersionTLS12, tlsSettings{MinVersion: tls.VersionTLS13}))
+	// Servers which only support versions below TLS 1.2 must be rejected by default.
+	suite.Assert().NotNil(handshake(tls.VersionTLS11, tlsSettings{}))
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_TLSPinnedCertFingerprints() {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	handshake := func(caPool *x509.CertPool, fingerprints [][32]byte) error {
+		hostConfig, err := createTLSConfig(nil, func() *x509.CertPool {
+			return caPool
+		}, tlsSettings{PinnedCertFingerprints: fingerprints}).MakeForHost("127.0.0.1")
+		suite.Require().Nil(err, err)
+
+		conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), hostConfig)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	pinned := sha256.Sum256(srv.Certificate().Raw)
+	other := sha256.Sum256([]byte("not a certificate"))
+
+	suite.Assert().Nil(handshake(pool, nil))
+	suite.Assert().Nil(handshake(pool, [][32]byte{other, pinned}))
+	suite.Assert().NotNil(handshake(pool, [][32]byte{other}))
+	// Pinning applies even when certificate verification is skipped.
+	suite.Assert().Nil(handshake(nil, [][32]byte{pinned}))
+	suite.Assert().NotNil(handshake(nil, [][32]byte{other}))
+}
+
 func (suite *StandardTestSuite) TestAgentConfig_Couchbase2() {
 	connStr := "couchbase://10.112.192.101,10.112.192.102"
 
@@ -160,6 +320,7 @@ func (suite *StandardTestSuite) TestAgentConfig_Network() {
 		name     string
 		connStr  string
 		expected string
+		wantErr  bool
 	}{
 		{
 			name:     "external",
@@ -171,12 +332,26 @@ func (suite *StandardTestSuite) TestAgentConfig_Network() {
 			connStr:  "couchbase://10.112.192.101?network=default",
 			expected: "default",
 		},
+		{
+			name:     "auto",
+			connStr:  "couchbase://10.112.192.101?network=auto",
+			expected: "auto",
+		},
+		{
+			name:    "unknown",
+			connStr: "couchbase://10.112.192.101?network=externall",
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		suite.T().Run(tt.name, func(t *testing.T) {
 			config := &AgentConfig{}
-			if err := config.FromConnStr(tt.connStr); err != nil {
-				t.Errorf("FromConnStr() error = %v", err)
+			err := config.FromConnStr(tt.connStr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromConnStr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
 			}
 
 			if config.IoConfig.NetworkType != tt.expected {
@@ -809,6 +984,119 @@ func (suite *StandardTestSuite) TestAgentConfig_HTTPRetryDelay() {
 	}
 }
 
+func (suite *UnitTestSuite) TestAgentConfig_HTTPMaxConsecutiveFailures() {
+	tests := []struct {
+		name     string
+		connStr  string
+		expected uint32
+		wantErr  bool
+	}{
+		{
+			name:     "number",
+			connStr:  "couchbase://10.112.192.101?http_max_consecutive_failures=3",
+			expected: 3,
+		},
+		{
+			name:    "invalid",
+			connStr: "couchbase://10.112.192.101?http_max_consecutive_failures=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "negative",
+			connStr: "couchbase://10.112.192.101?http_max_consecutive_failures=-1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		suite.T().Run(tt.name, func(t *testing.T) {
+			config := &AgentConfig{}
+			if err := config.FromConnStr(tt.connStr); (err != nil) != tt.wantErr {
+				t.Errorf("FromConnStr() error = %v, wanted error = %t", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if config.ConfigPollerConfig.HTTPMaxConsecutiveFailures != tt.expected {
+				suite.T().Fatalf("Expected %d but was %d", tt.expected, config.ConfigPollerConfig.HTTPMaxConsecutiveFailures)
+			}
+		})
+	}
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_HTTPRetryJitter() {
+	tests := []struct {
+		name     string
+		connStr  string
+		expected float64
+		wantErr  bool
+	}{
+		{
+			name:     "fraction",
+			connStr:  "couchbase://10.112.192.101?http_retry_jitter=0.25",
+			expected: 0.25,
+		},
+		{
+			name:    "invalid",
+			connStr: "couchbase://10.112.192.101?http_retry_jitter=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "above one",
+			connStr: "couchbase://10.112.192.101?http_retry_jitter=1.5",
+			wantErr: true,
+		},
+		{
+			name:    "negative",
+			connStr: "couchbase://10.112.192.101?http_retry_jitter=-0.5",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		suite.T().Run(tt.name, func(t *testing.T) {
+			config := &AgentConfig{}
+			if err := config.FromConnStr(tt.connStr); (err != nil) != tt.wantErr {
+				t.Errorf("FromConnStr() error = %v, wanted error = %t", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if config.ConfigPollerConfig.HTTPRetryJitter != tt.expected {
+				suite.T().Fatalf("Expected %f but was %f", tt.expected, config.ConfigPollerConfig.HTTPRetryJitter)
+			}
+		})
+	}
+}
+
+func (suite *UnitTestSuite) TestCreateAgentInvalidHTTPRetryJitter() {
+	config := &AgentConfig{
+		BucketName: "default",
+		SeedConfig: SeedConfig{
+			MemdAddrs: []string{"10.112.192.101:11210"},
+		},
+		ConfigPollerConfig: ConfigPollerConfig{
+			HTTPRetryJitter: 2,
+		},
+	}
+
+	_, err := CreateAgent(config)
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+
+	_, err = CreateDcpAgent(&DCPAgentConfig{
+		BucketName: "default",
+		SeedConfig: SeedConfig{
+			MemdAddrs: []string{"10.112.192.101:11210"},
+		},
+		ConfigPollerConfig: ConfigPollerConfig{
+			HTTPRetryJitter: 2,
+		},
+	}, "stream", 0)
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+}
+
 func (suite *StandardTestSuite) TestAgentConfig_KVPoolSize() {
 	tests := []struct {
 		name     string
@@ -921,3 +1209,382 @@ func (suite *StandardTestSuite) TestAgentConfig_UseClusterMapNotifications() {
 		})
 	}
 }
+
+func (suite *UnitTestSuite) TestAgentConfig_ServiceTimeouts() {
+	tests := []struct {
+		name       string
+		connStr    string
+		expected   HTTPConfig
+		expectedKV time.Duration
+		wantErr    bool
+	}{
+		{
+			name:    "unset",
+			connStr: "couchbase://10.112.192.101",
+		},
+		{
+			name: "numbers",
+			connStr: "couchbase://10.112.192.101?query_timeout=75000&analytics_timeout=80000" +
+				"&search_timeout=60000&view_timeout=70000&management_timeout=90000&kv_timeout=2500",
+			expected: HTTPConfig{
+				QueryTimeout:      75 * time.Second,
+				AnalyticsTimeout:  80 * time.Second,
+				SearchTimeout:     60 * time.Second,
+				ViewTimeout:       70 * time.Second,
+				ManagementTimeout: 90 * time.Second,
+			},
+			expectedKV: 2500 * time.Millisecond,
+		},
+		{
+			name:       "durations",
+			connStr:    "couchbase://10.112.192.101?query_timeout=10s&kv_timeout=2s",
+			expected:   HTTPConfig{QueryTimeout: 10 * time.Second},
+			expectedKV: 2 * time.Second,
+		},
+		{
+			name:    "invalid query",
+			connStr: "couchbase://10.112.192.101?query_timeout=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "invalid analytics",
+			connStr: "couchbase://10.112.192.101?analytics_timeout=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "invalid search",
+			connStr: "couchbase://10.112.192.101?search_timeout=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "invalid view",
+			connStr: "couchbase://10.112.192.101?view_timeout=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "invalid management",
+			connStr: "couchbase://10.112.192.101?management_timeout=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "invalid kv",
+			connStr: "couchbase://10.112.192.101?kv_timeout=squirrel",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		suite.T().Run(tt.name, func(t *testing.T) {
+			config := &AgentConfig{}
+			if err := config.FromConnStr(tt.connStr); (err != nil) != tt.wantErr {
+				t.Errorf("FromConnStr() error = %v, wanted error = %t", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if config.HTTPConfig != tt.expected {
+				t.Fatalf("Expected %+v but was %+v", tt.expected, config.HTTPConfig)
+			}
+			if config.KVConfig.Timeout != tt.expectedKV {
+				t.Fatalf("Expected KV timeout %s but was %s", tt.expectedKV, config.KVConfig.Timeout)
+			}
+		})
+	}
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_FromEnvironment() {
+	env := map[string]string{
+		"GOCBCORE_TEST_CONNSTR":        "couchbase://10.112.192.101?kv_pool_size=2&query_timeout=10000",
+		"GOCBCORE_TEST_BUCKET":         "default",
+		"GOCBCORE_TEST_USER":           "Administrator",
+		"GOCBCORE_TEST_PASS":           "password",
+		"GOCBCORE_TEST_KV_POOL_SIZE":   "4",
+		"GOCBCORE_TEST_MAX_QUEUE_SIZE": "1024",
+	}
+	for k, v := range env {
+		suite.Require().Nil(os.Setenv(k, v))
+	}
+	defer func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}()
+
+	config := &AgentConfig{
+		UserAgent: "test",
+	}
+	err := config.FromEnvironment("GOCBCORE_TEST")
+	suite.Require().Nil(err, err)
+
+	suite.Assert().Equal("test", config.UserAgent)
+	suite.Assert().Equal("default", config.BucketName)
+	suite.Assert().Equal([]string{"10.112.192.101:11210"}, config.SeedConfig.MemdAddrs)
+	suite.Assert().Equal(PasswordAuthProvider{Username: "Administrator", Password: "password"}, config.SecurityConfig.Auth)
+	// The environment variable should override the connection string option.
+	suite.Assert().Equal(4, config.KVConfig.PoolSize)
+	suite.Assert().Equal(1024, config.KVConfig.MaxQueueSize)
+	suite.Assert().Equal(10*time.Second, config.HTTPConfig.QueryTimeout)
+
+	suite.Require().Nil(os.Setenv("GOCBCORE_TEST_KV_POOL_SIZE", "squirrel"))
+	err = (&AgentConfig{}).FromEnvironment("GOCBCORE_TEST")
+	suite.Assert().NotNil(err)
+
+	// Nothing set should leave the config untouched.
+	config = &AgentConfig{BucketName: "existing"}
+	err = config.FromEnvironment("GOCBCORE_TEST_UNSET")
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(&AgentConfig{BucketName: "existing"}, config)
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_Validate() {
+	validConfig := func() AgentConfig {
+		return AgentConfig{
+			SeedConfig: SeedConfig{
+				MemdAddrs: []string{"10.0.0.1:11210"},
+			},
+			SecurityConfig: SecurityConfig{
+				Auth: PasswordAuthProvider{Username: "Administrator", Password: "password"},
+			},
+		}
+	}
+
+	type tCase struct {
+		name     string
+		modify   func(config *AgentConfig)
+		expected error
+	}
+	testCases := []tCase{
+		{
+			name:   "valid",
+			modify: func(config *AgentConfig) {},
+		},
+		{
+			name: "no addresses",
+			modify: func(config *AgentConfig) {
+				config.SeedConfig.MemdAddrs = nil
+			},
+			expected: ErrNoSeedAddresses,
+		},
+		{
+			name: "http addresses only",
+			modify: func(config *AgentConfig) {
+				config.SeedConfig.MemdAddrs = nil
+				config.SeedConfig.HTTPAddrs = []string{"10.0.0.1:8091"}
+			},
+		},
+		{
+			name: "negative kv timeout",
+			modify: func(config *AgentConfig) {
+				config.KVConfig.ConnectTimeout = -1 * time.Second
+			},
+			expected: ErrInvalidTimeout,
+		},
+		{
+			name: "negative kv timeout",
+			modify: func(config *AgentConfig) {
+				config.KVConfig.Timeout = -1 * time.Second
+			},
+			expected: ErrInvalidTimeout,
+		},
+		{
+			name: "negative query timeout",
+			modify: func(config *AgentConfig) {
+				config.HTTPConfig.QueryTimeout = -1 * time.Second
+			},
+			expected: ErrInvalidTimeout,
+		},
+		{
+			name: "negative compression ratio",
+			modify: func(config *AgentConfig) {
+				config.CompressionConfig.MinRatio = -0.5
+			},
+			expected: ErrInvalidCompressionRatio,
+		},
+		{
+			name: "compression ratio above one",
+			modify: func(config *AgentConfig) {
+				config.CompressionConfig.MinRatio = 1.5
+			},
+			expected: ErrInvalidCompressionRatio,
+		},
+		{
+			name: "compression ratio of one",
+			modify: func(config *AgentConfig) {
+				config.CompressionConfig.MinRatio = 1
+			},
+		},
+		{
+			name: "root ca provider without tls",
+			modify: func(config *AgentConfig) {
+				config.SecurityConfig.TLSRootCAProvider = func() *x509.CertPool { return nil }
+			},
+			expected: ErrInvalidArgument,
+		},
+		{
+			name: "pinned certificates without tls",
+			modify: func(config *AgentConfig) {
+				config.SecurityConfig.TLSPinnedCertFingerprints = [][32]byte{{1}}
+			},
+			expected: ErrInvalidArgument,
+		},
+		{
+			name: "root ca provider with tls",
+			modify: func(config *AgentConfig) {
+				config.SecurityConfig.UseTLS = true
+				config.SecurityConfig.TLSRootCAProvider = func() *x509.CertPool { return nil }
+			},
+		},
+		{
+			name: "tls min version too low",
+			modify: func(config *AgentConfig) {
+				config.SecurityConfig.UseTLS = true
+				config.SecurityConfig.TLSMinVersion = tls.VersionTLS11
+			},
+			expected: ErrInvalidArgument,
+		},
+		{
+			name: "negative http retry jitter",
+			modify: func(config *AgentConfig) {
+				config.ConfigPollerConfig.HTTPRetryJitter = -0.1
+			},
+			expected: ErrInvalidArgument,
+		},
+		{
+			name: "http retry jitter above one",
+			modify: func(config *AgentConfig) {
+				config.ConfigPollerConfig.HTTPRetryJitter = 1.5
+			},
+			expected: ErrInvalidArgument,
+		},
+		{
+			name: "negative max node queue size",
+			modify: func(config *AgentConfig) {
+				config.KVConfig.MaxNodeQueueSize = -1
+			},
+			expected: ErrInvalidArgument,
+		},
+		{
+			name: "negative pool size",
+			modify: func(config *AgentConfig) {
+				config.KVConfig.PoolSize = -1
+			},
+			expected: ErrInvalidPoolSize,
+		},
+		{
+			name: "no auth",
+			modify: func(config *AgentConfig) {
+				config.SecurityConfig.Auth = nil
+			},
+			expected: ErrInvalidArgument,
+		},
+		{
+			name: "certificate auth without tls",
+			modify: func(config *AgentConfig) {
+				config.SecurityConfig.Auth = CertificateAuthenticator{}
+			},
+			expected: ErrAuthTransportMismatch,
+		},
+		{
+			name: "certificate auth with tls",
+			modify: func(config *AgentConfig) {
+				config.SecurityConfig.UseTLS = true
+				config.SecurityConfig.Auth = CertificateAuthenticator{}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			config := validConfig()
+			tc.modify(&config)
+
+			err := config.Validate()
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("Expected error to be %v but was %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_RedactedUserAgent() {
+	SetLogRedactionLevel(RedactFull)
+	defer SetLogRedactionLevel(RedactNone)
+
+	config := &AgentConfig{
+		BucketName: "default",
+		UserAgent:  "myapp/tenant-1234",
+		SeedConfig: SeedConfig{
+			MemdAddrs: []string{"10.112.192.101:11210"},
+			HTTPAddrs: []string{"10.112.192.101:8091"},
+		},
+	}
+
+	redacted, ok := config.redacted().(AgentConfig)
+	suite.Require().True(ok)
+
+	suite.Assert().Equal("<md>myapp/tenant-1234</md>", redacted.UserAgent)
+	suite.Assert().Equal("<md>default</md>", redacted.BucketName)
+	suite.Assert().Equal([]string{"<sd>10.112.192.101:11210</sd>"}, redacted.SeedConfig.MemdAddrs)
+
+	// The original config must be untouched and must not share slices with the copy.
+	suite.Assert().Equal("myapp/tenant-1234", config.UserAgent)
+	suite.Assert().Equal("default", config.BucketName)
+	redacted.SeedConfig.MemdAddrs[0] = "changed"
+	redacted.SeedConfig.HTTPAddrs[0] = "changed"
+	suite.Assert().Equal([]string{"10.112.192.101:11210"}, config.SeedConfig.MemdAddrs)
+	suite.Assert().Equal([]string{"10.112.192.101:8091"}, config.SeedConfig.HTTPAddrs)
+}
+
+func (suite *UnitTestSuite) TestAgentConfig_CompressionAdaptiveBounds() {
+	tests := []struct {
+		name          string
+		connStr       string
+		expectedLower int
+		expectedUpper int
+		wantErr       bool
+	}{
+		{
+			name:          "both",
+			connStr:       "couchbase://10.112.192.101?compression_adaptive_min_size_lower_bound=64&compression_adaptive_min_size_upper_bound=4096",
+			expectedLower: 64,
+			expectedUpper: 4096,
+		},
+		{
+			name:    "invalid lower",
+			connStr: "couchbase://10.112.192.101?compression_adaptive_min_size_lower_bound=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "negative upper",
+			connStr: "couchbase://10.112.192.101?compression_adaptive_min_size_upper_bound=-1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		suite.T().Run(tt.name, func(t *testing.T) {
+			config := &AgentConfig{}
+			if err := config.FromConnStr(tt.connStr); (err != nil) != tt.wantErr {
+				t.Errorf("FromConnStr() error = %v, wanted error = %t", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if config.CompressionConfig.AdaptiveMinSizeLowerBound != tt.expectedLower {
+				suite.T().Fatalf("Expected %d but was %d", tt.expectedLower, config.CompressionConfig.AdaptiveMinSizeLowerBound)
+			}
+			if config.CompressionConfig.AdaptiveMinSizeUpperBound != tt.expectedUpper {
+				suite.T().Fatalf("Expected %d but was %d", tt.expectedUpper, config.CompressionConfig.AdaptiveMinSizeUpperBound)
+			}
+		})
+	}
+}
@@ -34,10 +34,49 @@ func (agent *Agent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplicaCallba
 	return agent.crud.GetOneReplica(opts, cb)
 }
 
+// BulkGetCallback is invoked upon completion of a BulkGet operation.
+type BulkGetCallback func(*BulkGetResult)
+
+// BulkGet retrieves many documents, with at most BulkGetOptions.Concurrency gets outstanding at once. The callback
+// is invoked once every key has either been fetched or failed, each key has its own error. Keys which have not been
+// fetched by the time the deadline passes, or the operation is cancelled, fail with a timeout or cancellation error
+// whilst the results of any others are still returned.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) BulkGet(opts BulkGetOptions, cb BulkGetCallback) (PendingOp, error) {
+	return agent.crud.BulkGet(opts, cb)
+}
+
+// GetAllReplicasCallback is invoked for each of the individual reads performed by a GetAllReplicas operation.
+// A replicaIdx of 0 indicates the active, and -1 indicates that the operation failed before any reads were sent.
+type GetAllReplicasCallback func(replicaIdx int, result *GetReplicaResult, err error)
+
+// GetAllReplicas retrieves a document from the active and every replica concurrently. The callback is invoked as
+// each response arrives, once for the active and once for each replica, unless the operation fails before any
+// reads are sent in which case it is invoked only once. Cancelling the returned operation cancels any reads that
+// are still outstanding, such as once the first response has been received.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetAllReplicas(opts GetAllReplicasOptions, cb GetAllReplicasCallback) (PendingOp, error) {
+	return agent.crud.GetAllReplicas(opts, cb)
+}
+
+// GetAnyReplicaCallback is invoked upon completion of a GetAnyReplica operation. A replicaIdx of 0 indicates that
+// the result came from the active, and -1 indicates that the operation failed.
+type GetAnyReplicaCallback func(replicaIdx int, result *GetReplicaResult, err error)
+
+// GetAnyReplica retrieves a document from the active and every replica concurrently, invoking the callback once
+// with the first successful response and cancelling the remaining reads. If no copy of the document could be read
+// then the operation fails with ErrDocumentNotFound, unless the reads timed out or were cancelled.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetAnyReplica(opts GetAnyReplicaOptions, cb GetAnyReplicaCallback) (PendingOp, error) {
+	return agent.crud.GetAnyReplica(opts, cb)
+}
+
 // TouchCallback is invoked upon completion of a Touch operation.
 type TouchCallback func(*TouchResult, error)
 
-// Touch updates the expiry for a document.
+// Touch updates the expiry for a document without fetching it, returning the new CAS and mutation token. As with
+// the write operations an expiry of up to 30 days is relative to now, and anything larger is an absolute unix
+// timestamp, see ExpiryFromDuration. An expiry of 0 removes any existing expiry.
 func (agent *Agent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp, error) {
 	return agent.crud.Touch(opts, cb)
 }
@@ -79,12 +118,14 @@ func (agent *Agent) Replace(opts ReplaceOptions, cb StoreCallback) (PendingOp, e
 // AdjoinCallback is invoked upon completion of a Append or Prepend operation.
 type AdjoinCallback func(*AdjoinResult, error)
 
-// Append appends some bytes to a document.
+// Append appends some bytes to a document. The value is treated as raw bytes, so no datatype flags are set on it.
+// The document must already exist, otherwise the operation fails with ErrNotStored.
 func (agent *Agent) Append(opts AdjoinOptions, cb AdjoinCallback) (PendingOp, error) {
 	return agent.crud.Append(opts, cb)
 }
 
-// Prepend prepends some bytes to a document.
+// Prepend prepends some bytes to a document. The value is treated as raw bytes, so no datatype flags are set on it.
+// The document must already exist, otherwise the operation fails with ErrNotStored.
 func (agent *Agent) Prepend(opts AdjoinOptions, cb AdjoinCallback) (PendingOp, error) {
 	return agent.crud.Prepend(opts, cb)
 }
@@ -93,11 +134,13 @@ func (agent *Agent) Prepend(opts AdjoinOptions, cb AdjoinCallback) (PendingOp, e
 type CounterCallback func(*CounterResult, error)
 
 // Increment increments the unsigned integer value in a document.
+// See CounterOptions for how a document which does not exist is handled.
 func (agent *Agent) Increment(opts CounterOptions, cb CounterCallback) (PendingOp, error) {
 	return agent.crud.Increment(opts, cb)
 }
 
 // Decrement decrements the unsigned integer value in a document.
+// See CounterOptions for how a document which does not exist is handled.
 func (agent *Agent) Decrement(opts CounterOptions, cb CounterCallback) (PendingOp, error) {
 	return agent.crud.Decrement(opts, cb)
 }
@@ -146,6 +189,15 @@ func (agent *Agent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp, error
 	return agent.stats.Stats(opts, cb)
 }
 
+// GetAllVBucketSeqnosCallback is invoked upon completion of a GetAllVBucketSeqnos operation.
+type GetAllVBucketSeqnosCallback func([]VBucketSeqno, error)
+
+// GetAllVBucketSeqnos retrieves the high sequence number of every active vbucket in the bucket, sorted by vbucket
+// id. A single request is sent to each node. If any node fails then the operation fails.
+func (agent *Agent) GetAllVBucketSeqnos(opts GetAllVBucketSeqnosOptions, cb GetAllVBucketSeqnosCallback) (PendingOp, error) {
+	return agent.stats.GetAllVBucketSeqnos(opts, cb)
+}
+
 // ObserveCallback is invoked upon completion of a Observe operation.
 type ObserveCallback func(*ObserveResult, error)
 
@@ -163,6 +215,16 @@ func (agent *Agent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallback) (Pend
 	return agent.observe.ObserveVb(opts, cb)
 }
 
+// ObserveDurabilityCallback is invoked upon completion of a ObserveDurability operation.
+type ObserveDurabilityCallback func(*ObserveDurabilityResult, error)
+
+// ObserveDurability repeatedly observes a mutation on the active and its replicas until it has been replicated
+// and persisted to the requested number of nodes. This can be used to achieve durability against clusters which do
+// not support synchronous durability levels.
+func (agent *Agent) ObserveDurability(opts ObserveDurabilityOptions, cb ObserveDurabilityCallback) (PendingOp, error) {
+	return agent.observe.ObserveDurability(opts, cb)
+}
+
 // SubDocOp defines a per-operation structure to be passed to MutateIn
 // or LookupIn for performing many sub-document operations.
 type SubDocOp struct {
@@ -238,11 +300,21 @@ func (agent *Agent) DoHTTPRequest(req *HTTPRequest, cb DoHTTPRequestCallback) (P
 type GetCollectionManifestCallback func(*GetCollectionManifestResult, error)
 
 // GetCollectionManifest fetches the current server manifest. This function will not update the client's collection
-// id cache.
+// id cache. If collections are not enabled then a manifest containing only the default scope and collection is returned.
 func (agent *Agent) GetCollectionManifest(opts GetCollectionManifestOptions, cb GetCollectionManifestCallback) (PendingOp, error) {
 	return agent.collections.GetCollectionManifest(opts, cb)
 }
 
+// WarmCollectionsCallback is invoked upon completion of a WarmCollections operation.
+type WarmCollectionsCallback func(*WarmCollectionsResult, error)
+
+// WarmCollections resolves and caches the collection IDs for the given collections in parallel, so that the first
+// operation against each collection does not incur the latency of resolving the ID. A failure to resolve a collection
+// is reported against that collection in the result rather than failing the whole operation.
+func (agent *Agent) WarmCollections(opts WarmCollectionsOptions, cb WarmCollectionsCallback) (PendingOp, error) {
+	return agent.collections.WarmCollections(opts, cb)
+}
+
 // GetAllCollectionManifestsCallback is invoked upon completion of a GetAllCollectionManifests operation.
 type GetAllCollectionManifestsCallback func(*GetAllCollectionManifestsResult, error)
 
@@ -276,7 +348,14 @@ func (agent *Agent) Ping(opts PingOptions, cb PingCallback) (PendingOp, error) {
 // Mainly containing a list of open connections and their current
 // states.
 func (agent *Agent) Diagnostics(opts DiagnosticsOptions) (*DiagnosticInfo, error) {
-	return agent.diagnostics.Diagnostics(opts)
+	info, err := agent.diagnostics.Diagnostics(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	info.ClockSkew = agent.clockSkew.Skews()
+
+	return info, nil
 }
 
 // WaitUntilReadyCallback is invoked upon completion of a WaitUntilReady operation.
@@ -299,6 +378,17 @@ type RangeScanContinueActionCallback func(*RangeScanContinueResult, error)
 // RangeScanCancelCallback is invoked upon completion of a RangeScanCancel operation.
 type RangeScanCancelCallback func(*RangeScanCancelResult, error)
 
+// RangeScanCallback is invoked once a RangeScan operation has started.
+// Volatile: This API is subject to change at any time.
+type RangeScanCallback func(*RangeScanRowReader, error)
+
+// RangeScan scans every vbucket for the keys beginning with a prefix, creating, continuing and cancelling the
+// underlying range scans as required.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) RangeScan(opts RangeScanOptions, cb RangeScanCallback) (PendingOp, error) {
+	return agent.crud.RangeScan(opts, cb)
+}
+
 // WaitForConfigSnapshotOptions encapsulates the parameters for a WaitForConfigSnapshot operation.
 // Volatile: This API is subject to change at any time.
 type WaitForConfigSnapshotOptions struct {
@@ -0,0 +1,101 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// AgentStats is a snapshot of the counters of KV operations performed by an agent since it was created.
+// Volatile: This API is subject to change at any time.
+type AgentStats struct {
+	// KVOpsIssued is the number of operations dispatched.
+	KVOpsIssued uint64
+	// KVOpsCompleted is the number of operations which completed successfully.
+	KVOpsCompleted uint64
+	// KVOpsRetried is the total number of retry attempts made across all finished operations.
+	KVOpsRetried uint64
+	// KVOpsFailed is the number of operations which failed, the sum of the KVOpsFailed* counters.
+	KVOpsFailed uint64
+
+	// KVOpsFailedTimeout is the number of operations which failed due to timing out.
+	KVOpsFailedTimeout uint64
+	// KVOpsFailedCanceled is the number of operations which failed due to being canceled.
+	KVOpsFailedCanceled uint64
+	// KVOpsFailedDocument is the number of operations which failed due to the state of the document, such as it
+	// not existing, already existing, being locked or a CAS mismatch.
+	KVOpsFailedDocument uint64
+	// KVOpsFailedTemporary is the number of operations which failed due to a temporary condition, such as a
+	// temporary failure or overload.
+	KVOpsFailedTemporary uint64
+	// KVOpsFailedDurability is the number of operations which failed due to a durability error.
+	KVOpsFailedDurability uint64
+	// KVOpsFailedOther is the number of operations which failed for any other reason.
+	KVOpsFailedOther uint64
+}
+
+type kvOpStats struct {
+	issued    uint64
+	completed uint64
+	retried   uint64
+
+	failedTimeout    uint64
+	failedCanceled   uint64
+	failedDocument   uint64
+	failedTemporary  uint64
+	failedDurability uint64
+	failedOther      uint64
+}
+
+func (s *kvOpStats) recordIssued() {
+	atomic.AddUint64(&s.issued, 1)
+}
+
+func (s *kvOpStats) revertIssued() {
+	atomic.AddUint64(&s.issued, ^uint64(0))
+}
+
+func (s *kvOpStats) recordFinished(retries uint32, err error) {
+	if retries > 0 {
+		atomic.AddUint64(&s.retried, uint64(retries))
+	}
+
+	if err == nil {
+		atomic.AddUint64(&s.completed, 1)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrTimeout):
+		atomic.AddUint64(&s.failedTimeout, 1)
+	case errors.Is(err, ErrRequestCanceled):
+		atomic.AddUint64(&s.failedCanceled, 1)
+	case errors.Is(err, ErrDocumentNotFound), errors.Is(err, ErrDocumentExists), errors.Is(err, ErrCasMismatch),
+		errors.Is(err, ErrDocumentLocked), errors.Is(err, ErrNotStored):
+		atomic.AddUint64(&s.failedDocument, 1)
+	case errors.Is(err, ErrTemporaryFailure), errors.Is(err, ErrOverload):
+		atomic.AddUint64(&s.failedTemporary, 1)
+	case errors.Is(err, ErrDurabilityAmbiguous), errors.Is(err, ErrDurabilityImpossible),
+		errors.Is(err, ErrDurabilityLevelNotAvailable):
+		atomic.AddUint64(&s.failedDurability, 1)
+	default:
+		atomic.AddUint64(&s.failedOther, 1)
+	}
+}
+
+func (s *kvOpStats) Snapshot() AgentStats {
+	stats := AgentStats{
+		KVOpsIssued:           atomic.LoadUint64(&s.issued),
+		KVOpsCompleted:        atomic.LoadUint64(&s.completed),
+		KVOpsRetried:          atomic.LoadUint64(&s.retried),
+		KVOpsFailedTimeout:    atomic.LoadUint64(&s.failedTimeout),
+		KVOpsFailedCanceled:   atomic.LoadUint64(&s.failedCanceled),
+		KVOpsFailedDocument:   atomic.LoadUint64(&s.failedDocument),
+		KVOpsFailedTemporary:  atomic.LoadUint64(&s.failedTemporary),
+		KVOpsFailedDurability: atomic.LoadUint64(&s.failedDurability),
+		KVOpsFailedOther:      atomic.LoadUint64(&s.failedOther),
+	}
+	stats.KVOpsFailed = stats.KVOpsFailedTimeout + stats.KVOpsFailedCanceled + stats.KVOpsFailedDocument +
+		stats.KVOpsFailedTemporary + stats.KVOpsFailedDurability + stats.KVOpsFailedOther
+
+	return stats
+}
@@ -0,0 +1,75 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestKVOpStats() {
+	var stats kvOpStats
+
+	for i := 0; i < 6; i++ {
+		stats.recordIssued()
+	}
+
+	stats.recordFinished(0, nil)
+	stats.recordFinished(2, errAmbiguousTimeout)
+	stats.recordFinished(0, ErrRequestCanceled)
+	stats.recordFinished(1, &KeyValueError{InnerError: ErrDocumentNotFound})
+	stats.recordFinished(3, errTemporaryFailure)
+	stats.recordFinished(0, errInvalidArgument)
+
+	before := stats.Snapshot()
+	suite.Assert().Equal(AgentStats{
+		KVOpsIssued:          6,
+		KVOpsCompleted:       1,
+		KVOpsRetried:         6,
+		KVOpsFailed:          5,
+		KVOpsFailedTimeout:   1,
+		KVOpsFailedCanceled:  1,
+		KVOpsFailedDocument:  1,
+		KVOpsFailedTemporary: 1,
+		KVOpsFailedOther:     1,
+	}, before)
+
+	stats.recordIssued()
+	stats.recordFinished(0, errDurabilityAmbiguous)
+
+	after := stats.Snapshot()
+	suite.Assert().Equal(uint64(6), before.KVOpsIssued)
+	suite.Assert().Equal(uint64(7), after.KVOpsIssued)
+	suite.Assert().Equal(uint64(1), after.KVOpsFailedDurability)
+	suite.Assert().Equal(uint64(6), after.KVOpsFailed)
+}
+
+func (suite *UnitTestSuite) TestKVMuxTrackStats() {
+	mux := &kvMux{}
+
+	var called int
+	req := &memdQRequest{
+		Callback: func(*memdQResponse, *memdQRequest, error) {
+			called++
+		},
+	}
+
+	mux.trackStats(req)
+	// A second dispatch of the same request must not be counted again.
+	mux.trackStats(req)
+	req.recordRetryAttempt(KVNotMyVBucketRetryReason)
+	req.tryCallback(nil, nil)
+
+	suite.Assert().Equal(1, called)
+	stats := mux.Stats()
+	suite.Assert().Equal(uint64(1), stats.KVOpsIssued)
+	suite.Assert().Equal(uint64(1), stats.KVOpsCompleted)
+	suite.Assert().Equal(uint64(1), stats.KVOpsRetried)
+
+	failed := &memdQRequest{
+		Callback: func(*memdQResponse, *memdQRequest, error) {
+			called++
+		},
+	}
+	untrack := mux.trackStats(failed)
+	untrack()
+	failed.tryCallback(nil, errOverload)
+
+	suite.Assert().Equal(2, called)
+	stats = mux.Stats()
+	suite.Assert().Equal(uint64(1), stats.KVOpsIssued)
+	suite.Assert().Equal(uint64(0), stats.KVOpsFailed)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/couchbase/gocbcore/v10/memd"
+	"github.com/couchbase/gocbcore/v10/memdmock"
 )
 
 func (suite *StandardTestSuite) verifyExpiryUsingHLC(key string, agent *Agent, s *TestSubHarness, expiry uint32) {
@@ -1679,6 +1680,37 @@ func (suite *StandardTestSuite) TestRandomGet() {
 	suite.VerifyKVMetrics(suite.meter, "GetRandom", attempts, false, false)
 }
 
+func (suite *StandardTestSuite) TestGetAllVBucketSeqnos() {
+	agent, s := suite.GetAgentAndHarness()
+
+	snapshot, err := agent.ConfigSnapshot()
+	suite.Require().Nil(err, err)
+	numVbuckets, err := snapshot.NumVbuckets()
+	suite.Require().Nil(err, err)
+
+	s.PushOp(agent.GetAllVBucketSeqnos(GetAllVBucketSeqnosOptions{
+		IncludeVbUUID: true,
+	}, func(seqnos []VBucketSeqno, err error) {
+		s.Wrap(func() {
+			if err != nil {
+				s.Fatalf("GetAllVBucketSeqnos operation failed: %v", err)
+			}
+			if len(seqnos) != numVbuckets {
+				s.Fatalf("Expected %d vbuckets but got %d", numVbuckets, len(seqnos))
+			}
+			for i, seqno := range seqnos {
+				if int(seqno.VbID) != i {
+					s.Fatalf("Expected vbuckets to be sorted, got %d at index %d", seqno.VbID, i)
+				}
+				if seqno.VbUUID == 0 {
+					s.Fatalf("Expected vbucket %d to have a uuid", seqno.VbID)
+				}
+			}
+		})
+	}))
+	s.Wait(0)
+}
+
 func (suite *StandardTestSuite) TestStats() {
 	agent, s := suite.GetAgentAndHarness()
 
@@ -1955,6 +1987,19 @@ func (suite *StandardTestSuite) TestDiagnostics() {
 			suite.T().Fatalf("Diagnostic report contained invalid entry")
 		}
 	}
+
+	if len(report.MemdEndpoints) == 0 {
+		suite.T().Fatalf("Diagnostics report contained no endpoints")
+	}
+
+	for addr, endpoint := range report.MemdEndpoints {
+		if endpoint.NumConns == 0 {
+			suite.T().Fatalf("Diagnostic report contained no connections for %s", addr)
+		}
+		if endpoint.NumBusyConns > endpoint.NumConns {
+			suite.T().Fatalf("Diagnostic report contained more busy connections than connections for %s", addr)
+		}
+	}
 }
 
 type testAlternateAddressesRouteConfigMgr struct {
@@ -3268,3 +3313,112 @@ func dumpManifest(agent *Agent, t *testing.T) {
 	}
 	<-waitCh
 }
+
+func (suite *UnitTestSuite) TestAgentNetworkType() {
+	cfgBytes, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err, err)
+	cfgBk, err := parseConfig(cfgBytes, "localhost")
+	suite.Require().Nil(err, err)
+
+	cfgManager := newConfigManager(configManagerProperties{
+		SrcMemdAddrs: []routeEndpoint{{Address: "192.168.132.234:32799"}},
+		NetworkType:  "auto",
+	})
+	agent := &Agent{
+		cfgManager:  cfgManager,
+		shutdownSig: make(chan struct{}),
+	}
+
+	// The network type is resolved by the first config, which may be applied whilst we are waiting.
+	go cfgManager.OnNewConfig(cfgBk)
+
+	networkType, err := agent.NetworkType()
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal("external", networkType)
+
+	close(agent.shutdownSig)
+	_, err = agent.NetworkType()
+	suite.Assert().ErrorIs(err, ErrShutdown)
+}
+
+// createMemdMockAgent creates an agent connected to srv, which must use the credentials user and pass, and waits for
+// it to be ready. config may be used to set any other options.
+func (suite *UnitTestSuite) createMemdMockAgent(srv *memdmock.Server, config *AgentConfig) *Agent {
+	if config == nil {
+		config = &AgentConfig{}
+	}
+	suite.Require().Nil(config.FromConnStr(srv.ConnStr()))
+	config.BucketName = srv.BucketName()
+	config.SecurityConfig.Auth = PasswordAuthProvider{Username: "user", Password: "pass"}
+	config.SecurityConfig.AuthMechanisms = []AuthMechanism{PlainAuthMechanism}
+
+	// Using PLAIN over a non-TLS connection logs a warning.
+	globalTestLogger.SuppressWarnings(true)
+	agent, err := CreateAgent(config)
+	globalTestLogger.SuppressWarnings(false)
+	suite.Require().Nil(err, err)
+
+	waitCh := make(chan error, 1)
+	_, err = agent.WaitUntilReady(time.Now().Add(5*time.Second), WaitUntilReadyOptions{}, func(res *WaitUntilReadyResult, err error) {
+		waitCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().Nil(<-waitCh)
+
+	return agent
+}
+
+func (suite *UnitTestSuite) TestAgentAgainstMemdMock() {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		BucketName: "mock",
+		Username:   "user",
+		Password:   "pass",
+	})
+	suite.Require().Nil(err, err)
+	defer srv.Close()
+
+	agent := suite.createMemdMockAgent(srv, nil)
+	defer agent.Close()
+
+	setCh := make(chan *StoreResult, 1)
+	_, err = agent.Set(SetOptions{
+		Key:   []byte("key"),
+		Value: []byte(`{"x":1}`),
+		Flags: 0x2000000,
+	}, func(res *StoreResult, err error) {
+		suite.Assert().Nil(err, err)
+		setCh <- res
+	})
+	suite.Require().Nil(err, err)
+	setRes := <-setCh
+	suite.Require().NotNil(setRes)
+	suite.Assert().NotZero(setRes.Cas)
+
+	getCh := make(chan *GetResult, 1)
+	_, err = agent.Get(GetOptions{
+		Key: []byte("key"),
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err, err)
+		getCh <- res
+	})
+	suite.Require().Nil(err, err)
+	getRes := <-getCh
+	suite.Require().NotNil(getRes)
+	suite.Assert().Equal([]byte(`{"x":1}`), getRes.Value)
+	suite.Assert().Equal(uint32(0x2000000), getRes.Flags)
+	suite.Assert().Equal(setRes.Cas, getRes.Cas)
+
+	srv.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		return memdmock.ErrorResponse(req, memd.StatusLocked)
+	})
+
+	errCh := make(chan error, 1)
+	_, err = agent.Get(GetOptions{
+		Key:           []byte("key"),
+		RetryStrategy: newFailFastRetryStrategy(),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().ErrorIs(<-errCh, ErrDocumentLocked)
+}
@@ -42,6 +42,7 @@ func CreateAgentGroup(config *AgentGroupConfig) (*AgentGroup, error) {
 		SeedConfig:           config.SeedConfig,
 		SecurityConfig:       config.SecurityConfig,
 		HTTPConfig:           config.HTTPConfig,
+		HTTPTransportFactory: config.HTTPTransportFactory,
 		TracerConfig:         config.TracerConfig,
 		MeterConfig:          config.MeterConfig,
 		DefaultRetryStrategy: config.DefaultRetryStrategy,
@@ -1,11 +1,15 @@
 package gocbcore
 
 import (
+	"container/list"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,15 +18,30 @@ type AnalyticsRowReader struct {
 	streamer   *queryStreamer
 	statement  string
 	statusCode int
+	watchdog   *rowStreamWatchdog
 }
 
 // NextRow reads the next rows bytes from the stream
 func (q *AnalyticsRowReader) NextRow() []byte {
-	return q.streamer.NextRow()
+	row := q.streamer.NextRow()
+	if row == nil {
+		q.watchdog.Stop()
+	} else {
+		q.watchdog.Reset()
+	}
+
+	return row
 }
 
 // Err returns any errors that occurred during streaming.
 func (q AnalyticsRowReader) Err() error {
+	if q.watchdog.TimedOut() {
+		return wrapAnalyticsError(nil, q.statement, &TimeoutError{
+			InnerError:  errAmbiguousTimeout,
+			OperationID: "AnalyticsQuery",
+		}, "", q.statusCode)
+	}
+
 	err := q.streamer.Err()
 	if err != nil {
 		return err
@@ -61,22 +80,145 @@ func (q *AnalyticsRowReader) MetaData() ([]byte, error) {
 	return q.streamer.MetaData()
 }
 
+// AnalyticsMetrics encapsulates the metrics returned by an analytics query.
+type AnalyticsMetrics struct {
+	ElapsedTime      string `json:"elapsedTime"`
+	ExecutionTime    string `json:"executionTime"`
+	ResultCount      uint64 `json:"resultCount"`
+	ResultSize       uint64 `json:"resultSize"`
+	ErrorCount       uint64 `json:"errorCount"`
+	WarningCount     uint64 `json:"warningCount"`
+	ProcessedObjects uint64 `json:"processedObjects"`
+}
+
+// AnalyticsMetaData encapsulates the parsed meta-data returned by an analytics query.
+type AnalyticsMetaData struct {
+	RequestID       string            `json:"requestID"`
+	ClientContextID string            `json:"clientContextID"`
+	Status          string            `json:"status"`
+	Metrics         *AnalyticsMetrics `json:"metrics"`
+}
+
+// EarlyMetaData returns the meta-data which has been streamed so far, without consuming any rows.
+// The request and client context IDs are available as soon as the reader is created, the server sends the
+// status and metrics after the rows so these are only populated once the rows have been exhausted.
+// Volatile: This API is subject to change at any time.
+func (q *AnalyticsRowReader) EarlyMetaData() (*AnalyticsMetaData, error) {
+	metaBytes, err := q.streamer.PartialMetaData()
+	if err != nil {
+		return nil, err
+	}
+
+	var meta AnalyticsMetaData
+	err = json.Unmarshal(metaBytes, &meta)
+	if err != nil {
+		return nil, wrapAnalyticsError(nil, q.statement, err, "", q.statusCode)
+	}
+
+	return &meta, nil
+}
+
+func (q *AnalyticsRowReader) preparedName() (string, error) {
+	val := q.streamer.EarlyMetadata("prepared")
+	if val == nil {
+		return "", errors.New("prepared name not found in metadata")
+	}
+
+	var name string
+	err := json.Unmarshal(val, &name)
+	if err != nil {
+		return "", errors.New("failed to parse prepared name")
+	}
+
+	return name, nil
+}
+
 // Close immediately shuts down the connection
 func (q *AnalyticsRowReader) Close() error {
+	q.watchdog.Stop()
 	return q.streamer.Close()
 }
 
+// rowStreamWatchdog cancels the request underlying a row stream if the rows are not read within the timeout.
+type rowStreamWatchdog struct {
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut uint32
+}
+
+func newRowStreamWatchdog(timeout time.Duration, cancel func()) *rowStreamWatchdog {
+	w := &rowStreamWatchdog{
+		timeout: timeout,
+	}
+	w.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreUint32(&w.timedOut, 1)
+		cancel()
+	})
+
+	return w
+}
+
+func (w *rowStreamWatchdog) Reset() {
+	if w == nil {
+		return
+	}
+
+	// If the timer has already fired then the stream has been cancelled and there's nothing to reset.
+	if w.timer.Stop() {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+func (w *rowStreamWatchdog) Stop() {
+	if w == nil {
+		return
+	}
+
+	w.timer.Stop()
+}
+
+func (w *rowStreamWatchdog) TimedOut() bool {
+	if w == nil {
+		return false
+	}
+
+	return atomic.LoadUint32(&w.timedOut) == 1
+}
+
 // AnalyticsQueryOptions represents the various options available for an analytics query.
 type AnalyticsQueryOptions struct {
 	Payload       []byte
 	Priority      int
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
+
+	// Context, if set, cancels the query when it is done. If Deadline is not set then the deadline of the context
+	// is used instead.
+	Context context.Context
+
+	// Prepared specifies that the statement should be prepared by the server, with the prepared statement handle
+	// being cached and reused for subsequent queries with the same statement.
+	Prepared bool
+
+	// BucketName and ScopeName, if set, run the statement against that scope by setting the query_context of the
+	// request, so the same statement can be used against different scopes. Both must be set together.
+	BucketName string
+	ScopeName  string
+
+	// QueryContext, if set, is used as the query_context of the request, e.g. "default:`bucket`.`scope`". It must not
+	// contradict BucketName and ScopeName, or any query_context already present in the payload.
+	QueryContext string
+
+	// RowStreamDeadline, if set, is the maximum period of time allowed between reading rows from the returned
+	// AnalyticsRowReader. If it elapses the underlying request is cancelled and the reader returns ErrTimeout.
+	RowStreamDeadline time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 func wrapAnalyticsError(req *httpRequest, statement string, err error, errBody string, statusCode int) *AnalyticsError {
@@ -200,26 +342,112 @@ func parseAnalyticsError(respBody []byte) (string, []AnalyticsErrorDesc, error)
 	return rawErrors, errorDescs, err
 }
 
+// analyticsPreparedCacheSize is the maximum number of prepared statement handles cached by the analytics component.
+const analyticsPreparedCacheSize = 1024
+
 type analyticsQueryComponent struct {
-	httpComponent *httpComponent
-	tracer        *tracerComponent
+	httpComponent  httpComponentInterface
+	tracer         *tracerComponent
+	defaultTimeout time.Duration
+	logger         *scopedLogger
+
+	preparedCache *analyticsPreparedCache
 }
 
-func newAnalyticsQueryComponent(httpComponent *httpComponent, tracer *tracerComponent) *analyticsQueryComponent {
+func newAnalyticsQueryComponent(httpComponent httpComponentInterface, tracer *tracerComponent, defaultTimeout time.Duration,
+	logger *scopedLogger) *analyticsQueryComponent {
 	return &analyticsQueryComponent{
-		httpComponent: httpComponent,
-		tracer:        tracer,
+		httpComponent:  httpComponent,
+		tracer:         tracer,
+		defaultTimeout: defaultTimeout,
+		logger:         logger,
+		preparedCache:  newAnalyticsPreparedCache(analyticsPreparedCacheSize),
+	}
+}
+
+type analyticsPreparedCacheKey struct {
+	Statement string
+	Context   string
+}
+
+type analyticsPreparedCacheEntry struct {
+	key  analyticsPreparedCacheKey
+	name string
+}
+
+// analyticsPreparedCache is an LRU cache of prepared statement handles, keyed by statement and query context.
+type analyticsPreparedCache struct {
+	lock    sync.Mutex
+	maxSize int
+	entries map[analyticsPreparedCacheKey]*list.Element
+	lru     *list.List
+}
+
+func newAnalyticsPreparedCache(maxSize int) *analyticsPreparedCache {
+	return &analyticsPreparedCache{
+		maxSize: maxSize,
+		entries: make(map[analyticsPreparedCacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (cache *analyticsPreparedCache) Get(key analyticsPreparedCacheKey) (string, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	elem, ok := cache.entries[key]
+	if !ok {
+		return "", false
+	}
+	cache.lru.MoveToFront(elem)
+
+	return elem.Value.(*analyticsPreparedCacheEntry).name, true
+}
+
+func (cache *analyticsPreparedCache) Put(key analyticsPreparedCacheKey, name string) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if elem, ok := cache.entries[key]; ok {
+		elem.Value.(*analyticsPreparedCacheEntry).name = name
+		cache.lru.MoveToFront(elem)
+		return
+	}
+
+	cache.entries[key] = cache.lru.PushFront(&analyticsPreparedCacheEntry{
+		key:  key,
+		name: name,
+	})
+
+	for cache.lru.Len() > cache.maxSize {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*analyticsPreparedCacheEntry).key)
+	}
+}
+
+func (cache *analyticsPreparedCache) Delete(key analyticsPreparedCacheKey) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if elem, ok := cache.entries[key]; ok {
+		cache.lru.Remove(elem)
+		delete(cache.entries, key)
 	}
 }
 
 // AnalyticsQuery executes an analytics query
 func (aqc *analyticsQueryComponent) AnalyticsQuery(opts AnalyticsQueryOptions, cb AnalyticsQueryCallback) (PendingOp, error) {
-	tracer := aqc.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "AnalyticsQuery", opts.TraceContext)
+	opts.Deadline = deadlineFromContext(opts.Deadline, opts.Context)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, opts.Timeout)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, aqc.defaultTimeout)
+
+	tracer := aqc.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "AnalyticsQuery", opts.TraceContext, opts.NoRootSpan)
 
 	var payloadMap map[string]interface{}
 	err := json.Unmarshal(opts.Payload, &payloadMap)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, wrapAnalyticsError(nil, "", wrapError(err, "expected a JSON payload"), "", 0)
 	}
 
@@ -227,7 +455,16 @@ func (aqc *analyticsQueryComponent) AnalyticsQuery(opts AnalyticsQueryOptions, c
 	clientContextID := getMapValueString(payloadMap, "client_context_id", "")
 	readOnly := getMapValueBool(payloadMap, "readonly", false)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	queryContext, err := analyticsQueryContext(opts, getMapValueString(payloadMap, "query_context", ""))
+	if err != nil {
+		tracer.FinishWithError(err)
+		return nil, wrapAnalyticsError(nil, statement, err, "", 0)
+	}
+	if queryContext != "" {
+		payloadMap["query_context"] = queryContext
+	}
+
+	ctx, cancel := context.WithCancel(contextOrBackground(opts.Context))
 	ireq := &httpRequest{
 		Service: CbasService,
 		Method:  "POST",
@@ -247,10 +484,16 @@ func (aqc *analyticsQueryComponent) AnalyticsQuery(opts AnalyticsQueryOptions, c
 	}
 
 	go func() {
-		res, err := aqc.analyticsQuery(ireq, payloadMap, statement, tracer.StartTime())
+		var res *AnalyticsRowReader
+		var err error
+		if opts.Prepared {
+			res, err = aqc.executePrepared(ireq, payloadMap, statement, tracer.StartTime(), opts.RowStreamDeadline)
+		} else {
+			res, err = aqc.analyticsQuery(ireq, payloadMap, statement, tracer.StartTime(), opts.RowStreamDeadline)
+		}
 		if err != nil {
 			cancel()
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -262,8 +505,90 @@ func (aqc *analyticsQueryComponent) AnalyticsQuery(opts AnalyticsQueryOptions, c
 	return ireq, nil
 }
 
+// analyticsQueryContext resolves the query_context to use for a request from its options and the query_context already
+// present in its payload, if any, returning an error if they contradict one another.
+func analyticsQueryContext(opts AnalyticsQueryOptions, payloadContext string) (string, error) {
+	if (opts.BucketName == "") != (opts.ScopeName == "") {
+		return "", wrapError(errInvalidArgument, "bucket name and scope name must be specified together")
+	}
+
+	queryContext := opts.QueryContext
+	if opts.ScopeName != "" {
+		scopeContext := fmt.Sprintf("default:`%s`.`%s`", opts.BucketName, opts.ScopeName)
+		if queryContext != "" && queryContext != scopeContext {
+			return "", wrapError(errInvalidArgument, "query context contradicts bucket name and scope name")
+		}
+		queryContext = scopeContext
+	}
+
+	if queryContext != "" && payloadContext != "" && queryContext != payloadContext {
+		return "", wrapError(errInvalidArgument, "query context contradicts the query_context of the payload")
+	}
+
+	return queryContext, nil
+}
+
+// isAnalyticsPreparedStatementNotFound returns whether err reports that a prepared statement handle is unknown to the
+// server. The analytics service has no dedicated error code for this so the message must be checked.
+func isAnalyticsPreparedStatementNotFound(err error) bool {
+	var analyticsErr *AnalyticsError
+	if !errors.As(err, &analyticsErr) || len(analyticsErr.Errors) == 0 {
+		return false
+	}
+
+	msgLower := strings.ToLower(analyticsErr.Errors[0].Message)
+	return strings.Contains(msgLower, "prepared statement") && strings.Contains(msgLower, "not found")
+}
+
+func (aqc *analyticsQueryComponent) executePrepared(ireq *httpRequest, payloadMap map[string]interface{},
+	statement string, startTime time.Time, rowStreamDeadline time.Duration) (*AnalyticsRowReader, error) {
+	key := analyticsPreparedCacheKey{
+		Statement: statement,
+		Context:   getMapValueString(payloadMap, "query_context", ""),
+	}
+
+	if name, ok := aqc.preparedCache.Get(key); ok {
+		delete(payloadMap, "statement")
+		payloadMap["prepared"] = name
+
+		res, err := aqc.analyticsQuery(ireq, payloadMap, statement, startTime, rowStreamDeadline)
+		if err == nil {
+			return res, nil
+		}
+
+		// Only an error reporting that the server no longer knows the handle causes it to be evicted and the statement
+		// to be prepared again. Any other error may have occurred after the statement was partially executed, so
+		// running it again is not safe.
+		if !isAnalyticsPreparedStatementNotFound(err) {
+			return nil, err
+		}
+
+		aqc.logger.debugf("Prepared analytics statement execution failed, will attempt reprepare: %v", err)
+		aqc.preparedCache.Delete(key)
+		delete(payloadMap, "prepared")
+	}
+
+	payloadMap["statement"] = "PREPARE " + statement
+	payloadMap["auto_execute"] = true
+
+	res, err := aqc.analyticsQuery(ireq, payloadMap, statement, startTime, rowStreamDeadline)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := res.preparedName()
+	if err != nil {
+		aqc.logger.warnf("Failed to read prepared name from analytics result: %s", err)
+		return res, nil
+	}
+
+	aqc.preparedCache.Put(key, name)
+
+	return res, nil
+}
+
 func (aqc *analyticsQueryComponent) analyticsQuery(ireq *httpRequest, payloadMap map[string]interface{},
-	statement string, startTime time.Time) (*AnalyticsRowReader, error) {
+	statement string, startTime time.Time, rowStreamDeadline time.Duration) (*AnalyticsRowReader, error) {
 	for {
 		{
 			if !ireq.Deadline.IsZero() {
@@ -322,6 +647,7 @@ func (aqc *analyticsQueryComponent) analyticsQuery(ireq *httpRequest, payloadMap
 				return nil, analyticsErr
 			}
 
+			ireq.setServerRetryAfter(resp)
 			shouldRetry, retryTime := retryOrchMaybeRetry(ireq, retryReason)
 			if !shouldRetry {
 				// analyticsErr is already wrapped here
@@ -349,13 +675,18 @@ func (aqc *analyticsQueryComponent) analyticsQuery(ireq *httpRequest, payloadMap
 		if err != nil {
 			respBody, readErr := ioutil.ReadAll(resp.Body)
 			if readErr != nil {
-				logDebugf("Failed to read response body: %v", readErr)
+				aqc.logger.debugf("Failed to read response body: %v", readErr)
 			}
 			return nil, wrapAnalyticsError(ireq, statement, err, string(respBody), resp.StatusCode)
 		}
 
-		return &AnalyticsRowReader{
+		reader := &AnalyticsRowReader{
 			streamer: streamer,
-		}, nil
+		}
+		if rowStreamDeadline > 0 {
+			reader.watchdog = newRowStreamWatchdog(rowStreamDeadline, ireq.CancelFunc)
+		}
+
+		return reader, nil
 	}
 }
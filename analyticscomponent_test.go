@@ -1,12 +1,19 @@
 package gocbcore
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/mock"
 )
 
 type analyticsTestHelper struct {
@@ -237,7 +244,7 @@ func (suite *StandardTestSuite) TestAnalyticsCancel() {
 		agent.httpMux,
 		agent.tracer,
 	)
-	cbasCpt := newAnalyticsQueryComponent(httpCpt, &tracerComponent{tracer: suite.tracer, metrics: suite.meter})
+	cbasCpt := newAnalyticsQueryComponent(httpCpt, &tracerComponent{tracer: suite.tracer, metrics: suite.meter}, 0, nil)
 
 	resCh := make(chan *AnalyticsRowReader)
 	errCh := make(chan error)
@@ -343,3 +350,344 @@ func (suite *StandardTestSuite) TestAnalyticsTimeout() {
 
 	suite.VerifyMetrics(suite.meter, "cbas:AnalyticsQuery", 1, false, false)
 }
+
+func (suite *UnitTestSuite) TestAnalyticsRowReaderEarlyMetaData() {
+	body := `{"requestID":"c8b7e9c4","clientContextID":"1235","signature":{"*":"*"},"results":[{"i":1},{"i":2}],` +
+		`"plans":{},"status":"success","metrics":{"elapsedTime":"20.5ms","executionTime":"18.1ms","resultCount":2,` +
+		`"resultSize":14,"processedObjects":2}}`
+	streamer, err := newQueryStreamer(ioutil.NopCloser(bytes.NewBufferString(body)), "results")
+	suite.Require().Nil(err)
+
+	reader := &AnalyticsRowReader{
+		streamer:   streamer,
+		statement:  "SELECT i FROM dataset",
+		statusCode: 200,
+	}
+
+	meta, err := reader.EarlyMetaData()
+	suite.Require().Nil(err)
+	suite.Assert().Equal("c8b7e9c4", meta.RequestID)
+	suite.Assert().Equal("1235", meta.ClientContextID)
+	suite.Assert().Empty(meta.Status)
+	suite.Assert().Nil(meta.Metrics)
+
+	// Fetching the meta-data must not have consumed any rows.
+	suite.Assert().Equal([]byte(`{"i":1}`), reader.NextRow())
+	suite.Assert().Equal([]byte(`{"i":2}`), reader.NextRow())
+	suite.Assert().Nil(reader.NextRow())
+	suite.Require().Nil(reader.Err())
+
+	meta, err = reader.EarlyMetaData()
+	suite.Require().Nil(err)
+	suite.Assert().Equal("c8b7e9c4", meta.RequestID)
+	suite.Assert().Equal("success", meta.Status)
+	suite.Require().NotNil(meta.Metrics)
+	suite.Assert().Equal(uint64(2), meta.Metrics.ResultCount)
+	suite.Assert().Equal("20.5ms", meta.Metrics.ElapsedTime)
+}
+
+func (suite *UnitTestSuite) TestAnalyticsRowReaderRowStreamDeadline() {
+	pr, pw := io.Pipe()
+	go func() {
+		// Send a single row and then stall, as a slow server or stalled consumer would.
+		_, _ = pw.Write([]byte(`{"requestID":"c8b7e9c4","results":[{"i":1},`))
+	}()
+
+	streamer, err := newQueryStreamer(pr, "results")
+	suite.Require().Nil(err)
+
+	var cancelled uint32
+	reader := &AnalyticsRowReader{
+		streamer: streamer,
+		watchdog: newRowStreamWatchdog(50*time.Millisecond, func() {
+			atomic.StoreUint32(&cancelled, 1)
+			pw.CloseWithError(context.Canceled)
+		}),
+	}
+
+	suite.Assert().Equal([]byte(`{"i":1}`), reader.NextRow())
+	suite.Assert().Nil(reader.NextRow())
+	suite.Assert().Equal(uint32(1), atomic.LoadUint32(&cancelled))
+
+	err = reader.Err()
+	suite.Assert().True(errors.Is(err, ErrTimeout), "Expected timeout error but was %v", err)
+}
+
+func (suite *UnitTestSuite) TestAnalyticsPreparedStatementCache() {
+	var payloads []map[string]interface{}
+	type response struct {
+		statusCode int
+		body       string
+	}
+	responses := []response{
+		{200, `{"requestID":"1","prepared":"p1","results":[{"i":1}],"status":"success"}`},
+		{500, `{"requestID":"2","errors":[{"code":25000,"msg":"Prepared statement p1 not found"}],"status":"fatal"}`},
+		{200, `{"requestID":"3","prepared":"p2","results":[{"i":1}],"status":"success"}`},
+		{500, `{"requestID":"4","errors":[{"code":25000,"msg":"Internal error"}],"status":"fatal"}`},
+	}
+
+	httpC := new(mockHttpComponentInterface)
+	for _, resp := range responses {
+		resp := resp
+		httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
+			Return(func(req *httpRequest, _ bool) *HTTPResponse {
+				return &HTTPResponse{
+					StatusCode: resp.statusCode,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(resp.body)),
+				}
+			}, nil).
+			Run(func(args mock.Arguments) {
+				var payload map[string]interface{}
+				suite.Require().Nil(json.Unmarshal(args[0].(*httpRequest).Body, &payload))
+				payloads = append(payloads, payload)
+			}).
+			Once()
+	}
+
+	cbasC := newAnalyticsQueryComponent(httpC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil), 0, nil)
+
+	runQuery := func() error {
+		errCh := make(chan error, 1)
+		_, err := cbasC.AnalyticsQuery(AnalyticsQueryOptions{
+			Payload:  []byte(`{"statement":"SELECT i FROM dataset"}`),
+			Prepared: true,
+		}, func(reader *AnalyticsRowReader, err error) {
+			if err == nil {
+				var numRows int
+				for reader.NextRow() != nil {
+					numRows++
+				}
+				suite.Assert().Equal(1, numRows)
+				err = reader.Err()
+			}
+			errCh <- err
+		})
+		suite.Require().Nil(err, err)
+
+		return <-errCh
+	}
+
+	// The first query prepares the statement and caches the handle.
+	suite.Require().Nil(runQuery())
+	suite.Require().Len(payloads, 1)
+	suite.Assert().Equal("PREPARE SELECT i FROM dataset", payloads[0]["statement"])
+	suite.Assert().Equal(true, payloads[0]["auto_execute"])
+
+	name, ok := cbasC.preparedCache.Get(analyticsPreparedCacheKey{Statement: "SELECT i FROM dataset"})
+	suite.Require().True(ok)
+	suite.Assert().Equal("p1", name)
+
+	// The second query uses the handle, which the server no longer knows about, so it is evicted and reprepared.
+	suite.Require().Nil(runQuery())
+	suite.Require().Len(payloads, 3)
+	suite.Assert().Equal("p1", payloads[1]["prepared"])
+	suite.Assert().NotContains(payloads[1], "statement")
+	suite.Assert().Equal("PREPARE SELECT i FROM dataset", payloads[2]["statement"])
+	suite.Assert().NotContains(payloads[2], "prepared")
+
+	name, ok = cbasC.preparedCache.Get(analyticsPreparedCacheKey{Statement: "SELECT i FROM dataset"})
+	suite.Require().True(ok)
+	suite.Assert().Equal("p2", name)
+
+	// Any other error may have occurred part way through execution so the statement must not be run again.
+	var analyticsErr *AnalyticsError
+	suite.Require().True(errors.As(runQuery(), &analyticsErr))
+	suite.Assert().Equal(uint32(25000), analyticsErr.Errors[0].Code)
+	suite.Require().Len(payloads, 4)
+	suite.Assert().Equal("p2", payloads[3]["prepared"])
+
+	name, ok = cbasC.preparedCache.Get(analyticsPreparedCacheKey{Statement: "SELECT i FROM dataset"})
+	suite.Require().True(ok)
+	suite.Assert().Equal("p2", name)
+
+	httpC.AssertExpectations(suite.T())
+}
+
+func (suite *UnitTestSuite) TestAnalyticsPreparedCacheEviction() {
+	cache := newAnalyticsPreparedCache(2)
+
+	cache.Put(analyticsPreparedCacheKey{Statement: "a"}, "pa")
+	cache.Put(analyticsPreparedCacheKey{Statement: "b"}, "pb")
+
+	// Reading a makes b the least recently used entry.
+	_, ok := cache.Get(analyticsPreparedCacheKey{Statement: "a"})
+	suite.Require().True(ok)
+
+	cache.Put(analyticsPreparedCacheKey{Statement: "c"}, "pc")
+
+	_, ok = cache.Get(analyticsPreparedCacheKey{Statement: "b"})
+	suite.Assert().False(ok)
+	name, ok := cache.Get(analyticsPreparedCacheKey{Statement: "a"})
+	suite.Assert().True(ok)
+	suite.Assert().Equal("pa", name)
+	name, ok = cache.Get(analyticsPreparedCacheKey{Statement: "c"})
+	suite.Assert().True(ok)
+	suite.Assert().Equal("pc", name)
+
+	cache.Delete(analyticsPreparedCacheKey{Statement: "a"})
+	_, ok = cache.Get(analyticsPreparedCacheKey{Statement: "a"})
+	suite.Assert().False(ok)
+}
+
+func (suite *UnitTestSuite) TestAnalyticsQueryContext() {
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+
+	reqCh := make(chan *httpRequest, 1)
+	httpC := new(mockHttpComponentInterface)
+	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
+		Return(func(req *httpRequest, _ bool) (*HTTPResponse, error) {
+			reqCh <- req
+			<-req.Context.Done()
+			return nil, errRequestCanceled
+		}).
+		Once()
+
+	cbasC := newAnalyticsQueryComponent(httpC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil), 0, nil)
+
+	errCh := make(chan error, 1)
+	_, err := cbasC.AnalyticsQuery(AnalyticsQueryOptions{
+		Payload: []byte(`{"statement":"SELECT 1=1"}`),
+		Context: ctx,
+	}, func(reader *AnalyticsRowReader, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+
+	req := <-reqCh
+	suite.Assert().Equal(deadline, req.Deadline)
+
+	// Cancelling the context must cancel the pending request.
+	cancel()
+	suite.Assert().ErrorIs(<-errCh, ErrRequestCanceled)
+
+	httpC.AssertExpectations(suite.T())
+}
+
+func (suite *UnitTestSuite) TestAnalyticsQueryScope() {
+	var payloads []map[string]interface{}
+	httpC := new(mockHttpComponentInterface)
+	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
+		Return(func(req *httpRequest, _ bool) *HTTPResponse {
+			return &HTTPResponse{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"requestID":"1","results":[],"status":"success"}`)),
+			}
+		}, nil).
+		Run(func(args mock.Arguments) {
+			var payload map[string]interface{}
+			suite.Require().Nil(json.Unmarshal(args[0].(*httpRequest).Body, &payload))
+			payloads = append(payloads, payload)
+		})
+
+	cbasC := newAnalyticsQueryComponent(httpC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil), 0, nil)
+
+	runQuery := func(opts AnalyticsQueryOptions) error {
+		errCh := make(chan error, 1)
+		_, err := cbasC.AnalyticsQuery(opts, func(reader *AnalyticsRowReader, err error) {
+			if err == nil {
+				err = reader.Close()
+			}
+			errCh <- err
+		})
+		if err != nil {
+			return err
+		}
+
+		return <-errCh
+	}
+
+	type tCase struct {
+		name            string
+		opts            AnalyticsQueryOptions
+		expectedContext string
+		expectedErr     error
+	}
+	testCases := []tCase{
+		{
+			name:            "none",
+			opts:            AnalyticsQueryOptions{Payload: []byte(`{"statement":"SELECT 1=1"}`)},
+			expectedContext: "",
+		},
+		{
+			name: "scope",
+			opts: AnalyticsQueryOptions{
+				Payload:    []byte(`{"statement":"SELECT 1=1"}`),
+				BucketName: "travel-sample",
+				ScopeName:  "inventory",
+			},
+			expectedContext: "default:`travel-sample`.`inventory`",
+		},
+		{
+			name: "query context",
+			opts: AnalyticsQueryOptions{
+				Payload:      []byte(`{"statement":"SELECT 1=1"}`),
+				QueryContext: "default:`travel-sample`.`tenant`",
+			},
+			expectedContext: "default:`travel-sample`.`tenant`",
+		},
+		{
+			name: "scope matching query context and payload",
+			opts: AnalyticsQueryOptions{
+				Payload:      []byte(`{"statement":"SELECT 1=1","query_context":"default:` + "`travel-sample`.`inventory`" + `"}`),
+				BucketName:   "travel-sample",
+				ScopeName:    "inventory",
+				QueryContext: "default:`travel-sample`.`inventory`",
+			},
+			expectedContext: "default:`travel-sample`.`inventory`",
+		},
+		{
+			name: "payload only",
+			opts: AnalyticsQueryOptions{
+				Payload: []byte(`{"statement":"SELECT 1=1","query_context":"default:` + "`travel-sample`.`inventory`" + `"}`),
+			},
+			expectedContext: "default:`travel-sample`.`inventory`",
+		},
+		{
+			name: "scope without bucket",
+			opts: AnalyticsQueryOptions{
+				Payload:   []byte(`{"statement":"SELECT 1=1"}`),
+				ScopeName: "inventory",
+			},
+			expectedErr: errInvalidArgument,
+		},
+		{
+			name: "scope contradicting query context",
+			opts: AnalyticsQueryOptions{
+				Payload:      []byte(`{"statement":"SELECT 1=1"}`),
+				BucketName:   "travel-sample",
+				ScopeName:    "inventory",
+				QueryContext: "default:`travel-sample`.`tenant`",
+			},
+			expectedErr: errInvalidArgument,
+		},
+		{
+			name: "query context contradicting payload",
+			opts: AnalyticsQueryOptions{
+				Payload:      []byte(`{"statement":"SELECT 1=1","query_context":"default:` + "`travel-sample`.`inventory`" + `"}`),
+				QueryContext: "default:`travel-sample`.`tenant`",
+			},
+			expectedErr: errInvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			payloads = nil
+			err := runQuery(tc.opts)
+			if tc.expectedErr != nil {
+				suite.Assert().ErrorIs(err, tc.expectedErr)
+				suite.Assert().Empty(payloads)
+				return
+			}
+
+			suite.Require().Nil(err, err)
+			suite.Require().Len(payloads, 1)
+			if tc.expectedContext == "" {
+				suite.Assert().NotContains(payloads[0], "query_context")
+			} else {
+				suite.Assert().Equal(tc.expectedContext, payloads[0]["query_context"])
+			}
+		})
+	}
+}
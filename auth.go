@@ -1,6 +1,9 @@
 package gocbcore
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+	"fmt"
+)
 
 // UserPassPair represents a username and password pair.
 type UserPassPair struct {
@@ -9,12 +12,15 @@ type UserPassPair struct {
 }
 
 // AuthCredsRequest represents an authentication details request from the agent.
+// BucketName is the name of the bucket the agent is connected to, or empty for a cluster level agent.
 type AuthCredsRequest struct {
-	Service  ServiceType
-	Endpoint string
+	Service    ServiceType
+	Endpoint   string
+	BucketName string
 }
 
 // AuthCertRequest represents a certificate details request from the agent.
+// Service and Endpoint identify the connection being made, Service is zero when it cannot be determined.
 type AuthCertRequest struct {
 	Service  ServiceType
 	Endpoint string
@@ -42,10 +48,11 @@ func getSingleAuthCreds(auth AuthProvider, req AuthCredsRequest) (UserPassPair,
 	return creds[0], nil
 }
 
-func getKvAuthCreds(auth AuthProvider, endpoint string) (UserPassPair, error) {
+func getKvAuthCreds(auth AuthProvider, endpoint, bucketName string) (UserPassPair, error) {
 	return getSingleAuthCreds(auth, AuthCredsRequest{
-		Service:  MemdService,
-		Endpoint: endpoint,
+		Service:    MemdService,
+		Endpoint:   endpoint,
+		BucketName: bucketName,
 	})
 }
 
@@ -78,3 +85,76 @@ func (auth PasswordAuthProvider) Credentials(req AuthCredsRequest) ([]UserPassPa
 		Password: auth.Password,
 	}}, nil
 }
+
+// CallbackCertificateAuthenticator provides an AuthProvider implementation which authenticates using client
+// certificates, selecting the certificate for each connection by invoking CertificateCallback.
+type CallbackCertificateAuthenticator struct {
+	CertificateCallback func(req AuthCertRequest) (*tls.Certificate, error)
+}
+
+// SupportsNonTLS specifies whether this authenticator supports non-TLS connections.
+func (auth CallbackCertificateAuthenticator) SupportsNonTLS() bool {
+	return false
+}
+
+// SupportsTLS specifies whether this authenticator supports TLS connections.
+func (auth CallbackCertificateAuthenticator) SupportsTLS() bool {
+	return true
+}
+
+// Certificate returns the certificate chain selected by the callback for the connection.
+func (auth CallbackCertificateAuthenticator) Certificate(req AuthCertRequest) (*tls.Certificate, error) {
+	if auth.CertificateCallback == nil {
+		return nil, nil
+	}
+
+	return auth.CertificateCallback(req)
+}
+
+// Credentials returns empty credentials, as authentication is performed using the certificate.
+func (auth CallbackCertificateAuthenticator) Credentials(req AuthCredsRequest) ([]UserPassPair, error) {
+	return []UserPassPair{{
+		Username: "",
+		Password: "",
+	}}, nil
+}
+
+// BucketScopedAuthProvider provides an AuthProvider implementation which selects the username/password pair
+// to use based on the bucket that the agent is connected to.
+type BucketScopedAuthProvider struct {
+	// BucketCredentials maps bucket names to the credentials to use for that bucket.
+	BucketCredentials map[string]UserPassPair
+	// DefaultCredentials, if set, is used when there are no credentials for the bucket, including for cluster
+	// level agents which are not connected to a bucket.
+	DefaultCredentials *UserPassPair
+}
+
+// SupportsNonTLS specifies whether this authenticator supports non-TLS connections.
+func (auth BucketScopedAuthProvider) SupportsNonTLS() bool {
+	return true
+}
+
+// SupportsTLS specifies whether this authenticator supports TLS connections.
+func (auth BucketScopedAuthProvider) SupportsTLS() bool {
+	return true
+}
+
+// Certificate directly returns a certificate chain to present for the connection.
+func (auth BucketScopedAuthProvider) Certificate(req AuthCertRequest) (*tls.Certificate, error) {
+	return nil, nil
+}
+
+// Credentials returns the username/password for the bucket in the request, falling back to the default
+// credentials. An error is returned if neither are available.
+func (auth BucketScopedAuthProvider) Credentials(req AuthCredsRequest) ([]UserPassPair, error) {
+	if creds, ok := auth.BucketCredentials[req.BucketName]; ok {
+		return []UserPassPair{creds}, nil
+	}
+
+	if auth.DefaultCredentials != nil {
+		return []UserPassPair{*auth.DefaultCredentials}, nil
+	}
+
+	return nil, wrapError(errInvalidCredentials, fmt.Sprintf("no credentials available for bucket %s",
+		redactMetaData(req.BucketName)))
+}
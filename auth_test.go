@@ -0,0 +1,114 @@
+package gocbcore
+
+import (
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+func (suite *UnitTestSuite) TestCallbackCertificateAuthenticator() {
+	kvCert := &tls.Certificate{Certificate: [][]byte{[]byte("kv")}}
+	mgmtCert := &tls.Certificate{Certificate: [][]byte{[]byte("mgmt")}}
+
+	var requests []AuthCertRequest
+	auth := CallbackCertificateAuthenticator{
+		CertificateCallback: func(req AuthCertRequest) (*tls.Certificate, error) {
+			requests = append(requests, req)
+			if req.Service == MemdService {
+				return kvCert, nil
+			}
+			return mgmtCert, nil
+		},
+	}
+	suite.Assert().True(auth.SupportsTLS())
+	suite.Assert().False(auth.SupportsNonTLS())
+
+	tlsConfig := createTLSConfig(auth, nil, tlsSettings{})
+
+	kvTLSConfig, err := tlsConfig.MakeForAddr("10.112.192.101:11207", MemdService)
+	suite.Require().Nil(err)
+	cert, err := kvTLSConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(kvCert, cert)
+
+	mux := newHTTPClientMux(&routeConfig{}, httpClientMuxEndpoints{
+		mgmtEpList: []routeEndpoint{{Address: "https://10.112.192.101:18091"}},
+		n1qlEpList: []routeEndpoint{{Address: "https://10.112.192.101:18093"}},
+	}, tlsConfig, auth, CircuitBreakerConfig{})
+	suite.Assert().Equal(N1qlService, mux.ServiceForAddress("10.112.192.101:18093"))
+	suite.Assert().Equal(ServiceType(0), mux.ServiceForAddress("10.112.192.102:18093"))
+
+	mgmtTLSConfig, err := tlsConfig.MakeForAddr("10.112.192.101:18091", mux.ServiceForAddress("10.112.192.101:18091"))
+	suite.Require().Nil(err)
+	cert, err = mgmtTLSConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(mgmtCert, cert)
+
+	suite.Assert().Equal([]AuthCertRequest{
+		{Service: MemdService, Endpoint: "10.112.192.101:11207"},
+		{Service: MgmtService, Endpoint: "10.112.192.101:18091"},
+	}, requests)
+}
+
+func (suite *UnitTestSuite) TestBucketScopedAuthProvider() {
+	auth := BucketScopedAuthProvider{
+		BucketCredentials: map[string]UserPassPair{
+			"travel-sample": {Username: "travel", Password: "password1"},
+			"beer-sample":   {Username: "beer", Password: "password2"},
+		},
+	}
+	suite.Assert().True(auth.SupportsTLS())
+	suite.Assert().True(auth.SupportsNonTLS())
+
+	creds, err := getKvAuthCreds(auth, "10.112.192.101:11210", "beer-sample")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(UserPassPair{Username: "beer", Password: "password2"}, creds)
+
+	_, err = auth.Credentials(AuthCredsRequest{Service: N1qlService, BucketName: "default"})
+	suite.Assert().True(errors.Is(err, ErrInvalidCredentials))
+
+	auth.DefaultCredentials = &UserPassPair{Username: "Administrator", Password: "password"}
+	credsList, err := auth.Credentials(AuthCredsRequest{Service: N1qlService, BucketName: "default"})
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]UserPassPair{{Username: "Administrator", Password: "password"}}, credsList)
+
+	credsList, err = auth.Credentials(AuthCredsRequest{Service: MgmtService})
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]UserPassPair{{Username: "Administrator", Password: "password"}}, credsList)
+}
+
+type credsTestBootstrapClient struct {
+	bootstrapClient
+}
+
+func (client *credsTestBootstrapClient) Address() string  { return "10.112.192.101:11210" }
+func (client *credsTestBootstrapClient) ConnID() string   { return "connid" }
+func (client *credsTestBootstrapClient) LoggerID() string { return "10.112.192.101:11210/connid" }
+
+func (client *credsTestBootstrapClient) ExecHello(clientID string, features []memd.HelloFeature,
+	deadline time.Time) (chan ExecHelloResponse, error) {
+	return make(chan ExecHelloResponse, 1), nil
+}
+
+func (client *credsTestBootstrapClient) ExecGetErrorMap(version uint16, deadline time.Time) (chan errorMapResponse, error) {
+	return make(chan errorMapResponse, 1), nil
+}
+
+func (suite *UnitTestSuite) TestBucketScopedAuthProviderNoCredentialsFailsBootstrap() {
+	mcc := &memdClientDialerComponent{
+		bootstrapProps: bootstrapProps{
+			Bucket: "default",
+		},
+	}
+	auth := BucketScopedAuthProvider{
+		BucketCredentials: map[string]UserPassPair{
+			"travel-sample": {Username: "travel", Password: "password1"},
+		},
+	}
+
+	// Without credentials for the bucket the bootstrap must fail, rather than continuing without authenticating.
+	err := mcc.bootstrap(&credsTestBootstrapClient{}, time.Now().Add(time.Second), []AuthMechanism{PlainAuthMechanism}, auth)
+	suite.Assert().ErrorIs(err, ErrInvalidCredentials)
+}
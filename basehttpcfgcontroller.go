@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/url"
 	"sync"
 	"sync/atomic"
@@ -37,14 +38,21 @@ func hostnameFromURI(uri string) string {
 }
 
 type baseHTTPConfigController struct {
+	logger               *scopedLogger
 	cfgMgr               *configManagementComponent
 	confHTTPRetryDelay   time.Duration
+	confHTTPRetryJitter  float64
 	confHTTPRedialPeriod time.Duration
 	confHTTPMaxWait      time.Duration
+	confHTTPMaxFailures  uint32
 	httpComponent        *httpComponent
 	bucketName           string
 	endpointCallback     func(uint64) string
 
+	// These are only ever accessed from the poll loop goroutine.
+	endpointFailures   map[string]uint32
+	abandonedEndpoints map[string]struct{}
+
 	looperStopSig chan struct{}
 
 	fetchErr error
@@ -53,9 +61,12 @@ type baseHTTPConfigController struct {
 
 type httpPollerProperties struct {
 	confHTTPRetryDelay   time.Duration
+	confHTTPRetryJitter  float64
 	confHTTPRedialPeriod time.Duration
 	confHTTPMaxWait      time.Duration
+	confHTTPMaxFailures  uint32
 	httpComponent        *httpComponent
+	logger               *scopedLogger
 }
 
 func newBaseHTTPConfigController(bucketName string, props httpPollerProperties, cfgMgr *configManagementComponent,
@@ -64,10 +75,16 @@ func newBaseHTTPConfigController(bucketName string, props httpPollerProperties,
 		cfgMgr:               cfgMgr,
 		confHTTPRedialPeriod: props.confHTTPRedialPeriod,
 		confHTTPRetryDelay:   props.confHTTPRetryDelay,
+		confHTTPRetryJitter:  props.confHTTPRetryJitter,
 		confHTTPMaxWait:      props.confHTTPMaxWait,
+		confHTTPMaxFailures:  props.confHTTPMaxFailures,
 		httpComponent:        props.httpComponent,
+		logger:               props.logger,
 		bucketName:           bucketName,
 
+		endpointFailures:   make(map[string]uint32),
+		abandonedEndpoints: make(map[string]struct{}),
+
 		looperStopSig: make(chan struct{}),
 
 		endpointCallback: endpointCallback,
@@ -86,7 +103,7 @@ func (hcc *baseHTTPConfigController) setError(err error) {
 }
 
 func (hcc *baseHTTPConfigController) Stop() {
-	logDebugf("HTTP Looper stopping.")
+	hcc.logger.debugf("HTTP Looper stopping.")
 	close(hcc.looperStopSig)
 }
 
@@ -95,19 +112,75 @@ func (hcc *baseHTTPConfigController) Reset() {
 	hcc.looperStopSig = make(chan struct{})
 }
 
+// isAbandoned returns whether the endpoint has been abandoned due to too many consecutive failures.
+func (hcc *baseHTTPConfigController) isAbandoned(endpoint string) bool {
+	_, ok := hcc.abandonedEndpoints[endpoint]
+	return ok
+}
+
+// resetAbandoned makes all previously abandoned endpoints eligible for selection again.
+func (hcc *baseHTTPConfigController) resetAbandoned() {
+	if len(hcc.abandonedEndpoints) == 0 {
+		return
+	}
+
+	hcc.logger.debugf("All HTTP poller endpoints have been abandoned, resetting")
+	hcc.abandonedEndpoints = make(map[string]struct{})
+}
+
+func (hcc *baseHTTPConfigController) recordEndpointSuccess(endpoint string) {
+	delete(hcc.endpointFailures, endpoint)
+	delete(hcc.abandonedEndpoints, endpoint)
+}
+
+func (hcc *baseHTTPConfigController) recordEndpointFailure(endpoint string, err error) {
+	if hcc.confHTTPMaxFailures == 0 {
+		return
+	}
+
+	numFailures := hcc.endpointFailures[endpoint] + 1
+	if numFailures < hcc.confHTTPMaxFailures {
+		hcc.endpointFailures[endpoint] = numFailures
+		return
+	}
+
+	delete(hcc.endpointFailures, endpoint)
+	hcc.abandonedEndpoints[endpoint] = struct{}{}
+
+	if isLogRedactionLevelFull() {
+		hcc.logger.warnf("HTTP poller abandoning endpoint %s after %d consecutive failures, last error: %v",
+			redactSystemData(endpoint), numFailures, redactSystemData(err))
+	} else {
+		hcc.logger.warnf("HTTP poller abandoning endpoint %s after %d consecutive failures, last error: %v",
+			endpoint, numFailures, err)
+	}
+}
+
+// jitterDuration randomly varies d by up to the jitter fraction of itself in either direction. The jitter is clamped
+// to 1 so that the result can never be negative.
+func jitterDuration(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || d <= 0 {
+		return d
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	return d + time.Duration(float64(d)*jitter*(2*rand.Float64()-1)) // #nosec G404
+}
+
 func (hcc *baseHTTPConfigController) DoLoop() {
 	hcc.doLoop()
-	logDebugf("HTTP Looper stopped.")
+	hcc.logger.debugf("HTTP Looper stopped.")
 }
 
 func (hcc *baseHTTPConfigController) doLoop() {
-	waitPeriod := hcc.confHTTPRetryDelay
 	maxConnPeriod := hcc.confHTTPRedialPeriod
 
 	var iterNum uint64 = 1
 	iterSawConfig := false
 
-	logDebugf("HTTP Looper starting.")
+	hcc.logger.debugf("HTTP Looper starting.")
 
 	for {
 		select {
@@ -119,30 +192,30 @@ func (hcc *baseHTTPConfigController) doLoop() {
 		pickedSrv := hcc.endpointCallback(iterNum)
 
 		if pickedSrv == "" {
-			logDebugf("Pick Failed.")
+			hcc.logger.debugf("Pick Failed.")
 			// All servers have been visited during this iteration
 
 			if !iterSawConfig {
-				logDebugf("Looper waiting...")
+				hcc.logger.debugf("Looper waiting...")
 				// Wait for a period before trying again if there was a problem...
 				// We also watch for the client being shut down.
 				select {
 				case <-hcc.looperStopSig:
 					return
-				case <-time.After(waitPeriod):
+				case <-time.After(jitterDuration(hcc.confHTTPRetryDelay, hcc.confHTTPRetryJitter)):
 				}
 			}
-			logDebugf("Looping again.")
+			hcc.logger.debugf("Looping again.")
 			// Go to next iteration and try all servers again
 			iterNum++
 			iterSawConfig = false
 			continue
 		}
 
-		logDebugf("Http Picked: %s.", pickedSrv)
+		hcc.logger.debugf("Http Picked: %s.", pickedSrv)
 
 		hostname := hostnameFromURI(pickedSrv)
-		logDebugf("HTTP Hostname: %s.", hostname)
+		hcc.logger.debugf("HTTP Hostname: %s.", hostname)
 
 		var resp *HTTPResponse
 		// 1 on success, 0 on failure for node, -1 for generic failure
@@ -155,7 +228,7 @@ func (hcc *baseHTTPConfigController) doLoop() {
 			}
 			// HTTP request time!
 			uri := fmt.Sprintf("/pools/default/%s/%s", streamPath, url.PathEscape(hcc.bucketName))
-			logDebugf("Requesting config from: %s/%s.", pickedSrv, uri)
+			hcc.logger.debugf("Requesting config from: %s/%s.", pickedSrv, uri)
 
 			req := &httpRequest{
 				Service:  MgmtService,
@@ -169,7 +242,7 @@ func (hcc *baseHTTPConfigController) doLoop() {
 			var err error
 			resp, err = hcc.httpComponent.DoInternalHTTPRequest(req, true)
 			if err != nil {
-				logWarnf("Failed to connect to host. %v", err)
+				hcc.logger.warnf("Failed to connect to host. %v", err)
 				hcc.setError(err)
 				return 0
 			}
@@ -177,22 +250,22 @@ func (hcc *baseHTTPConfigController) doLoop() {
 			if resp.StatusCode != 200 {
 				err := resp.Body.Close()
 				if err != nil {
-					logErrorf("Socket close failed handling status code != 200 (%s)", err)
+					hcc.logger.errorf("Socket close failed handling status code != 200 (%s)", err)
 				}
 				if resp.StatusCode == 401 {
-					logWarnf("Failed to connect to host, bad auth.")
+					hcc.logger.warnf("Failed to connect to host, bad auth.")
 					hcc.setError(errAuthenticationFailure)
 					return -1
 				} else if resp.StatusCode == 404 {
 					if is2x {
-						logWarnf("Failed to connect to host, bad bucket.")
+						hcc.logger.warnf("Failed to connect to host, bad bucket.")
 						hcc.setError(errAuthenticationFailure)
 						return -1
 					}
 
 					return doConfigRequest(true)
 				}
-				logWarnf("Failed to connect to host, unexpected status code: %v.", resp.StatusCode)
+				hcc.logger.warnf("Failed to connect to host, unexpected status code: %v.", resp.StatusCode)
 				hcc.setError(errCliInternalError)
 				return 0
 			}
@@ -202,12 +275,15 @@ func (hcc *baseHTTPConfigController) doLoop() {
 
 		switch doConfigRequest(false) {
 		case 0:
+			hcc.recordEndpointFailure(pickedSrv, hcc.Error())
 			continue
 		case -1:
 			continue
 		}
 
-		logDebugf("Connected.")
+		hcc.recordEndpointSuccess(pickedSrv)
+
+		hcc.logger.debugf("Connected.")
 
 		var autoDisconnected int32
 
@@ -218,13 +294,13 @@ func (hcc *baseHTTPConfigController) doLoop() {
 			case <-hcc.looperStopSig:
 			}
 
-			logDebugf("Automatically resetting our HTTP connection")
+			hcc.logger.debugf("Automatically resetting our HTTP connection")
 
 			atomic.StoreInt32(&autoDisconnected, 1)
 
 			err := resp.Body.Close()
 			if err != nil {
-				logErrorf("Socket close failed during auto-dc (%s)", err)
+				hcc.logger.errorf("Socket close failed during auto-dc (%s)", err)
 			}
 		}()
 
@@ -240,39 +316,39 @@ func (hcc *baseHTTPConfigController) doLoop() {
 					break
 				}
 
-				logWarnf("Config block decode failure (%s)", err)
+				hcc.logger.warnf("Config block decode failure (%s)", err)
 
 				if err != io.EOF {
 					err = resp.Body.Close()
 					if err != nil {
-						logErrorf("Socket close failed after decode fail (%s)", err)
+						hcc.logger.errorf("Socket close failed after decode fail (%s)", err)
 					}
 				}
 
 				break
 			}
 
-			logDebugf("Got Block: %v", string(configBlock.Bytes))
+			hcc.logger.debugf("Got Block: %v", string(configBlock.Bytes))
 
 			bkCfg, err := parseConfig(configBlock.Bytes, hostname)
 			if err != nil {
-				logDebugf("Got error while parsing config: %v", err)
+				hcc.logger.debugf("Got error while parsing config: %v", err)
 
 				err = resp.Body.Close()
 				if err != nil {
-					logErrorf("Socket close failed after parsing fail (%s)", err)
+					hcc.logger.errorf("Socket close failed after parsing fail (%s)", err)
 				}
 
 				break
 			}
 
-			logDebugf("Got Config.")
+			hcc.logger.debugf("Got Config.")
 
 			iterSawConfig = true
-			logDebugf("HTTP Config Update")
+			hcc.logger.debugf("HTTP Config Update")
 			hcc.cfgMgr.OnNewConfig(bkCfg)
 		}
 
-		logDebugf("HTTP, Setting %s to iter %d", pickedSrv, iterNum)
+		hcc.logger.debugf("HTTP, Setting %s to iter %d", pickedSrv, iterNum)
 	}
 }
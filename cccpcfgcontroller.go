@@ -9,11 +9,19 @@ import (
 	"github.com/couchbase/gocbcore/v10/memd"
 )
 
+// defaultCCCPRetryBackoff returns the backoff used between CCCP polls which failed to fetch a config from any node,
+// which starts from the usual poll period so that a failing cluster is never polled more often than a healthy one.
+func defaultCCCPRetryBackoff(pollPeriod, maxWait time.Duration) BackoffCalculator {
+	return ExponentialBackoff(pollPeriod, maxWait, 2)
+}
+
 type cccpConfigController struct {
-	muxer              dispatcher
-	cfgMgr             *configManagementComponent
-	confCccpPollPeriod time.Duration
-	cccpFetcher        *cccpConfigFetcher
+	logger               *scopedLogger
+	muxer                dispatcher
+	cfgMgr               *configManagementComponent
+	confCccpPollPeriod   time.Duration
+	confCccpRetryBackoff BackoffCalculator
+	cccpFetcher          *cccpConfigFetcher
 
 	looperStopSig chan struct{}
 
@@ -27,10 +35,12 @@ type cccpConfigController struct {
 func newCCCPConfigController(props cccpPollerProperties, muxer dispatcher, cfgMgr *configManagementComponent,
 	isFallbackErrorFn func(error) bool, noConfigFoundFn func(error)) *cccpConfigController {
 	return &cccpConfigController{
-		muxer:              muxer,
-		cfgMgr:             cfgMgr,
-		confCccpPollPeriod: props.confCccpPollPeriod,
-		cccpFetcher:        props.cccpConfigFetcher,
+		muxer:                muxer,
+		cfgMgr:               cfgMgr,
+		confCccpPollPeriod:   props.confCccpPollPeriod,
+		confCccpRetryBackoff: props.confCccpRetryBackoff,
+		cccpFetcher:          props.cccpConfigFetcher,
+		logger:               props.logger,
 
 		looperStopSig: make(chan struct{}),
 
@@ -40,8 +50,10 @@ func newCCCPConfigController(props cccpPollerProperties, muxer dispatcher, cfgMg
 }
 
 type cccpPollerProperties struct {
-	confCccpPollPeriod time.Duration
-	cccpConfigFetcher  *cccpConfigFetcher
+	confCccpPollPeriod   time.Duration
+	confCccpRetryBackoff BackoffCalculator
+	cccpConfigFetcher    *cccpConfigFetcher
+	logger               *scopedLogger
 }
 
 func (ccc *cccpConfigController) Error() error {
@@ -57,7 +69,7 @@ func (ccc *cccpConfigController) setError(err error) {
 }
 
 func (ccc *cccpConfigController) Stop() {
-	logInfof("CCCP Looper stopping")
+	ccc.logger.infof("CCCP Looper stopping")
 	close(ccc.looperStopSig)
 }
 
@@ -68,22 +80,22 @@ func (ccc *cccpConfigController) Reset() {
 
 func (ccc *cccpConfigController) DoLoop() error {
 	if err := ccc.doLoop(); err != nil {
-		logInfof("CCCP Looper errored")
+		ccc.logger.infof("CCCP Looper errored")
 
 		return err
 	}
 
-	logInfof("CCCP Looper stopped")
+	ccc.logger.infof("CCCP Looper stopped")
 	return nil
 }
 
 func (ccc *cccpConfigController) doLoop() error {
-	tickTime := ccc.confCccpPollPeriod
-
-	logInfof("CCCP Looper starting.")
+	ccc.logger.infof("CCCP Looper starting.")
 	nodeIdx := -1
 	// The first time that we loop we want to skip any sleep so that we can try get a config and bootstrapped ASAP.
 	firstLoop := true
+	// The number of consecutive polls which failed to fetch a config from any node, used to back off between them.
+	var numFailedPolls uint32
 
 	for {
 		if !firstLoop {
@@ -91,7 +103,7 @@ func (ccc *cccpConfigController) doLoop() error {
 			select {
 			case <-ccc.looperStopSig:
 				return nil
-			case <-time.After(tickTime):
+			case <-time.After(ccc.pollWait(numFailedPolls)):
 			}
 		}
 		firstLoop = false
@@ -104,7 +116,7 @@ func (ccc *cccpConfigController) doLoop() error {
 
 		numNodes := iter.NumPipelines()
 		if numNodes == 0 {
-			logInfof("CCCPPOLL: No nodes available to poll, returning upstream")
+			ccc.logger.infof("CCCPPOLL: No nodes available to poll, returning upstream")
 			return errNoCCCPHosts
 		}
 
@@ -135,31 +147,31 @@ func (ccc *cccpConfigController) doLoop() error {
 				// If we cancelled the request or we're shutting down the connection then it's not really unexpected.
 				if errors.Is(err, ErrRequestCanceled) || errors.Is(err, ErrShutdown) {
 					wasCancelled = true
-					logDebugf("CCCPPOLL: CCCP request was cancelled or connection was shutdown: %v", err)
+					ccc.logger.debugf("CCCPPOLL: CCCP request was cancelled or connection was shutdown: %v", err)
 					return true
 				}
 
 				// This error is checked by WaitUntilReady when no config has been seen.
 				ccc.setError(err)
 
-				logWarnf("CCCPPOLL: Failed to retrieve CCCP config. %s", err)
+				ccc.logger.warnf("CCCPPOLL: Failed to retrieve CCCP config. %s", err)
 				return false
 			}
 			fallbackErr = nil
 			ccc.setError(nil)
 
 			if len(cccpBytes) > 0 {
-				logDebugf("CCCPPOLL: Got Block: %s", string(cccpBytes))
+				ccc.logger.debugf("CCCPPOLL: Got Block: %s", string(cccpBytes))
 
 				hostName, err := hostFromHostPort(pipeline.Address())
 				if err != nil {
-					logWarnf("CCCPPOLL: Failed to parse source address. %s", err)
+					ccc.logger.warnf("CCCPPOLL: Failed to parse source address. %s", err)
 					return false
 				}
 
 				bk, err := parseConfig(cccpBytes, hostName)
 				if err != nil {
-					logWarnf("CCCPPOLL: Failed to parse CCCP config. %v", err)
+					ccc.logger.warnf("CCCPPOLL: Failed to parse CCCP config. %v", err)
 					return false
 				}
 
@@ -171,16 +183,18 @@ func (ccc *cccpConfigController) doLoop() error {
 		})
 		if fallbackErr != nil {
 			// This error is indicative of a memcached bucket which we can't handle so return the error.
-			logInfof("CCCPPOLL: CCCP not supported, returning error upstream.")
+			ccc.logger.infof("CCCPPOLL: CCCP not supported, returning error upstream.")
 			return fallbackErr
 		}
 
 		if numNodesSupportNotifs == numNodes {
+			numFailedPolls = 0
 			continue
 		}
 
 		if configAlreadyLatest {
-			logDebugf("CCCPPOLL: Received empty config")
+			ccc.logger.debugf("CCCPPOLL: Received empty config")
+			numFailedPolls = 0
 			continue
 		}
 
@@ -188,15 +202,17 @@ func (ccc *cccpConfigController) doLoop() error {
 			// Only log the error at warn if it's unexpected.
 			// If we cancelled the request then we're shutting down or request was requeued and this isn't unexpected.
 			if wasCancelled {
-				logDebugf("CCCPPOLL: CCCP request was cancelled.")
+				ccc.logger.debugf("CCCPPOLL: CCCP request was cancelled.")
 			} else {
-				logWarnf("CCCPPOLL: Failed to retrieve config from any node.")
+				ccc.logger.warnf("CCCPPOLL: Failed to retrieve config from any node.")
 				ccc.noConfigFoundFn(err)
+				numFailedPolls++
 			}
 			continue
 		}
 
-		logDebugf("CCCPPOLL: Received new config")
+		ccc.logger.debugf("CCCPPOLL: Received new config")
+		numFailedPolls = 0
 		ccc.cfgMgr.OnNewConfig(foundConfig)
 
 	}
@@ -204,6 +220,21 @@ func (ccc *cccpConfigController) doLoop() error {
 	return nil
 }
 
+// pollWait returns how long to wait before the next poll, backing off if the previous polls failed. The wait is never
+// less than the poll period.
+func (ccc *cccpConfigController) pollWait(numFailedPolls uint32) time.Duration {
+	if numFailedPolls == 0 || ccc.confCccpRetryBackoff == nil {
+		return ccc.confCccpPollPeriod
+	}
+
+	wait := ccc.confCccpRetryBackoff(numFailedPolls)
+	if wait < ccc.confCccpPollPeriod {
+		return ccc.confCccpPollPeriod
+	}
+
+	return wait
+}
+
 func (ccc *cccpConfigController) getClusterConfig(pipeline *memdPipeline) ([]byte, error) {
 	revID, revEpoch := ccc.cfgMgr.CurrentRev()
 	cfg, err := ccc.cccpFetcher.GetClusterConfig(pipeline, revID, revEpoch, ccc.looperStopSig)
@@ -214,7 +245,7 @@ func (ccc *cccpConfigController) getClusterConfig(pipeline *memdPipeline) ([]byt
 			for _, cli := range clients {
 				err := cli.Error()
 				if err != nil {
-					logDebugf("Found error in pipeline client %p/%s: %v", cli, cli.address, err)
+					ccc.logger.debugf("Found error in pipeline client %p/%s: %v", cli, cli.address, err)
 					return nil, err
 				}
 			}
@@ -0,0 +1,52 @@
+package gocbcore
+
+import (
+	"time"
+)
+
+func (suite *UnitTestSuite) TestCCCPConfigControllerPollWait() {
+	ccc := newCCCPConfigController(cccpPollerProperties{
+		confCccpPollPeriod:   time.Second,
+		confCccpRetryBackoff: defaultCCCPRetryBackoff(time.Second, 10*time.Second),
+	}, nil, nil, nil, nil)
+
+	suite.Assert().Equal(time.Second, ccc.pollWait(0))
+	suite.Assert().Equal(2*time.Second, ccc.pollWait(1))
+	suite.Assert().Equal(4*time.Second, ccc.pollWait(2))
+	suite.Assert().Equal(10*time.Second, ccc.pollWait(10))
+
+	ccc = newCCCPConfigController(cccpPollerProperties{
+		confCccpPollPeriod: 2500 * time.Millisecond,
+		confCccpRetryBackoff: func(retryAttempt uint32) time.Duration {
+			return time.Duration(retryAttempt) * time.Minute
+		},
+	}, nil, nil, nil, nil)
+
+	suite.Assert().Equal(2500*time.Millisecond, ccc.pollWait(0))
+	suite.Assert().Equal(3*time.Minute, ccc.pollWait(3))
+}
+
+func (suite *UnitTestSuite) TestCCCPConfigControllerPollWaitNeverShorterThanPollPeriod() {
+	pollPeriod := 2500 * time.Millisecond
+	backoffs := map[string]BackoffCalculator{
+		// The defaults, where the max wait is only just above the poll period.
+		"default": defaultCCCPRetryBackoff(pollPeriod, 3*time.Second),
+		// A max wait below the poll period must not shorten the wait.
+		"low max wait": defaultCCCPRetryBackoff(pollPeriod, time.Second),
+		"user": func(retryAttempt uint32) time.Duration {
+			return time.Duration(retryAttempt) * 100 * time.Millisecond
+		},
+	}
+
+	for name, backoff := range backoffs {
+		ccc := newCCCPConfigController(cccpPollerProperties{
+			confCccpPollPeriod:   pollPeriod,
+			confCccpRetryBackoff: backoff,
+		}, nil, nil, nil, nil)
+
+		for numFailedPolls := uint32(1); numFailedPolls <= 20; numFailedPolls++ {
+			suite.Assert().GreaterOrEqual(ccc.pollWait(numFailedPolls), pollPeriod, "%s after %d failed polls", name,
+				numFailedPolls)
+		}
+	}
+}
@@ -13,6 +13,24 @@ const (
 	circuitBreakerStateOpen
 )
 
+// CircuitBreakerState represents the state of a circuit breaker.
+type CircuitBreakerState uint32
+
+const (
+	// CircuitBreakerStateClosed indicates that the circuit breaker is allowing requests.
+	CircuitBreakerStateClosed = CircuitBreakerState(circuitBreakerStateClosed)
+
+	// CircuitBreakerStateHalfOpen indicates that the circuit breaker has sent a canary request and is waiting on the
+	// result before allowing requests.
+	CircuitBreakerStateHalfOpen = CircuitBreakerState(circuitBreakerStateHalfOpen)
+
+	// CircuitBreakerStateOpen indicates that the circuit breaker is rejecting requests.
+	CircuitBreakerStateOpen = CircuitBreakerState(circuitBreakerStateOpen)
+)
+
+// CircuitBreakerStateChangeCallback is the callback invoked when a circuit breaker changes state.
+type CircuitBreakerStateChangeCallback func(endpoint string, from, to CircuitBreakerState)
+
 type circuitBreaker interface {
 	AllowsRequest() bool
 	MarkSuccessful()
@@ -40,10 +58,24 @@ type CircuitBreakerConfig struct {
 	SleepWindow time.Duration
 	// RollingWindow is the rolling timeframe which is used to calculate the error threshold percentage.
 	RollingWindow time.Duration
-	// CompletionCallback is called on every response to determine if it is successful or not.
+	// CompletionCallback is called on every response to determine if it is successful or not, returning true
+	// indicates success. This is optional, by default only timeouts count as failures so application level errors
+	// such as ErrDocumentNotFound, ErrCasMismatch and ErrDocumentLocked never open the circuit. Requests which cannot
+	// be written to the connection always count as failures, as do canaries which fail with any error.
 	CompletionCallback CircuitBreakerCallback
 	// CanaryTimeout is the timeout for the canary request until it is deemed failed.
 	CanaryTimeout time.Duration
+	// OnStateChange, if set, is called whenever the circuit breaker for an endpoint changes state. It is called
+	// asynchronously so invocations for different transitions may be observed out of order.
+	OnStateChange CircuitBreakerStateChangeCallback
+}
+
+// HealthChecker allows external health signals to influence the endpoints which requests are routed to.
+// IsHealthy is called with the address of a KV node (host:port) or the URL of an HTTP service endpoint before a
+// request is sent to it, when false is returned the endpoint is treated as though its circuit breaker is open.
+// IsHealthy is called on hot paths and must not block.
+type HealthChecker interface {
+	IsHealthy(endpoint string) bool
 }
 
 type noopCircuitBreaker struct {
@@ -90,10 +122,12 @@ type lazyCircuitBreaker struct {
 	openedAt                 int64
 	sendCanaryFn             func()
 	completionCallback       CircuitBreakerCallback
+	onStateChange            CircuitBreakerStateChangeCallback
+	endpoint                 string
 	state                    uint32
 }
 
-func newLazyCircuitBreaker(config CircuitBreakerConfig, canaryFn func()) *lazyCircuitBreaker {
+func newLazyCircuitBreaker(config CircuitBreakerConfig, endpoint string, canaryFn func()) *lazyCircuitBreaker {
 	if config.VolumeThreshold == 0 {
 		config.VolumeThreshold = 20
 	}
@@ -123,6 +157,8 @@ func newLazyCircuitBreaker(config CircuitBreakerConfig, canaryFn func()) *lazyCi
 		canaryTimeout:            config.CanaryTimeout,
 		sendCanaryFn:             canaryFn,
 		completionCallback:       config.CompletionCallback,
+		onStateChange:            config.OnStateChange,
+		endpoint:                 endpoint,
 	}
 	breaker.Reset()
 
@@ -150,6 +186,7 @@ func (lcb *lazyCircuitBreaker) AllowsRequest() bool {
 
 	elapsed := (time.Now().UnixNano() - atomic.LoadInt64(&lcb.openedAt)) > lcb.sleepWindow
 	if elapsed && atomic.CompareAndSwapUint32(&lcb.state, circuitBreakerStateOpen, circuitBreakerStateHalfOpen) {
+		lcb.notifyStateChange(circuitBreakerStateOpen, circuitBreakerStateHalfOpen)
 		// If we're outside of the sleep window and the circuit is open then send a canary.
 		go lcb.sendCanaryFn()
 	}
@@ -160,6 +197,7 @@ func (lcb *lazyCircuitBreaker) MarkSuccessful() {
 	if atomic.CompareAndSwapUint32(&lcb.state, circuitBreakerStateHalfOpen, circuitBreakerStateClosed) {
 		logDebugf("Moving circuit breaker to closed")
 		lcb.Reset()
+		lcb.notifyStateChange(circuitBreakerStateHalfOpen, circuitBreakerStateClosed)
 		return
 	}
 
@@ -172,6 +210,7 @@ func (lcb *lazyCircuitBreaker) MarkFailure() {
 	if atomic.CompareAndSwapUint32(&lcb.state, circuitBreakerStateHalfOpen, circuitBreakerStateOpen) {
 		logDebugf("Moving circuit breaker from half open to open")
 		atomic.StoreInt64(&lcb.openedAt, now)
+		lcb.notifyStateChange(circuitBreakerStateHalfOpen, circuitBreakerStateOpen)
 		return
 	}
 
@@ -197,11 +236,23 @@ func (lcb *lazyCircuitBreaker) maybeOpenCircuit() {
 	currentPercentage := (float64(atomic.LoadInt64(&lcb.failed)) / float64(atomic.LoadInt64(&lcb.total))) * 100
 	if currentPercentage >= lcb.errorPercentageThreshold {
 		logDebugf("Moving circuit breaker to open")
-		atomic.StoreUint32(&lcb.state, circuitBreakerStateOpen)
+		prevState := atomic.SwapUint32(&lcb.state, circuitBreakerStateOpen)
 		atomic.StoreInt64(&lcb.openedAt, time.Now().UnixNano())
+		if prevState != circuitBreakerStateOpen {
+			lcb.notifyStateChange(prevState, circuitBreakerStateOpen)
+		}
 	}
 }
 
+func (lcb *lazyCircuitBreaker) notifyStateChange(from, to uint32) {
+	if lcb.onStateChange == nil {
+		return
+	}
+
+	// The callback is invoked asynchronously so that user code can never block the request path.
+	go lcb.onStateChange(lcb.endpoint, CircuitBreakerState(from), CircuitBreakerState(to))
+}
+
 func (lcb *lazyCircuitBreaker) maybeResetRollingWindow() {
 	now := time.Now().UnixNano()
 	if (now - atomic.LoadInt64(&lcb.windowStart)) <= lcb.rollingWindow {
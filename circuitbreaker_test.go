@@ -1,8 +1,11 @@
 package gocbcore
 
 import (
+	"errors"
 	"sync/atomic"
 	"time"
+
+	"github.com/stretchr/testify/mock"
 )
 
 func (suite *StandardTestSuite) TestLazyCircuitBreakerSuccessfulCanary() {
@@ -13,7 +16,7 @@ func (suite *StandardTestSuite) TestLazyCircuitBreakerSuccessfulCanary() {
 		ErrorThresholdPercentage: 60,
 		SleepWindow:              10 * time.Millisecond,
 		RollingWindow:            70 * time.Millisecond,
-	}, func() {
+	}, "10.112.192.101:11210", func() {
 		atomic.StoreInt32(&canarySent, 1)
 		breaker.MarkSuccessful()
 	})
@@ -82,7 +85,7 @@ func (suite *StandardTestSuite) TestLazyCircuitBreakerFailedCanary() {
 		ErrorThresholdPercentage: 60,
 		SleepWindow:              10 * time.Millisecond,
 		RollingWindow:            70 * time.Millisecond,
-	}, func() {
+	}, "10.112.192.101:11210", func() {
 		atomic.StoreInt32(&canarySent, 1)
 		breaker.MarkFailure()
 	})
@@ -151,7 +154,7 @@ func (suite *StandardTestSuite) TestLazyCircuitBreakerReset() {
 		ErrorThresholdPercentage: 60,
 		SleepWindow:              10 * time.Millisecond,
 		RollingWindow:            1 * time.Second,
-	}, func() {
+	}, "10.112.192.101:11210", func() {
 		atomic.StoreInt32(&canarySent, 1)
 		breaker.MarkFailure()
 	})
@@ -209,3 +212,121 @@ func (suite *StandardTestSuite) TestLazyCircuitBreakerReset() {
 		suite.T().Fatalf("Circuit breaker should have allowed request")
 	}
 }
+
+type testHealthChecker struct {
+	unhealthy map[string]bool
+}
+
+func (hc *testHealthChecker) IsHealthy(endpoint string) bool {
+	return !hc.unhealthy[endpoint]
+}
+
+func (suite *UnitTestSuite) TestHTTPComponentHealthChecker() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	healthChecker := &testHealthChecker{
+		unhealthy: map[string]bool{
+			"http://10.112.210.101:8093": true,
+		},
+	}
+	mux := newHTTPMux(CircuitBreakerConfig{}, cfgMgr, &httpClientMux{
+		n1qlEpList: []routeEndpoint{
+			{Address: "http://10.112.210.101:8093"},
+			{Address: "http://10.112.210.102:8093"},
+		},
+	}, false, nil)
+	hc := newHTTPComponentWithClient(httpComponentProps{HealthChecker: healthChecker}, nil, mux, nil)
+
+	denylist := make([]string, 0, 4)
+	for i := 0; i < 20; i++ {
+		endpoint, err := hc.randomEndpoint(N1qlService, denylist)
+		suite.Require().Nil(err, err)
+		suite.Assert().Equal("http://10.112.210.102:8093", endpoint)
+	}
+	// The callers denylist must not have been written to.
+	suite.Assert().Empty(denylist[:1][0])
+
+	healthChecker.unhealthy["http://10.112.210.102:8093"] = true
+	_, err := hc.randomEndpoint(N1qlService, nil)
+	suite.Assert().True(errors.Is(err, ErrCircuitBreakerOpen))
+
+	// With no endpoints for the service at all the original error should be returned.
+	_, err = hc.randomEndpoint(FtsService, nil)
+	suite.Assert().True(errors.Is(err, ErrServiceNotAvailable))
+}
+
+func (suite *UnitTestSuite) TestLazyCircuitBreakerOnStateChange() {
+	type transition struct {
+		endpoint string
+		from     CircuitBreakerState
+		to       CircuitBreakerState
+	}
+	transitionsCh := make(chan transition, 10)
+
+	var breaker *lazyCircuitBreaker
+	breaker = newLazyCircuitBreaker(CircuitBreakerConfig{
+		VolumeThreshold:          2,
+		ErrorThresholdPercentage: 50,
+		SleepWindow:              10 * time.Millisecond,
+		RollingWindow:            time.Minute,
+		OnStateChange: func(endpoint string, from, to CircuitBreakerState) {
+			transitionsCh <- transition{endpoint: endpoint, from: from, to: to}
+		},
+	}, "10.112.192.101:11210", func() {
+		breaker.MarkSuccessful()
+	})
+
+	nextTransition := func() transition {
+		select {
+		case t := <-transitionsCh:
+			return t
+		case <-time.After(time.Second):
+			suite.T().Fatalf("Timed out waiting for state change")
+		}
+		return transition{}
+	}
+
+	breaker.MarkFailure()
+	breaker.MarkFailure()
+	suite.Assert().Equal(transition{"10.112.192.101:11210", CircuitBreakerStateClosed, CircuitBreakerStateOpen},
+		nextTransition())
+
+	// Further failures whilst open must not be reported as transitions.
+	breaker.MarkFailure()
+
+	time.Sleep(20 * time.Millisecond)
+	suite.Assert().False(breaker.AllowsRequest())
+
+	// The open to half open and half open to closed transitions are reported asynchronously so may arrive in any order.
+	transitions := []transition{nextTransition(), nextTransition()}
+	suite.Assert().ElementsMatch([]transition{
+		{"10.112.192.101:11210", CircuitBreakerStateOpen, CircuitBreakerStateHalfOpen},
+		{"10.112.192.101:11210", CircuitBreakerStateHalfOpen, CircuitBreakerStateClosed},
+	}, transitions)
+
+	select {
+	case t := <-transitionsCh:
+		suite.T().Fatalf("Unexpected state change: %v", t)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func (suite *UnitTestSuite) TestLazyCircuitBreakerDefaultCompletionCallback() {
+	breaker := newLazyCircuitBreaker(CircuitBreakerConfig{}, "10.112.192.101:11210", func() {})
+
+	suite.Assert().True(breaker.CompletionCallback(nil))
+	suite.Assert().True(breaker.CompletionCallback(errDocumentNotFound))
+	suite.Assert().True(breaker.CompletionCallback(errCasMismatch))
+	suite.Assert().True(breaker.CompletionCallback(errDocumentLocked))
+	suite.Assert().False(breaker.CompletionCallback(errUnambiguousTimeout))
+	suite.Assert().False(breaker.CompletionCallback(&TimeoutError{InnerError: errAmbiguousTimeout}))
+
+	breaker = newLazyCircuitBreaker(CircuitBreakerConfig{
+		CompletionCallback: func(err error) bool {
+			return !errors.Is(err, ErrDocumentLocked)
+		},
+	}, "10.112.192.101:11210", func() {})
+	suite.Assert().False(breaker.CompletionCallback(errDocumentLocked))
+	suite.Assert().True(breaker.CompletionCallback(errDocumentNotFound))
+}
@@ -0,0 +1,191 @@
+package gocbcore
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type clockSkewComponent struct {
+	stats     *statsComponent
+	interval  time.Duration
+	threshold time.Duration
+
+	lock  sync.Mutex
+	skews map[string]time.Duration
+	// maxSkew is the largest absolute skew seen in the last check, in nanoseconds.
+	maxSkew int64
+	// warnedExpiry is used to only log a single absolute expiry warning per check.
+	warnedExpiry uint32
+
+	firstConfigSig chan struct{}
+	firstConfig    uint32
+	stopSig        chan struct{}
+	logger         *scopedLogger
+}
+
+func newClockSkewComponent(stats *statsComponent, cfgMgr configManager, interval, threshold time.Duration,
+	logger *scopedLogger) *clockSkewComponent {
+	csc := &clockSkewComponent{
+		stats:          stats,
+		interval:       interval,
+		threshold:      threshold,
+		skews:          make(map[string]time.Duration),
+		firstConfigSig: make(chan struct{}),
+		stopSig:        make(chan struct{}),
+		logger:         logger,
+	}
+
+	cfgMgr.AddConfigWatcher(csc)
+
+	return csc
+}
+
+func (csc *clockSkewComponent) OnNewRouteConfig(cfg *routeConfig) {
+	if cfg.revID < 0 {
+		return
+	}
+
+	if atomic.CompareAndSwapUint32(&csc.firstConfig, 0, 1) {
+		close(csc.firstConfigSig)
+	}
+}
+
+func (csc *clockSkewComponent) Start() {
+	// Perform the first check as soon as we've bootstrapped and then periodically after that.
+	select {
+	case <-csc.stopSig:
+		return
+	case <-csc.firstConfigSig:
+	}
+
+	for {
+		csc.check()
+
+		select {
+		case <-csc.stopSig:
+			return
+		case <-time.After(csc.interval):
+		}
+	}
+}
+
+func (csc *clockSkewComponent) Stop() {
+	close(csc.stopSig)
+}
+
+func (csc *clockSkewComponent) check() {
+	waitCh := make(chan struct{}, 1)
+	start := time.Now()
+	_, err := csc.stats.Stats(StatsOptions{
+		Deadline: start.Add(csc.interval),
+	}, func(res *StatsResult, err error) {
+		defer func() {
+			waitCh <- struct{}{}
+		}()
+
+		if err != nil {
+			csc.logger.debugf("Failed to fetch stats for clock skew detection: %v", err)
+			return
+		}
+
+		// Use the midpoint of the request to minimise the effect of network latency on the result.
+		end := time.Now()
+		localTime := start.Add(end.Sub(start) / 2)
+		csc.handleStats(localTime, res)
+	})
+	if err != nil {
+		csc.logger.debugf("Failed to dispatch stats for clock skew detection: %v", err)
+		return
+	}
+
+	select {
+	case <-csc.stopSig:
+	case <-waitCh:
+	}
+}
+
+func (csc *clockSkewComponent) handleStats(localTime time.Time, res *StatsResult) {
+	skews := make(map[string]time.Duration)
+	var maxSkew time.Duration
+	for address, server := range res.Servers {
+		if server.Error != nil {
+			continue
+		}
+
+		serverTimeStr, ok := server.Stats["time"]
+		if !ok {
+			continue
+		}
+
+		serverTime, err := strconv.ParseInt(serverTimeStr, 10, 64)
+		if err != nil {
+			csc.logger.debugf("Failed to parse server time for clock skew detection: %v", err)
+			continue
+		}
+
+		// The server only reports time to the second so we truncate our own time to match.
+		skew := localTime.Truncate(time.Second).Sub(time.Unix(serverTime, 0))
+		skews[address] = skew
+
+		absSkew := skew
+		if absSkew < 0 {
+			absSkew = -absSkew
+		}
+		if absSkew > maxSkew {
+			maxSkew = absSkew
+		}
+
+		if absSkew > csc.threshold {
+			csc.logger.warnf("Detected clock skew of %s between the client and %s, absolute expiry times may be "+
+				"applied incorrectly", skew, redactSystemData(address))
+		}
+	}
+
+	csc.lock.Lock()
+	csc.skews = skews
+	csc.lock.Unlock()
+	atomic.StoreInt64(&csc.maxSkew, int64(maxSkew))
+	atomic.StoreUint32(&csc.warnedExpiry, 0)
+}
+
+// Skews returns the clock skew detected for each server during the last check. A positive value indicates that
+// the client clock is ahead of the server.
+func (csc *clockSkewComponent) Skews() map[string]time.Duration {
+	if csc == nil {
+		return nil
+	}
+
+	csc.lock.Lock()
+	defer csc.lock.Unlock()
+
+	if len(csc.skews) == 0 {
+		return nil
+	}
+
+	skews := make(map[string]time.Duration, len(csc.skews))
+	for address, skew := range csc.skews {
+		skews[address] = skew
+	}
+
+	return skews
+}
+
+// CheckExpiry logs a warning if the expiry will be treated as an absolute timestamp by the server whilst
+// significant clock skew has been detected.
+func (csc *clockSkewComponent) CheckExpiry(expiry uint32) {
+	if csc == nil || expiry <= relativeExpiryLimit {
+		return
+	}
+
+	maxSkew := time.Duration(atomic.LoadInt64(&csc.maxSkew))
+	if maxSkew <= csc.threshold {
+		return
+	}
+
+	if atomic.CompareAndSwapUint32(&csc.warnedExpiry, 0, 1) {
+		csc.logger.warnf("Absolute expiry of %d used whilst clock skew of up to %s has been detected, the document "+
+			"may expire at an unexpected time", expiry, maxSkew)
+	}
+}
@@ -0,0 +1,56 @@
+package gocbcore
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestClockSkewComponentHandleStats() {
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	csc := &clockSkewComponent{
+		threshold: 5 * time.Second,
+		skews:     make(map[string]time.Duration),
+	}
+
+	now := time.Unix(1700000000, 0)
+	csc.handleStats(now, &StatsResult{
+		Servers: map[string]SingleServerStats{
+			"10.112.210.101:11210": {
+				Stats: map[string]string{"time": strconv.FormatInt(now.Unix()-2, 10)},
+			},
+			"10.112.210.102:11210": {
+				Stats: map[string]string{"time": strconv.FormatInt(now.Unix()+30, 10)},
+			},
+			"10.112.210.103:11210": {
+				Error: errors.New("connection refused"),
+			},
+			"10.112.210.104:11210": {
+				Stats: map[string]string{"time": "notanumber"},
+			},
+		},
+	})
+
+	skews := csc.Skews()
+	suite.Require().Len(skews, 2)
+	suite.Assert().Equal(2*time.Second, skews["10.112.210.101:11210"])
+	suite.Assert().Equal(-30*time.Second, skews["10.112.210.102:11210"])
+	suite.Assert().Equal(int64(30*time.Second), atomic.LoadInt64(&csc.maxSkew))
+
+	// A relative expiry should not trigger the warning.
+	csc.CheckExpiry(60)
+	suite.Assert().Zero(atomic.LoadUint32(&csc.warnedExpiry))
+
+	csc.CheckExpiry(uint32(now.Add(24 * time.Hour * 60).Unix()))
+	suite.Assert().Equal(uint32(1), atomic.LoadUint32(&csc.warnedExpiry))
+}
+
+func (suite *UnitTestSuite) TestClockSkewComponentNil() {
+	var csc *clockSkewComponent
+
+	suite.Assert().Nil(csc.Skews())
+	csc.CheckExpiry(uint32(time.Now().Unix()))
+}
@@ -32,7 +32,7 @@ func createClusterAgent(config *clusterAgentConfig) (*clusterAgent, error) {
 
 	c.tracer = newTracerComponent(config.TracerConfig.Tracer, "", config.TracerConfig.NoRootTraceSpans, config.MeterConfig.Meter, c)
 
-	tlsConfig, err := setupTLSConfig(config.SeedConfig.MemdAddrs, config.SecurityConfig)
+	tlsConfig, err := setupTLSConfig(config.SeedConfig.MemdAddrs, config.SecurityConfig, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +75,7 @@ func createClusterAgent(config *clusterAgentConfig) (*clusterAgent, error) {
 		c,
 		&httpClientMux{tlsConfig: tlsConfig, auth: config.SecurityConfig.Auth},
 		config.SecurityConfig.NoTLSSeedNode,
+		nil,
 	)
 	c.http = newHTTPComponent(
 		httpComponentProps{
@@ -86,17 +87,18 @@ func createClusterAgent(config *clusterAgentConfig) (*clusterAgent, error) {
 			maxIdleConnsPerHost: config.HTTPConfig.MaxIdleConnsPerHost,
 			idleTimeout:         httpIdleConnTimeout,
 			connectTimeout:      httpConnectTimeout,
+			transportFactory:    config.HTTPTransportFactory,
 		},
 		c.httpMux,
 		c.tracer,
 	)
-	c.n1ql = newN1QLQueryComponent(c.http, c, c.tracer)
-	c.analytics = newAnalyticsQueryComponent(c.http, c.tracer)
-	c.search = newSearchQueryComponent(c.http, c, c.tracer)
-	c.views = newViewQueryComponent(c.http, c.tracer)
+	c.n1ql = newN1QLQueryComponent(c.http, c, c.tracer, config.HTTPConfig.QueryTimeout, nil)
+	c.analytics = newAnalyticsQueryComponent(c.http, c.tracer, config.HTTPConfig.AnalyticsTimeout, nil)
+	c.search = newSearchQueryComponent(c.http, c, c.tracer, config.HTTPConfig.SearchTimeout, nil)
+	c.views = newViewQueryComponent(c.http, c.tracer, config.HTTPConfig.ViewTimeout, nil)
 	// diagnostics at this level will never need to hook KV. There are no persistent connections
 	// so Diagnostics calls should be blocked. Ping and WaitUntilReady will only try HTTP services.
-	c.diagnostics = newDiagnosticsComponent(nil, c.httpMux, c.http, "", c.defaultRetryStrategy, nil)
+	c.diagnostics = newDiagnosticsComponent(nil, c.httpMux, c.http, "", c.defaultRetryStrategy, nil, nil)
 
 	// Kick everything off.
 	cfg := &routeConfig{
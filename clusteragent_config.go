@@ -1,5 +1,7 @@
 package gocbcore
 
+import "net/http"
+
 type clusterAgentConfig struct {
 	UserAgent string
 
@@ -9,6 +11,8 @@ type clusterAgentConfig struct {
 
 	HTTPConfig HTTPConfig
 
+	HTTPTransportFactory func() *http.Transport
+
 	TracerConfig TracerConfig
 
 	MeterConfig MeterConfig
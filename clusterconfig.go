@@ -0,0 +1,77 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// ClusterConfig is the routing information contained within a cluster config, as exported by
+// Agent.ExportClusterConfig, allowing external processes to map keys to nodes.
+// Volatile: This API is subject to change at any time.
+type ClusterConfig struct {
+	RevID    int64
+	RevEpoch int64
+
+	// BucketName is the name of the bucket that the config is for, empty for a cluster level config.
+	BucketName string
+
+	// ServerList is the list of KV addresses, as host:port, indexed by VbucketMap.
+	ServerList []string
+
+	// VbucketMap contains an entry for each vbucket listing the index in ServerList of the active followed by each
+	// replica. An index of -1 indicates that there is no node for that copy of the vbucket.
+	VbucketMap [][]int
+
+	// NumReplicas is the number of replicas configured for the bucket.
+	NumReplicas int
+
+	// CollectionsManifestUID is the uid of the bucket's collections manifest, 0 if it is not known.
+	CollectionsManifestUID uint64
+}
+
+// ParseClusterConfig parses a JSON cluster config, such as one returned by Agent.ExportClusterConfig.
+// Volatile: This API is subject to change at any time.
+func ParseClusterConfig(data []byte) (*ClusterConfig, error) {
+	var cfg cfgBucket
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, wrapError(errInvalidArgument, "failed to parse cluster config: "+err.Error())
+	}
+
+	var manifestUID uint64
+	if cfg.CollectionsManifestUID != "" {
+		var err error
+		manifestUID, err = strconv.ParseUint(cfg.CollectionsManifestUID, 16, 64)
+		if err != nil {
+			return nil, wrapError(errInvalidArgument, "failed to parse collections manifest uid: "+err.Error())
+		}
+	}
+
+	return &ClusterConfig{
+		RevID:                  cfg.Rev,
+		RevEpoch:               cfg.RevEpoch,
+		BucketName:             cfg.Name,
+		ServerList:             cfg.VBucketServerMap.ServerList,
+		VbucketMap:             cfg.VBucketServerMap.VBucketMap,
+		NumReplicas:            cfg.VBucketServerMap.NumReplicas,
+		CollectionsManifestUID: manifestUID,
+	}, nil
+}
+
+// ExportClusterConfig returns the cluster config currently in use by the agent as JSON, which can be parsed using
+// ParseClusterConfig or persisted for later analysis. The JSON is re-encoded from the config as parsed by the agent
+// so any fields not used by the SDK are omitted, and placeholder hostnames have been replaced.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ExportClusterConfig() ([]byte, error) {
+	select {
+	case <-agent.shutdownSig:
+		return nil, errShutdown
+	default:
+	}
+
+	cfg := agent.cfgManager.CurrentBucketConfig()
+	if cfg == nil {
+		return nil, wrapError(errServiceNotAvailable, "no cluster config has been applied yet")
+	}
+
+	return json.Marshal(cfg)
+}
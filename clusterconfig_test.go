@@ -0,0 +1,136 @@
+package gocbcore
+
+import (
+	"github.com/couchbase/gocbcore/v10/memdmock"
+)
+
+func (suite *UnitTestSuite) TestParseClusterConfig() {
+	cfgBytes, err := suite.LoadRawTestDataset("bucket_config_with_rev_epoch")
+	suite.Require().Nil(err, err)
+
+	cfg, err := ParseClusterConfig(cfgBytes)
+	suite.Require().Nil(err, err)
+
+	suite.Assert().Equal(int64(2), cfg.RevID)
+	suite.Assert().Equal(int64(2), cfg.RevEpoch)
+	suite.Assert().Equal("travel-sample", cfg.BucketName)
+	suite.Assert().Equal([]string{"$HOST:11210"}, cfg.ServerList)
+	suite.Assert().Equal(1, cfg.NumReplicas)
+	suite.Assert().Len(cfg.VbucketMap, 1024)
+	suite.Assert().Equal([]int{0, -1}, cfg.VbucketMap[0])
+	suite.Assert().Equal(uint64(1), cfg.CollectionsManifestUID)
+
+	_, err = ParseClusterConfig([]byte("{"))
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+
+	_, err = ParseClusterConfig([]byte(`{"collectionsManifestUid":"zz"}`))
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *UnitTestSuite) TestAgentExportClusterConfig() {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		Username: "user",
+		Password: "pass",
+	})
+	suite.Require().Nil(err, err)
+	defer srv.Close()
+
+	agent := suite.createMemdMockAgent(srv, nil)
+
+	cfgBytes, err := agent.ExportClusterConfig()
+	suite.Require().Nil(err, err)
+
+	cfg, err := ParseClusterConfig(cfgBytes)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(int64(1), cfg.RevID)
+	suite.Assert().Equal(srv.BucketName(), cfg.BucketName)
+	suite.Assert().Equal([]string{srv.Address()}, cfg.ServerList)
+	suite.Assert().Len(cfg.VbucketMap, 64)
+
+	snapshot, err := agent.ConfigSnapshot()
+	suite.Require().Nil(err, err)
+	vbID, err := snapshot.KeyToVbucket([]byte("key"))
+	suite.Require().Nil(err, err)
+	serverIdx, err := snapshot.VbucketToServer(vbID, 0)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(cfg.VbucketMap[vbID][0], serverIdx)
+
+	suite.Require().Nil(agent.Close())
+
+	_, err = agent.ExportClusterConfig()
+	suite.Assert().ErrorIs(err, ErrShutdown)
+}
+
+func (suite *UnitTestSuite) TestAgentSeedClusterConfig() {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		Username: "user",
+		Password: "pass",
+	})
+	suite.Require().Nil(err, err)
+	defer srv.Close()
+
+	agent := suite.createMemdMockAgent(srv, nil)
+	cfgBytes, err := agent.ExportClusterConfig()
+	suite.Require().Nil(err, err)
+	suite.Require().Nil(agent.Close())
+
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr(srv.ConnStr()))
+	config.BucketName = srv.BucketName()
+	config.SecurityConfig.Auth = PasswordAuthProvider{Username: "user", Password: "pass"}
+	config.SecurityConfig.AuthMechanisms = []AuthMechanism{PlainAuthMechanism}
+	config.SeedConfig.ClusterConfig = cfgBytes
+
+	globalTestLogger.SuppressWarnings(true)
+	agent, err = CreateAgent(config)
+	globalTestLogger.SuppressWarnings(false)
+	suite.Require().Nil(err, err)
+	defer agent.Close()
+
+	// The seed config is applied before the agent has fetched one from the cluster, so it can be routed with
+	// immediately.
+	snapshot, err := agent.ConfigSnapshot()
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(int64(1), snapshot.RevID())
+	numVbuckets, err := snapshot.NumVbuckets()
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(64, numVbuckets)
+
+	getCh := make(chan error, 1)
+	_, err = agent.Get(GetOptions{
+		Key: []byte("key"),
+	}, func(res *GetResult, err error) {
+		getCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().ErrorIs(<-getCh, ErrDocumentNotFound)
+}
+
+func (suite *UnitTestSuite) TestAgentVbucketToServer() {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		Username: "user",
+		Password: "pass",
+	})
+	suite.Require().Nil(err, err)
+	defer srv.Close()
+
+	agent := suite.createMemdMockAgent(srv, nil)
+	defer agent.Close()
+
+	vbID, err := agent.KeyToVbucket([]byte("key"))
+	suite.Require().Nil(err, err)
+
+	snapshot, err := agent.ConfigSnapshot()
+	suite.Require().Nil(err, err)
+	snapshotVbID, err := snapshot.KeyToVbucket([]byte("key"))
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(snapshotVbID, vbID)
+
+	address, err := agent.VbucketToServer(vbID, 0)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(srv.Address(), address)
+
+	// The mock bucket has no replicas.
+	_, err = agent.VbucketToServer(vbID, 1)
+	suite.Assert().ErrorIs(err, ErrInvalidReplica)
+}
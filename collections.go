@@ -11,6 +11,15 @@ const (
 	pendingCid = uint32(0xFFFFFFFE)
 )
 
+// defaultCollectionManifest is the manifest returned when collections are not in use, in which case the default
+// collection is the only one which can be used.
+var defaultCollectionManifest = []byte(
+	`{"uid":"0","scopes":[{"uid":"0","name":"_default","collections":[{"uid":"0","name":"_default"}]}]}`)
+
+// CollectionManifestUpdateCallback is invoked with the uid of the collections manifest each time that a cluster
+// config containing a newer manifest uid is seen.
+type CollectionManifestUpdateCallback func(manifestUID uint64)
+
 // ManifestCollection is the representation of a collection within a manifest.
 type ManifestCollection struct {
 	UID     uint32
@@ -101,8 +110,10 @@ func (item *Manifest) UnmarshalJSON(data []byte) error {
 // GetCollectionManifestOptions are the options available to the GetCollectionManifest command.
 type GetCollectionManifestOptions struct {
 	TraceContext  RequestSpanContext
+	NoRootSpan    bool
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
@@ -111,8 +122,10 @@ type GetCollectionManifestOptions struct {
 // GetAllCollectionManifestsOptions are the options available to the GetAllCollectionManifests command.
 type GetAllCollectionManifestsOptions struct {
 	TraceContext  RequestSpanContext
+	NoRootSpan    bool
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
@@ -120,9 +133,15 @@ type GetAllCollectionManifestsOptions struct {
 
 // GetCollectionIDOptions are the options available to the GetCollectionID command.
 type GetCollectionIDOptions struct {
+	// AllowCached returns the collection ID from the cache used for operations, if it is present, rather than fetching
+	// it from the server. Cached IDs are invalidated whenever the server reports the collection as unknown.
+	AllowCached bool
+
 	RetryStrategy RetryStrategy
 	TraceContext  RequestSpanContext
+	NoRootSpan    bool
 	Deadline      time.Time
+	Timeout       time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
@@ -139,6 +158,40 @@ type GetCollectionIDResult struct {
 	}
 }
 
+// ScopeCollectionName identifies a collection by its scope and collection names.
+type ScopeCollectionName struct {
+	ScopeName      string
+	CollectionName string
+}
+
+// WarmCollectionsOptions are the options available to the WarmCollections command.
+type WarmCollectionsOptions struct {
+	Collections   []ScopeCollectionName
+	RetryStrategy RetryStrategy
+	TraceContext  RequestSpanContext
+	NoRootSpan    bool
+	Deadline      time.Time
+	Timeout       time.Duration
+
+	// Internal: This should never be used and is not supported.
+	User string
+}
+
+// WarmCollectionResult encapsulates the result of resolving a single collection during a WarmCollections operation.
+type WarmCollectionResult struct {
+	ScopeName      string
+	CollectionName string
+	ManifestID     uint64
+	CollectionID   uint32
+	Error          error
+}
+
+// WarmCollectionsResult encapsulates the result of a WarmCollections operation. Collections are returned in the
+// same order that they were provided in the options.
+type WarmCollectionsResult struct {
+	Collections []WarmCollectionResult
+}
+
 // GetCollectionManifestResult encapsulates the result of a GetCollectionManifest operation.
 type GetCollectionManifestResult struct {
 	Manifest []byte
@@ -149,6 +202,16 @@ type GetCollectionManifestResult struct {
 	}
 }
 
+// ParseManifest parses the manifest JSON into a Manifest.
+func (res *GetCollectionManifestResult) ParseManifest() (*Manifest, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(res.Manifest, &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
 // SingleServerManifestResult encapsulates the result from a single server when using the GetAllCollectionManifests
 // operation.
 type SingleServerManifestResult struct {
@@ -24,17 +24,33 @@ type collectionsComponent struct {
 	maxQueueSize         int
 	tracer               *tracerComponent
 	defaultRetryStrategy RetryStrategy
-	cfgMgr               configManager
+	defaultTimeout       time.Duration
+	logger               *scopedLogger
 
 	// pendingOpQueue is used when collections are enabled but we've not yet seen a cluster config to confirm
 	// whether or not collections are supported.
 	pendingOpQueue *memdOpQueue
 	configSeen     uint32
+
+	maxKeyLength int
+
+	// manifestUID is the newest collections manifest uid seen in a cluster config, protected by mapLock.
+	manifestUID      uint64
+	onManifestUpdate CollectionManifestUpdateCallback
+	notifyLock       sync.Mutex
+	notifiedUID      uint64
 }
 
+// maxKeyLength is the maximum length of a key supported by the server, including the collection ID prefix.
+const maxKeyLength = 250
+
 type collectionIDProps struct {
 	MaxQueueSize         int
 	DefaultRetryStrategy RetryStrategy
+	MaxKeyLength         int
+	OnManifestUpdate     CollectionManifestUpdateCallback
+	DefaultTimeout       time.Duration
+	Logger               *scopedLogger
 }
 
 func newCollectionIDManager(props collectionIDProps, dispatcher dispatcher, tracer *tracerComponent,
@@ -45,8 +61,15 @@ func newCollectionIDManager(props collectionIDProps, dispatcher dispatcher, trac
 		maxQueueSize:         props.MaxQueueSize,
 		tracer:               tracer,
 		defaultRetryStrategy: props.DefaultRetryStrategy,
-		cfgMgr:               cfgMgr,
 		pendingOpQueue:       newMemdOpQueue(),
+		maxKeyLength:         maxKeyLength,
+		onManifestUpdate:     props.OnManifestUpdate,
+		defaultTimeout:       props.DefaultTimeout,
+		logger:               props.Logger,
+	}
+
+	if props.MaxKeyLength > 0 && props.MaxKeyLength < maxKeyLength {
+		cidMgr.maxKeyLength = props.MaxKeyLength
 	}
 
 	cfgMgr.AddConfigWatcher(cidMgr)
@@ -56,12 +79,13 @@ func newCollectionIDManager(props collectionIDProps, dispatcher dispatcher, trac
 }
 
 func (cidMgr *collectionsComponent) OnNewRouteConfig(cfg *routeConfig) {
+	cidMgr.handleManifestUID(cfg.collectionsManifestUID)
+
 	if !atomic.CompareAndSwapUint32(&cidMgr.configSeen, 0, 1) {
 		return
 	}
 
 	colsSupported := cfg.ContainsBucketCapability("collections")
-	cidMgr.cfgMgr.RemoveConfigWatcher(cidMgr)
 	cidMgr.pendingOpQueue.Close()
 	cidMgr.pendingOpQueue.Drain(func(request *memdQRequest) {
 		// Anything in this queue is here because collections were present so if we definitely don't support collections
@@ -74,6 +98,50 @@ func (cidMgr *collectionsComponent) OnNewRouteConfig(cfg *routeConfig) {
 	})
 }
 
+// handleManifestUID marks any cached collection IDs fetched from an older manifest than manifestUID as unknown, so
+// that they are resolved again before they are next used. A collection which is dropped and recreated keeps its name
+// but gets a new ID, so ops would otherwise be sent with the stale ID until the server rejected them.
+func (cidMgr *collectionsComponent) handleManifestUID(manifestUID uint64) {
+	if manifestUID == 0 {
+		return
+	}
+
+	cidMgr.mapLock.Lock()
+	if manifestUID <= cidMgr.manifestUID {
+		cidMgr.mapLock.Unlock()
+		return
+	}
+	cidMgr.manifestUID = manifestUID
+
+	for _, id := range cidMgr.idMap {
+		id.lock.Lock()
+		if id.id != unknownCid && id.id != pendingCid && id.manifestID < manifestUID {
+			cidMgr.logger.debugf("Collections manifest is now %d, invalidating cache entry for %s.%s from manifest %d",
+				manifestUID, id.scopeName, id.collectionName, id.manifestID)
+			id.setID(unknownCid)
+		}
+		id.lock.Unlock()
+	}
+	cidMgr.mapLock.Unlock()
+
+	if cidMgr.onManifestUpdate == nil {
+		return
+	}
+
+	// Config watchers must not block, so the callback is invoked from its own goroutine. The lock ensures that
+	// callbacks are invoked one at a time, and with increasing uids.
+	go func() {
+		cidMgr.notifyLock.Lock()
+		defer cidMgr.notifyLock.Unlock()
+
+		if manifestUID <= cidMgr.notifiedUID {
+			return
+		}
+		cidMgr.notifiedUID = manifestUID
+		cidMgr.onManifestUpdate(manifestUID)
+	}()
+}
+
 func (cidMgr *collectionsComponent) handleCollectionUnknown(req *memdQRequest) bool {
 	if !canRetryOnCollectionUnknown(req) {
 		return false
@@ -108,12 +176,29 @@ func (cidMgr *collectionsComponent) handleOpRoutingResp(resp *memdQResponse, req
 }
 
 func (cidMgr *collectionsComponent) GetCollectionManifest(opts GetCollectionManifestOptions, cb GetCollectionManifestCallback) (PendingOp, error) {
-	tracer := cidMgr.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "GetCollectionManifest", opts.TraceContext)
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, cidMgr.defaultTimeout)
+
+	if !cidMgr.dispatcher.CollectionsEnabled() {
+		manifest := make([]byte, len(defaultCollectionManifest))
+		copy(manifest, defaultCollectionManifest)
+		op := &localPendingOp{cancelCb: func() {
+			cb(nil, errRequestCanceled)
+		}}
+		// As with a cached GetCollectionID, the default manifest is delivered asynchronously.
+		go op.complete(func() {
+			cb(&GetCollectionManifestResult{
+				Manifest: manifest,
+			}, nil)
+		})
+		return op, nil
+	}
+
+	tracer := cidMgr.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "GetCollectionManifest", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
 			cb(nil, err)
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			return
 		}
 
@@ -150,7 +235,7 @@ func (cidMgr *collectionsComponent) GetCollectionManifest(opts GetCollectionMani
 
 	op, err := cidMgr.dispatcher.DispatchDirect(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -177,7 +262,9 @@ func (cidMgr *collectionsComponent) GetCollectionManifest(opts GetCollectionMani
 }
 
 func (cidMgr *collectionsComponent) GetAllCollectionManifests(opts GetAllCollectionManifestsOptions, cb GetAllCollectionManifestsCallback) (PendingOp, error) {
-	tracer := cidMgr.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "GetAllCollectionManifests", opts.TraceContext)
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, cidMgr.defaultTimeout)
+
+	tracer := cidMgr.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "GetAllCollectionManifests", opts.TraceContext, opts.NoRootSpan)
 
 	if opts.RetryStrategy == nil {
 		opts.RetryStrategy = cidMgr.defaultRetryStrategy
@@ -185,7 +272,7 @@ func (cidMgr *collectionsComponent) GetAllCollectionManifests(opts GetAllCollect
 
 	iter, err := cidMgr.dispatcher.PipelineSnapshot()
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -284,15 +371,56 @@ func (cidMgr *collectionsComponent) GetAllCollectionManifests(opts GetAllCollect
 	return op, nil
 }
 
+// localPendingOp is the PendingOp for a request which is answered locally, such as from the cache, rather than being
+// sent to the server. Only one of complete and Cancel will ever invoke its callback.
+type localPendingOp struct {
+	completed uint32
+	cancelCb  func()
+}
+
+func (op *localPendingOp) complete(cb func()) {
+	if atomic.CompareAndSwapUint32(&op.completed, 0, 1) {
+		cb()
+	}
+}
+
+func (op *localPendingOp) Cancel() {
+	if atomic.CompareAndSwapUint32(&op.completed, 0, 1) {
+		op.cancelCb()
+	}
+}
+
 // GetCollectionID does not trigger retries on unknown collection. This is because the request sets the scope and collection
 // name in the key rather than in the corresponding fields.
 func (cidMgr *collectionsComponent) GetCollectionID(scopeName string, collectionName string, opts GetCollectionIDOptions,
 	cb GetCollectionIDCallback) (PendingOp, error) {
-	tracer := cidMgr.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "GetCollectionID", opts.TraceContext)
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, cidMgr.defaultTimeout)
+
+	if opts.AllowCached {
+		if manifestID, collectionID, ok := cidMgr.cachedID(scopeName, collectionName); ok {
+			op := &localPendingOp{cancelCb: func() {
+				cb(nil, errRequestCanceled)
+			}}
+			// The callback is invoked asynchronously, as it is when the request is sent to the server, so that the
+			// caller can never be called back before GetCollectionID returns and the op can still be cancelled.
+			go op.complete(func() {
+				cb(&GetCollectionIDResult{
+					ManifestID:   manifestID,
+					CollectionID: collectionID,
+				}, nil)
+			})
+			return op, nil
+		}
+	}
+
+	tracer := cidMgr.tracer.StartTelemeteryHandler(metricValueServiceAnalyticsValue, "GetCollectionID", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			if errors.Is(err, ErrCollectionNotFound) || errors.Is(err, ErrScopeNotFound) {
+				cidMgr.invalidate(scopeName, collectionName)
+			}
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -300,7 +428,7 @@ func (cidMgr *collectionsComponent) GetCollectionID(scopeName string, collection
 		manifestID := binary.BigEndian.Uint64(resp.Extras[0:])
 		collectionID := binary.BigEndian.Uint32(resp.Extras[8:])
 
-		cidMgr.upsert(scopeName, collectionName, collectionID)
+		cidMgr.upsert(scopeName, collectionName, manifestID, collectionID)
 
 		res := GetCollectionIDResult{
 			ManifestID:   manifestID,
@@ -353,7 +481,7 @@ func (cidMgr *collectionsComponent) GetCollectionID(scopeName string, collection
 
 	op, err := cidMgr.dispatcher.DispatchDirect(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -379,7 +507,68 @@ func (cidMgr *collectionsComponent) GetCollectionID(scopeName string, collection
 	return op, nil
 }
 
-func (cidMgr *collectionsComponent) upsert(scopeName, collectionName string, value uint32) *collectionIDCache {
+// WarmCollections resolves the IDs for a number of collections in parallel, caching them so that subsequent operations
+// do not need to wait for the collection ID to be fetched. Failures are reported per collection.
+func (cidMgr *collectionsComponent) WarmCollections(opts WarmCollectionsOptions, cb WarmCollectionsCallback) (PendingOp, error) {
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, cidMgr.defaultTimeout)
+
+	results := make([]WarmCollectionResult, len(opts.Collections))
+	if len(results) == 0 {
+		cb(&WarmCollectionsResult{}, nil)
+		return &multiPendingOp{isIdempotent: true}, nil
+	}
+
+	op := &multiPendingOp{
+		isIdempotent: true,
+	}
+	var resultsLock sync.Mutex
+
+	opCompleteLocked := func() {
+		completed := op.IncrementCompletedOps()
+		if len(results)-int(completed) == 0 {
+			cb(&WarmCollectionsResult{Collections: results}, nil)
+		}
+	}
+
+	for i, name := range opts.Collections {
+		i := i
+		results[i] = WarmCollectionResult{
+			ScopeName:      name.ScopeName,
+			CollectionName: name.CollectionName,
+		}
+
+		curOp, err := cidMgr.GetCollectionID(name.ScopeName, name.CollectionName, GetCollectionIDOptions{
+			RetryStrategy: opts.RetryStrategy,
+			TraceContext:  opts.TraceContext,
+			NoRootSpan:    opts.NoRootSpan,
+			Deadline:      opts.Deadline,
+			User:          opts.User,
+		}, func(res *GetCollectionIDResult, err error) {
+			resultsLock.Lock()
+			if err != nil {
+				results[i].Error = err
+			} else {
+				results[i].CollectionID = res.CollectionID
+				results[i].ManifestID = res.ManifestID
+			}
+			opCompleteLocked()
+			resultsLock.Unlock()
+		})
+		if err != nil {
+			resultsLock.Lock()
+			results[i].Error = err
+			opCompleteLocked()
+			resultsLock.Unlock()
+			continue
+		}
+
+		op.AddOp(curOp)
+	}
+
+	return op, nil
+}
+
+func (cidMgr *collectionsComponent) upsert(scopeName, collectionName string, manifestID uint64, value uint32) *collectionIDCache {
 	cidMgr.mapLock.Lock()
 	id, ok := cidMgr.idMap[cidMgr.createKey(scopeName, collectionName)]
 	if !ok {
@@ -389,12 +578,47 @@ func (cidMgr *collectionsComponent) upsert(scopeName, collectionName string, val
 	}
 	id.lock.Lock()
 	id.setID(value)
+	id.manifestID = manifestID
 	id.lock.Unlock()
 	cidMgr.mapLock.Unlock()
 
 	return id
 }
 
+// cachedID returns the manifest and collection ID for a collection if they are cached and known to be current.
+func (cidMgr *collectionsComponent) cachedID(scopeName, collectionName string) (uint64, uint32, bool) {
+	cidMgr.mapLock.Lock()
+	id, ok := cidMgr.idMap[cidMgr.createKey(scopeName, collectionName)]
+	cidMgr.mapLock.Unlock()
+	if !ok {
+		return 0, 0, false
+	}
+
+	id.lock.Lock()
+	defer id.lock.Unlock()
+	if id.id == unknownCid || id.id == pendingCid {
+		return 0, 0, false
+	}
+
+	return id.manifestID, id.id, true
+}
+
+// invalidate marks a cached collection ID as unknown, so that it is refreshed before it is next used.
+func (cidMgr *collectionsComponent) invalidate(scopeName, collectionName string) {
+	cidMgr.mapLock.Lock()
+	id, ok := cidMgr.idMap[cidMgr.createKey(scopeName, collectionName)]
+	cidMgr.mapLock.Unlock()
+	if !ok {
+		return
+	}
+
+	id.lock.Lock()
+	if id.id != unknownCid && id.id != pendingCid {
+		id.setID(unknownCid)
+	}
+	id.lock.Unlock()
+}
+
 func (cidMgr *collectionsComponent) getAndMaybeInsert(scopeName, collectionName string, value uint32) *collectionIDCache {
 	cidMgr.mapLock.Lock()
 	id, ok := cidMgr.idMap[cidMgr.createKey(scopeName, collectionName)]
@@ -413,7 +637,7 @@ func (cidMgr *collectionsComponent) getAndMaybeInsert(scopeName, collectionName
 }
 
 func (cidMgr *collectionsComponent) remove(scopeName, collectionName string) {
-	logDebugf("Removing cache entry for %s.%s", scopeName, collectionName)
+	cidMgr.logger.debugf("Removing cache entry for %s.%s", scopeName, collectionName)
 	cidMgr.mapLock.Lock()
 	delete(cidMgr.idMap, cidMgr.createKey(scopeName, collectionName))
 	cidMgr.mapLock.Unlock()
@@ -432,6 +656,7 @@ func (cidMgr *collectionsComponent) newCollectionIDCache(scope, collection strin
 type collectionIDCache struct {
 	opQueue        *memdOpQueue
 	id             uint32
+	manifestID     uint64
 	collectionName string
 	scopeName      string
 	parent         *collectionsComponent
@@ -445,7 +670,10 @@ func (cid *collectionIDCache) sendWithCid(req *memdQRequest) error {
 	id := cid.id
 	cid.lock.Unlock()
 	if err := setRequestCid(req, id); err != nil {
-		logDebugf("Failed to set collection ID on request: %v", err)
+		cid.parent.logger.debugf("Failed to set collection ID on request: %v", err)
+		return err
+	}
+	if err := cid.parent.checkKeyLength(req, id, true); err != nil {
 		return err
 	}
 
@@ -457,24 +685,35 @@ func (cid *collectionIDCache) sendWithCid(req *memdQRequest) error {
 	return nil
 }
 
+// pushPendingRequest queues a request which is waiting on a collection ID, translating a full queue into the same
+// error as when a pipeline queue is full.
+func pushPendingRequest(q *memdOpQueue, req *memdQRequest, maxItems int) error {
+	err := q.Push(req, maxItems)
+	if errors.Is(err, errOpQueueFull) {
+		return errOverload
+	}
+
+	return err
+}
+
 func (cid *collectionIDCache) queueRequest(req *memdQRequest) error {
 	cid.lock.Lock()
 	defer cid.lock.Unlock()
-	return cid.opQueue.Push(req, cid.maxQueueSize)
+	return pushPendingRequest(cid.opQueue, req, cid.maxQueueSize)
 }
 
 func (cid *collectionIDCache) setID(id uint32) {
-	logDebugf("Setting cache ID to %d for %s.%s", id, cid.scopeName, cid.collectionName)
+	cid.parent.logger.debugf("Setting cache ID to %d for %s.%s", id, cid.scopeName, cid.collectionName)
 	cid.id = id
 }
 
 func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
-	err := cid.opQueue.Push(req, cid.maxQueueSize)
+	err := pushPendingRequest(cid.opQueue, req, cid.maxQueueSize)
 	if err != nil {
 		return err
 	}
 
-	logDebugf("Refreshing collection ID for %s.%s", req.ScopeName, req.CollectionName)
+	cid.parent.logger.debugf("Refreshing collection ID for %s.%s", req.ScopeName, req.CollectionName)
 	_, err = cid.parent.GetCollectionID(req.ScopeName, req.CollectionName, GetCollectionIDOptions{TraceContext: req.RootTraceContext},
 		func(result *GetCollectionIDResult, err error) {
 			if err != nil {
@@ -483,7 +722,7 @@ func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
 					// Retrying the request will requeue it in the cid manager so either it will pick up the unknown cid
 					// and cause a refresh or another request will and this one will get queued within the cache.
 					// Either the collection will eventually come online or this request will timeout.
-					logDebugf("Collection %s.%s not found, attempting retry", req.ScopeName, req.CollectionName)
+					cid.parent.logger.debugf("Collection %s.%s not found, attempting retry", req.ScopeName, req.CollectionName)
 					cid.lock.Lock()
 					cid.setID(unknownCid)
 					cid.lock.Unlock()
@@ -492,11 +731,11 @@ func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
 							return
 						}
 					} else {
-						logDebugf("Request no longer existed in op queue, possibly cancelled?",
+						cid.parent.logger.debugf("Request no longer existed in op queue, possibly cancelled?",
 							req.Opaque, req.CollectionName)
 					}
 				} else {
-					logDebugf("Collection ID refresh failed: %v", err)
+					cid.parent.logger.debugf("Collection ID refresh failed: %v", err)
 				}
 
 				// There was an error getting this collection ID so lets remove the cache from the manager and try to
@@ -516,13 +755,17 @@ func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
 			cid.opQueue = newMemdOpQueue()
 			cid.lock.Unlock()
 
-			logDebugf("Collection %s.%s refresh succeeded, requeuing %d requests", req.ScopeName, req.CollectionName, opQueue.items.Len())
+			cid.parent.logger.debugf("Collection %s.%s refresh succeeded, requeuing %d requests", req.ScopeName, req.CollectionName, opQueue.Len())
 			opQueue.Close()
 			opQueue.Drain(func(request *memdQRequest) {
 				request.AddResourceUnitsFromUnitResult(result.Internal.ResourceUnits)
 
 				if err := setRequestCid(request, result.CollectionID); err != nil {
-					logDebugf("Failed to set collection ID on request: %v", err)
+					cid.parent.logger.debugf("Failed to set collection ID on request: %v", err)
+					request.cancelWithCallback(err)
+					return
+				}
+				if err := cid.parent.checkKeyLength(request, result.CollectionID, true); err != nil {
 					request.cancelWithCallback(err)
 					return
 				}
@@ -541,7 +784,7 @@ func (cid *collectionIDCache) dispatch(req *memdQRequest) error {
 	// otherwise send the request
 	switch cid.id {
 	case unknownCid:
-		logDebugf("Collection %s.%s unknown, refreshing id", req.ScopeName, req.CollectionName)
+		cid.parent.logger.debugf("Collection %s.%s unknown, refreshing id", req.ScopeName, req.CollectionName)
 		cid.setID(pendingCid)
 		newOpQueue := newMemdOpQueue()
 		if cid.opQueue != nil {
@@ -571,7 +814,7 @@ func (cid *collectionIDCache) dispatch(req *memdQRequest) error {
 		cid.lock.Unlock()
 		return nil
 	case pendingCid:
-		logDebugf("Collection %s.%s pending, queueing request OP=0x%x", req.ScopeName, req.CollectionName, req.Command)
+		cid.parent.logger.debugf("Collection %s.%s pending, queueing request OP=0x%x", req.ScopeName, req.CollectionName, req.Command)
 		cid.lock.Unlock()
 		return cid.queueRequest(req)
 	default:
@@ -589,6 +832,9 @@ func (cidMgr *collectionsComponent) Dispatch(req *memdQRequest) (PendingOp, erro
 		if !isDefaultCollectionName || collectionIDPresent {
 			return nil, errCollectionsUnsupported
 		}
+		if err := cidMgr.checkKeyLength(req, 0, false); err != nil {
+			return nil, err
+		}
 		_, err := cidMgr.dispatcher.DispatchDirect(req)
 		if err != nil {
 			return nil, err
@@ -598,12 +844,17 @@ func (cidMgr *collectionsComponent) Dispatch(req *memdQRequest) (PendingOp, erro
 	}
 
 	if isDefaultCollectionName || collectionIDPresent {
+		// If we haven't yet seen a config then we don't know whether the collection ID will be sent, in that case
+		// we leave it to the server to reject the key.
+		if err := cidMgr.checkKeyLength(req, req.CollectionID, cidMgr.dispatcher.SupportsCollections()); err != nil {
+			return nil, err
+		}
 		return cidMgr.dispatcher.DispatchDirect(req)
 	}
 
 	if atomic.LoadUint32(&cidMgr.configSeen) == 0 {
-		logDebugf("Collections are enabled but we've not yet seen a config so queueing request")
-		err := cidMgr.pendingOpQueue.Push(req, cidMgr.maxQueueSize)
+		cidMgr.logger.debugf("Collections are enabled but we've not yet seen a config so queueing request")
+		err := pushPendingRequest(cidMgr.pendingOpQueue, req, cidMgr.maxQueueSize)
 		if err != nil {
 			return nil, err
 		}
@@ -638,6 +889,36 @@ func (cidMgr *collectionsComponent) requeue(req *memdQRequest) {
 	}
 }
 
+// checkKeyLength verifies that the on the wire length of the request key, including the leb128 encoded collection ID
+// when it will be sent, does not exceed the maximum key length.
+func (cidMgr *collectionsComponent) checkKeyLength(req *memdQRequest, cid uint32, withCollectionID bool) error {
+	keyLen := len(req.Key)
+	if keyLen == 0 {
+		return nil
+	}
+
+	if withCollectionID {
+		keyLen += uleb128Len(cid)
+	}
+
+	if keyLen > cidMgr.maxKeyLength {
+		return wrapError(errKeyTooLong, fmt.Sprintf("key length of %d bytes exceeds the maximum of %d bytes",
+			keyLen, cidMgr.maxKeyLength))
+	}
+
+	return nil
+}
+
+func uleb128Len(v uint32) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+
+	return n
+}
+
 func setRequestCid(req *memdQRequest, cid uint32) error {
 	if req.Command == memd.CmdRangeScanCreate {
 		var createReq *rangeScanCreateRequest
@@ -146,7 +146,6 @@ func (suite *UnitTestSuite) TestCollectionsComponentCollectionsStateUnknownSuppo
 
 	cfgMgr := new(mockConfigManager)
 	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
-	cfgMgr.On("RemoveConfigWatcher", mock.AnythingOfType("*gocbcore.collectionsComponent")).Return()
 
 	dispatcher := new(mockDispatcher)
 	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
@@ -248,7 +247,6 @@ func (suite *UnitTestSuite) TestCollectionsComponentCollectionsStateUnknownColle
 
 	cfgMgr := new(mockConfigManager)
 	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
-	cfgMgr.On("RemoveConfigWatcher", mock.AnythingOfType("*gocbcore.collectionsComponent")).Return()
 
 	dispatcher := new(mockDispatcher)
 	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
@@ -366,7 +364,6 @@ func (suite *UnitTestSuite) TestCollectionsComponentCollectionsStateUnknownGener
 
 	cfgMgr := new(mockConfigManager)
 	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
-	cfgMgr.On("RemoveConfigWatcher", mock.AnythingOfType("*gocbcore.collectionsComponent")).Return()
 
 	dispatcher := new(mockDispatcher)
 	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
@@ -448,7 +445,6 @@ func (suite *UnitTestSuite) TestCollectionsComponentCollectionsStateUnknownUnsup
 
 	cfgMgr := new(mockConfigManager)
 	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
-	cfgMgr.On("RemoveConfigWatcher", mock.AnythingOfType("*gocbcore.collectionsComponent")).Return()
 
 	dispatcher := new(mockDispatcher)
 	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
@@ -928,3 +924,476 @@ func (suite *UnitTestSuite) TestCollectionsComponentCollectionsSupportedCollecti
 	cfgMgr.AssertExpectations(suite.T())
 	dispatcher.AssertExpectations(suite.T())
 }
+
+// This test is for the scenario when a number of collections are warmed up front, where one of the collections
+// doesn't exist. We should see the existing collection cached and the missing one reported without failing the batch.
+func (suite *UnitTestSuite) TestCollectionsComponentWarmCollections() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("DispatchDirect", mock.AnythingOfType("*gocbcore.memdQRequest")).Return(&memdQRequest{}, nil).
+		Run(func(args mock.Arguments) {
+			req := args[0].(*memdQRequest)
+
+			suite.Assert().Equal(memd.CmdCollectionsGetID, req.Command)
+
+			if string(req.Value) == "_default.missing" {
+				time.AfterFunc(time.Millisecond, func() {
+					req.Callback(nil, req, errCollectionNotFound)
+				})
+				return
+			}
+
+			extras := make([]byte, 12)
+			binary.BigEndian.PutUint64(extras[0:], 1)
+			binary.BigEndian.PutUint32(extras[8:], 8)
+
+			time.AfterFunc(time.Millisecond, func() {
+				req.Callback(&memdQResponse{Packet: &memd.Packet{Extras: extras}}, req, nil)
+			})
+		})
+
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100},
+		dispatcher,
+		newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, cfgMgr),
+		cfgMgr,
+	)
+
+	waitCh := make(chan *WarmCollectionsResult, 1)
+	_, err := cidMgr.WarmCollections(WarmCollectionsOptions{
+		Collections: []ScopeCollectionName{
+			{ScopeName: "_default", CollectionName: "test"},
+			{ScopeName: "_default", CollectionName: "missing"},
+		},
+	}, func(res *WarmCollectionsResult, err error) {
+		suite.Assert().Nil(err, err)
+		waitCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	var res *WarmCollectionsResult
+	select {
+	case <-time.After(1 * time.Second):
+		suite.T().Fatalf("Timed out waiting for callback to be called")
+	case res = <-waitCh:
+	}
+
+	suite.Require().Len(res.Collections, 2)
+	suite.Assert().Equal("test", res.Collections[0].CollectionName)
+	suite.Assert().Nil(res.Collections[0].Error)
+	suite.Assert().Equal(uint32(8), res.Collections[0].CollectionID)
+	suite.Assert().Equal("missing", res.Collections[1].CollectionName)
+	suite.Assert().ErrorIs(res.Collections[1].Error, errCollectionNotFound)
+
+	cidMgr.mapLock.Lock()
+	cached, ok := cidMgr.idMap[cidMgr.createKey("_default", "test")]
+	cidMgr.mapLock.Unlock()
+	suite.Require().True(ok)
+	suite.Assert().Equal(uint32(8), cached.id)
+
+	dispatcher.AssertExpectations(suite.T())
+}
+
+func (suite *UnitTestSuite) TestCollectionsComponentGetCollectionManifest() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	manifest := `{"uid":"a","scopes":[{"uid":"8","name":"inventory","collections":[{"uid":"9","name":"hotels"}]}]}`
+
+	collectionsEnabled := true
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("CollectionsEnabled").Return(func() bool { return collectionsEnabled })
+	dispatcher.On("DispatchDirect", mock.AnythingOfType("*gocbcore.memdQRequest")).Return(&memdQRequest{}, nil).
+		Run(func(args mock.Arguments) {
+			req := args[0].(*memdQRequest)
+			suite.Assert().Equal(memd.CmdCollectionsGetManifest, req.Command)
+			req.Callback(&memdQResponse{Packet: &memd.Packet{Value: []byte(manifest)}}, req, nil)
+		}).Once()
+
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100},
+		dispatcher,
+		newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, cfgMgr),
+		cfgMgr,
+	)
+
+	getManifest := func() *Manifest {
+		waitCh := make(chan *GetCollectionManifestResult, 1)
+		_, err := cidMgr.GetCollectionManifest(GetCollectionManifestOptions{}, func(res *GetCollectionManifestResult, err error) {
+			suite.Assert().Nil(err, err)
+			waitCh <- res
+		})
+		suite.Require().Nil(err, err)
+
+		res := <-waitCh
+		suite.Require().NotNil(res)
+		parsed, err := res.ParseManifest()
+		suite.Require().Nil(err, err)
+		return parsed
+	}
+
+	suite.Assert().Equal(&Manifest{
+		UID: 10,
+		Scopes: []ManifestScope{{
+			UID:         8,
+			Name:        "inventory",
+			Collections: []ManifestCollection{{UID: 9, Name: "hotels"}},
+		}},
+	}, getManifest())
+
+	// Without collections the default collection is the only one available, and the server is not contacted.
+	collectionsEnabled = false
+	suite.Assert().Equal(&Manifest{
+		Scopes: []ManifestScope{{
+			Name:        "_default",
+			Collections: []ManifestCollection{{Name: "_default"}},
+		}},
+	}, getManifest())
+
+	dispatcher.AssertExpectations(suite.T())
+}
+
+func (suite *UnitTestSuite) TestCollectionsComponentGetCollectionIDCached() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	var lock sync.Mutex
+	var dispatched int
+	var dropped bool
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("DispatchDirect", mock.AnythingOfType("*gocbcore.memdQRequest")).Return(&memdQRequest{}, nil).
+		Run(func(args mock.Arguments) {
+			req := args[0].(*memdQRequest)
+
+			lock.Lock()
+			dispatched++
+			isDropped := dropped
+			lock.Unlock()
+
+			if isDropped {
+				req.Callback(nil, req, errCollectionNotFound)
+				return
+			}
+
+			extras := make([]byte, 12)
+			binary.BigEndian.PutUint64(extras[0:], 4)
+			binary.BigEndian.PutUint32(extras[8:], 9)
+			req.Callback(&memdQResponse{Packet: &memd.Packet{Extras: extras}}, req, nil)
+		})
+
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100},
+		dispatcher,
+		newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, cfgMgr),
+		cfgMgr,
+	)
+
+	getCollectionID := func(allowCached bool) (*GetCollectionIDResult, error) {
+		type result struct {
+			res *GetCollectionIDResult
+			err error
+		}
+		waitCh := make(chan result, 1)
+		_, err := cidMgr.GetCollectionID("scope", "collection", GetCollectionIDOptions{
+			AllowCached: allowCached,
+		}, func(res *GetCollectionIDResult, err error) {
+			waitCh <- result{res: res, err: err}
+		})
+		suite.Require().Nil(err, err)
+
+		res := <-waitCh
+		return res.res, res.err
+	}
+	assertDispatched := func(expected int) {
+		lock.Lock()
+		suite.Assert().Equal(expected, dispatched)
+		lock.Unlock()
+	}
+
+	res, err := getCollectionID(true)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(&GetCollectionIDResult{ManifestID: 4, CollectionID: 9}, res)
+	assertDispatched(1)
+
+	res, err = getCollectionID(true)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(&GetCollectionIDResult{ManifestID: 4, CollectionID: 9}, res)
+	assertDispatched(1)
+
+	// Without AllowCached the server is always asked, and reporting the collection as missing invalidates the cache.
+	lock.Lock()
+	dropped = true
+	lock.Unlock()
+	_, err = getCollectionID(false)
+	suite.Assert().ErrorIs(err, ErrCollectionNotFound)
+	assertDispatched(2)
+
+	_, err = getCollectionID(true)
+	suite.Assert().ErrorIs(err, ErrCollectionNotFound)
+	assertDispatched(3)
+}
+
+func (suite *UnitTestSuite) TestCollectionsComponentCachedOpCancel() {
+	var calls int
+	var cbErr error
+	cb := func(res *GetCollectionIDResult, err error) {
+		calls++
+		cbErr = err
+	}
+	op := &localPendingOp{cancelCb: func() {
+		cb(nil, errRequestCanceled)
+	}}
+
+	// Once cancelled the cached result must not be delivered, and the callback only ever invoked once.
+	op.Cancel()
+	op.complete(func() {
+		cb(&GetCollectionIDResult{ManifestID: 4, CollectionID: 9}, nil)
+	})
+	op.Cancel()
+
+	suite.Assert().Equal(1, calls)
+	suite.Assert().ErrorIs(cbErr, ErrRequestCanceled)
+}
+
+func (suite *UnitTestSuite) TestCollectionsComponentManifestCollectionsDisabled() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("CollectionsEnabled").Return(false)
+
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100,
+	},
+		dispatcher,
+		newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, cfgMgr),
+		cfgMgr,
+	)
+
+	// The default manifest is delivered asynchronously, never before GetCollectionManifest returns.
+	returnedCh := make(chan struct{})
+	resCh := make(chan *GetCollectionManifestResult, 1)
+	op, err := cidMgr.GetCollectionManifest(GetCollectionManifestOptions{}, func(res *GetCollectionManifestResult, err error) {
+		suite.Assert().Nil(err, err)
+		select {
+		case <-returnedCh:
+		case <-time.After(time.Second):
+			suite.T().Errorf("Callback was invoked before GetCollectionManifest returned")
+		}
+		resCh <- res
+	})
+	close(returnedCh)
+	suite.Require().Nil(err, err)
+	suite.Require().NotNil(op)
+
+	res := <-resCh
+	suite.Assert().Equal(defaultCollectionManifest, res.Manifest)
+}
+
+func (suite *UnitTestSuite) TestCollectionsComponentManifestUIDChange() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	var lock sync.Mutex
+	var lookups int
+	sentCids := make(chan uint32, 3)
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("CollectionsEnabled").Return(true)
+	dispatcher.On("SupportsCollections").Return(true)
+	dispatcher.On("DispatchDirect", mock.AnythingOfType("*gocbcore.memdQRequest")).Return(&memdQRequest{}, nil).
+		Run(func(args mock.Arguments) {
+			req := args[0].(*memdQRequest)
+			if req.Command == memd.CmdGet {
+				sentCids <- req.CollectionID
+				return
+			}
+
+			suite.Assert().Equal(memd.CmdCollectionsGetID, req.Command)
+			lock.Lock()
+			lookups++
+			// The collection has been dropped and recreated in manifest 2, giving it a new ID.
+			manifestID, collectionID := uint64(1), uint32(8)
+			if lookups > 1 {
+				manifestID, collectionID = 2, 9
+			}
+			lock.Unlock()
+
+			extras := make([]byte, 12)
+			binary.BigEndian.PutUint64(extras[0:], manifestID)
+			binary.BigEndian.PutUint32(extras[8:], collectionID)
+			time.AfterFunc(time.Millisecond, func() {
+				req.Callback(&memdQResponse{Packet: &memd.Packet{Extras: extras}}, req, nil)
+			})
+		})
+	dispatcher.On("RequeueDirect", mock.AnythingOfType("*gocbcore.memdQRequest"), false).Return(&memdQRequest{}, nil).
+		Run(func(args mock.Arguments) {
+			sentCids <- args[0].(*memdQRequest).CollectionID
+		})
+
+	updates := make(chan uint64, 2)
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100,
+		OnManifestUpdate: func(manifestUID uint64) {
+			updates <- manifestUID
+		}},
+		dispatcher,
+		newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, cfgMgr),
+		cfgMgr,
+	)
+
+	get := func() uint32 {
+		_, err := cidMgr.Dispatch(&memdQRequest{
+			Packet: memd.Packet{
+				Magic:   memd.CmdMagicReq,
+				Command: memd.CmdGet,
+				Key:     []byte("test-key"),
+			},
+			CollectionName:   "test",
+			ScopeName:        "_default",
+			Callback:         func(resp *memdQResponse, req *memdQRequest, err error) {},
+			RootTraceContext: noopSpanContext{},
+		})
+		suite.Require().Nil(err, err)
+
+		select {
+		case cid := <-sentCids:
+			return cid
+		case <-time.After(time.Second):
+			suite.T().Fatalf("Timed out waiting for request to be sent")
+		}
+		return 0
+	}
+
+	cidMgr.OnNewRouteConfig(&routeConfig{
+		bucketCapabilities:     []string{"collections"},
+		collectionsManifestUID: 1,
+	})
+	suite.Assert().Equal(uint64(1), <-updates)
+
+	suite.Assert().Equal(uint32(8), get())
+	suite.Assert().Equal(uint32(8), get())
+
+	// A config with the same manifest uid must not cause the ID to be resolved again.
+	cidMgr.OnNewRouteConfig(&routeConfig{
+		bucketCapabilities:     []string{"collections"},
+		collectionsManifestUID: 1,
+	})
+	suite.Assert().Equal(uint32(8), get())
+
+	cidMgr.OnNewRouteConfig(&routeConfig{
+		bucketCapabilities:     []string{"collections"},
+		collectionsManifestUID: 2,
+	})
+	suite.Assert().Equal(uint64(2), <-updates)
+	suite.Assert().Equal(uint32(9), get())
+
+	lock.Lock()
+	suite.Assert().Equal(2, lookups)
+	lock.Unlock()
+}
+
+func (suite *UnitTestSuite) TestCollectionsComponentKeyTooLong() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("CollectionsEnabled").Return(true)
+	dispatcher.On("SupportsCollections").Return(true)
+	dispatcher.On("DispatchDirect", mock.AnythingOfType("*gocbcore.memdQRequest")).Return(&memdQRequest{}, nil)
+
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100,
+	}, dispatcher, nil, cfgMgr)
+
+	dispatch := func(keyLen int, cid uint32) error {
+		_, err := cidMgr.Dispatch(&memdQRequest{
+			Packet: memd.Packet{
+				Key:          make([]byte, keyLen),
+				CollectionID: cid,
+			},
+		})
+		return err
+	}
+
+	// The default collection is encoded in a single byte.
+	suite.Assert().Nil(dispatch(249, 0))
+	suite.Assert().ErrorIs(dispatch(250, 0), ErrKeyTooLong)
+
+	// A collection ID of 0x80 requires two bytes, so 248 bytes of key is the most that fits on the wire.
+	suite.Assert().Nil(dispatch(248, 0x80))
+	suite.Assert().ErrorIs(dispatch(249, 0x80), ErrKeyTooLong)
+
+	// A collection ID of 0x4000 requires three bytes.
+	suite.Assert().Nil(dispatch(247, 0x4000))
+	suite.Assert().ErrorIs(dispatch(248, 0x4000), ErrKeyTooLong)
+
+	cidMgr = newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100,
+		MaxKeyLength:         100,
+	}, dispatcher, nil, cfgMgr)
+	suite.Assert().Nil(dispatch(99, 0))
+	suite.Assert().ErrorIs(dispatch(100, 0), ErrKeyTooLong)
+}
+
+func (suite *UnitTestSuite) TestULEB128Len() {
+	for _, v := range []uint32{0, 0x7f, 0x80, 0x3fff, 0x4000, 0x1fffff, 0x200000, 0xffffffff} {
+		suite.Assert().Equal(len(memd.AppendULEB128_32(nil, v)), uleb128Len(v), "value %x", v)
+	}
+}
+
+func (suite *UnitTestSuite) TestCollectionsComponentPendingQueueOverload() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("CollectionsEnabled").Return(true)
+
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         1},
+		dispatcher,
+		newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, cfgMgr),
+		cfgMgr,
+	)
+
+	newReq := func() *memdQRequest {
+		return &memdQRequest{
+			Packet: memd.Packet{
+				Magic:   memd.CmdMagicReq,
+				Command: memd.CmdGet,
+				Key:     []byte("test-key"),
+			},
+			CollectionName:   "test",
+			ScopeName:        "_default",
+			Callback:         func(resp *memdQResponse, req *memdQRequest, err error) {},
+			RootTraceContext: noopSpanContext{},
+		}
+	}
+
+	// Requests are queued until a config has been seen, so the second overflows the queue.
+	_, err := cidMgr.Dispatch(newReq())
+	suite.Require().Nil(err, err)
+
+	_, err = cidMgr.Dispatch(newReq())
+	suite.Assert().True(errors.Is(err, ErrOverload), err)
+	suite.Assert().False(errors.Is(err, ErrTimeout), err)
+
+	cidMgr.pendingOpQueue.Close()
+	cidMgr.pendingOpQueue.Drain(func(req *memdQRequest) {})
+}
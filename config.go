@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 )
 
@@ -70,9 +71,9 @@ type cfgVBucketServerMap struct {
 
 // Bucket is the primary entry point for most data operations.
 type cfgBucket struct {
-	Rev                 int64 `json:"rev"`
-	RevEpoch            int64 `json:"revEpoch"`
-	SourceHostname      string
+	Rev                 int64    `json:"rev"`
+	RevEpoch            int64    `json:"revEpoch"`
+	SourceHostname      string   `json:"-"`
 	Capabilities        []string `json:"bucketCapabilities"`
 	CapabilitiesVersion string   `json:"bucketCapabilitiesVer"`
 	Name                string   `json:"name"`
@@ -93,6 +94,7 @@ type cfgBucket struct {
 	ClusterCapabilities    map[string][]string `json:"clusterCapabilities,omitempty"`
 	ClusterUUID            string              `json:"clusterUUID,omitempty"`
 	ClusterName            string              `json:"clusterName,omitempty"`
+	CollectionsManifestUID string              `json:"collectionsManifestUid,omitempty"`
 }
 
 type localLoopbackAddress struct {
@@ -103,7 +105,9 @@ type localLoopbackAddress struct {
 // BuildRouteConfig builds a new route config from this config.
 // overwriteSeedNode indicates that we should set the hostname for a node to the cfg.SourceHostname when the config has
 // been sourced from that node.
-func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstConnect bool, loopbackAddr *localLoopbackAddress) *routeConfig {
+// resolver, if set, is used to rewrite the address of each service endpoint found in nodesExt.
+func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstConnect bool, loopbackAddr *localLoopbackAddress,
+	resolver addressResolverFunc, logger *scopedLogger) *routeConfig {
 	var (
 		kvServerList   = routeEndpoints{}
 		capiEpList     = routeEndpoints{}
@@ -126,7 +130,7 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 		if cfg.UUID == "" {
 			bktType = bktTypeNone
 		} else {
-			logDebugf("Invalid nodeLocator %s", cfg.NodeLocator)
+			logger.debugf("Invalid nodeLocator %s", cfg.NodeLocator)
 			bktType = bktTypeInvalid
 		}
 	}
@@ -146,7 +150,7 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 					}
 				} else {
 					if !firstConnect {
-						logDebugf("Invalid config network type %s", networkType)
+						logger.debugf("Invalid config network type %s", networkType)
 					}
 					continue
 				}
@@ -158,17 +162,17 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 			} else {
 				isSeedNode = fmt.Sprintf("%s:%d", node.Hostname, node.Services.Mgmt) == loopbackAddr.Identifier
 				if isSeedNode {
-					logDebugf("Seed node detected and set to overwrite, setting hostname to %s", loopbackAddr.LoopbackAddr)
+					logger.debugf("Seed node detected and set to overwrite, setting hostname to %s", loopbackAddr.LoopbackAddr)
 					hostname = loopbackAddr.LoopbackAddr
 				} else {
 					hostname = getHostname(hostname, cfg.SourceHostname)
 				}
 			}
 
-			endpoints := endpointsFromPorts(ports, hostname, isSeedNode, serverGroup)
+			endpoints := endpointsFromPorts(ports, hostname, isSeedNode, serverGroup, resolver)
 			if endpoints.kvServer.Address != "" {
 				if bktType > bktTypeInvalid && i >= lenNodes {
-					logDebugf("KV node present in nodesext but not in nodes for %s", endpoints.kvServer.Address)
+					logger.debugf("KV node present in nodesext but not in nodes for %s", endpoints.kvServer.Address)
 				} else {
 					kvServerList.NonSSLEndpoints = append(kvServerList.NonSSLEndpoints, endpoints.kvServer)
 				}
@@ -200,7 +204,7 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 
 			if endpoints.kvServerSSL.Address != "" {
 				if bktType > bktTypeInvalid && i >= lenNodes {
-					logDebugf("KV node present in nodesext but not in nodes for %s", endpoints.kvServerSSL.Address)
+					logger.debugf("KV node present in nodesext but not in nodes for %s", endpoints.kvServerSSL.Address)
 				} else {
 					kvServerList.SSLEndpoints = append(kvServerList.SSLEndpoints, endpoints.kvServerSSL)
 				}
@@ -298,6 +302,15 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 		clusterName:            cfg.ClusterName,
 	}
 
+	if cfg.CollectionsManifestUID != "" {
+		manifestUID, err := strconv.ParseUint(cfg.CollectionsManifestUID, 16, 64)
+		if err != nil {
+			logger.debugf("Failed to parse collections manifest uid %s: %v", cfg.CollectionsManifestUID, err)
+		} else {
+			rc.collectionsManifestUID = manifestUID
+		}
+	}
+
 	if bktType == bktTypeCouchbase {
 		vbMap := cfg.VBucketServerMap.VBucketMap
 		numReplicas := cfg.VBucketServerMap.NumReplicas
@@ -352,131 +365,147 @@ func getHostname(hostname, sourceHostname string) string {
 	return hostname
 }
 
-func endpointsFromPorts(ports cfgNodeServices, hostname string, isSeedNode bool, serverGroup string) *serverEps {
+// addressResolverFunc rewrites the host and port of a service endpoint extracted from a config.
+type addressResolverFunc func(host string, port int, service ServiceType) (string, int)
+
+func endpointsFromPorts(ports cfgNodeServices, hostname string, isSeedNode bool, serverGroup string,
+	resolver addressResolverFunc) *serverEps {
 	lists := &serverEps{}
 
+	address := func(scheme string, port uint16, service ServiceType) string {
+		if resolver == nil {
+			return fmt.Sprintf("%s://%s:%d", scheme, hostname, port)
+		}
+
+		host, resolvedPort := resolver(strings.TrimSuffix(strings.TrimPrefix(hostname, "["), "]"), int(port), service)
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		return fmt.Sprintf("%s://%s:%d", scheme, host, resolvedPort)
+	}
+
 	if ports.KvSsl > 0 {
 		lists.kvServerSSL = routeEndpoint{
-			Address:     fmt.Sprintf("couchbases://%s:%d", hostname, ports.KvSsl),
+			Address:     address("couchbases", ports.KvSsl, MemdService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.CapiSsl > 0 {
 		lists.capiEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.CapiSsl),
+			Address:     address("https", ports.CapiSsl, CapiService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.MgmtSsl > 0 {
 		lists.mgmtEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.MgmtSsl),
+			Address:     address("https", ports.MgmtSsl, MgmtService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.N1qlSsl > 0 {
 		lists.n1qlEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.N1qlSsl),
+			Address:     address("https", ports.N1qlSsl, N1qlService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.FtsSsl > 0 {
 		lists.ftsEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.FtsSsl),
+			Address:     address("https", ports.FtsSsl, FtsService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.CbasSsl > 0 {
 		lists.cbasEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.CbasSsl),
+			Address:     address("https", ports.CbasSsl, CbasService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.EventingSsl > 0 {
 		lists.eventingEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.EventingSsl),
+			Address:     address("https", ports.EventingSsl, EventingService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.GSISsl > 0 {
 		lists.gsiEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.GSISsl),
+			Address:     address("https", ports.GSISsl, GSIService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.BackupSsl > 0 {
 		lists.backupEpSSL = routeEndpoint{
-			Address:     fmt.Sprintf("https://%s:%d", hostname, ports.BackupSsl),
+			Address:     address("https", ports.BackupSsl, BackupService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.Kv > 0 {
 		lists.kvServer = routeEndpoint{
-			Address:     fmt.Sprintf("couchbase://%s:%d", hostname, ports.Kv),
+			Address:     address("couchbase", ports.Kv, MemdService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.Capi > 0 {
 		lists.capiEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.Capi),
+			Address:     address("http", ports.Capi, CapiService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.Mgmt > 0 {
 		lists.mgmtEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.Mgmt),
+			Address:     address("http", ports.Mgmt, MgmtService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.N1ql > 0 {
 		lists.n1qlEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.N1ql),
+			Address:     address("http", ports.N1ql, N1qlService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.Fts > 0 {
 		lists.ftsEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.Fts),
+			Address:     address("http", ports.Fts, FtsService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.Cbas > 0 {
 		lists.cbasEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.Cbas),
+			Address:     address("http", ports.Cbas, CbasService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.Eventing > 0 {
 		lists.eventingEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.Eventing),
+			Address:     address("http", ports.Eventing, EventingService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.GSI > 0 {
 		lists.gsiEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.GSI),
+			Address:     address("http", ports.GSI, GSIService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
 	}
 	if ports.Backup > 0 {
 		lists.backupEp = routeEndpoint{
-			Address:     fmt.Sprintf("http://%s:%d", hostname, ports.Backup),
+			Address:     address("http", ports.Backup, BackupService),
 			IsSeedNode:  isSeedNode,
 			ServerGroup: serverGroup,
 		}
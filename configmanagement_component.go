@@ -5,31 +5,47 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/couchbase/gocbcore/v10/memd"
 )
 
+// reconfigureMinInterval is the minimum time between the out of band config fetches triggered by Reconfigure.
+const reconfigureMinInterval = time.Second
+
 type configManagementComponent struct {
+	logger      *scopedLogger
 	useSSL      bool
 	networkType string
 
 	seedNodeAddr      string
 	localLoopbackAddr *localLoopbackAddress
+	addressResolver   addressResolverFunc
 
 	currentConfig *routeConfig
-	configLock    sync.Mutex
+	// currentBucketConfig is the config that currentConfig was built from, nil until a config has been applied.
+	currentBucketConfig *cfgBucket
+	configLock          sync.Mutex
 
 	cfgChangeWatchers []routeConfigWatcher
 	watchersLock      sync.Mutex
 
 	srcServers []routeEndpoint
 
-	seenConfig bool
+	seenConfig     bool
+	firstConfigSig chan struct{}
+
+	// usingSeedConfig indicates that the current config was supplied by the user rather than fetched from the
+	// cluster, in which case the next config from the cluster is applied regardless of revision.
+	usingSeedConfig bool
 
 	configFetcher      *cccpConfigFetcher
 	configFetchSig     chan struct{}
 	configFetchSigLock sync.Mutex
 
+	lastReconfigure     time.Time
+	lastReconfigureLock sync.Mutex
+
 	shutdownSig chan struct{}
 }
 
@@ -39,6 +55,9 @@ type configManagerProperties struct {
 	NetworkType  string
 	SrcMemdAddrs []routeEndpoint
 	SrcHTTPAddrs []routeEndpoint
+
+	AddressResolver addressResolverFunc
+	Logger          *scopedLogger
 }
 
 type routeConfigWatcher interface {
@@ -52,14 +71,17 @@ type configManager interface {
 
 func newConfigManager(props configManagerProperties) *configManagementComponent {
 	return &configManagementComponent{
-		useSSL:       props.UseTLS,
-		seedNodeAddr: props.SeedNodeAddr,
-		networkType:  props.NetworkType,
-		srcServers:   append(props.SrcMemdAddrs, props.SrcHTTPAddrs...),
+		useSSL:          props.UseTLS,
+		seedNodeAddr:    props.SeedNodeAddr,
+		networkType:     props.NetworkType,
+		addressResolver: props.AddressResolver,
+		logger:          props.Logger,
+		srcServers:      append(props.SrcMemdAddrs, props.SrcHTTPAddrs...),
 		currentConfig: &routeConfig{
 			revID: -1,
 		},
-		shutdownSig: make(chan struct{}),
+		shutdownSig:    make(chan struct{}),
+		firstConfigSig: make(chan struct{}),
 	}
 }
 
@@ -91,42 +113,90 @@ func (cm *configManagementComponent) CurrentRev() (int64, int64) {
 	return revID, revEpoch
 }
 
+// CurrentBucketConfig returns the most recently applied config, or nil if no config has been applied.
+func (cm *configManagementComponent) CurrentBucketConfig() *cfgBucket {
+	cm.configLock.Lock()
+	cfg := cm.currentBucketConfig
+	cm.configLock.Unlock()
+
+	return cfg
+}
+
 func (cm *configManagementComponent) OnNewConfig(cfg *cfgBucket) {
-	cm.onNewConfig(cfg)
+	cm.onNewConfig(cfg, false)
+}
+
+// onNewConfig applies the config if it is valid and newer than the current config, if force is set then the config is
+// applied regardless of revision ordering.
+func (cm *configManagementComponent) onNewConfig(cfg *cfgBucket, force bool) bool {
+	return cm.applyConfig(cfg, force, false)
 }
 
-func (cm *configManagementComponent) onNewConfig(cfg *cfgBucket) bool {
+// OnSeedConfig applies a config which was previously exported from an agent, so that requests can be routed before
+// a config has been fetched from the cluster. The config is only applied if no other config has been and it contains
+// one of the source servers, so that it is known to be for the cluster being connected to. As the config may be stale
+// the next config received from the cluster is applied regardless of its revision.
+func (cm *configManagementComponent) OnSeedConfig(cfg *cfgBucket) bool {
+	return cm.applyConfig(cfg, false, true)
+}
+
+// applyConfig applies the config to the manager and sends it out to the watchers. The checks made on a seed config are
+// performed under the same lock as applying it so that a config received from the cluster in the meantime can never
+// be overridden by the seed.
+func (cm *configManagementComponent) applyConfig(cfg *cfgBucket, force, isSeed bool) bool {
 	var routeCfg *routeConfig
 	cm.configLock.Lock()
+	if isSeed && cm.seenConfig {
+		cm.configLock.Unlock()
+		return false
+	}
+
+	force = force || cm.usingSeedConfig
 	if cm.seenConfig {
-		routeCfg = cfg.BuildRouteConfig(cm.useSSL, cm.networkType, false, cm.localLoopbackAddr)
+		routeCfg = cfg.BuildRouteConfig(cm.useSSL, cm.networkType, false, cm.localLoopbackAddr, cm.addressResolver, cm.logger)
 	} else {
+		// Building the first route config resolves the network type, if a seed config is rejected then that must
+		// be left for the first config received from the cluster to do.
+		networkType := cm.networkType
+		localLoopbackAddr := cm.localLoopbackAddr
 		routeCfg = cm.buildFirstRouteConfig(cfg, cm.useSSL)
+		if isSeed && (routeCfg == nil || !routeCfg.IsValid() || !cm.containsSrcServer(routeCfg, cm.useSSL)) {
+			cm.networkType = networkType
+			cm.localLoopbackAddr = localLoopbackAddr
+			cm.configLock.Unlock()
+			cm.logger.debugf("Seed config is not valid for the source servers, ignoring")
+			return false
+		}
 		if routeCfg == nil {
 			cm.configLock.Unlock()
 			// If the routeCfg isn't valid then ignore it.
 			return false
 		}
-		logDebugf("Using network type %s for connections", cm.networkType)
+		cm.logger.debugf("Using network type %s for connections", cm.networkType)
 	}
 	if !routeCfg.IsValid() {
 		cm.configLock.Unlock()
-		logDebugf("Routing data is not valid, skipping update: \n%s", routeCfg.DebugString())
+		cm.logger.debugf("Routing data is not valid, skipping update: \n%s", routeCfg.DebugString())
 		return false
 	}
 
 	// There's something wrong with this route config so don't send it to the watchers.
-	if !cm.canUpdateRouteConfig(routeCfg) {
+	if !cm.canUpdateRouteConfig(routeCfg, force) {
 		cm.configLock.Unlock()
 		return false
 	}
 
 	cm.currentConfig = routeCfg
+	cm.currentBucketConfig = cfg
+	cm.usingSeedConfig = isSeed
+	if !cm.seenConfig && cm.firstConfigSig != nil {
+		close(cm.firstConfigSig)
+	}
 	cm.seenConfig = true
 	cm.configLock.Unlock()
 
-	logDebugf("Sending out mux routing data (update)...")
-	logDebugf("New Routing Data:\n%s", routeCfg.DebugString())
+	cm.logger.debugf("Sending out mux routing data (update)...")
+	cm.logger.debugf("New Routing Data:\n%s", routeCfg.DebugString())
 
 	// We can end up deadlocking if we iterate whilst in the lock and a watcher decides to remove itself.
 	cm.watchersLock.Lock()
@@ -141,6 +211,33 @@ func (cm *configManagementComponent) onNewConfig(cfg *cfgBucket) bool {
 	return true
 }
 
+// containsSrcServer returns whether any of the source servers are KV or management endpoints in the route config.
+func (cm *configManagementComponent) containsSrcServer(cfg *routeConfig, useSSL bool) bool {
+	var kvServerList, mgmtEpList []routeEndpoint
+	if useSSL {
+		kvServerList = cfg.kvServerList.SSLEndpoints
+		mgmtEpList = cfg.mgmtEpList.SSLEndpoints
+	} else {
+		kvServerList = cfg.kvServerList.NonSSLEndpoints
+		mgmtEpList = cfg.mgmtEpList.NonSSLEndpoints
+	}
+
+	for _, srcServer := range cm.srcServers {
+		for _, endpoint := range kvServerList {
+			if trimSchemePrefix(endpoint.Address) == srcServer.Address {
+				return true
+			}
+		}
+		for _, endpoint := range mgmtEpList {
+			if endpoint.Address == srcServer.Address {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func (cm *configManagementComponent) RefreshConfig(snapshot *pipelineSnapshot) {
 	currentRev, currentEpoch := cm.CurrentRev()
 	cm.configFetchSigLock.Lock()
@@ -152,7 +249,7 @@ func (cm *configManagementComponent) RefreshConfig(snapshot *pipelineSnapshot) {
 	cm.configFetchSig = make(chan struct{})
 	cm.configFetchSigLock.Unlock()
 
-	cm.fetchConfig(snapshot, currentRev, currentEpoch)
+	cm.fetchConfig(snapshot, currentRev, currentEpoch, false)
 
 	cm.configFetchSigLock.Lock()
 	close(cm.configFetchSig)
@@ -160,13 +257,36 @@ func (cm *configManagementComponent) RefreshConfig(snapshot *pipelineSnapshot) {
 	cm.configFetchSigLock.Unlock()
 }
 
+// BeginReconfigure returns whether an out of band config refresh should be performed for Reconfigure, recording the
+// time of the refresh if so. No refresh is performed if one was started within reconfigureMinInterval or a fetch is
+// already in progress. This does not depend on CCCP being in use, as the refresh can fall back to HTTP.
+func (cm *configManagementComponent) BeginReconfigure() bool {
+	cm.lastReconfigureLock.Lock()
+	defer cm.lastReconfigureLock.Unlock()
+	if time.Since(cm.lastReconfigure) < reconfigureMinInterval {
+		cm.logger.debugf("CfgManager: Ignoring reconfigure as one was recently performed")
+		return false
+	}
+
+	cm.configFetchSigLock.Lock()
+	fetching := cm.configFetchSig != nil
+	cm.configFetchSigLock.Unlock()
+	if fetching {
+		cm.logger.debugf("CfgManager: Ignoring reconfigure as a config fetch is already in progress")
+		return false
+	}
+
+	cm.lastReconfigure = time.Now()
+	return true
+}
+
 func (cm *configManagementComponent) OnNewConfigChangeNotifBrief(snapshot *pipelineSnapshot, notif []byte) {
 	if cm.configFetcher == nil {
 		// No point in doing anything if we can't fetch a config anyway.
 		return
 	}
 	if len(notif) != 16 {
-		logWarnf("Invalid clustermap notification brief data size")
+		cm.logger.warnf("Invalid clustermap notification brief data size")
 		return
 	}
 	serverRevEpoch := int64(binary.BigEndian.Uint64(notif[0:]))
@@ -177,16 +297,16 @@ func (cm *configManagementComponent) OnNewConfigChangeNotifBrief(snapshot *pipel
 		currentRev, currentEpoch = cm.CurrentRev()
 
 		if serverRevEpoch < currentEpoch {
-			logDebugf("Ignoring configuration notification as it has an older revision epoch. Old: %d, new: %d", currentEpoch, serverRevEpoch)
+			cm.logger.debugf("Ignoring configuration notification as it has an older revision epoch. Old: %d, new: %d", currentEpoch, serverRevEpoch)
 			return
 		} else if serverRevEpoch == currentEpoch {
 			if serverRevID == 0 {
-				logDebugf("Unversioned configuration notification data, switching.")
+				cm.logger.debugf("Unversioned configuration notification data, switching.")
 			} else if serverRevID == currentRev {
-				logDebugf("Ignoring configuration notification with identical revision number - %d", serverRevID)
+				cm.logger.debugf("Ignoring configuration notification with identical revision number - %d", serverRevID)
 				return
 			} else if serverRevID < currentRev {
-				logDebugf("Ignoring new configuration notification as it has an older revision id. Old: %d, new: %d", currentRev, serverRevID)
+				cm.logger.debugf("Ignoring new configuration notification as it has an older revision id. Old: %d, new: %d", currentRev, serverRevID)
 				return
 			}
 		}
@@ -204,7 +324,7 @@ func (cm *configManagementComponent) OnNewConfigChangeNotifBrief(snapshot *pipel
 		<-waitSig
 	}
 
-	cm.fetchConfig(snapshot, currentRev, currentEpoch)
+	cm.fetchConfig(snapshot, currentRev, currentEpoch, false)
 
 	cm.configFetchSigLock.Lock()
 	close(cm.configFetchSig)
@@ -212,26 +332,31 @@ func (cm *configManagementComponent) OnNewConfigChangeNotifBrief(snapshot *pipel
 	cm.configFetchSigLock.Unlock()
 }
 
-func (cm *configManagementComponent) fetchConfig(snapshot *pipelineSnapshot, currentRev, currentEpoch int64) {
+// fetchConfig tries each node in turn until a newer config is applied. Unless anyNode is set, nodes which do not support
+// known versions are skipped as they would always send their config, even if it is not newer.
+func (cm *configManagementComponent) fetchConfig(snapshot *pipelineSnapshot, currentRev, currentEpoch int64, anyNode bool) {
 	if cm.configFetcher == nil {
-		logDebugf("CfgManager: Cannot fetch config as the configFetcher is unset, likely because the agent is in ns server mode")
+		cm.logger.debugf("CfgManager: Cannot fetch config as the configFetcher is unset, likely because the agent is in ns server mode")
 		return
 	}
 
 	numNodes := snapshot.NumPipelines()
+	if numNodes == 0 {
+		return
+	}
 	nodeIdx := rand.Intn(numNodes) // #nosec G404
 
 	// We try to fetch the config from each node once.
 	// If we cannot get it from any node then we just return.
 	snapshot.Iterate(nodeIdx, func(pipeline *memdPipeline) bool {
 		nodeIdx = (nodeIdx + 1) % numNodes
-		if !pipeline.SupportsFeature(memd.FeatureClusterMapKnownVersion) {
+		if !anyNode && !pipeline.SupportsFeature(memd.FeatureClusterMapKnownVersion) {
 			// No point in sending a request to a node that doesn't support known versions.
 			return false
 		}
 		cfgBytes, err := cm.configFetcher.GetClusterConfig(pipeline, currentRev, currentEpoch, cm.shutdownSig)
 		if err != nil {
-			logDebugf("CfgManager: Failed to fetch config: %s", err)
+			cm.logger.debugf("CfgManager: Failed to fetch config: %s", err)
 			return false
 		}
 		if len(cfgBytes) == 0 {
@@ -239,22 +364,162 @@ func (cm *configManagementComponent) fetchConfig(snapshot *pipelineSnapshot, cur
 			return false
 		}
 
-		logDebugf("CfgManager: Got Block: %s", string(cfgBytes))
+		cm.logger.debugf("CfgManager: Got Block: %s", string(cfgBytes))
+
+		hostName, err := hostFromHostPort(pipeline.Address())
+		if err != nil {
+			cm.logger.warnf("CfgManager:Failed to parse source address. %s", err)
+			return false
+		}
+
+		bk, err := parseConfig(cfgBytes, hostName)
+		if err != nil {
+			cm.logger.debugf("CfgManager:Failed to parse config. %v", err)
+			return false
+		}
+
+		return cm.onNewConfig(bk, false)
+	})
+}
+
+// ForceRefreshConfig fetches a config from the cluster and applies it regardless of whether it is newer than the
+// current config. Each node is tried in turn until a config is applied or the deadline is reached.
+func (cm *configManagementComponent) ForceRefreshConfig(snapshot *pipelineSnapshot, deadline time.Time) error {
+	return cm.RefreshConfigNow(snapshot, deadline, true)
+}
+
+// RefreshConfigNow fetches the current config from the cluster, outside of the usual polling, and applies it. If
+// force is set the config is applied regardless of revision ordering, otherwise it is only applied if it is newer than
+// the current config. Each node is tried in turn until a config is fetched or the deadline is reached.
+func (cm *configManagementComponent) RefreshConfigNow(snapshot *pipelineSnapshot, deadline time.Time, force bool) error {
+	if cm.configFetcher == nil {
+		return wrapError(errFeatureNotAvailable, "out of band config refresh is only supported when using cccp")
+	}
+
+	// cancelSig is closed at the deadline, or on shutdown, to abandon any fetch in progress.
+	cancelSig := make(chan struct{})
+	doneSig := make(chan struct{})
+	defer close(doneSig)
+	go func() {
+		var deadlineCh <-chan time.Time
+		if !deadline.IsZero() {
+			tmr := time.NewTimer(time.Until(deadline))
+			defer tmr.Stop()
+			deadlineCh = tmr.C
+		}
+
+		select {
+		case <-deadlineCh:
+		case <-cm.shutdownSig:
+		case <-doneSig:
+			return
+		}
+		close(cancelSig)
+	}()
+
+	// Wait for any in progress fetch to complete so that it can't race with us.
+	for {
+		cm.configFetchSigLock.Lock()
+		if cm.configFetchSig == nil {
+			cm.configFetchSig = make(chan struct{})
+			cm.configFetchSigLock.Unlock()
+			break
+		}
+		waitSig := cm.configFetchSig
+		cm.configFetchSigLock.Unlock()
+
+		select {
+		case <-waitSig:
+		case <-cancelSig:
+			return cm.forceRefreshCancelledError(deadline)
+		}
+	}
+	defer func() {
+		cm.configFetchSigLock.Lock()
+		close(cm.configFetchSig)
+		cm.configFetchSig = nil
+		cm.configFetchSigLock.Unlock()
+	}()
+
+	numNodes := snapshot.NumPipelines()
+	if numNodes == 0 {
+		return errNoCCCPHosts
+	}
+	nodeIdx := rand.Intn(numNodes) // #nosec G404
+
+	var lastErr error
+	var fetched bool
+	snapshot.Iterate(nodeIdx, func(pipeline *memdPipeline) bool {
+		select {
+		case <-cancelSig:
+			return true
+		default:
+		}
+
+		// We don't send the current revision, so that the server always sends us its current config.
+		cfgBytes, err := cm.configFetcher.GetClusterConfig(pipeline, 0, 0, cancelSig)
+		if err != nil {
+			cm.logger.debugf("CfgManager: Failed to fetch config for refresh: %s", err)
+			lastErr = err
+			return false
+		}
 
 		hostName, err := hostFromHostPort(pipeline.Address())
 		if err != nil {
-			logWarnf("CfgManager:Failed to parse source address. %s", err)
+			lastErr = err
 			return false
 		}
 
 		bk, err := parseConfig(cfgBytes, hostName)
 		if err != nil {
-			logDebugf("CfgManager:Failed to parse config. %v", err)
+			cm.logger.debugf("CfgManager: Failed to parse config for refresh. %v", err)
+			lastErr = err
 			return false
 		}
 
-		return cm.onNewConfig(bk)
+		if !force {
+			// The config not being newer than the current one is not an error, we are already up to date.
+			cm.OnNewConfig(bk)
+			fetched = true
+			return true
+		}
+
+		cm.logger.debugf("CfgManager: Applying forced config refresh from %s", redactSystemData(pipeline.Address()))
+		fetched = cm.ForceApplyConfig(bk)
+		if !fetched {
+			lastErr = wrapError(errCliInternalError, "fetched config was not valid so could not be applied")
+		}
+		return fetched
 	})
+	if fetched {
+		return nil
+	}
+
+	select {
+	case <-cancelSig:
+		return cm.forceRefreshCancelledError(deadline)
+	default:
+	}
+
+	if lastErr == nil {
+		lastErr = errNoCCCPHosts
+	}
+
+	return lastErr
+}
+
+// ForceApplyConfig applies a config which has been fetched outside of the usual polling, regardless of whether it
+// is newer than the current config.
+func (cm *configManagementComponent) ForceApplyConfig(cfg *cfgBucket) bool {
+	return cm.onNewConfig(cfg, true)
+}
+
+func (cm *configManagementComponent) forceRefreshCancelledError(deadline time.Time) error {
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		return wrapError(errUnambiguousTimeout, "timed out waiting for config refresh")
+	}
+
+	return errShutdown
 }
 
 func (cm *configManagementComponent) Close() {
@@ -275,6 +540,7 @@ func (cm *configManagementComponent) ResetConfig() {
 	cm.currentConfig = &routeConfig{
 		revID: -1,
 	}
+	cm.currentBucketConfig = nil
 	cm.configLock.Unlock()
 }
 
@@ -311,19 +577,19 @@ func (cm *configManagementComponent) RemoveConfigWatcher(watcher routeConfigWatc
 
 // We should never be receiving concurrent updates and nothing should be accessing
 // our internal route config so we shouldn't need to lock here.
-func (cm *configManagementComponent) canUpdateRouteConfig(cfg *routeConfig) bool {
+func (cm *configManagementComponent) canUpdateRouteConfig(cfg *routeConfig, force bool) bool {
 	oldCfg := cm.currentConfig
 
 	// Check some basic things to ensure consistency!
 	// If oldCfg name was empty and the new cfg isn't then we're moving from cluster to bucket connection.
 	if cfg.revID > -1 && (oldCfg.name != "" && cfg.name != "") {
 		if (cfg.vbMap == nil) != (oldCfg.vbMap == nil) {
-			logErrorf("Received a configuration with a different number of vbuckets %s-%s.  Ignoring.", oldCfg.name, cfg.name)
+			cm.logger.errorf("Received a configuration with a different number of vbuckets %s-%s.  Ignoring.", oldCfg.name, cfg.name)
 			return false
 		}
 
 		if cfg.vbMap != nil && cfg.vbMap.NumVbuckets() != oldCfg.vbMap.NumVbuckets() {
-			logErrorf("Received a configuration with a different number of vbuckets %s-%s.  Ignoring.", oldCfg.name, cfg.name)
+			cm.logger.errorf("Received a configuration with a different number of vbuckets %s-%s.  Ignoring.", oldCfg.name, cfg.name)
 			return false
 		}
 	}
@@ -334,7 +600,9 @@ func (cm *configManagementComponent) canUpdateRouteConfig(cfg *routeConfig) bool
 	// In the case where the rev epochs are the same then we need to compare rev IDs. If the new config epoch is lower
 	// than the old one then we ignore it, if it's newer then we apply the new config.
 	if cfg.bktType != oldCfg.bktType {
-		logDebugf("Configuration data changed bucket type, switching.")
+		cm.logger.debugf("Configuration data changed bucket type, switching.")
+	} else if force {
+		cm.logger.debugf("Forcing configuration update regardless of revision. Old: %d, new: %d", oldCfg.revID, cfg.revID)
 	} else if !cfg.IsNewerThan(oldCfg) {
 		return false
 	}
@@ -355,15 +623,15 @@ func (cm *configManagementComponent) buildFirstRouteConfig(config *cfgBucket, us
 		}
 
 		if cm.localLoopbackAddr == nil {
-			logWarnf("Ignoring config, nodesExt entry contained no thisNode node")
+			cm.logger.warnf("Ignoring config, nodesExt entry contained no thisNode node")
 			return &routeConfig{}
 		}
 	}
 	if cm.networkType != "" && cm.networkType != "auto" {
-		return config.BuildRouteConfig(useSSL, cm.networkType, true, cm.localLoopbackAddr)
+		return config.BuildRouteConfig(useSSL, cm.networkType, true, cm.localLoopbackAddr, cm.addressResolver, cm.logger)
 	}
 
-	defaultRouteConfig := config.BuildRouteConfig(useSSL, "default", true, cm.localLoopbackAddr)
+	defaultRouteConfig := config.BuildRouteConfig(useSSL, "default", true, cm.localLoopbackAddr, cm.addressResolver, cm.logger)
 
 	var kvServerList []routeEndpoint
 	var mgmtEpList []routeEndpoint
@@ -396,7 +664,7 @@ func (cm *configManagementComponent) buildFirstRouteConfig(config *cfgBucket, us
 	}
 
 	// Next lets see if we have an external config, if so, default to that
-	externalRouteCfg := config.BuildRouteConfig(useSSL, "external", true, cm.localLoopbackAddr)
+	externalRouteCfg := config.BuildRouteConfig(useSSL, "external", true, cm.localLoopbackAddr, cm.addressResolver, cm.logger)
 	if externalRouteCfg.IsValid() {
 		cm.networkType = "external"
 		return externalRouteCfg
@@ -408,5 +676,15 @@ func (cm *configManagementComponent) buildFirstRouteConfig(config *cfgBucket, us
 }
 
 func (cm *configManagementComponent) NetworkType() string {
-	return cm.networkType
+	cm.configLock.Lock()
+	networkType := cm.networkType
+	cm.configLock.Unlock()
+
+	return networkType
+}
+
+// FirstConfigApplied returns a channel which is closed once the first config has been applied, at which point the
+// network type has been resolved.
+func (cm *configManagementComponent) FirstConfigApplied() <-chan struct{} {
+	return cm.firstConfigSig
 }
@@ -3,6 +3,7 @@ package gocbcore
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 type testRouteWatcher struct {
@@ -139,7 +140,7 @@ func (suite *UnitTestSuite) TestConfigComponentRevEpoch() {
 				useSSL:            false,
 				networkType:       "default",
 				cfgChangeWatchers: []routeConfigWatcher{watcher},
-				currentConfig:     oldCfg.BuildRouteConfig(false, "default", false, nil),
+				currentConfig:     oldCfg.BuildRouteConfig(false, "default", false, nil, nil, nil),
 			}
 
 			newCfg := *cfg
@@ -159,3 +160,248 @@ func (suite *UnitTestSuite) TestConfigComponentRevEpoch() {
 		})
 	}
 }
+
+func (suite *UnitTestSuite) TestConfigComponentForcedUpdate() {
+	data, err := suite.LoadRawTestDataset("bucket_config_with_rev_epoch")
+	suite.Require().Nil(err)
+
+	var cfg *cfgBucket
+	suite.Require().Nil(json.Unmarshal(data, &cfg))
+
+	oldCfg := *cfg
+	oldCfg.Rev = 5
+	oldCfg.RevEpoch = 2
+
+	watcher := &testRouteWatcher{}
+	cmpt := configManagementComponent{
+		useSSL:            false,
+		networkType:       "default",
+		cfgChangeWatchers: []routeConfigWatcher{watcher},
+		currentConfig:     oldCfg.BuildRouteConfig(false, "default", false, nil, nil, nil),
+	}
+
+	newCfg := *cfg
+	newCfg.Rev = 1
+	newCfg.RevEpoch = 1
+
+	// A forced update is applied even though the revision is older.
+	suite.Assert().True(cmpt.onNewConfig(&newCfg, true))
+	suite.Require().NotNil(watcher.receivedConfig)
+	suite.Assert().Equal(int64(1), watcher.receivedConfig.revID)
+
+	revID, revEpoch := cmpt.CurrentRev()
+	suite.Assert().Equal(int64(1), revID)
+	suite.Assert().Equal(int64(1), revEpoch)
+}
+
+func (suite *UnitTestSuite) TestConfigComponentSeedConfig() {
+	data, err := suite.LoadRawTestDataset("bucket_config_with_rev_epoch")
+	suite.Require().Nil(err)
+
+	cfg, err := parseConfig(data, "127.0.0.1")
+	suite.Require().Nil(err)
+
+	// A seed config which does not contain any of the source servers is ignored, and does not resolve the network type.
+	cmpt := newConfigManager(configManagerProperties{
+		SrcMemdAddrs: []routeEndpoint{{Address: "10.0.0.1:11210"}},
+	})
+	suite.Assert().False(cmpt.OnSeedConfig(cfg))
+	suite.Assert().Nil(cmpt.CurrentBucketConfig())
+	suite.Assert().Equal("", cmpt.NetworkType())
+
+	watcher := &testRouteWatcher{}
+	cmpt = newConfigManager(configManagerProperties{
+		SrcMemdAddrs: []routeEndpoint{{Address: "127.0.0.1:11210"}},
+	})
+	cmpt.AddConfigWatcher(watcher)
+	suite.Require().True(cmpt.OnSeedConfig(cfg))
+	suite.Require().NotNil(watcher.receivedConfig)
+	suite.Assert().Equal(int64(2), watcher.receivedConfig.revID)
+	suite.Assert().Equal("default", cmpt.NetworkType())
+
+	// The first config from the cluster replaces the seed config even though it has an older revision.
+	clusterCfg := *cfg
+	clusterCfg.Rev = 1
+	clusterCfg.RevEpoch = 1
+	cmpt.OnNewConfig(&clusterCfg)
+
+	revID, revEpoch := cmpt.CurrentRev()
+	suite.Assert().Equal(int64(1), revID)
+	suite.Assert().Equal(int64(1), revEpoch)
+
+	// After which revisions are compared as normal.
+	staleCfg := *cfg
+	staleCfg.Rev = 5
+	staleCfg.RevEpoch = 0
+	cmpt.OnNewConfig(&staleCfg)
+
+	revID, _ = cmpt.CurrentRev()
+	suite.Assert().Equal(int64(1), revID)
+
+	// A seed config is never applied once a config has been.
+	suite.Assert().False(cmpt.OnSeedConfig(cfg))
+}
+
+func (suite *UnitTestSuite) TestConfigComponentSeedConfigAfterClusterConfig() {
+	data, err := suite.LoadRawTestDataset("bucket_config_with_rev_epoch")
+	suite.Require().Nil(err)
+
+	cfg, err := parseConfig(data, "127.0.0.1")
+	suite.Require().Nil(err)
+
+	cmpt := newConfigManager(configManagerProperties{
+		SrcMemdAddrs: []routeEndpoint{{Address: "127.0.0.1:11210"}},
+	})
+
+	// A config from the cluster arrives before the seed config is applied, such as from a pipeline which connected
+	// whilst the seed config was being parsed.
+	cmpt.OnNewConfig(cfg)
+	suite.Assert().False(cmpt.OnSeedConfig(cfg))
+	suite.Assert().False(cmpt.usingSeedConfig)
+
+	// As the seed was not applied, configs with older revisions must still be ignored.
+	staleCfg := *cfg
+	staleCfg.Rev = 1
+	cmpt.OnNewConfig(&staleCfg)
+
+	revID, _ := cmpt.CurrentRev()
+	suite.Assert().Equal(int64(2), revID)
+}
+
+func (suite *UnitTestSuite) TestConfigComponentForceRefreshConfigNoFetcher() {
+	cmpt := newConfigManager(configManagerProperties{
+		NetworkType: "default",
+	})
+
+	err := cmpt.ForceRefreshConfig(&pipelineSnapshot{}, time.Time{})
+	suite.Assert().ErrorIs(err, ErrFeatureNotAvailable)
+}
+
+func (suite *UnitTestSuite) TestConfigComponentForceRefreshConfigDeadline() {
+	cmpt := newConfigManager(configManagerProperties{
+		NetworkType: "default",
+	})
+	cmpt.SetConfigFetcher(newCCCPConfigFetcher(time.Second))
+
+	// Another fetch is in progress and never completes, so the refresh must give up at the deadline.
+	cmpt.configFetchSig = make(chan struct{})
+
+	start := time.Now()
+	err := cmpt.ForceRefreshConfig(&pipelineSnapshot{state: &kvMuxState{}}, time.Now().Add(50*time.Millisecond))
+	suite.Assert().ErrorIs(err, ErrTimeout)
+	suite.Assert().Less(time.Since(start), time.Second)
+}
+
+func (suite *UnitTestSuite) TestConfigComponentBeginReconfigure() {
+	cmpt := newConfigManager(configManagerProperties{
+		NetworkType: "default",
+	})
+
+	// Reconfigure is allowed without a cccp fetcher, as the refresh falls back to http.
+	suite.Assert().True(cmpt.BeginReconfigure())
+	// A second call straight after the first is rate limited.
+	suite.Assert().False(cmpt.BeginReconfigure())
+
+	cmpt.lastReconfigure = time.Time{}
+	cmpt.SetConfigFetcher(newCCCPConfigFetcher(time.Second))
+	suite.Assert().True(cmpt.BeginReconfigure())
+	// A second call straight after the first is rate limited.
+	suite.Assert().False(cmpt.BeginReconfigure())
+
+	cmpt.lastReconfigure = time.Time{}
+	cmpt.configFetchSig = make(chan struct{})
+	// A reconfigure is not performed whilst another fetch is in progress.
+	suite.Assert().False(cmpt.BeginReconfigure())
+
+	cmpt.configFetchSig = nil
+	suite.Assert().True(cmpt.BeginReconfigure())
+}
+
+func (suite *UnitTestSuite) TestConfigComponentRefreshConfigNowNoNodes() {
+	cmpt := newConfigManager(configManagerProperties{
+		NetworkType: "default",
+	})
+	cmpt.SetConfigFetcher(newCCCPConfigFetcher(time.Second))
+
+	// With no pipelines there is nothing to fetch from, whether or not the refresh is forced.
+	err := cmpt.RefreshConfigNow(&pipelineSnapshot{state: &kvMuxState{}}, time.Time{}, false)
+	suite.Assert().ErrorIs(err, errNoCCCPHosts)
+}
+
+func (suite *UnitTestSuite) TestConfigComponentRefreshConfigNowNoFetcher() {
+	cmpt := newConfigManager(configManagerProperties{
+		NetworkType: "default",
+	})
+
+	// Without a cccp fetcher the refresh fails so that the caller can fall back to http.
+	err := cmpt.RefreshConfigNow(&pipelineSnapshot{state: &kvMuxState{}}, time.Time{}, false)
+	suite.Assert().ErrorIs(err, errFeatureNotAvailable)
+}
+
+func (suite *UnitTestSuite) TestBuildRouteConfigCollectionsManifestUID() {
+	cfg := &cfgBucket{
+		Rev:                    1,
+		NodeLocator:            "vbucket",
+		CollectionsManifestUID: "1a",
+	}
+	suite.Assert().Equal(uint64(0x1a), cfg.BuildRouteConfig(false, "default", false, nil, nil, nil).collectionsManifestUID)
+
+	cfg.CollectionsManifestUID = ""
+	suite.Assert().Zero(cfg.BuildRouteConfig(false, "default", false, nil, nil, nil).collectionsManifestUID)
+}
+
+func (suite *UnitTestSuite) TestBuildRouteConfigAddressResolver() {
+	cfg := &cfgBucket{
+		Rev: 1,
+		NodesExt: []cfgNodeExt{
+			{
+				Hostname: "10.0.0.1",
+				Services: cfgNodeServices{Kv: 11210, Mgmt: 8091, N1ql: 8093},
+			},
+			{
+				Hostname: "fd00::1",
+				Services: cfgNodeServices{Kv: 11210, Mgmt: 8091},
+			},
+		},
+	}
+
+	type resolved struct {
+		host    string
+		port    int
+		service ServiceType
+	}
+	var calls []resolved
+	resolver := func(host string, port int, service ServiceType) (string, int) {
+		calls = append(calls, resolved{host: host, port: port, service: service})
+		switch host {
+		case "10.0.0.1":
+			if service == MemdService {
+				return "203.0.113.1", 31210
+			}
+			return "203.0.113.1", port
+		case "fd00::1":
+			return "2001:db8::1", port
+		}
+		return host, port
+	}
+
+	routeCfg := cfg.BuildRouteConfig(false, "default", false, nil, resolver, nil)
+	suite.Assert().Equal([]routeEndpoint{
+		{Address: "couchbase://203.0.113.1:31210"},
+		{Address: "couchbase://[2001:db8::1]:11210"},
+	}, routeCfg.kvServerList.NonSSLEndpoints)
+	suite.Assert().Equal([]routeEndpoint{
+		{Address: "http://203.0.113.1:8091"},
+		{Address: "http://[2001:db8::1]:8091"},
+	}, routeCfg.mgmtEpList.NonSSLEndpoints)
+	suite.Assert().Equal([]routeEndpoint{{Address: "http://203.0.113.1:8093"}}, routeCfg.n1qlEpList.NonSSLEndpoints)
+	suite.Assert().Contains(calls, resolved{host: "fd00::1", port: 11210, service: MemdService})
+	suite.Assert().Contains(calls, resolved{host: "10.0.0.1", port: 8093, service: N1qlService})
+
+	// Without a resolver the addresses are used as is.
+	routeCfg = cfg.BuildRouteConfig(false, "default", false, nil, nil, nil)
+	suite.Assert().Equal([]routeEndpoint{
+		{Address: "couchbase://10.0.0.1:11210"},
+		{Address: "couchbase://[fd00::1]:11210"},
+	}, routeCfg.kvServerList.NonSSLEndpoints)
+}
@@ -10,6 +10,11 @@ func (pi ConfigSnapshot) RevID() int64 {
 	return pi.state.RevID()
 }
 
+// RevEpoch returns the config revision epoch for this snapshot.
+func (pi ConfigSnapshot) RevEpoch() int64 {
+	return pi.state.RevEpoch()
+}
+
 // KeyToVbucket translates a particular key to its assigned vbucket.
 func (pi ConfigSnapshot) KeyToVbucket(key []byte) (uint16, error) {
 	if pi.state.VBMap() == nil {
@@ -0,0 +1,75 @@
+package gocbcore
+
+// configUpdateQueueSize is the number of config updates which can be waiting for the callback before further updates
+// are dropped.
+const configUpdateQueueSize = 32
+
+// ConfigUpdateCallback is invoked with the revision, revision epoch and number of KV nodes of each cluster config
+// applied by the agent.
+type ConfigUpdateCallback func(rev, epoch int64, numNodes int)
+
+type configUpdate struct {
+	rev      int64
+	epoch    int64
+	numNodes int
+}
+
+// configUpdateComponent passes applied configs to the user callback from its own goroutine, so that a slow callback
+// cannot hold up the application of configs.
+type configUpdateComponent struct {
+	callback ConfigUpdateCallback
+	updateCh chan configUpdate
+	stopSig  chan struct{}
+	logger   *scopedLogger
+}
+
+func newConfigUpdateComponent(callback ConfigUpdateCallback, cfgMgr configManager, logger *scopedLogger) *configUpdateComponent {
+	cuc := &configUpdateComponent{
+		callback: callback,
+		updateCh: make(chan configUpdate, configUpdateQueueSize),
+		stopSig:  make(chan struct{}),
+		logger:   logger,
+	}
+
+	cfgMgr.AddConfigWatcher(cuc)
+
+	return cuc
+}
+
+func (cuc *configUpdateComponent) OnNewRouteConfig(cfg *routeConfig) {
+	if cfg.revID < 0 {
+		return
+	}
+
+	numNodes := len(cfg.kvServerList.NonSSLEndpoints)
+	if len(cfg.kvServerList.SSLEndpoints) > numNodes {
+		numNodes = len(cfg.kvServerList.SSLEndpoints)
+	}
+
+	update := configUpdate{
+		rev:      cfg.revID,
+		epoch:    cfg.revEpoch,
+		numNodes: numNodes,
+	}
+
+	select {
+	case cuc.updateCh <- update:
+	default:
+		cuc.logger.debugf("Config update callback is falling behind, dropping update for revision %d", cfg.revID)
+	}
+}
+
+func (cuc *configUpdateComponent) Start() {
+	for {
+		select {
+		case <-cuc.stopSig:
+			return
+		case update := <-cuc.updateCh:
+			cuc.callback(update.rev, update.epoch, update.numNodes)
+		}
+	}
+}
+
+func (cuc *configUpdateComponent) Stop() {
+	close(cuc.stopSig)
+}
@@ -0,0 +1,62 @@
+package gocbcore
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *UnitTestSuite) TestConfigUpdateComponent() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	type update struct {
+		rev, epoch int64
+		numNodes   int
+	}
+	updates := make(chan update, 2)
+	release := make(chan struct{})
+	cuc := newConfigUpdateComponent(func(rev, epoch int64, numNodes int) {
+		<-release
+		select {
+		case updates <- update{rev: rev, epoch: epoch, numNodes: numNodes}:
+		default:
+		}
+	}, cfgMgr, nil)
+	go cuc.Start()
+	defer func() {
+		cuc.Stop()
+		close(release)
+	}()
+
+	cfgMgr.AssertCalled(suite.T(), "AddConfigWatcher", cuc)
+
+	// Configs which haven't come from the server are never passed on.
+	cuc.OnNewRouteConfig(&routeConfig{revID: -1})
+	cuc.OnNewRouteConfig(&routeConfig{
+		revID:    5,
+		revEpoch: 1,
+		kvServerList: routeEndpoints{
+			NonSSLEndpoints: []routeEndpoint{{Address: "10.112.210.101:11210"}, {Address: "10.112.210.102:11210"}},
+		},
+	})
+
+	// The callback is blocked, so these must not block the caller even once the queue is full.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < configUpdateQueueSize*2; i++ {
+			cuc.OnNewRouteConfig(&routeConfig{revID: int64(6 + i), revEpoch: 1})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.T().Fatalf("Applying configs was blocked by the callback")
+	}
+
+	release <- struct{}{}
+	suite.Assert().Equal(update{rev: 5, epoch: 1, numNodes: 2}, <-updates)
+	release <- struct{}{}
+	suite.Assert().Equal(update{rev: 6, epoch: 1}, <-updates)
+}
@@ -0,0 +1,123 @@
+package gocbcore
+
+import "sync"
+
+// ConnectionState represents a change in the state of a KV connection, see AgentConfig.OnConnectionStateChange.
+// Volatile: This API is subject to change at any time.
+type ConnectionState uint32
+
+const (
+	// ConnectionStateConnected indicates that a new connection to an endpoint has been established. When more than one
+	// connection is made to each endpoint, see KVConfig.PoolSize, this is reported for each of them.
+	ConnectionStateConnected ConnectionState = 1
+
+	// ConnectionStateDisconnected indicates that a connection to an endpoint has been closed.
+	ConnectionStateDisconnected ConnectionState = 2
+
+	// ConnectionStateReconnected indicates that a connection to an endpoint has been established to replace a previous
+	// connection to it which was closed.
+	ConnectionStateReconnected ConnectionState = 3
+)
+
+// String returns the string representation of this state.
+func (state ConnectionState) String() string {
+	switch state {
+	case ConnectionStateConnected:
+		return "connected"
+	case ConnectionStateDisconnected:
+		return "disconnected"
+	case ConnectionStateReconnected:
+		return "reconnected"
+	}
+
+	return "unknown"
+}
+
+type connectionStateEvent struct {
+	endpoint string
+	state    ConnectionState
+}
+
+// connectionStateNotifier invokes the connection state change callback from its own goroutine, so that a slow
+// callback cannot stall the IO of the connection reporting the change. Events are delivered in the order that they
+// were reported and the goroutine only runs whilst there are events waiting to be delivered.
+type connectionStateNotifier struct {
+	fn func(endpoint string, state ConnectionState)
+
+	lock    sync.Mutex
+	queue   []connectionStateEvent
+	running bool
+
+	// closedConns counts the connections to each endpoint which have been closed and not yet replaced.
+	closedConns map[string]int
+}
+
+func newConnectionStateNotifier(fn func(endpoint string, state ConnectionState)) *connectionStateNotifier {
+	if fn == nil {
+		return nil
+	}
+
+	return &connectionStateNotifier{
+		fn:          fn,
+		closedConns: make(map[string]int),
+	}
+}
+
+// Connected reports that a connection to endpoint has been established, which is a reconnection if it replaces a
+// connection to the endpoint which was closed.
+func (n *connectionStateNotifier) Connected(endpoint string) {
+	if n == nil {
+		return
+	}
+
+	n.lock.Lock()
+	state := ConnectionStateConnected
+	if n.closedConns[endpoint] > 0 {
+		n.closedConns[endpoint]--
+		state = ConnectionStateReconnected
+	}
+	n.pushLocked(endpoint, state)
+}
+
+// Disconnected reports that a connection to endpoint has been closed.
+func (n *connectionStateNotifier) Disconnected(endpoint string) {
+	if n == nil {
+		return
+	}
+
+	n.lock.Lock()
+	n.closedConns[endpoint]++
+	n.pushLocked(endpoint, ConnectionStateDisconnected)
+}
+
+// pushLocked must be called with the lock held, and releases it.
+func (n *connectionStateNotifier) pushLocked(endpoint string, state ConnectionState) {
+	n.queue = append(n.queue, connectionStateEvent{
+		endpoint: endpoint,
+		state:    state,
+	})
+	if n.running {
+		n.lock.Unlock()
+		return
+	}
+	n.running = true
+	n.lock.Unlock()
+
+	go n.run()
+}
+
+func (n *connectionStateNotifier) run() {
+	for {
+		n.lock.Lock()
+		if len(n.queue) == 0 {
+			n.running = false
+			n.lock.Unlock()
+			return
+		}
+		event := n.queue[0]
+		n.queue = n.queue[1:]
+		n.lock.Unlock()
+
+		n.fn(event.endpoint, event.state)
+	}
+}
@@ -0,0 +1,126 @@
+package gocbcore
+
+import (
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memdmock"
+)
+
+func (suite *UnitTestSuite) TestConnectionStateNotifier() {
+	suite.Assert().Nil(newConnectionStateNotifier(nil))
+
+	// A nil notifier is safe to report to.
+	var nilNotifier *connectionStateNotifier
+	nilNotifier.Connected("10.112.210.101:11210")
+	nilNotifier.Disconnected("10.112.210.101:11210")
+
+	type event struct {
+		endpoint string
+		state    ConnectionState
+	}
+	unblockCh := make(chan struct{})
+	eventCh := make(chan event, 10)
+	notifier := newConnectionStateNotifier(func(endpoint string, state ConnectionState) {
+		<-unblockCh
+		eventCh <- event{endpoint: endpoint, state: state}
+	})
+
+	// The callback is blocked, which must not block reporting.
+	reported := make(chan struct{})
+	go func() {
+		notifier.Connected("10.112.210.101:11210")
+		notifier.Connected("10.112.210.102:11210")
+		notifier.Connected("10.112.210.101:11210")
+		notifier.Disconnected("10.112.210.101:11210")
+		notifier.Connected("10.112.210.101:11210")
+		notifier.Connected("10.112.210.101:11210")
+		close(reported)
+	}()
+
+	select {
+	case <-reported:
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Reporting state changes was blocked by the callback")
+	}
+
+	close(unblockCh)
+
+	expected := []event{
+		{endpoint: "10.112.210.101:11210", state: ConnectionStateConnected},
+		{endpoint: "10.112.210.102:11210", state: ConnectionStateConnected},
+		{endpoint: "10.112.210.101:11210", state: ConnectionStateConnected},
+		{endpoint: "10.112.210.101:11210", state: ConnectionStateDisconnected},
+		{endpoint: "10.112.210.101:11210", state: ConnectionStateReconnected},
+		{endpoint: "10.112.210.101:11210", state: ConnectionStateConnected},
+	}
+	for _, expectedEvent := range expected {
+		select {
+		case e := <-eventCh:
+			suite.Assert().Equal(expectedEvent, e)
+		case <-time.After(5 * time.Second):
+			suite.T().Fatalf("Timed out waiting for %s event", expectedEvent.state)
+		}
+	}
+
+	// Wait for the delivery goroutine to exit so that it isn't seen as leaked.
+	suite.Eventually(func() bool {
+		notifier.lock.Lock()
+		defer notifier.lock.Unlock()
+		return !notifier.running
+	}, 5*time.Second, time.Millisecond)
+}
+
+func (suite *UnitTestSuite) TestConnectionStateChangeWithPoolSize() {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		BucketName: "mock",
+		Username:   "user",
+		Password:   "pass",
+	})
+	suite.Require().Nil(err, err)
+	defer srv.Close()
+
+	stateCh := make(chan ConnectionState, 10)
+	agent := suite.createMemdMockAgent(srv, &AgentConfig{
+		KVConfig: KVConfig{
+			PoolSize: 2,
+		},
+		OnConnectionStateChange: func(endpoint string, state ConnectionState) {
+			suite.Assert().Equal(srv.Address(), endpoint)
+			stateCh <- state
+		},
+	})
+	defer agent.Close()
+
+	waitForState := func(expected ConnectionState) {
+		select {
+		case state := <-stateCh:
+			suite.Assert().Equal(expected, state)
+		case <-time.After(5 * time.Second):
+			suite.T().Fatalf("Timed out waiting for %s event", expected)
+		}
+	}
+
+	// Each connection in the pool is a new connection, rather than a reconnection.
+	waitForState(ConnectionStateConnected)
+	waitForState(ConnectionStateConnected)
+
+	pipeline := agent.kvMux.getState().GetPipeline(0)
+	suite.Require().NotNil(pipeline)
+	pipecli := pipeline.Clients()[0]
+	suite.Require().Eventually(func() bool {
+		pipecli.lock.Lock()
+		defer pipecli.lock.Unlock()
+		return pipecli.client != nil
+	}, 5*time.Second, time.Millisecond)
+
+	pipecli.lock.Lock()
+	client := pipecli.client
+	pipecli.lock.Unlock()
+
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+	suite.Require().Nil(client.Close())
+
+	waitForState(ConnectionStateDisconnected)
+	waitForState(ConnectionStateReconnected)
+}
@@ -70,6 +70,9 @@ const (
 	BucketCapabilityNonDedupedHistory    BucketCapability = 0x05
 	// Uncommitted: This API may change in the future.
 	BucketCapabilityReviveDocument BucketCapability = 0x06
+	// BucketCapabilityXdcr indicates that the bucket can be the target of cross datacenter replication, and so
+	// supports the GetMeta, SetMeta and DeleteMeta operations.
+	BucketCapabilityXdcr BucketCapability = 0x07
 )
 
 type CapabilityStatus uint32
@@ -123,6 +126,9 @@ const (
 	spanAttribNetPeerPortKey    = "net.peer.port"
 	spanAttribServerDurationKey = "db.couchbase.server_duration"
 	spanAttribNumRetries        = "db.couchbase.retries"
+	spanAttribDBNameKey         = "db.name"
+	spanAttribScopeNameKey      = "db.couchbase.scope"
+	spanAttribCollectionNameKey = "db.couchbase.collection"
 )
 
 const (
@@ -130,6 +136,7 @@ const (
 	metricAttribOperationKey         = "db.operation"
 	metricAttribClusterUUIDKey       = "db.couchbase.cluster_uuid"
 	metricAttribClusterNameKey       = "db.couchbase.cluster_name"
+	metricAttribOutcomeKey           = "outcome"
 	meterNameCBOperations            = "db.couchbase.operations"
 	metricValueServiceKeyValue       = "kv"
 	metricValueServiceQueryValue     = "n1ql"
@@ -137,6 +144,10 @@ const (
 	metricValueServiceAnalyticsValue = "cbas"
 	metricValueServiceViewsValue     = "capi"
 	metricValueServiceHTTPValue      = "http"
+	metricValueOutcomeSuccess        = "Success"
+	metricValueOutcomeTimeout        = "Timeout"
+	metricValueOutcomeCanceled       = "Canceled"
+	metricValueOutcomeError          = "Error"
 )
 
 type SpanStatus string
@@ -158,3 +169,26 @@ var revidMacro = []byte("\"${$document.revid}\"")
 var exptimeMacro = []byte("\"${$document.exptime}\"")
 var casMacro = []byte("\"${$document.CAS}\"")
 var hlcMacro = "$vbucket.HLC"
+
+// OperationPriority specifies the priority with which a KV operation is dispatched.
+type OperationPriority int
+
+const (
+	// OperationPriorityNormal indicates that the operation should be dispatched with normal priority.
+	OperationPriorityNormal = OperationPriority(0)
+
+	// OperationPriorityHigh indicates that the operation is latency critical. High priority operations are
+	// dispatched ahead of other operations and can also use any connections reserved via KVConfig.HighPriorityPoolSize.
+	OperationPriorityHigh = OperationPriority(1)
+
+	// OperationPriorityLow indicates that the operation is background work, which should be dispatched after
+	// higher priority operations.
+	OperationPriorityLow = OperationPriority(2)
+)
+
+// MaxLockTime is the maximum time, in seconds, for which the server will lock a document.
+const MaxLockTime = 30
+
+// CounterNoInitial can be used as CounterOptions.Initial to indicate that a counter operation should not create
+// the document if it does not exist, in which case the operation fails with ErrDocumentNotFound.
+const CounterNoInitial = uint64(0xFFFFFFFFFFFFFFFF)
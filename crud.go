@@ -1,9 +1,23 @@
 package gocbcore
 
+import "time"
+
 // Cas represents a unique revision of a document.  This can be used
 // to perform optimistic locking.
 type Cas uint64
 
+// hlcLogicalBits is the number of low order bits of a hybrid logical clock cas which hold a logical counter rather
+// than the physical time.
+const hlcLogicalBits = 16
+
+// HLCTime decodes the cas as a hybrid logical clock, returning the approximate wall-clock time at which the server
+// made the mutation. The server generates cas values in this form for buckets using last write wins (timestamp based)
+// conflict resolution, on other buckets the result is not meaningful. This is best-effort: the time comes from the
+// clock of the node which made the mutation, and the cas may have been advanced beyond it to remain monotonic.
+func (c Cas) HLCTime() time.Time {
+	return time.Unix(0, int64(uint64(c)>>hlcLogicalBits<<hlcLogicalBits))
+}
+
 // VbUUID represents a unique identifier for a particular vbucket history.
 type VbUUID uint64
 
@@ -15,11 +15,13 @@ type ObserveOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // ObserveVbOptions encapsulates the parameters for a ObserveVbEx operation.
@@ -29,11 +31,13 @@ type ObserveVbOptions struct {
 	ReplicaIdx    int
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // ObserveResult encapsulates the result of a ObserveEx operation.
@@ -43,7 +47,8 @@ type ObserveResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -59,6 +64,43 @@ type ObserveVbResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
+
+// ObserveDurabilityOptions encapsulates the parameters for a ObserveDurability operation.
+type ObserveDurabilityOptions struct {
+	Key []byte
+	// Cas is the CAS returned by the mutation which is being observed. It is not used when IsDelete is set.
+	Cas Cas
+	// IsDelete indicates that the mutation being observed was a delete.
+	IsDelete bool
+	// ReplicateTo is the number of replicas, not including the active, which must have the mutation in memory.
+	ReplicateTo uint
+	// PersistTo is the number of nodes, including the active, which must have persisted the mutation.
+	PersistTo uint
+	// PollInterval is the time to wait between each round of observes, defaults to 10ms.
+	PollInterval time.Duration
+
+	CollectionName string
+	ScopeName      string
+	CollectionID   uint32
+	RetryStrategy  RetryStrategy
+	Deadline       time.Time
+	Timeout        time.Duration
+
+	// Internal: This should never be used and is not supported.
+	User string
+
+	TraceContext RequestSpanContext
+	NoRootSpan   bool
+}
+
+// ObserveDurabilityResult encapsulates the result of a ObserveDurability operation.
+type ObserveDurabilityResult struct {
+	// NumReplicated is the number of replicas which were seen to have the mutation in memory.
+	NumReplicated uint
+	// NumPersisted is the number of nodes, including the active, which were seen to have persisted the mutation.
+	NumPersisted uint
+}
@@ -14,11 +14,30 @@ type GetOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
+
+	// DisableDecompression specifies that a compressed value should be returned as received from the server, with
+	// DatatypeFlagCompressed set on the result datatype, rather than being decompressed. Compression is otherwise
+	// handled as configured on the agent.
+	DisableDecompression bool
+
+	// WithExpiry specifies that the expiry of the document should also be fetched and returned on the result.
+	// This requires an additional GetMeta request to be sent to the server, which is performed transparently. It
+	// cannot be used with PinnedReplicaIdx.
+	WithExpiry bool
+
+	// PinnedReplicaIdx, when greater than zero, routes the read to the replica with the given index rather than to
+	// the active, bypassing the normal routing. This is intended for exercising replica read paths in testing.
+	// Volatile: This API is subject to change at any time.
+	PinnedReplicaIdx int
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // GetAndTouchOptions encapsulates the parameters for a GetAndTouchEx operation.
@@ -30,30 +49,56 @@ type GetAndTouchOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
+
+	// ExpiryDuration is an alternative to Expiry, see SetOptions.ExpiryDuration.
+	// Volatile: This API is subject to change at any time.
+	ExpiryDuration time.Duration
+
+	// DisableDecompression specifies that a compressed value should be returned without being decompressed, see
+	// GetOptions.DisableDecompression.
+	DisableDecompression bool
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // GetAndLockOptions encapsulates the parameters for a GetAndLockEx operation.
 type GetAndLockOptions struct {
-	Key            []byte
-	LockTime       uint32
+	Key []byte
+	// LockTime is the time in seconds for which the document is locked. The server does not lock documents for longer
+	// than MaxLockTime seconds, and a LockTime of 0 uses the server default of 15 seconds. A LockTime greater than
+	// MaxLockTime is rejected unless ClampLockTime is set.
+	LockTime uint32
+	// ClampLockTime specifies that a LockTime greater than MaxLockTime is reduced to MaxLockTime, rather than the
+	// operation failing with ErrInvalidArgument.
+	ClampLockTime  bool
 	CollectionName string
 	ScopeName      string
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
+
+	// DisableDecompression specifies that a compressed value should be returned without being decompressed, see
+	// GetOptions.DisableDecompression.
+	DisableDecompression bool
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
-// GetAnyReplicaOptions encapsulates the parameters for a GetAnyReplicaEx operation.
+// GetAnyReplicaOptions encapsulates the parameters for a GetAnyReplica operation.
 type GetAnyReplicaOptions struct {
 	Key            []byte
 	CollectionName string
@@ -61,11 +106,18 @@ type GetAnyReplicaOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	Priority       OperationPriority
+
+	// DisableDecompression specifies that a compressed value should be returned without being decompressed, see
+	// GetOptions.DisableDecompression.
+	DisableDecompression bool
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // GetOneReplicaOptions encapsulates the parameters for a GetOneReplicaEx operation.
@@ -77,6 +129,13 @@ type GetOneReplicaOptions struct {
 	RetryStrategy  RetryStrategy
 	ReplicaIdx     int
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
+
+	// DisableDecompression specifies that a compressed value should be returned without being decompressed, see
+	// GetOptions.DisableDecompression.
+	DisableDecompression bool
 
 	// Uncommitted: This API may change in the future.
 	ServerGroup string
@@ -85,6 +144,55 @@ type GetOneReplicaOptions struct {
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
+}
+
+// BulkGetOptions encapsulates the parameters for a BulkGet operation.
+type BulkGetOptions struct {
+	Keys           [][]byte
+	CollectionName string
+	ScopeName      string
+	CollectionID   uint32
+	RetryStrategy  RetryStrategy
+	Deadline       time.Time
+	Timeout        time.Duration
+	Priority       OperationPriority
+
+	// Concurrency is the maximum number of gets which can be outstanding at once, if zero then all of the gets
+	// are issued at once.
+	Concurrency int
+
+	// DisableDecompression specifies that a compressed value should be returned without being decompressed, see
+	// GetOptions.DisableDecompression.
+	DisableDecompression bool
+
+	// Internal: This should never be used and is not supported.
+	User string
+
+	TraceContext RequestSpanContext
+	NoRootSpan   bool
+}
+
+// GetAllReplicasOptions encapsulates the parameters for a GetAllReplicas operation.
+type GetAllReplicasOptions struct {
+	Key            []byte
+	CollectionName string
+	ScopeName      string
+	CollectionID   uint32
+	RetryStrategy  RetryStrategy
+	Deadline       time.Time
+	Timeout        time.Duration
+	Priority       OperationPriority
+
+	// DisableDecompression specifies that a compressed value should be returned without being decompressed, see
+	// GetOptions.DisableDecompression.
+	DisableDecompression bool
+
+	// Internal: This should never be used and is not supported.
+	User string
+
+	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // TouchOptions encapsulates the parameters for a TouchEx operation.
@@ -96,27 +204,41 @@ type TouchOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
+
+	// ExpiryDuration is an alternative to Expiry, see SetOptions.ExpiryDuration.
+	// Volatile: This API is subject to change at any time.
+	ExpiryDuration time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // UnlockOptions encapsulates the parameters for a UnlockEx operation.
 type UnlockOptions struct {
-	Key            []byte
+	Key []byte
+	// Cas is the cas returned by the GetAndLock which locked the document. If it does not match then the operation
+	// fails with ErrCasMismatch.
 	Cas            Cas
 	CollectionName string
 	ScopeName      string
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // DeleteOptions encapsulates the parameters for a DeleteEx operation.
@@ -130,11 +252,19 @@ type DeleteOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
+
+	// Idempotent marks the operation as safe to retry, see SetOptions.Idempotent.
+	// Volatile: This API is subject to change at any time.
+	Idempotent bool
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // AddOptions encapsulates the parameters for a AddEx operation.
@@ -151,11 +281,27 @@ type AddOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
+
+	// ExpiryDuration is an alternative to Expiry, see SetOptions.ExpiryDuration.
+	// Volatile: This API is subject to change at any time.
+	ExpiryDuration time.Duration
+
+	// IsJSON marks the value as JSON by setting memd.DatatypeFlagJSON on Datatype, so that services such as query
+	// treat it as a JSON document. Any other flags on Datatype are left unchanged.
+	IsJSON bool
+
+	// Idempotent marks the operation as safe to retry, see SetOptions.Idempotent.
+	// Volatile: This API is subject to change at any time.
+	Idempotent bool
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 type storeOptions struct {
@@ -172,12 +318,19 @@ type storeOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
 	PreserveExpiry         bool
+	ExpiryDuration         time.Duration
+	IsJSON                 bool
+	Idempotent             bool
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // SetOptions encapsulates the parameters for a SetEx operation.
@@ -194,12 +347,33 @@ type SetOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
 	PreserveExpiry         bool
 
+	// ExpiryDuration is an alternative to Expiry which is encoded using ExpiryFromDuration, so that durations of over
+	// 30 days are converted into the absolute unix timestamp that the server requires for them. It cannot be used
+	// together with Expiry.
+	// Volatile: This API is subject to change at any time.
+	ExpiryDuration time.Duration
+
+	// IsJSON marks the value as JSON by setting memd.DatatypeFlagJSON on Datatype, so that services such as query
+	// treat it as a JSON document. Any other flags on Datatype are left unchanged.
+	IsJSON bool
+
+	// Idempotent marks the operation as safe to retry, so that it is retried for reasons which would otherwise only
+	// retry idempotent operations, such as the connection closing whilst the operation is in flight. The retry strategy
+	// sees this through RetryRequest.Idempotent. It does not cover timeouts: the deadline applies to the operation as a
+	// whole, including any retries, so an operation which reaches it still fails with ErrAmbiguousTimeout.
+	// Volatile: This API is subject to change at any time.
+	Idempotent bool
+
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // ReplaceOptions encapsulates the parameters for a ReplaceEx operation.
@@ -217,12 +391,28 @@ type ReplaceOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
 	PreserveExpiry         bool
 
+	// ExpiryDuration is an alternative to Expiry, see SetOptions.ExpiryDuration.
+	// Volatile: This API is subject to change at any time.
+	ExpiryDuration time.Duration
+
+	// IsJSON marks the value as JSON by setting memd.DatatypeFlagJSON on Datatype, so that services such as query
+	// treat it as a JSON document. Any other flags on Datatype are left unchanged.
+	IsJSON bool
+
+	// Idempotent marks the operation as safe to retry, see SetOptions.Idempotent.
+	// Volatile: This API is subject to change at any time.
+	Idempotent bool
+
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // AdjoinOptions encapsulates the parameters for a AppendEx or PrependEx operation.
@@ -237,15 +427,27 @@ type AdjoinOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
 	PreserveExpiry         bool
 
+	// Idempotent marks the operation as safe to retry, see SetOptions.Idempotent.
+	// Volatile: This API is subject to change at any time.
+	Idempotent bool
+
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // CounterOptions encapsulates the parameters for a IncrementEx or DecrementEx operation.
+//
+// If the document does not exist then it is created with the value of Initial, unless Initial is CounterNoInitial in
+// which case the operation fails with ErrDocumentNotFound. Note that the zero value of Initial creates the document
+// with a value of 0. Expiry is only applied when the document is created, and cannot be used with CounterNoInitial.
 type CounterOptions struct {
 	Key                    []byte
 	Delta                  uint64
@@ -259,18 +461,33 @@ type CounterOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
 	PreserveExpiry         bool
 
+	// ExpiryDuration is an alternative to Expiry, see SetOptions.ExpiryDuration.
+	// Volatile: This API is subject to change at any time.
+	ExpiryDuration time.Duration
+
+	// Idempotent marks the operation as safe to retry, see SetOptions.Idempotent.
+	// Volatile: This API is subject to change at any time.
+	Idempotent bool
+
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // GetRandomOptions encapsulates the parameters for a GetRandomEx operation.
 type GetRandomOptions struct {
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
+	NoRetry       bool
+	Priority      OperationPriority
 
 	CollectionName string
 	ScopeName      string
@@ -280,6 +497,7 @@ type GetRandomOptions struct {
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // GetMetaOptions encapsulates the parameters for a GetMetaEx operation.
@@ -290,19 +508,29 @@ type GetMetaOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
+
+	// DisableDecompression specifies that a compressed value should be returned without being decompressed, see
+	// GetOptions.DisableDecompression.
+	DisableDecompression bool
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // SetMetaOptions encapsulates the parameters for a SetMetaEx operation.
 type SetMetaOptions struct {
-	Key            []byte
-	Value          []byte
-	Extra          []byte
-	Datatype       uint8
+	Key      []byte
+	Value    []byte
+	Extra    []byte
+	Datatype uint8
+	// Options is a bitwise combination of memd.SetMetaOption values. The bucket's conflict resolution mode is used
+	// unless memd.UseLwwConflictResolution or memd.SkipConflictResolution is set.
 	Options        uint32
 	Flags          uint32
 	Expiry         uint32
@@ -313,19 +541,25 @@ type SetMetaOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // DeleteMetaOptions encapsulates the parameters for a DeleteMetaEx operation.
 type DeleteMetaOptions struct {
-	Key            []byte
-	Value          []byte
-	Extra          []byte
-	Datatype       uint8
+	Key      []byte
+	Value    []byte
+	Extra    []byte
+	Datatype uint8
+	// Options is a bitwise combination of memd.SetMetaOption values. The bucket's conflict resolution mode is used
+	// unless memd.UseLwwConflictResolution or memd.SkipConflictResolution is set.
 	Options        uint32
 	Flags          uint32
 	Expiry         uint32
@@ -336,9 +570,13 @@ type DeleteMetaOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
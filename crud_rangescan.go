@@ -10,6 +10,7 @@ import (
 type RangeScanCreateOptions struct {
 	// Deadline will also be sent as a part of the payload if Snapshot is not nil.
 	Deadline time.Time
+	Timeout  time.Duration
 
 	CollectionName string
 	ScopeName      string
@@ -25,6 +26,7 @@ type RangeScanCreateOptions struct {
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 func (opts RangeScanCreateOptions) toRequest() (*rangeScanCreateRequest, error) {
@@ -172,6 +174,7 @@ func (createRes *rangeScanCreateResult) KeysOnly() bool {
 type RangeScanContinueOptions struct {
 	// Deadline will also be sent as a part of the payload if not zero.
 	Deadline time.Time
+	Timeout  time.Duration
 
 	MaxCount uint32
 	MaxBytes uint32
@@ -180,6 +183,7 @@ type RangeScanContinueOptions struct {
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // RangeScanItem encapsulates an iterm returned during a range scan.
@@ -202,12 +206,68 @@ type RangeScanContinueResult struct {
 // RangeScanCancelOptions encapsulates the parameters for a RangeScanCancel operation.
 type RangeScanCancelOptions struct {
 	Deadline time.Time
+	Timeout  time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // RangeScanCancelResult encapsulates the result of a RangeScanCancel operation.
 type RangeScanCancelResult struct{}
+
+// RangeScanOptions encapsulates the parameters for a RangeScan operation.
+// Volatile: This API is subject to change at any time.
+type RangeScanOptions struct {
+	// Prefix is the key prefix to scan for, every key beginning with it is returned.
+	Prefix []byte
+
+	// Deadline applies to the scan as a whole, including the time taken to read the rows from the reader.
+	Deadline time.Time
+	Timeout  time.Duration
+
+	CollectionName string
+	ScopeName      string
+	CollectionID   uint32
+
+	KeysOnly bool
+
+	// Concurrency is the number of vbuckets which are scanned at the same time, defaults to 1.
+	Concurrency int
+
+	// BatchItemLimit and BatchByteLimit are sent with each continue request to bound the size of a batch, which is
+	// held in memory until it has been read from the reader. They default to 50 items and 15000 bytes respectively.
+	BatchItemLimit uint32
+	BatchByteLimit uint32
+
+	// ResumeFrom resumes a previous scan, it should be the value returned by RangeScanRowReader.ResumeFrom.
+	// When not nil only the vbuckets within it are scanned, starting after the key for each.
+	ResumeFrom map[uint16][]byte
+
+	// Internal: This should never be used and is not supported.
+	User string
+
+	TraceContext RequestSpanContext
+	NoRootSpan   bool
+}
+
+// rangeCreateConfig returns the range to scan within a vbucket, starting after resumeKey if it is not empty.
+func (opts RangeScanOptions) rangeCreateConfig(resumeKey []byte) *RangeScanCreateRangeScanConfig {
+	// Keys are valid UTF-8 and so can never contain 0xff, making this the highest key with the prefix.
+	end := make([]byte, len(opts.Prefix)+1)
+	copy(end, opts.Prefix)
+	end[len(opts.Prefix)] = 0xff
+
+	cfg := &RangeScanCreateRangeScanConfig{
+		End: end,
+	}
+	if len(resumeKey) > 0 {
+		cfg.ExclusiveStart = resumeKey
+	} else {
+		cfg.Start = opts.Prefix
+	}
+
+	return cfg
+}
@@ -1,5 +1,7 @@
 package gocbcore
 
+import "time"
+
 // ResourceUnitResult describes the number of compute units used by an operation.
 // Internal: This should never be used and is not supported.
 type ResourceUnitResult struct {
@@ -14,9 +16,14 @@ type GetResult struct {
 	Datatype uint8
 	Cas      Cas
 
+	// Expiry is the absolute time at which the document will expire, it is only populated when WithExpiry is
+	// specified. The zero time indicates that the document has no expiry.
+	Expiry time.Time
+
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -29,7 +36,8 @@ type GetAndTouchResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -42,7 +50,8 @@ type GetAndLockResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -55,7 +64,8 @@ type GetReplicaResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -66,7 +76,8 @@ type TouchResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -77,7 +88,8 @@ type UnlockResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -88,7 +100,8 @@ type DeleteResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -99,7 +112,8 @@ type StoreResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -110,7 +124,8 @@ type AdjoinResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -122,7 +137,8 @@ type CounterResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -136,7 +152,8 @@ type GetRandomResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -152,7 +169,8 @@ type GetMetaResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -163,7 +181,8 @@ type SetMetaResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -174,6 +193,20 @@ type DeleteMetaResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
+
+// BulkGetEntry encapsulates the result of a single get performed as part of a BulkGet operation.
+type BulkGetEntry struct {
+	Key    []byte
+	Result *GetResult
+	Err    error
+}
+
+// BulkGetResult encapsulates the result of a BulkGet operation.
+type BulkGetResult struct {
+	// Entries contains the result of every key, in the same order as the keys in the BulkGetOptions.
+	Entries []BulkGetEntry
+}
@@ -1,6 +1,8 @@
 package gocbcore
 
 import (
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/couchbase/gocbcore/v10/memd"
@@ -16,6 +18,9 @@ type LookupInOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Timeout        time.Duration
+	NoRetry        bool
+	Priority       OperationPriority
 	ReplicaIdx     int
 
 	// Uncommitted: This API may change in the future.
@@ -25,6 +30,7 @@ type LookupInOptions struct {
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // MutateInOptions encapsulates the parameters for a MutateInEx operation.
@@ -41,18 +47,32 @@ type MutateInOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Timeout                time.Duration
+	NoRetry                bool
+	Priority               OperationPriority
 	PreserveExpiry         bool
 
+	// ExpiryDuration is an alternative to Expiry, see SetOptions.ExpiryDuration.
+	// Volatile: This API is subject to change at any time.
+	ExpiryDuration time.Duration
+
+	// Idempotent marks the operation as safe to retry, see SetOptions.Idempotent.
+	// Volatile: This API is subject to change at any time.
+	Idempotent bool
+
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // SubDocResult encapsulates the results from a single sub-document operation.
 type SubDocResult struct {
 	Err   error
 	Value []byte
+	// Status is the status returned by the server for this operation.
+	Status memd.StatusCode
 }
 
 // LookupInResult encapsulates the result of a LookupInEx operation.
@@ -62,8 +82,9 @@ type LookupInResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		IsDeleted     bool
-		ResourceUnits *ResourceUnitResult
+		IsDeleted      bool
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
 	}
 }
 
@@ -75,6 +96,92 @@ type MutateInResult struct {
 
 	// Internal: This should never be used and is not supported.
 	Internal struct {
-		ResourceUnits *ResourceUnitResult
+		ResourceUnits  *ResourceUnitResult
+		ServerDuration time.Duration
+	}
+}
+
+// DocumentMetaDataPath is the path of the virtual xattr containing the metadata of a document, such as its expiry.
+// It can be fetched as part of a LookupIn using a SubDocOpGet with the memd.SubdocFlagXattrPath flag.
+const DocumentMetaDataPath = "$document"
+
+// DocumentMetaData is the decoded content of the $document virtual xattr.
+// Volatile: This API is subject to change at any time.
+type DocumentMetaData struct {
+	Cas          Cas
+	SeqNo        SeqNo
+	VbUUID       VbUUID
+	RevID        string
+	Expiry       uint32
+	Flags        uint32
+	ValueBytes   uint64
+	Deleted      bool
+	Datatype     []string
+	LastModified time.Time
+}
+
+// ParseDocumentMetaData decodes the value returned by a lookup of the $document virtual xattr.
+// Volatile: This API is subject to change at any time.
+func ParseDocumentMetaData(value []byte) (*DocumentMetaData, error) {
+	var raw struct {
+		Cas          string   `json:"CAS"`
+		SeqNo        string   `json:"seqno"`
+		VbUUID       string   `json:"vbucket_uuid"`
+		RevID        string   `json:"revid"`
+		Expiry       uint32   `json:"exptime"`
+		Flags        uint32   `json:"flags"`
+		ValueBytes   uint64   `json:"value_bytes"`
+		Deleted      bool     `json:"deleted"`
+		Datatype     []string `json:"datatype"`
+		LastModified string   `json:"last_modified"`
+	}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return nil, wrapError(err, "failed to parse document metadata")
+	}
+
+	parseHex := func(name, val string) (uint64, error) {
+		if val == "" {
+			return 0, nil
+		}
+		parsed, err := strconv.ParseUint(val, 0, 64)
+		if err != nil {
+			return 0, wrapError(err, "failed to parse document metadata "+name)
+		}
+		return parsed, nil
+	}
+
+	cas, err := parseHex("CAS", raw.Cas)
+	if err != nil {
+		return nil, err
+	}
+	seqNo, err := parseHex("seqno", raw.SeqNo)
+	if err != nil {
+		return nil, err
+	}
+	vbUUID, err := parseHex("vbucket_uuid", raw.VbUUID)
+	if err != nil {
+		return nil, err
 	}
+
+	meta := &DocumentMetaData{
+		Cas:        Cas(cas),
+		SeqNo:      SeqNo(seqNo),
+		VbUUID:     VbUUID(vbUUID),
+		RevID:      raw.RevID,
+		Expiry:     raw.Expiry,
+		Flags:      raw.Flags,
+		ValueBytes: raw.ValueBytes,
+		Deleted:    raw.Deleted,
+		Datatype:   raw.Datatype,
+	}
+
+	if raw.LastModified != "" {
+		lastModified, err := strconv.ParseInt(raw.LastModified, 10, 64)
+		if err != nil {
+			return nil, wrapError(err, "failed to parse document metadata last_modified")
+		}
+		meta.LastModified = time.Unix(lastModified, 0)
+	}
+
+	return meta, nil
 }
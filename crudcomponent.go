@@ -2,6 +2,9 @@ package gocbcore
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/couchbase/gocbcore/v10/memd"
@@ -20,11 +23,15 @@ type crudComponent struct {
 	clientProvider         clientProvider
 	disableDecompression   bool
 	configSnapshotProvider configSnapshotProvider
+	clockSkew              *clockSkewComponent
+	defaultTimeout         time.Duration
+	logger                 *scopedLogger
 }
 
 func newCRUDComponent(cidMgr *collectionsComponent, defaultRetryStrategy RetryStrategy, tracerCmpt *tracerComponent,
 	errMapManager *errMapComponent, featureVerifier bucketCapabilityVerifier, clientProvider clientProvider,
-	disableDecompression bool, configSnapshotProvider configSnapshotProvider) *crudComponent {
+	disableDecompression bool, configSnapshotProvider configSnapshotProvider, clockSkew *clockSkewComponent,
+	defaultTimeout time.Duration, logger *scopedLogger) *crudComponent {
 	return &crudComponent{
 		cidMgr:                 cidMgr,
 		defaultRetryStrategy:   defaultRetryStrategy,
@@ -34,21 +41,40 @@ func newCRUDComponent(cidMgr *collectionsComponent, defaultRetryStrategy RetrySt
 		disableDecompression:   disableDecompression,
 		clientProvider:         clientProvider,
 		configSnapshotProvider: configSnapshotProvider,
+		clockSkew:              clockSkew,
+		defaultTimeout:         defaultTimeout,
+		logger:                 logger,
 	}
 }
 
+// deadline returns the deadline for an operation, which is derived from the operation timeout if no deadline was
+// specified or from the default timeout if neither was.
+func (crud *crudComponent) deadline(deadline time.Time, timeout time.Duration) time.Time {
+	return deadlineFromTimeouts(deadline, timeout, crud.defaultTimeout)
+}
+
 func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	if opts.WithExpiry {
+		return crud.getWithExpiry(opts, cb)
+	}
+
+	if opts.PinnedReplicaIdx > 0 {
+		return crud.getPinnedReplica(opts, cb)
+	}
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Extras) != 4 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -59,6 +85,7 @@ func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, erro
 		res.Cas = Cas(resp.Cas)
 		res.Datatype = resp.Datatype
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(&res, nil)
@@ -87,16 +114,19 @@ func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, erro
 			CollectionID:           opts.CollectionID,
 			UserImpersonationFrame: userFrame,
 		},
-		Callback:         handler,
-		RootTraceContext: tracer.RootContext(),
-		CollectionName:   opts.CollectionName,
-		ScopeName:        opts.ScopeName,
-		RetryStrategy:    opts.RetryStrategy,
+		Callback:             handler,
+		RootTraceContext:     tracer.RootContext(),
+		CollectionName:       opts.CollectionName,
+		ScopeName:            opts.ScopeName,
+		RetryStrategy:        opts.RetryStrategy,
+		NoRetry:              opts.NoRetry,
+		Priority:             opts.Priority,
+		DisableDecompression: opts.DisableDecompression,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -113,18 +143,144 @@ func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, erro
 	return op, nil
 }
 
+// getPinnedReplica performs a Get against the replica specified by the options rather than the active.
+func (crud *crudComponent) getPinnedReplica(opts GetOptions, cb GetCallback) (PendingOp, error) {
+	return crud.GetOneReplica(GetOneReplicaOptions{
+		Key:                  opts.Key,
+		CollectionName:       opts.CollectionName,
+		ScopeName:            opts.ScopeName,
+		CollectionID:         opts.CollectionID,
+		RetryStrategy:        opts.RetryStrategy,
+		ReplicaIdx:           opts.PinnedReplicaIdx,
+		Deadline:             opts.Deadline,
+		NoRetry:              opts.NoRetry,
+		Priority:             opts.Priority,
+		User:                 opts.User,
+		DisableDecompression: opts.DisableDecompression,
+		TraceContext:         opts.TraceContext,
+		NoRootSpan:           opts.NoRootSpan,
+	}, func(replicaRes *GetReplicaResult, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		res := GetResult{
+			Value:    replicaRes.Value,
+			Flags:    replicaRes.Flags,
+			Datatype: replicaRes.Datatype,
+			Cas:      replicaRes.Cas,
+		}
+		res.Internal.ResourceUnits = replicaRes.Internal.ResourceUnits
+		res.Internal.ServerDuration = replicaRes.Internal.ServerDuration
+
+		cb(&res, nil)
+	})
+}
+
+// getWithExpiryMaxAttempts is the number of times that getWithExpiry will refetch a document which is modified between
+// its Get and GetMeta before giving up.
+const getWithExpiryMaxAttempts = 3
+
+// getWithExpiry performs a Get followed by a GetMeta to fetch the document expiry, the caller only sees the
+// single combined result. If the document is modified between the two requests then both are sent again, so that the
+// expiry always belongs to the returned value.
+func (crud *crudComponent) getWithExpiry(opts GetOptions, cb GetCallback) (PendingOp, error) {
+	if opts.PinnedReplicaIdx > 0 {
+		// GetMeta can only be served by the active so the expiry could not be read from the pinned replica.
+		return nil, wrapError(errInvalidArgument, "WithExpiry cannot be used with PinnedReplicaIdx")
+	}
+
+	opts.WithExpiry = false
+	op := &multiPendingOp{}
+
+	err := crud.getWithExpiryAttempt(op, opts, 1, cb)
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+func (crud *crudComponent) getWithExpiryAttempt(op *multiPendingOp, opts GetOptions, attempt int, cb GetCallback) error {
+	subOp, err := crud.Get(opts, func(getRes *GetResult, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		metaOp, err := crud.getMeta(GetMetaOptions{
+			Key:            opts.Key,
+			CollectionName: opts.CollectionName,
+			ScopeName:      opts.ScopeName,
+			CollectionID:   opts.CollectionID,
+			RetryStrategy:  opts.RetryStrategy,
+			Deadline:       opts.Deadline,
+			NoRetry:        opts.NoRetry,
+			Priority:       opts.Priority,
+			User:           opts.User,
+			TraceContext:   opts.TraceContext,
+			NoRootSpan:     opts.NoRootSpan,
+		}, func(metaRes *GetMetaResult, err error) {
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			if metaRes.Cas != getRes.Cas {
+				if attempt >= getWithExpiryMaxAttempts {
+					cb(nil, wrapError(errCasMismatch, "document was modified whilst fetching its expiry"))
+					return
+				}
+
+				err := crud.getWithExpiryAttempt(op, opts, attempt+1, cb)
+				if err != nil {
+					cb(nil, err)
+				}
+				return
+			}
+
+			if metaRes.Expiry > 0 {
+				getRes.Expiry = time.Unix(int64(metaRes.Expiry), 0)
+			}
+
+			cb(getRes, nil)
+		})
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		op.AddOp(metaOp)
+	})
+	if err != nil {
+		return err
+	}
+
+	op.AddOp(subOp)
+
+	return nil
+}
+
 func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetAndTouch", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	expiry, err := resolveExpiry(opts.Expiry, opts.ExpiryDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetAndTouch", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Extras) != 4 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -138,6 +294,7 @@ func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCa
 			Datatype: resp.Datatype,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -154,8 +311,9 @@ func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCa
 		opts.RetryStrategy = crud.defaultRetryStrategy
 	}
 
+	crud.clockSkew.CheckExpiry(expiry)
 	extraBuf := make([]byte, 4)
-	binary.BigEndian.PutUint32(extraBuf[0:], opts.Expiry)
+	binary.BigEndian.PutUint32(extraBuf[0:], expiry)
 
 	req := &memdQRequest{
 		Packet: memd.Packet{
@@ -169,16 +327,19 @@ func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCa
 			CollectionID:           opts.CollectionID,
 			UserImpersonationFrame: userFrame,
 		},
-		Callback:         handler,
-		RootTraceContext: tracer.RootContext(),
-		CollectionName:   opts.CollectionName,
-		ScopeName:        opts.ScopeName,
-		RetryStrategy:    opts.RetryStrategy,
+		Callback:             handler,
+		RootTraceContext:     tracer.RootContext(),
+		CollectionName:       opts.CollectionName,
+		ScopeName:            opts.ScopeName,
+		RetryStrategy:        opts.RetryStrategy,
+		NoRetry:              opts.NoRetry,
+		Priority:             opts.Priority,
+		DisableDecompression: opts.DisableDecompression,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -196,17 +357,29 @@ func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCa
 }
 
 func (crud *crudComponent) GetAndLock(opts GetAndLockOptions, cb GetAndLockCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetAndLock", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	if opts.LockTime > MaxLockTime {
+		if !opts.ClampLockTime {
+			return nil, wrapError(errInvalidArgument,
+				fmt.Sprintf("lock time of %ds is greater than the maximum of %ds", opts.LockTime, MaxLockTime))
+		}
+
+		crud.logger.debugf("Clamping lock time of %ds to the maximum of %ds", opts.LockTime, MaxLockTime)
+		opts.LockTime = MaxLockTime
+	}
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetAndLock", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Extras) != 4 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -219,6 +392,7 @@ func (crud *crudComponent) GetAndLock(opts GetAndLockOptions, cb GetAndLockCallb
 			Datatype: resp.Datatype,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -250,16 +424,19 @@ func (crud *crudComponent) GetAndLock(opts GetAndLockOptions, cb GetAndLockCallb
 			CollectionID:           opts.CollectionID,
 			UserImpersonationFrame: userFrame,
 		},
-		Callback:         handler,
-		RootTraceContext: tracer.RootContext(),
-		CollectionName:   opts.CollectionName,
-		ScopeName:        opts.ScopeName,
-		RetryStrategy:    opts.RetryStrategy,
+		Callback:             handler,
+		RootTraceContext:     tracer.RootContext(),
+		CollectionName:       opts.CollectionName,
+		ScopeName:            opts.ScopeName,
+		RetryStrategy:        opts.RetryStrategy,
+		NoRetry:              opts.NoRetry,
+		Priority:             opts.Priority,
+		DisableDecompression: opts.DisableDecompression,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -277,22 +454,24 @@ func (crud *crudComponent) GetAndLock(opts GetAndLockOptions, cb GetAndLockCallb
 }
 
 func (crud *crudComponent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplicaCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetOneReplica", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetOneReplica", opts.TraceContext, opts.NoRootSpan)
 
 	if opts.ReplicaIdx <= 0 {
-		tracer.Finish()
+		tracer.FinishWithError(errInvalidReplica)
 		return nil, errInvalidReplica
 	}
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Extras) != 4 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -305,6 +484,7 @@ func (crud *crudComponent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplic
 			Datatype: resp.Datatype,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -333,18 +513,21 @@ func (crud *crudComponent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplic
 			CollectionID:           opts.CollectionID,
 			UserImpersonationFrame: userFrame,
 		},
-		Callback:         handler,
-		RootTraceContext: tracer.RootContext(),
-		ReplicaIdx:       opts.ReplicaIdx,
-		CollectionName:   opts.CollectionName,
-		ScopeName:        opts.ScopeName,
-		RetryStrategy:    opts.RetryStrategy,
-		ServerGroup:      opts.ServerGroup,
+		Callback:             handler,
+		RootTraceContext:     tracer.RootContext(),
+		ReplicaIdx:           opts.ReplicaIdx,
+		CollectionName:       opts.CollectionName,
+		ScopeName:            opts.ScopeName,
+		RetryStrategy:        opts.RetryStrategy,
+		NoRetry:              opts.NoRetry,
+		Priority:             opts.Priority,
+		DisableDecompression: opts.DisableDecompression,
+		ServerGroup:          opts.ServerGroup,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -361,12 +544,185 @@ func (crud *crudComponent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplic
 	return op, nil
 }
 
+func (crud *crudComponent) GetAllReplicas(opts GetAllReplicasOptions, cb GetAllReplicasCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	parentOp := &multiPendingOp{
+		isIdempotent: true,
+	}
+	snapshotOp, err := crud.configSnapshotProvider.WaitForConfigSnapshot(opts.Deadline, func(result *WaitForConfigSnapshotResult, err error) {
+		if err != nil {
+			cb(-1, nil, err)
+			return
+		}
+
+		numReplicas, err := result.Snapshot.NumReplicas()
+		if err != nil {
+			cb(-1, nil, err)
+			return
+		}
+
+		for replicaIdx := 0; replicaIdx <= numReplicas; replicaIdx++ {
+			curOp, err := crud.getFromReplicaIdx(replicaIdx, opts, cb)
+			if err != nil {
+				cb(replicaIdx, nil, err)
+				continue
+			}
+			parentOp.AddOp(curOp)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	parentOp.AddOp(snapshotOp)
+
+	return parentOp, nil
+}
+
+func (crud *crudComponent) GetAnyReplica(opts GetAnyReplicaOptions, cb GetAnyReplicaCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	parentOp := &multiPendingOp{
+		isIdempotent: true,
+	}
+
+	var lock sync.Mutex
+	var completed bool
+	var numFailed, numReads int
+	var failErr error
+	handler := func(replicaIdx int, res *GetReplicaResult, err error) {
+		lock.Lock()
+		if completed {
+			lock.Unlock()
+			return
+		}
+
+		if err == nil {
+			completed = true
+			lock.Unlock()
+			cb(replicaIdx, res, nil)
+			parentOp.Cancel()
+			return
+		}
+
+		// Timeouts and cancellations say nothing about whether a copy is available so take priority over
+		// the document not being found.
+		if errors.Is(err, ErrTimeout) || errors.Is(err, ErrRequestCanceled) {
+			failErr = err
+		}
+		numFailed++
+		if numFailed < numReads {
+			lock.Unlock()
+			return
+		}
+		completed = true
+		if failErr == nil {
+			failErr = errDocumentNotFound
+		}
+		lock.Unlock()
+
+		cb(-1, nil, failErr)
+	}
+
+	snapshotOp, err := crud.configSnapshotProvider.WaitForConfigSnapshot(opts.Deadline, func(result *WaitForConfigSnapshotResult, err error) {
+		if err != nil {
+			cb(-1, nil, err)
+			return
+		}
+
+		numReplicas, err := result.Snapshot.NumReplicas()
+		if err != nil {
+			cb(-1, nil, err)
+			return
+		}
+
+		lock.Lock()
+		numReads = numReplicas + 1
+		lock.Unlock()
+
+		for replicaIdx := 0; replicaIdx <= numReplicas; replicaIdx++ {
+			curOp, err := crud.getFromReplicaIdx(replicaIdx, GetAllReplicasOptions(opts), handler)
+			if err != nil {
+				handler(replicaIdx, nil, err)
+				continue
+			}
+			parentOp.AddOp(curOp)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	parentOp.AddOp(snapshotOp)
+
+	return parentOp, nil
+}
+
+// getFromReplicaIdx performs a single read for GetAllReplicas or GetAnyReplica, against the active if replicaIdx is 0.
+func (crud *crudComponent) getFromReplicaIdx(replicaIdx int, opts GetAllReplicasOptions,
+	cb GetAllReplicasCallback) (PendingOp, error) {
+	if replicaIdx == 0 {
+		return crud.Get(GetOptions{
+			Key:                  opts.Key,
+			CollectionName:       opts.CollectionName,
+			ScopeName:            opts.ScopeName,
+			CollectionID:         opts.CollectionID,
+			RetryStrategy:        opts.RetryStrategy,
+			Deadline:             opts.Deadline,
+			Priority:             opts.Priority,
+			DisableDecompression: opts.DisableDecompression,
+			User:                 opts.User,
+			TraceContext:         opts.TraceContext,
+			NoRootSpan:           opts.NoRootSpan,
+		}, func(getRes *GetResult, err error) {
+			if err != nil {
+				cb(0, nil, err)
+				return
+			}
+
+			res := GetReplicaResult{
+				Value:    getRes.Value,
+				Flags:    getRes.Flags,
+				Datatype: getRes.Datatype,
+				Cas:      getRes.Cas,
+			}
+			res.Internal.ResourceUnits = getRes.Internal.ResourceUnits
+			res.Internal.ServerDuration = getRes.Internal.ServerDuration
+
+			cb(0, &res, nil)
+		})
+	}
+
+	return crud.GetOneReplica(GetOneReplicaOptions{
+		Key:                  opts.Key,
+		CollectionName:       opts.CollectionName,
+		ScopeName:            opts.ScopeName,
+		CollectionID:         opts.CollectionID,
+		RetryStrategy:        opts.RetryStrategy,
+		ReplicaIdx:           replicaIdx,
+		Deadline:             opts.Deadline,
+		Priority:             opts.Priority,
+		DisableDecompression: opts.DisableDecompression,
+		User:                 opts.User,
+		TraceContext:         opts.TraceContext,
+		NoRootSpan:           opts.NoRootSpan,
+	}, func(res *GetReplicaResult, err error) {
+		cb(replicaIdx, res, err)
+	})
+}
+
 func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Touch", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	expiry, err := resolveExpiry(opts.Expiry, opts.ExpiryDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Touch", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -383,6 +739,7 @@ func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -395,8 +752,9 @@ func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp
 		}
 	}
 
+	crud.clockSkew.CheckExpiry(expiry)
 	extraBuf := make([]byte, 4)
-	binary.BigEndian.PutUint32(extraBuf[0:], opts.Expiry)
+	binary.BigEndian.PutUint32(extraBuf[0:], expiry)
 
 	if opts.RetryStrategy == nil {
 		opts.RetryStrategy = crud.defaultRetryStrategy
@@ -419,11 +777,13 @@ func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -441,11 +801,17 @@ func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp
 }
 
 func (crud *crudComponent) Unlock(opts UnlockOptions, cb UnlockCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Unlock", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	if opts.Cas == 0 {
+		return nil, wrapError(errInvalidArgument, "unlock requires the cas returned by GetAndLock")
+	}
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Unlock", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -462,6 +828,7 @@ func (crud *crudComponent) Unlock(opts UnlockOptions, cb UnlockCallback) (Pendin
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -495,11 +862,13 @@ func (crud *crudComponent) Unlock(opts UnlockOptions, cb UnlockCallback) (Pendin
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -517,11 +886,13 @@ func (crud *crudComponent) Unlock(opts UnlockOptions, cb UnlockCallback) (Pendin
 }
 
 func (crud *crudComponent) Delete(opts DeleteOptions, cb DeleteCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Delete", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Delete", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -538,23 +909,15 @@ func (crud *crudComponent) Delete(opts DeleteOptions, cb DeleteCallback) (Pendin
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
 	}
 
-	var duraLevelFrame *memd.DurabilityLevelFrame
-	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
-	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, CapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
-		}
-		duraLevelFrame = &memd.DurabilityLevelFrame{
-			DurabilityLevel: opts.DurabilityLevel,
-		}
-		duraTimeoutFrame = &memd.DurabilityTimeoutFrame{
-			DurabilityTimeout: opts.DurabilityLevelTimeout,
-		}
+	duraLevelFrame, duraTimeoutFrame, err := crud.durabilityFrames(opts.DurabilityLevel, opts.DurabilityLevelTimeout)
+	if err != nil {
+		return nil, err
 	}
 
 	var userFrame *memd.UserImpersonationFrame
@@ -587,11 +950,14 @@ func (crud *crudComponent) Delete(opts DeleteOptions, cb DeleteCallback) (Pendin
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		IsIdempotent:     opts.Idempotent,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -609,11 +975,11 @@ func (crud *crudComponent) Delete(opts DeleteOptions, cb DeleteCallback) (Pendin
 }
 
 func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeOptions, cb StoreCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, opName, opts.TraceContext)
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, opName, opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -630,23 +996,20 @@ func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeO
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
 	}
 
-	var duraLevelFrame *memd.DurabilityLevelFrame
-	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
-	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, CapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
-		}
-		duraLevelFrame = &memd.DurabilityLevelFrame{
-			DurabilityLevel: opts.DurabilityLevel,
-		}
-		duraTimeoutFrame = &memd.DurabilityTimeoutFrame{
-			DurabilityTimeout: opts.DurabilityLevelTimeout,
-		}
+	expiry, err := resolveExpiry(opts.Expiry, opts.ExpiryDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	duraLevelFrame, duraTimeoutFrame, err := crud.durabilityFrames(opts.DurabilityLevel, opts.DurabilityLevelTimeout)
+	if err != nil {
+		return nil, err
 	}
 
 	var userFrame *memd.UserImpersonationFrame
@@ -665,14 +1028,20 @@ func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeO
 		preserveExpiryFrame = &memd.PreserveExpiryFrame{}
 	}
 
+	datatype := opts.Datatype
+	if opts.IsJSON {
+		datatype |= uint8(memd.DatatypeFlagJSON)
+	}
+
+	crud.clockSkew.CheckExpiry(expiry)
 	extraBuf := make([]byte, 8)
 	binary.BigEndian.PutUint32(extraBuf[0:], opts.Flags)
-	binary.BigEndian.PutUint32(extraBuf[4:], opts.Expiry)
+	binary.BigEndian.PutUint32(extraBuf[4:], expiry)
 	req := &memdQRequest{
 		Packet: memd.Packet{
 			Magic:                  memd.CmdMagicReq,
 			Command:                opcode,
-			Datatype:               opts.Datatype,
+			Datatype:               datatype,
 			Cas:                    uint64(opts.Cas),
 			Extras:                 extraBuf,
 			Key:                    opts.Key,
@@ -688,11 +1057,14 @@ func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeO
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		IsIdempotent:     opts.Idempotent,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -710,17 +1082,25 @@ func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeO
 }
 
 func (crud *crudComponent) Set(opts SetOptions, cb StoreCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
 	return crud.store("Set", memd.CmdSet, storeOptions{
 		Key:                    opts.Key,
 		CollectionName:         opts.CollectionName,
 		ScopeName:              opts.ScopeName,
 		RetryStrategy:          opts.RetryStrategy,
+		NoRetry:                opts.NoRetry,
+		Idempotent:             opts.Idempotent,
+		Priority:               opts.Priority,
 		Value:                  opts.Value,
 		Flags:                  opts.Flags,
 		Datatype:               opts.Datatype,
+		IsJSON:                 opts.IsJSON,
 		Cas:                    0,
 		Expiry:                 opts.Expiry,
+		ExpiryDuration:         opts.ExpiryDuration,
 		TraceContext:           opts.TraceContext,
+		NoRootSpan:             opts.NoRootSpan,
 		DurabilityLevel:        opts.DurabilityLevel,
 		DurabilityLevelTimeout: opts.DurabilityLevelTimeout,
 		CollectionID:           opts.CollectionID,
@@ -731,17 +1111,25 @@ func (crud *crudComponent) Set(opts SetOptions, cb StoreCallback) (PendingOp, er
 }
 
 func (crud *crudComponent) Add(opts AddOptions, cb StoreCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
 	return crud.store("Add", memd.CmdAdd, storeOptions{
 		Key:                    opts.Key,
 		CollectionName:         opts.CollectionName,
 		ScopeName:              opts.ScopeName,
 		RetryStrategy:          opts.RetryStrategy,
+		NoRetry:                opts.NoRetry,
+		Idempotent:             opts.Idempotent,
+		Priority:               opts.Priority,
 		Value:                  opts.Value,
 		Flags:                  opts.Flags,
 		Datatype:               opts.Datatype,
+		IsJSON:                 opts.IsJSON,
 		Cas:                    0,
 		Expiry:                 opts.Expiry,
+		ExpiryDuration:         opts.ExpiryDuration,
 		TraceContext:           opts.TraceContext,
+		NoRootSpan:             opts.NoRootSpan,
 		DurabilityLevel:        opts.DurabilityLevel,
 		DurabilityLevelTimeout: opts.DurabilityLevelTimeout,
 		CollectionID:           opts.CollectionID,
@@ -751,18 +1139,22 @@ func (crud *crudComponent) Add(opts AddOptions, cb StoreCallback) (PendingOp, er
 }
 
 func (crud *crudComponent) Replace(opts ReplaceOptions, cb StoreCallback) (PendingOp, error) {
-	if opts.PreserveExpiry && opts.Expiry > 0 {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	if opts.PreserveExpiry && (opts.Expiry > 0 || opts.ExpiryDuration != 0) {
 		return nil, wrapError(errInvalidArgument, "cannot use preserve expiry and an expiry > 0 for replace")
 	}
 	return crud.store("Replace", memd.CmdReplace, storeOptions(opts), cb)
 }
 
 func (crud *crudComponent) adjoin(opName string, opcode memd.CmdCode, opts AdjoinOptions, cb AdjoinCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, opName, opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, opName, opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -778,23 +1170,15 @@ func (crud *crudComponent) adjoin(opName string, opcode memd.CmdCode, opts Adjoi
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
 	}
 
-	var duraLevelFrame *memd.DurabilityLevelFrame
-	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
-	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, CapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
-		}
-		duraLevelFrame = &memd.DurabilityLevelFrame{
-			DurabilityLevel: opts.DurabilityLevel,
-		}
-		duraTimeoutFrame = &memd.DurabilityTimeoutFrame{
-			DurabilityTimeout: opts.DurabilityLevelTimeout,
-		}
+	duraLevelFrame, duraTimeoutFrame, err := crud.durabilityFrames(opts.DurabilityLevel, opts.DurabilityLevelTimeout)
+	if err != nil {
+		return nil, err
 	}
 
 	var userFrame *memd.UserImpersonationFrame
@@ -833,11 +1217,14 @@ func (crud *crudComponent) adjoin(opName string, opcode memd.CmdCode, opts Adjoi
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		IsIdempotent:     opts.Idempotent,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -863,17 +1250,19 @@ func (crud *crudComponent) Prepend(opts AdjoinOptions, cb AdjoinCallback) (Pendi
 }
 
 func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts CounterOptions, cb CounterCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, opName, opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, opName, opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Value) != 8 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -891,28 +1280,25 @@ func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts Coun
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
 	}
 
+	expiry, err := resolveExpiry(opts.Expiry, opts.ExpiryDuration)
+	if err != nil {
+		return nil, err
+	}
+
 	// You cannot have an expiry when you do not want to create the document.
-	if opts.Initial == uint64(0xFFFFFFFFFFFFFFFF) && opts.Expiry != 0 {
+	if opts.Initial == CounterNoInitial && expiry != 0 {
 		return nil, errInvalidArgument
 	}
 
-	var duraLevelFrame *memd.DurabilityLevelFrame
-	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
-	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, CapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
-		}
-		duraLevelFrame = &memd.DurabilityLevelFrame{
-			DurabilityLevel: opts.DurabilityLevel,
-		}
-		duraTimeoutFrame = &memd.DurabilityTimeoutFrame{
-			DurabilityTimeout: opts.DurabilityLevelTimeout,
-		}
+	duraLevelFrame, duraTimeoutFrame, err := crud.durabilityFrames(opts.DurabilityLevel, opts.DurabilityLevelTimeout)
+	if err != nil {
+		return nil, err
 	}
 
 	var userFrame *memd.UserImpersonationFrame
@@ -930,11 +1316,12 @@ func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts Coun
 		opts.RetryStrategy = crud.defaultRetryStrategy
 	}
 
+	crud.clockSkew.CheckExpiry(expiry)
 	extraBuf := make([]byte, 20)
 	binary.BigEndian.PutUint64(extraBuf[0:], opts.Delta)
-	if opts.Initial != uint64(0xFFFFFFFFFFFFFFFF) {
+	if opts.Initial != CounterNoInitial {
 		binary.BigEndian.PutUint64(extraBuf[8:], opts.Initial)
-		binary.BigEndian.PutUint32(extraBuf[16:], opts.Expiry)
+		binary.BigEndian.PutUint32(extraBuf[16:], expiry)
 	} else {
 		binary.BigEndian.PutUint64(extraBuf[8:], 0x0000000000000000)
 		binary.BigEndian.PutUint32(extraBuf[16:], 0xFFFFFFFF)
@@ -960,11 +1347,14 @@ func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts Coun
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		IsIdempotent:     opts.Idempotent,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -990,17 +1380,19 @@ func (crud *crudComponent) Decrement(opts CounterOptions, cb CounterCallback) (P
 }
 
 func (crud *crudComponent) GetRandom(opts GetRandomOptions, cb GetRandomCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetRandom", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetRandom", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Extras) != 4 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -1014,6 +1406,7 @@ func (crud *crudComponent) GetRandom(opts GetRandomOptions, cb GetRandomCallback
 			Datatype: resp.Datatype,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -1045,13 +1438,15 @@ func (crud *crudComponent) GetRandom(opts GetRandomOptions, cb GetRandomCallback
 		Callback:         handler,
 		RootTraceContext: tracer.RootContext(),
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		Priority:         opts.Priority,
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -1069,17 +1464,30 @@ func (crud *crudComponent) GetRandom(opts GetRandomOptions, cb GetRandomCallback
 }
 
 func (crud *crudComponent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetMeta", opts.TraceContext)
+	if err := crud.checkMetaOpsSupported(); err != nil {
+		return nil, err
+	}
+
+	return crud.getMeta(opts, cb)
+}
+
+// getMeta sends a GetMeta request without checking whether the bucket supports meta operations. Unlike SetMeta and
+// DeleteMeta, reading document metadata is supported by all buckets, so internal callers such as Get with
+// WithExpiry use this directly.
+func (crud *crudComponent) getMeta(opts GetMetaOptions, cb GetMetaCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetMeta", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Extras) != 21 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -1094,6 +1502,7 @@ func (crud *crudComponent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (Pen
 		res.SeqNo = SeqNo(binary.BigEndian.Uint64(resp.Extras[12:]))
 		res.Datatype = resp.Extras[20]
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -1125,16 +1534,19 @@ func (crud *crudComponent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (Pen
 			CollectionID:           opts.CollectionID,
 			UserImpersonationFrame: userFrame,
 		},
-		Callback:         handler,
-		RootTraceContext: tracer.RootContext(),
-		CollectionName:   opts.CollectionName,
-		ScopeName:        opts.ScopeName,
-		RetryStrategy:    opts.RetryStrategy,
+		Callback:             handler,
+		RootTraceContext:     tracer.RootContext(),
+		CollectionName:       opts.CollectionName,
+		ScopeName:            opts.ScopeName,
+		RetryStrategy:        opts.RetryStrategy,
+		NoRetry:              opts.NoRetry,
+		Priority:             opts.Priority,
+		DisableDecompression: opts.DisableDecompression,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -1152,11 +1564,17 @@ func (crud *crudComponent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (Pen
 }
 
 func (crud *crudComponent) SetMeta(opts SetMetaOptions, cb SetMetaCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "SetMeta", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	if err := crud.checkMetaOpsSupported(); err != nil {
+		return nil, err
+	}
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "SetMeta", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -1172,6 +1590,7 @@ func (crud *crudComponent) SetMeta(opts SetMetaOptions, cb SetMetaCallback) (Pen
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -1214,11 +1633,13 @@ func (crud *crudComponent) SetMeta(opts SetMetaOptions, cb SetMetaCallback) (Pen
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -1236,11 +1657,17 @@ func (crud *crudComponent) SetMeta(opts SetMetaOptions, cb SetMetaCallback) (Pen
 }
 
 func (crud *crudComponent) DeleteMeta(opts DeleteMetaOptions, cb DeleteMetaCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "DeleteMeta", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	if err := crud.checkMetaOpsSupported(); err != nil {
+		return nil, err
+	}
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "DeleteMeta", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -1256,6 +1683,7 @@ func (crud *crudComponent) DeleteMeta(opts DeleteMetaOptions, cb DeleteMetaCallb
 			MutationToken: mutToken,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -1298,11 +1726,13 @@ func (crud *crudComponent) DeleteMeta(opts DeleteMetaOptions, cb DeleteMetaCallb
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -1318,3 +1748,40 @@ func (crud *crudComponent) DeleteMeta(opts DeleteMetaOptions, cb DeleteMetaCallb
 
 	return op, nil
 }
+
+// checkMetaOpsSupported fails meta operations immediately if the bucket is known not to support them, such as a
+// memcached bucket, rather than waiting for the server to reject them.
+func (crud *crudComponent) checkMetaOpsSupported() error {
+	if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityXdcr, CapabilityStatusUnsupported) {
+		return wrapError(errFeatureNotAvailable, "meta operations are not supported by this bucket")
+	}
+
+	return nil
+}
+
+// durabilityFrames creates the frames used to request synchronous durability for a write. If the bucket is known not
+// to support durable writes then the request fails immediately, rather than waiting for the server to reject it.
+func (crud *crudComponent) durabilityFrames(level memd.DurabilityLevel,
+	timeout time.Duration) (*memd.DurabilityLevelFrame, *memd.DurabilityTimeoutFrame, error) {
+	if level == 0 {
+		return nil, nil, nil
+	}
+	if level > memd.DurabilityLevelPersistToMajority {
+		return nil, nil, wrapError(errInvalidArgument, fmt.Sprintf("unknown durability level %d", level))
+	}
+	if timeout < 0 {
+		return nil, nil, wrapError(errInvalidArgument, "durability level timeout cannot be negative")
+	}
+	if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, CapabilityStatusUnsupported) {
+		return nil, nil, wrapError(errFeatureNotAvailable, "durable writes are not supported by this bucket")
+	}
+
+	levelFrame := &memd.DurabilityLevelFrame{
+		DurabilityLevel: level,
+	}
+	timeoutFrame := &memd.DurabilityTimeoutFrame{
+		DurabilityTimeout: timeout,
+	}
+
+	return levelFrame, timeoutFrame, nil
+}
@@ -0,0 +1,160 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+)
+
+type bulkGetOp struct {
+	crud     *crudComponent
+	opts     BulkGetOptions
+	start    time.Time
+	callback BulkGetCallback
+
+	lock      sync.Mutex
+	entries   []BulkGetEntry
+	completed []bool
+	next      int
+	remaining int
+	ops       map[int]PendingOp
+	stopErr   error
+}
+
+func (crud *crudComponent) BulkGet(opts BulkGetOptions, cb BulkGetCallback) (PendingOp, error) {
+	if len(opts.Keys) == 0 {
+		return nil, wrapError(errInvalidArgument, "at least one key must be specified")
+	}
+	if opts.Concurrency < 0 {
+		return nil, wrapError(errInvalidArgument, "concurrency cannot be negative")
+	}
+
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	concurrency := opts.Concurrency
+	if concurrency == 0 || concurrency > len(opts.Keys) {
+		concurrency = len(opts.Keys)
+	}
+
+	op := &bulkGetOp{
+		crud:      crud,
+		opts:      opts,
+		start:     time.Now(),
+		callback:  cb,
+		entries:   make([]BulkGetEntry, len(opts.Keys)),
+		completed: make([]bool, len(opts.Keys)),
+		remaining: len(opts.Keys),
+		ops:       make(map[int]PendingOp),
+	}
+	for i, key := range opts.Keys {
+		op.entries[i].Key = key
+	}
+
+	for i := 0; i < concurrency; i++ {
+		op.issueNext()
+	}
+
+	return op, nil
+}
+
+func (op *bulkGetOp) Cancel() {
+	op.lock.Lock()
+	if op.stopErr != nil {
+		op.lock.Unlock()
+		return
+	}
+	op.stopErr = errRequestCanceled
+	ops := make([]PendingOp, 0, len(op.ops))
+	for _, subOp := range op.ops {
+		ops = append(ops, subOp)
+	}
+	op.lock.Unlock()
+
+	for _, subOp := range ops {
+		subOp.Cancel()
+	}
+
+	// Any keys which have not yet been issued will be failed by issueNext as the in flight gets complete, but if
+	// nothing was in flight then we need to do that ourselves.
+	if len(ops) == 0 {
+		op.issueNext()
+	}
+}
+
+func (op *bulkGetOp) issueNext() {
+	op.lock.Lock()
+	if op.next >= len(op.entries) {
+		op.lock.Unlock()
+		return
+	}
+	idx := op.next
+	op.next++
+	stopErr := op.stopErr
+	op.lock.Unlock()
+
+	if stopErr != nil {
+		op.complete(idx, nil, stopErr)
+		return
+	}
+
+	if !op.opts.Deadline.IsZero() && !time.Now().Before(op.opts.Deadline) {
+		op.complete(idx, nil, &TimeoutError{
+			InnerError:   errUnambiguousTimeout,
+			OperationID:  "BulkGet",
+			TimeObserved: time.Since(op.start),
+		})
+		return
+	}
+
+	subOp, err := op.crud.Get(GetOptions{
+		Key:                  op.opts.Keys[idx],
+		CollectionName:       op.opts.CollectionName,
+		ScopeName:            op.opts.ScopeName,
+		CollectionID:         op.opts.CollectionID,
+		RetryStrategy:        op.opts.RetryStrategy,
+		Deadline:             op.opts.Deadline,
+		Priority:             op.opts.Priority,
+		DisableDecompression: op.opts.DisableDecompression,
+		User:                 op.opts.User,
+		TraceContext:         op.opts.TraceContext,
+		NoRootSpan:           op.opts.NoRootSpan,
+	}, func(res *GetResult, err error) {
+		op.complete(idx, res, err)
+	})
+	if err != nil {
+		op.complete(idx, nil, err)
+		return
+	}
+
+	op.lock.Lock()
+	if op.completed[idx] {
+		op.lock.Unlock()
+		return
+	}
+	op.ops[idx] = subOp
+	cancelled := op.stopErr != nil
+	op.lock.Unlock()
+
+	if cancelled {
+		subOp.Cancel()
+	}
+}
+
+func (op *bulkGetOp) complete(idx int, res *GetResult, err error) {
+	op.lock.Lock()
+	op.entries[idx].Result = res
+	op.entries[idx].Err = err
+	op.completed[idx] = true
+	delete(op.ops, idx)
+	op.remaining--
+	done := op.remaining == 0
+	op.lock.Unlock()
+
+	if done {
+		op.callback(&BulkGetResult{
+			Entries: op.entries,
+		})
+		return
+	}
+
+	op.issueNext()
+}
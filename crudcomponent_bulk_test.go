@@ -0,0 +1,137 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+func (suite *UnitTestSuite) TestBulkGet() {
+	var lock sync.Mutex
+	var pending []*memdQRequest
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		lock.Lock()
+		pending = append(pending, req)
+		lock.Unlock()
+	})
+
+	respondNext := func() {
+		lock.Lock()
+		req := pending[0]
+		pending = pending[1:]
+		lock.Unlock()
+
+		if string(req.Key) == "missing" {
+			req.Callback(nil, req, errDocumentNotFound)
+			return
+		}
+
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Extras: make([]byte, 4),
+				Value:  append([]byte("value-"), req.Key...),
+			},
+		}, req, nil)
+	}
+
+	resCh := make(chan *BulkGetResult, 1)
+	_, err := crud.BulkGet(BulkGetOptions{
+		Keys:        [][]byte{[]byte("a"), []byte("missing"), []byte("b"), []byte("c")},
+		Concurrency: 2,
+	}, func(res *BulkGetResult) {
+		resCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	for i := 0; i < 4; i++ {
+		lock.Lock()
+		suite.Assert().LessOrEqual(len(pending), 2)
+		lock.Unlock()
+		respondNext()
+	}
+
+	res := <-resCh
+	suite.Require().Len(res.Entries, 4)
+	for i, key := range []string{"a", "missing", "b", "c"} {
+		entry := res.Entries[i]
+		suite.Assert().Equal(key, string(entry.Key))
+		if key == "missing" {
+			suite.Assert().True(errors.Is(entry.Err, ErrDocumentNotFound), entry.Err)
+			continue
+		}
+		suite.Require().Nil(entry.Err, entry.Err)
+		suite.Assert().Equal("value-"+key, string(entry.Result.Value))
+	}
+}
+
+func (suite *UnitTestSuite) TestBulkGetCancelReturnsPartialResults() {
+	var lock sync.Mutex
+	var pending []*memdQRequest
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		lock.Lock()
+		pending = append(pending, req)
+		lock.Unlock()
+	})
+
+	resCh := make(chan *BulkGetResult, 1)
+	op, err := crud.BulkGet(BulkGetOptions{
+		Keys:        [][]byte{[]byte("a"), []byte("b"), []byte("c")},
+		Concurrency: 1,
+	}, func(res *BulkGetResult) {
+		resCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	lock.Lock()
+	req := pending[0]
+	lock.Unlock()
+	req.Callback(&memdQResponse{
+		Packet: &memd.Packet{
+			Status: memd.StatusSuccess,
+			Extras: make([]byte, 4),
+			Value:  []byte("value-a"),
+		},
+	}, req, nil)
+
+	// The second get is now in flight, cancelling should fail it and the third without it being sent.
+	lock.Lock()
+	inFlight := pending[1]
+	lock.Unlock()
+	op.Cancel()
+	inFlight.cancelWithCallback(errRequestCanceled)
+
+	res := <-resCh
+	suite.Require().Len(res.Entries, 3)
+	suite.Require().Nil(res.Entries[0].Err, res.Entries[0].Err)
+	suite.Assert().Equal("value-a", string(res.Entries[0].Result.Value))
+	suite.Assert().True(errors.Is(res.Entries[1].Err, ErrRequestCanceled), res.Entries[1].Err)
+	suite.Assert().True(errors.Is(res.Entries[2].Err, ErrRequestCanceled), res.Entries[2].Err)
+
+	lock.Lock()
+	suite.Assert().Len(pending, 2)
+	lock.Unlock()
+}
+
+func (suite *UnitTestSuite) TestBulkGetDeadlinePassed() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		suite.T().Errorf("No requests should be sent once the deadline has passed")
+	})
+
+	resCh := make(chan *BulkGetResult, 1)
+	_, err := crud.BulkGet(BulkGetOptions{
+		Keys:     [][]byte{[]byte("a"), []byte("b")},
+		Deadline: time.Now().Add(-time.Second),
+	}, func(res *BulkGetResult) {
+		resCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	res := <-resCh
+	suite.Require().Len(res.Entries, 2)
+	for _, entry := range res.Entries {
+		suite.Assert().True(errors.Is(entry.Err, ErrTimeout), entry.Err)
+	}
+}
@@ -39,14 +39,16 @@ type rangeScanCreateSnapshot struct {
 }
 
 func (crud *crudComponent) RangeScanCreate(vbID uint16, opts RangeScanCreateOptions, cb RangeScanCreateCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
 	if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityRangeScan, CapabilityStatusUnsupported) {
 		return nil, errFeatureNotAvailable
 	}
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "RangeScanCreate", opts.TraceContext)
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "RangeScanCreate", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -99,7 +101,7 @@ func (crud *crudComponent) RangeScanCreate(vbID uint16, opts RangeScanCreateOpti
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -127,20 +129,22 @@ func (crud *crudComponent) RangeScanCreate(vbID uint16, opts RangeScanCreateOpti
 
 func (createRes *rangeScanCreateResult) RangeScanContinue(opts RangeScanContinueOptions, dataCb RangeScanContinueDataCallback,
 	actionCb RangeScanContinueActionCallback) (PendingOp, error) {
+	opts.Deadline = createRes.parent.deadline(opts.Deadline, opts.Timeout)
+
 	if createRes.parent.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityRangeScan, CapabilityStatusUnsupported) {
 		return nil, errFeatureNotAvailable
 	}
-	tracer := createRes.parent.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "RangeScanContinue", opts.TraceContext)
+	tracer := createRes.parent.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "RangeScanContinue", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			actionCb(nil, err)
 			return
 		}
 
 		if len(resp.Extras) != 4 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			actionCb(nil, errProtocol)
 			return
 		}
@@ -149,7 +153,7 @@ func (createRes *rangeScanCreateResult) RangeScanContinue(opts RangeScanContinue
 
 		items, err := parseRangeScanData(resp.Value, keysOnlyFlag == 0, createRes.parent.disableDecompression)
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			actionCb(nil, err)
 			return
 		}
@@ -227,13 +231,13 @@ func (createRes *rangeScanCreateResult) RangeScanContinue(opts RangeScanContinue
 	createRes.parent.tracer.StartCmdTrace(req)
 	cli, err := createRes.parent.clientProvider.GetByConnID(createRes.connID)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
 	err = cli.SendRequest(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -260,15 +264,17 @@ func (createRes *rangeScanCreateResult) RangeScanContinue(opts RangeScanContinue
 }
 
 func (createRes *rangeScanCreateResult) RangeScanCancel(opts RangeScanCancelOptions, cb RangeScanCancelCallback) (PendingOp, error) {
+	opts.Deadline = createRes.parent.deadline(opts.Deadline, opts.Timeout)
+
 	if createRes.parent.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityRangeScan, CapabilityStatusUnsupported) {
 		return nil, errFeatureNotAvailable
 	}
 
-	tracer := createRes.parent.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "RangeScanCancel", opts.TraceContext)
+	tracer := createRes.parent.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "RangeScanCancel", opts.TraceContext, opts.NoRootSpan)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -310,13 +316,13 @@ func (createRes *rangeScanCreateResult) RangeScanCancel(opts RangeScanCancelOpti
 	createRes.parent.tracer.StartCmdTrace(req)
 	cli, err := createRes.parent.clientProvider.GetByConnID(createRes.connID)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
 	err = cli.SendRequest(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
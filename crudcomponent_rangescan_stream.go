@@ -0,0 +1,382 @@
+package gocbcore
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+type rangeScanCreateFunc func(vbID uint16, opts RangeScanCreateOptions, cb RangeScanCreateCallback) (PendingOp, error)
+
+type rangeScanStreamItem struct {
+	vbID uint16
+	// item is nil once every item for the vbucket has been sent.
+	item *RangeScanItem
+}
+
+const (
+	defaultRangeScanBatchItemLimit = 50
+	defaultRangeScanBatchByteLimit = 15000
+)
+
+// RangeScanRowReader provides access to the items returned by a RangeScan operation.
+// Volatile: This API is subject to change at any time.
+type RangeScanRowReader struct {
+	opts   RangeScanOptions
+	create rangeScanCreateFunc
+	start  time.Time
+	items  chan rangeScanStreamItem
+	done   chan struct{}
+
+	lock       sync.Mutex
+	err        error
+	closed     bool
+	timeoutTmr *time.Timer
+	vbuckets   []uint16
+	nextVbIdx  int
+	resumeFrom map[uint16][]byte
+	logger     *scopedLogger
+}
+
+// RangeScan scans every vbucket for the keys beginning with the prefix in the options, streaming the items found to
+// the returned reader. Items are ordered within a vbucket but not across vbuckets.
+func (crud *crudComponent) RangeScan(opts RangeScanOptions, cb RangeScanCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	if len(opts.Prefix) == 0 {
+		return nil, wrapError(errInvalidArgument, "prefix must be set")
+	}
+	if opts.Concurrency < 0 {
+		return nil, wrapError(errInvalidArgument, "concurrency cannot be negative")
+	}
+	if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityRangeScan, CapabilityStatusUnsupported) {
+		return nil, errFeatureNotAvailable
+	}
+
+	return crud.rangeScan(opts, crud.RangeScanCreate, cb)
+}
+
+func (crud *crudComponent) rangeScan(opts RangeScanOptions, create rangeScanCreateFunc, cb RangeScanCallback) (PendingOp, error) {
+	if opts.Concurrency == 0 {
+		opts.Concurrency = 1
+	}
+	if opts.BatchItemLimit == 0 {
+		opts.BatchItemLimit = defaultRangeScanBatchItemLimit
+	}
+	if opts.BatchByteLimit == 0 {
+		opts.BatchByteLimit = defaultRangeScanBatchByteLimit
+	}
+
+	return crud.configSnapshotProvider.WaitForConfigSnapshot(opts.Deadline, func(result *WaitForConfigSnapshotResult, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		numVbuckets, err := result.Snapshot.NumVbuckets()
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		reader := newRangeScanRowReader(opts, create, numVbuckets, crud.logger)
+		reader.run()
+		cb(reader, nil)
+	})
+}
+
+func newRangeScanRowReader(opts RangeScanOptions, create rangeScanCreateFunc, numVbuckets int,
+	logger *scopedLogger) *RangeScanRowReader {
+	reader := &RangeScanRowReader{
+		opts:       opts,
+		create:     create,
+		start:      time.Now(),
+		items:      make(chan rangeScanStreamItem, opts.Concurrency),
+		done:       make(chan struct{}),
+		resumeFrom: make(map[uint16][]byte),
+		logger:     logger,
+	}
+
+	if opts.ResumeFrom != nil {
+		for vbID, key := range opts.ResumeFrom {
+			if int(vbID) >= numVbuckets {
+				continue
+			}
+			reader.vbuckets = append(reader.vbuckets, vbID)
+			reader.resumeFrom[vbID] = key
+		}
+		sort.Slice(reader.vbuckets, func(i, j int) bool {
+			return reader.vbuckets[i] < reader.vbuckets[j]
+		})
+	} else {
+		for vbID := 0; vbID < numVbuckets; vbID++ {
+			reader.vbuckets = append(reader.vbuckets, uint16(vbID))
+			reader.resumeFrom[uint16(vbID)] = nil
+		}
+	}
+
+	return reader
+}
+
+func (r *RangeScanRowReader) run() {
+	if !r.opts.Deadline.IsZero() {
+		r.lock.Lock()
+		r.timeoutTmr = time.AfterFunc(r.opts.Deadline.Sub(r.start), func() {
+			r.finish(&TimeoutError{
+				InnerError:   errUnambiguousTimeout,
+				OperationID:  "RangeScan",
+				TimeObserved: time.Since(r.start),
+			})
+		})
+		r.lock.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Concurrency && i < len(r.vbuckets); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				vbID, resumeKey, ok := r.nextVbucket()
+				if !ok || !r.scanVbucket(vbID, resumeKey) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		r.lock.Lock()
+		if r.timeoutTmr != nil {
+			r.timeoutTmr.Stop()
+		}
+		r.lock.Unlock()
+		close(r.items)
+	}()
+}
+
+func (r *RangeScanRowReader) nextVbucket() (uint16, []byte, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed || r.nextVbIdx >= len(r.vbuckets) {
+		return 0, nil, false
+	}
+
+	vbID := r.vbuckets[r.nextVbIdx]
+	r.nextVbIdx++
+	return vbID, r.resumeFrom[vbID], true
+}
+
+// scanVbucket runs a scan against a single vbucket until it is complete, returning false if the reader has been
+// stopped.
+func (r *RangeScanRowReader) scanVbucket(vbID uint16, resumeKey []byte) bool {
+	type createResult struct {
+		res RangeScanCreateResult
+		err error
+	}
+	createCh := make(chan createResult, 1)
+	createOp, err := r.create(vbID, RangeScanCreateOptions{
+		Deadline:       r.opts.Deadline,
+		CollectionName: r.opts.CollectionName,
+		ScopeName:      r.opts.ScopeName,
+		CollectionID:   r.opts.CollectionID,
+		KeysOnly:       r.opts.KeysOnly,
+		Range:          r.opts.rangeCreateConfig(resumeKey),
+		User:           r.opts.User,
+		TraceContext:   r.opts.TraceContext,
+		NoRootSpan:     r.opts.NoRootSpan,
+	}, func(res RangeScanCreateResult, err error) {
+		select {
+		case createCh <- createResult{res: res, err: err}:
+		default:
+		}
+	})
+	if err != nil {
+		r.finish(err)
+		return false
+	}
+
+	var created createResult
+	select {
+	case created = <-createCh:
+	case <-r.done:
+		createOp.Cancel()
+		return false
+	}
+
+	if errors.Is(created.err, errDocumentNotFound) {
+		// There are no keys within the range on this vbucket.
+		return r.send(rangeScanStreamItem{vbID: vbID})
+	}
+	if created.err != nil {
+		r.finish(created.err)
+		return false
+	}
+
+	scan := created.res
+	for {
+		type continueResult struct {
+			res *RangeScanContinueResult
+			err error
+		}
+		var itemsLock sync.Mutex
+		var items []RangeScanItem
+		continueCh := make(chan continueResult, 1)
+		continueOp, err := scan.RangeScanContinue(RangeScanContinueOptions{
+			Deadline:     r.opts.Deadline,
+			MaxCount:     r.opts.BatchItemLimit,
+			MaxBytes:     r.opts.BatchByteLimit,
+			User:         r.opts.User,
+			TraceContext: r.opts.TraceContext,
+			NoRootSpan:   r.opts.NoRootSpan,
+		}, func(batch []RangeScanItem) {
+			itemsLock.Lock()
+			items = append(items, batch...)
+			itemsLock.Unlock()
+		}, func(res *RangeScanContinueResult, err error) {
+			select {
+			case continueCh <- continueResult{res: res, err: err}:
+			default:
+			}
+		})
+		if err != nil {
+			r.cancelScan(scan)
+			r.finish(err)
+			return false
+		}
+
+		var continued continueResult
+		select {
+		case continued = <-continueCh:
+		case <-r.done:
+			continueOp.Cancel()
+			r.cancelScan(scan)
+			return false
+		}
+
+		if continued.err != nil {
+			r.cancelScan(scan)
+			r.finish(continued.err)
+			return false
+		}
+
+		// Items are only sent to the reader here, rather than from the data callback, so that a slow reader
+		// cannot block the connection which the responses are read from.
+		itemsLock.Lock()
+		batch := items
+		itemsLock.Unlock()
+		for i := range batch {
+			if !r.send(rangeScanStreamItem{vbID: vbID, item: &batch[i]}) {
+				if !continued.res.Complete {
+					r.cancelScan(scan)
+				}
+				return false
+			}
+		}
+
+		if continued.res.Complete {
+			return r.send(rangeScanStreamItem{vbID: vbID})
+		}
+	}
+}
+
+func (r *RangeScanRowReader) cancelScan(scan RangeScanCreateResult) {
+	_, err := scan.RangeScanCancel(RangeScanCancelOptions{
+		User:         r.opts.User,
+		TraceContext: r.opts.TraceContext,
+		NoRootSpan:   r.opts.NoRootSpan,
+	}, func(res *RangeScanCancelResult, err error) {
+		if err != nil {
+			r.logger.debugf("Failed to cancel range scan: %v", err)
+		}
+	})
+	if err != nil {
+		r.logger.debugf("Failed to send range scan cancel: %v", err)
+	}
+}
+
+func (r *RangeScanRowReader) send(item rangeScanStreamItem) bool {
+	select {
+	case r.items <- item:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+func (r *RangeScanRowReader) finish(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.err = err
+	if r.timeoutTmr != nil {
+		r.timeoutTmr.Stop()
+	}
+	close(r.done)
+}
+
+// NextRow returns the next item returned by the scan, or nil once there are no more items or the scan has failed.
+func (r *RangeScanRowReader) NextRow() *RangeScanItem {
+	for {
+		select {
+		case <-r.done:
+			return nil
+		default:
+		}
+
+		select {
+		case <-r.done:
+			return nil
+		case streamItem, ok := <-r.items:
+			if !ok {
+				return nil
+			}
+
+			r.lock.Lock()
+			if streamItem.item == nil {
+				delete(r.resumeFrom, streamItem.vbID)
+				r.lock.Unlock()
+				continue
+			}
+			r.resumeFrom[streamItem.vbID] = streamItem.item.Key
+			r.lock.Unlock()
+
+			return streamItem.item
+		}
+	}
+}
+
+// Err returns any errors that occurred during the scan.
+func (r *RangeScanRowReader) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.err
+}
+
+// ResumeFrom returns the last key returned by NextRow for each vbucket which has not yet been fully read, nil for
+// those which have not returned any keys. It can be passed to RangeScanOptions to resume the scan.
+func (r *RangeScanRowReader) ResumeFrom() map[uint16][]byte {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	resumeFrom := make(map[uint16][]byte, len(r.resumeFrom))
+	for vbID, key := range r.resumeFrom {
+		resumeFrom[vbID] = key
+	}
+
+	return resumeFrom
+}
+
+// Close stops the scan, cancelling any scans which are still running against the server.
+func (r *RangeScanRowReader) Close() error {
+	r.finish(nil)
+	return r.Err()
+}
@@ -0,0 +1,267 @@
+package gocbcore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type testRangeScan struct {
+	keys     [][]byte
+	batch    int
+	failWith error
+
+	lock      sync.Mutex
+	cancelled bool
+	continues []RangeScanContinueOptions
+}
+
+func (scan *testRangeScan) ScanUUID() []byte {
+	return make([]byte, 16)
+}
+
+func (scan *testRangeScan) KeysOnly() bool {
+	return true
+}
+
+func (scan *testRangeScan) RangeScanContinue(opts RangeScanContinueOptions, dataCb RangeScanContinueDataCallback,
+	actionCb RangeScanContinueActionCallback) (PendingOp, error) {
+	if scan.failWith != nil {
+		actionCb(nil, scan.failWith)
+		return &multiPendingOp{}, nil
+	}
+
+	scan.lock.Lock()
+	scan.continues = append(scan.continues, opts)
+	n := scan.batch
+	if n > len(scan.keys) {
+		n = len(scan.keys)
+	}
+	batch := scan.keys[:n]
+	scan.keys = scan.keys[n:]
+	complete := len(scan.keys) == 0
+	scan.lock.Unlock()
+
+	items := make([]RangeScanItem, len(batch))
+	for i, key := range batch {
+		items[i] = RangeScanItem{Key: key}
+	}
+	dataCb(items)
+	actionCb(&RangeScanContinueResult{More: !complete, Complete: complete}, nil)
+
+	return &multiPendingOp{}, nil
+}
+
+func (scan *testRangeScan) RangeScanCancel(opts RangeScanCancelOptions, cb RangeScanCancelCallback) (PendingOp, error) {
+	scan.lock.Lock()
+	scan.cancelled = true
+	scan.lock.Unlock()
+
+	cb(&RangeScanCancelResult{}, nil)
+	return &multiPendingOp{}, nil
+}
+
+type testRangeScanCreator struct {
+	keys     map[uint16][][]byte
+	failWith error
+
+	lock    sync.Mutex
+	creates map[uint16]RangeScanCreateOptions
+	scans   []*testRangeScan
+}
+
+func (c *testRangeScanCreator) create(vbID uint16, opts RangeScanCreateOptions, cb RangeScanCreateCallback) (PendingOp, error) {
+	c.lock.Lock()
+	c.creates[vbID] = opts
+
+	var keys [][]byte
+	for _, key := range c.keys[vbID] {
+		if bytes.Compare(key, opts.Range.ExclusiveStart) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	scan := &testRangeScan{keys: keys, batch: 2, failWith: c.failWith}
+	c.scans = append(c.scans, scan)
+	c.lock.Unlock()
+
+	if len(keys) == 0 {
+		cb(nil, errDocumentNotFound)
+	} else {
+		cb(scan, nil)
+	}
+
+	return &multiPendingOp{}, nil
+}
+
+func (suite *UnitTestSuite) newTestRangeScanCrudComponent(numVbuckets int) *crudComponent {
+	servers := make([][]int, numVbuckets)
+	for i := range servers {
+		servers[i] = []int{0}
+	}
+
+	return &crudComponent{
+		featureVerifier: &testSubdocCapabilityVerifier{},
+		configSnapshotProvider: &testConfigSnapshotProvider{
+			snapshot: &ConfigSnapshot{
+				state: &kvMuxState{
+					routeCfg: routeConfig{
+						vbMap: newVbucketMap(servers, 0),
+					},
+				},
+			},
+		},
+	}
+}
+
+func (suite *UnitTestSuite) doTestRangeScan(crud *crudComponent, opts RangeScanOptions,
+	creator *testRangeScanCreator) *RangeScanRowReader {
+	readerCh := make(chan *RangeScanRowReader, 1)
+	_, err := crud.rangeScan(opts, creator.create, func(reader *RangeScanRowReader, err error) {
+		suite.Assert().Nil(err, err)
+		readerCh <- reader
+	})
+	suite.Require().Nil(err, err)
+
+	return <-readerCh
+}
+
+func (suite *UnitTestSuite) TestRangeScan() {
+	crud := suite.newTestRangeScanCrudComponent(4)
+	creator := &testRangeScanCreator{
+		keys: map[uint16][][]byte{
+			0: {[]byte("user::1"), []byte("user::4"), []byte("user::5")},
+			1: {[]byte("user::2")},
+			3: {[]byte("user::3"), []byte("user::6")},
+		},
+		creates: make(map[uint16]RangeScanCreateOptions),
+	}
+
+	reader := suite.doTestRangeScan(crud, RangeScanOptions{
+		Prefix:      []byte("user::"),
+		KeysOnly:    true,
+		Concurrency: 2,
+		Timeout:     time.Second,
+	}, creator)
+
+	var keys []string
+	for item := reader.NextRow(); item != nil; item = reader.NextRow() {
+		keys = append(keys, string(item.Key))
+	}
+	suite.Require().Nil(reader.Err())
+	sort.Strings(keys)
+	suite.Assert().Equal([]string{"user::1", "user::2", "user::3", "user::4", "user::5", "user::6"}, keys)
+	suite.Assert().Empty(reader.ResumeFrom())
+
+	suite.Require().Len(creator.creates, 4)
+	for _, opts := range creator.creates {
+		suite.Assert().True(opts.KeysOnly)
+		suite.Assert().Equal([]byte("user::"), opts.Range.Start)
+		suite.Assert().Equal([]byte("user::\xff"), opts.Range.End)
+		suite.Assert().Empty(opts.Range.ExclusiveStart)
+	}
+
+	// The batch limits default to non-zero values, so that the size of each batch held in memory is bounded.
+	suite.Require().Len(creator.scans, 4)
+	for _, scan := range creator.scans {
+		for _, opts := range scan.continues {
+			suite.Assert().Equal(uint32(defaultRangeScanBatchItemLimit), opts.MaxCount)
+			suite.Assert().Equal(uint32(defaultRangeScanBatchByteLimit), opts.MaxBytes)
+		}
+	}
+}
+
+func (suite *UnitTestSuite) TestRangeScanResume() {
+	crud := suite.newTestRangeScanCrudComponent(2)
+	keys := map[uint16][][]byte{
+		0: {[]byte("user::1"), []byte("user::3"), []byte("user::5"), []byte("user::7"), []byte("user::9")},
+		1: {[]byte("user::2"), []byte("user::4")},
+	}
+	creator := &testRangeScanCreator{
+		keys:    keys,
+		creates: make(map[uint16]RangeScanCreateOptions),
+	}
+
+	reader := suite.doTestRangeScan(crud, RangeScanOptions{
+		Prefix:  []byte("user::"),
+		Timeout: time.Second,
+	}, creator)
+
+	item := reader.NextRow()
+	suite.Require().NotNil(item)
+	suite.Assert().Equal([]byte("user::1"), item.Key)
+	suite.Require().Nil(reader.Close())
+	suite.Assert().Nil(reader.NextRow())
+
+	resumeFrom := reader.ResumeFrom()
+	suite.Assert().Equal(map[uint16][]byte{0: []byte("user::1"), 1: nil}, resumeFrom)
+
+	// The reader only buffers a single item so the scan against vbucket 0 cannot have completed, and must have been
+	// cancelled.
+	suite.Require().Eventually(func() bool {
+		creator.lock.Lock()
+		defer creator.lock.Unlock()
+		creator.scans[0].lock.Lock()
+		defer creator.scans[0].lock.Unlock()
+		return creator.scans[0].cancelled
+	}, time.Second, time.Millisecond)
+
+	creator = &testRangeScanCreator{
+		keys:    keys,
+		creates: make(map[uint16]RangeScanCreateOptions),
+	}
+	reader = suite.doTestRangeScan(crud, RangeScanOptions{
+		Prefix:     []byte("user::"),
+		Timeout:    time.Second,
+		ResumeFrom: resumeFrom,
+	}, creator)
+
+	var resumedKeys []string
+	for item := reader.NextRow(); item != nil; item = reader.NextRow() {
+		resumedKeys = append(resumedKeys, string(item.Key))
+	}
+	suite.Require().Nil(reader.Err())
+	suite.Assert().Equal([]string{"user::3", "user::5", "user::7", "user::9", "user::2", "user::4"}, resumedKeys)
+	suite.Assert().Equal([]byte("user::1"), creator.creates[0].Range.ExclusiveStart)
+	suite.Assert().Empty(creator.creates[0].Range.Start)
+	suite.Assert().Equal([]byte("user::"), creator.creates[1].Range.Start)
+}
+
+func (suite *UnitTestSuite) TestRangeScanError() {
+	crud := suite.newTestRangeScanCrudComponent(8)
+	keys := make(map[uint16][][]byte)
+	for vbID := uint16(0); vbID < 8; vbID++ {
+		keys[vbID] = [][]byte{[]byte(fmt.Sprintf("user::%d", vbID))}
+	}
+	creator := &testRangeScanCreator{
+		keys:     keys,
+		failWith: errTemporaryFailure,
+		creates:  make(map[uint16]RangeScanCreateOptions),
+	}
+
+	reader := suite.doTestRangeScan(crud, RangeScanOptions{
+		Prefix:      []byte("user::"),
+		Concurrency: 4,
+		Timeout:     time.Second,
+	}, creator)
+
+	suite.Assert().Nil(reader.NextRow())
+	suite.Assert().True(errors.Is(reader.Err(), ErrTemporaryFailure))
+}
+
+func (suite *UnitTestSuite) TestRangeScanInvalidArguments() {
+	crud := suite.newTestRangeScanCrudComponent(1)
+
+	_, err := crud.RangeScan(RangeScanOptions{}, func(reader *RangeScanRowReader, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	_, err = crud.RangeScan(RangeScanOptions{Prefix: []byte("user::"), Concurrency: -1},
+		func(reader *RangeScanRowReader, err error) {
+			suite.T().Errorf("Callback should not have been called")
+		})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
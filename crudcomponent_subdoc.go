@@ -2,6 +2,7 @@ package gocbcore
 
 import (
 	"encoding/binary"
+	"fmt"
 	"sync"
 	"time"
 
@@ -33,7 +34,9 @@ func (sol *subdocOpList) Reorder(ops []SubDocOp) {
 }
 
 func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (PendingOp, error) {
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "LookupIn", opts.TraceContext)
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "LookupIn", opts.TraceContext, opts.NoRootSpan)
 
 	results := make([]SubDocResult, len(opts.Ops))
 	var subdocs subdocOpList
@@ -43,7 +46,7 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 			!isErrorStatus(err, memd.StatusSubDocMultiPathFailureDeleted) &&
 			!isErrorStatus(err, memd.StatusSubDocSuccessDeleted) &&
 			!isErrorStatus(err, memd.StatusSubDocBadMulti) {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -51,7 +54,7 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 		respIter := 0
 		for i := range results {
 			if respIter+6 > len(resp.Value) {
-				tracer.Finish()
+				tracer.FinishWithError(errProtocol)
 				cb(nil, errProtocol)
 				return
 			}
@@ -60,13 +63,14 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 			resValueLen := int(binary.BigEndian.Uint32(resp.Value[respIter+2:]))
 
 			if respIter+6+resValueLen > len(resp.Value) {
-				tracer.Finish()
+				tracer.FinishWithError(errProtocol)
 				cb(nil, errProtocol)
 				return
 			}
 
+			results[subdocs.indexes[i]].Status = resError
 			if resError != memd.StatusSuccess {
-				results[subdocs.indexes[i]].Err = crud.makeSubDocError(i, resError, req, resp)
+				results[subdocs.indexes[i]].Err = crud.makeSubDocError(subdocs.indexes[i], resError, req, resp)
 			}
 
 			results[subdocs.indexes[i]].Value = resp.Value[respIter+6 : respIter+6+resValueLen]
@@ -79,6 +83,7 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 		res.Internal.IsDeleted = isErrorStatus(err, memd.StatusSubDocSuccessDeleted) ||
 			isErrorStatus(err, memd.StatusSubDocMultiPathFailureDeleted)
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -100,10 +105,12 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 	for i, op := range subdocs.ops {
 		if op.Op != memd.SubDocOpGet && op.Op != memd.SubDocOpExists &&
 			op.Op != memd.SubDocOpGetDoc && op.Op != memd.SubDocOpGetCount {
-			return nil, errInvalidArgument
+			return nil, wrapError(errInvalidArgument,
+				fmt.Sprintf("op %d is not a lookup in operation", subdocs.indexes[i]))
 		}
 		if op.Value != nil {
-			return nil, errInvalidArgument
+			return nil, wrapError(errInvalidArgument,
+				fmt.Sprintf("op %d is a lookup in operation so cannot have a value", subdocs.indexes[i]))
 		}
 
 		pathBytes := pathBytesList[i]
@@ -157,13 +164,15 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		Priority:         opts.Priority,
 		ReplicaIdx:       opts.ReplicaIdx,
 		ServerGroup:      opts.ServerGroup,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -181,6 +190,8 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 }
 
 func (crud *crudComponent) LookupInServerGroup(serverGroup string, opts LookupInOptions, cb LookupInCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
 	parentOp := &multiPendingOp{
 		isIdempotent: true,
 	}
@@ -249,11 +260,14 @@ func (crud *crudComponent) LookupInServerGroup(serverGroup string, opts LookupIn
 				ScopeName:      opts.ScopeName,
 				CollectionID:   opts.CollectionID,
 				RetryStrategy:  opts.RetryStrategy,
+				NoRetry:        opts.NoRetry,
+				Priority:       opts.Priority,
 				Deadline:       opts.Deadline,
 				ReplicaIdx:     replicaIdx,
 				ServerGroup:    serverGroup,
 				User:           opts.User,
 				TraceContext:   opts.TraceContext,
+				NoRootSpan:     opts.NoRootSpan,
 			}, func(result *LookupInResult, err error) {
 				if err != nil {
 					opCompleted()
@@ -293,11 +307,13 @@ func (crud *crudComponent) LookupInServerGroup(serverGroup string, opts LookupIn
 }
 
 func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (PendingOp, error) {
+	opts.Deadline = crud.deadline(opts.Deadline, opts.Timeout)
+
 	if len(opts.Ops) == 0 {
 		return nil, wrapError(errInvalidArgument, "at least one op must be present")
 	}
 
-	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "MutateIn", opts.TraceContext)
+	tracer := crud.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "MutateIn", opts.TraceContext, opts.NoRootSpan)
 
 	results := make([]SubDocResult, len(opts.Ops))
 	var subdocs subdocOpList
@@ -305,7 +321,7 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		// GOCBC-1356: memcached can return a NOT_STORED response when inserting a doc with sub-doc.
 		if isErrorStatus(err, memd.StatusNotStored) && opts.Flags&memd.SubdocDocFlagAddDoc != 0 {
-			tracer.Finish()
+			tracer.FinishWithError(errDocumentExists)
 			cb(nil, crud.errMapManager.EnhanceKvError(errDocumentExists, resp, req))
 			return
 		}
@@ -313,23 +329,34 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 		if err != nil &&
 			!isErrorStatus(err, memd.StatusSubDocSuccessDeleted) &&
 			!isErrorStatus(err, memd.StatusSubDocBadMulti) {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if isErrorStatus(err, memd.StatusSubDocBadMulti) {
 			if len(resp.Value) != 3 {
-				tracer.Finish()
+				tracer.FinishWithError(errProtocol)
 				cb(nil, errProtocol)
 				return
 			}
 
 			opIndex := int(resp.Value[0])
 			resError := memd.StatusCode(binary.BigEndian.Uint16(resp.Value[1:]))
+			if opIndex >= len(subdocs.indexes) {
+				tracer.FinishWithError(errProtocol)
+				cb(nil, errProtocol)
+				return
+			}
 
-			err := crud.makeSubDocError(opIndex, resError, req, resp)
-			tracer.Finish()
+			// The ops may have been reordered so we need to translate back to the index that the user provided.
+			specIndex := subdocs.indexes[opIndex]
+			err := SubDocMutateError{
+				InnerError: crud.makeSubDocError(specIndex, resError, req, resp),
+				Index:      specIndex,
+				StatusCode: resError,
+			}
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -338,7 +365,10 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 			opIndex := int(resp.Value[readPos+0])
 			opStatus := memd.StatusCode(binary.BigEndian.Uint16(resp.Value[readPos+1:]))
 
-			results[subdocs.indexes[opIndex]].Err = crud.makeSubDocError(opIndex, opStatus, req, resp)
+			results[subdocs.indexes[opIndex]].Status = opStatus
+			if opStatus != memd.StatusSuccess {
+				results[subdocs.indexes[opIndex]].Err = crud.makeSubDocError(subdocs.indexes[opIndex], opStatus, req, resp)
+			}
 			readPos += 3
 
 			if opStatus == memd.StatusSuccess {
@@ -360,23 +390,20 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 			Ops:           results,
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
 	}
 
-	var duraLevelFrame *memd.DurabilityLevelFrame
-	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
-	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, CapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
-		}
-		duraLevelFrame = &memd.DurabilityLevelFrame{
-			DurabilityLevel: opts.DurabilityLevel,
-		}
-		duraTimeoutFrame = &memd.DurabilityTimeoutFrame{
-			DurabilityTimeout: opts.DurabilityLevelTimeout,
-		}
+	expiry, err := resolveExpiry(opts.Expiry, opts.ExpiryDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	duraLevelFrame, duraTimeoutFrame, err := crud.durabilityFrames(opts.DurabilityLevel, opts.DurabilityLevelTimeout)
+	if err != nil {
+		return nil, err
 	}
 
 	var userFrame *memd.UserImpersonationFrame
@@ -391,7 +418,7 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 		if opts.Flags|memd.SubdocDocFlagAddDoc == 1 {
 			return nil, wrapError(errInvalidArgument, "cannot use preserve expiry with add doc flags")
 		}
-		if opts.Expiry != 0 && opts.PreserveExpiry && opts.Flags|memd.SubdocDocFlagNone == 1 {
+		if expiry != 0 && opts.PreserveExpiry && opts.Flags|memd.SubdocDocFlagNone == 1 {
 			return nil, wrapError(errInvalidArgument, "cannot use preserve expiry with expiry and no doc flags")
 		}
 		preserveExpiryFrame = &memd.PreserveExpiryFrame{}
@@ -436,7 +463,8 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 			op.Op != memd.SubDocOpCounter && op.Op != memd.SubDocOpSetDoc &&
 			op.Op != memd.SubDocOpAddDoc && op.Op != memd.SubDocOpDeleteDoc &&
 			op.Op != memd.SubDocOpReplaceBodyWithXattr {
-			return nil, errInvalidArgument
+			return nil, wrapError(errInvalidArgument,
+				fmt.Sprintf("op %d is not a mutate in operation", subdocs.indexes[i]))
 		}
 
 		if op.Op == memd.SubDocOpReplaceBodyWithXattr {
@@ -461,9 +489,10 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 	}
 
 	var extraBuf []byte
-	if opts.Expiry != 0 {
+	if expiry != 0 {
+		crud.clockSkew.CheckExpiry(expiry)
 		tmpBuf := make([]byte, 4)
-		binary.BigEndian.PutUint32(tmpBuf[0:], opts.Expiry)
+		binary.BigEndian.PutUint32(tmpBuf[0:], expiry)
 		extraBuf = append(extraBuf, tmpBuf...)
 	}
 	if opts.Flags != 0 {
@@ -494,11 +523,14 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		NoRetry:          opts.NoRetry,
+		IsIdempotent:     opts.Idempotent,
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
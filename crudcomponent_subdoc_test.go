@@ -2,10 +2,12 @@ package gocbcore
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
 
 	"strconv"
 	"strings"
@@ -1561,3 +1563,216 @@ func (suite *StandardTestSuite) TestLookupInReplicaReads() {
 		time.Sleep(50 * time.Millisecond)
 	}
 }
+
+type testSubdocCapabilityVerifier struct{}
+
+func (v *testSubdocCapabilityVerifier) HasBucketCapabilityStatus(BucketCapability, CapabilityStatus) bool {
+	return false
+}
+
+func (suite *UnitTestSuite) newTestSubdocCrudComponent(respond func(req *memdQRequest)) *crudComponent {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	dispatcher := new(mockDispatcher)
+	dispatcher.On("SetPostCompleteErrorHandler", mock.AnythingOfType("gocbcore.postCompleteErrorHandler")).Return()
+	dispatcher.On("CollectionsEnabled").Return(false)
+	dispatcher.On("DispatchDirect", mock.AnythingOfType("*gocbcore.memdQRequest")).Return(&memdQRequest{}, nil).
+		Run(func(args mock.Arguments) {
+			respond(args[0].(*memdQRequest))
+		})
+
+	tracer := newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, cfgMgr)
+	cidMgr := newCollectionIDManager(collectionIDProps{
+		DefaultRetryStrategy: &failFastRetryStrategy{},
+		MaxQueueSize:         100},
+		dispatcher,
+		tracer,
+		cfgMgr,
+	)
+
+	return newCRUDComponent(cidMgr, &failFastRetryStrategy{}, tracer, newErrMapManager("default"),
+		&testSubdocCapabilityVerifier{}, nil, false, nil, nil, 0, nil)
+}
+
+func (suite *UnitTestSuite) TestMutateInSubDocMutateError() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		// The xattr op will have been moved to the front, so the failing op at server index 1 is the users op 0.
+		resp := &memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSubDocBadMulti,
+				Value:  []byte{1, 0, byte(memd.StatusSubDocPathNotFound)},
+			},
+		}
+		req.Callback(resp, req, &KeyValueError{
+			InnerError: ErrMemdSubDocBadMulti,
+			StatusCode: memd.StatusSubDocBadMulti,
+		})
+	})
+
+	waitCh := make(chan error, 1)
+	_, err := crud.MutateIn(MutateInOptions{
+		Key: []byte("test"),
+		Ops: []SubDocOp{
+			{Op: memd.SubDocOpReplace, Path: "missing", Value: []byte("1")},
+			{Op: memd.SubDocOpDictSet, Flags: memd.SubdocFlagXattrPath, Path: "x.y", Value: []byte("1")},
+		},
+	}, func(res *MutateInResult, err error) {
+		waitCh <- err
+	})
+	suite.Require().Nil(err, err)
+
+	err = <-waitCh
+	var mutateErr SubDocMutateError
+	suite.Require().ErrorAs(err, &mutateErr)
+	suite.Assert().Equal(0, mutateErr.Index)
+	suite.Assert().Equal(memd.StatusSubDocPathNotFound, mutateErr.StatusCode)
+
+	var subdocErr SubDocumentError
+	suite.Require().ErrorAs(err, &subdocErr)
+	suite.Assert().Equal(0, subdocErr.Index)
+	suite.Assert().ErrorIs(err, ErrPathNotFound)
+}
+
+func (suite *UnitTestSuite) TestLookupInPerSpecStatus() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		value := make([]byte, 0)
+		for _, status := range []memd.StatusCode{memd.StatusSuccess, memd.StatusSubDocPathNotFound} {
+			entry := make([]byte, 6)
+			binary.BigEndian.PutUint16(entry[0:], uint16(status))
+			value = append(value, entry...)
+		}
+		resp := &memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSubDocMultiPathFailureDeleted,
+				Value:  value,
+			},
+		}
+		req.Callback(resp, req, &KeyValueError{
+			InnerError: ErrMemdSubDocMultiPathFailureDeleted,
+			StatusCode: memd.StatusSubDocMultiPathFailureDeleted,
+		})
+	})
+
+	waitCh := make(chan *LookupInResult, 1)
+	_, err := crud.LookupIn(LookupInOptions{
+		Key: []byte("test"),
+		Ops: []SubDocOp{
+			{Op: memd.SubDocOpGet, Path: "missing"},
+			{Op: memd.SubDocOpGet, Flags: memd.SubdocFlagXattrPath, Path: "x.y"},
+		},
+	}, func(res *LookupInResult, err error) {
+		suite.Assert().Nil(err, err)
+		waitCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	res := <-waitCh
+	suite.Require().Len(res.Ops, 2)
+
+	// The xattr op is sent first so the server results are in reverse order to the users ops.
+	suite.Assert().Equal(memd.StatusSubDocPathNotFound, res.Ops[0].Status)
+	var subdocErr SubDocumentError
+	suite.Require().ErrorAs(res.Ops[0].Err, &subdocErr)
+	suite.Assert().Equal(0, subdocErr.Index)
+
+	suite.Assert().Equal(memd.StatusSuccess, res.Ops[1].Status)
+	suite.Assert().Nil(res.Ops[1].Err)
+}
+
+func (suite *UnitTestSuite) TestParseDocumentMetaData() {
+	meta, err := ParseDocumentMetaData([]byte(`{"CAS":"0x16b1a8e6ba2e0000","vbucket_uuid":"0x0000c4e1ee5fb5dc",` +
+		`"seqno":"0x0000000000000012","revid":"3","exptime":1700000000,"value_bytes":13,"deleted":false,"flags":33554432,` +
+		`"datatype":["json"],"last_modified":"1599565612","value_crc32c":"0x3ba2c9ef"}`))
+	suite.Require().Nil(err, err)
+
+	suite.Assert().Equal(&DocumentMetaData{
+		Cas:          Cas(0x16b1a8e6ba2e0000),
+		SeqNo:        SeqNo(0x12),
+		VbUUID:       VbUUID(0xc4e1ee5fb5dc),
+		RevID:        "3",
+		Expiry:       1700000000,
+		Flags:        33554432,
+		ValueBytes:   13,
+		Datatype:     []string{"json"},
+		LastModified: time.Unix(1599565612, 0),
+	}, meta)
+
+	_, err = ParseDocumentMetaData([]byte(`{"CAS":"notacas"}`))
+	suite.Assert().NotNil(err)
+}
+
+func (suite *UnitTestSuite) TestMutateInDurabilityCasAndExpiry() {
+	reqCh := make(chan *memdQRequest, 1)
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		reqCh <- req
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    1234,
+			},
+		}, req, nil)
+	})
+
+	waitCh := make(chan *MutateInResult, 1)
+	_, err := crud.MutateIn(MutateInOptions{
+		Key:                    []byte("test"),
+		Cas:                    5678,
+		Expiry:                 10,
+		DurabilityLevel:        memd.DurabilityLevelMajorityAndPersistOnMaster,
+		DurabilityLevelTimeout: 2 * time.Second,
+		Ops: []SubDocOp{
+			{Op: memd.SubDocOpDictSet, Path: "a.b", Value: []byte("1"), Flags: memd.SubdocFlagMkDirP},
+			{Op: memd.SubDocOpCounter, Path: "x.count", Value: []byte("1"),
+				Flags: memd.SubdocFlagXattrPath | memd.SubdocFlagMkDirP},
+		},
+	}, func(res *MutateInResult, err error) {
+		suite.Assert().Nil(err, err)
+		waitCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	req := <-reqCh
+	suite.Assert().Equal(uint64(5678), req.Cas)
+	suite.Assert().Equal([]byte{0, 0, 0, 10}, req.Extras)
+	suite.Require().NotNil(req.DurabilityLevelFrame)
+	suite.Assert().Equal(memd.DurabilityLevelMajorityAndPersistOnMaster, req.DurabilityLevelFrame.DurabilityLevel)
+	suite.Require().NotNil(req.DurabilityTimeoutFrame)
+	suite.Assert().Equal(2*time.Second, req.DurabilityTimeoutFrame.DurabilityTimeout)
+	// The xattr op must be sent first.
+	suite.Assert().Equal(uint8(memd.SubDocOpCounter), req.Value[0])
+
+	res := <-waitCh
+	suite.Assert().Equal(Cas(1234), res.Cas)
+	suite.Assert().Len(res.Ops, 2)
+}
+
+func (suite *UnitTestSuite) TestSubDocInvalidOpReportsIndex() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		suite.T().Errorf("No request should have been sent")
+	})
+
+	_, err := crud.MutateIn(MutateInOptions{
+		Key: []byte("test"),
+		Ops: []SubDocOp{
+			{Op: memd.SubDocOpDictSet, Path: "a", Value: []byte("1")},
+			{Op: memd.SubDocOpGet, Path: "b"},
+		},
+	}, func(res *MutateInResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Require().True(errors.Is(err, ErrInvalidArgument), err)
+	suite.Assert().Contains(err.Error(), "op 1")
+
+	_, err = crud.LookupIn(LookupInOptions{
+		Key: []byte("test"),
+		Ops: []SubDocOp{
+			{Op: memd.SubDocOpGet, Path: "a"},
+			{Op: memd.SubDocOpDictSet, Path: "b", Value: []byte("1"), Flags: memd.SubdocFlagXattrPath},
+		},
+	}, func(res *LookupInResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Require().True(errors.Is(err, ErrInvalidArgument), err)
+	suite.Assert().Contains(err.Error(), "op 1")
+}
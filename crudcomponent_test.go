@@ -1,8 +1,12 @@
 package gocbcore
 
 import (
+	"encoding/binary"
 	"errors"
+	"sync"
+	"time"
 
+	"github.com/golang/snappy"
 	"github.com/google/uuid"
 
 	"github.com/couchbase/gocbcore/v10/memd"
@@ -46,6 +50,70 @@ func (suite *StandardTestSuite) TestDocumentNotLocked() {
 	s.Wait(0)
 }
 
+func (suite *StandardTestSuite) TestUnlockCasMismatch() {
+	agent, s := suite.GetAgentAndHarness()
+
+	docID := uuid.NewString()
+
+	s.PushOp(agent.Set(SetOptions{
+		Key:            []byte(docID),
+		Value:          []byte("test"),
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+	}, func(res *StoreResult, err error) {
+		s.Wrap(func() {
+			if err != nil {
+				s.Fatalf("Set operation failed: %v", err)
+			}
+		})
+	}))
+	s.Wait(0)
+
+	var lockCas Cas
+	s.PushOp(agent.GetAndLock(GetAndLockOptions{
+		Key:            []byte(docID),
+		LockTime:       10,
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+	}, func(res *GetAndLockResult, err error) {
+		s.Wrap(func() {
+			if err != nil {
+				s.Fatalf("GetAndLock operation failed: %v", err)
+			}
+			lockCas = res.Cas
+		})
+	}))
+	s.Wait(0)
+
+	s.PushOp(agent.Unlock(UnlockOptions{
+		Key:            []byte(docID),
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+		Cas:            lockCas + 1,
+	}, func(result *UnlockResult, err error) {
+		s.Wrap(func() {
+			if !errors.Is(err, ErrCasMismatch) {
+				s.Fatalf("Unlock operation failed with unexpected error, should've been cas mismatch: %v", err)
+			}
+		})
+	}))
+	s.Wait(0)
+
+	s.PushOp(agent.Unlock(UnlockOptions{
+		Key:            []byte(docID),
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+		Cas:            lockCas,
+	}, func(result *UnlockResult, err error) {
+		s.Wrap(func() {
+			if err != nil {
+				s.Fatalf("Unlock operation failed: %v", err)
+			}
+		})
+	}))
+	s.Wait(0)
+}
+
 func (suite *StandardTestSuite) TestResourceUnits() {
 	suite.EnsureSupportsFeature(TestFeatureResourceUnits)
 
@@ -264,3 +332,998 @@ func (suite *StandardTestSuite) TestResourceUnits() {
 // 		suite.Require().GreaterOrEqual(1, int(resourceUnits.WriteUnits))
 // 	}
 // }
+
+func (suite *UnitTestSuite) TestGetWithExpiry() {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	var commands []memd.CmdCode
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		commands = append(commands, req.Command)
+
+		resp := &memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    123,
+			},
+		}
+		switch req.Command {
+		case memd.CmdGet:
+			resp.Extras = make([]byte, 4)
+			resp.Value = []byte("{}")
+		case memd.CmdGetMeta:
+			resp.Extras = make([]byte, 21)
+			binary.BigEndian.PutUint32(resp.Extras[8:], uint32(expiry.Unix()))
+		}
+		req.Callback(resp, req, nil)
+	})
+
+	waitCh := make(chan *GetResult, 1)
+	_, err := crud.Get(GetOptions{
+		Key:        []byte("test"),
+		WithExpiry: true,
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err, err)
+		waitCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	res := <-waitCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal([]memd.CmdCode{memd.CmdGet, memd.CmdGetMeta}, commands)
+	suite.Assert().Equal([]byte("{}"), res.Value)
+	suite.Assert().Equal(Cas(123), res.Cas)
+	suite.Assert().True(expiry.Equal(res.Expiry))
+}
+
+func (suite *UnitTestSuite) TestGetWithExpiryMetaOpsUnsupported() {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	var commands []memd.CmdCode
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		commands = append(commands, req.Command)
+
+		resp := &memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    123,
+			},
+		}
+		switch req.Command {
+		case memd.CmdGet:
+			resp.Extras = make([]byte, 4)
+			resp.Value = []byte("{}")
+		case memd.CmdGetMeta:
+			resp.Extras = make([]byte, 21)
+			binary.BigEndian.PutUint32(resp.Extras[8:], uint32(expiry.Unix()))
+		}
+		req.Callback(resp, req, nil)
+	})
+	crud.featureVerifier = &testMetaOpsCapabilityVerifier{status: CapabilityStatusUnsupported}
+
+	waitCh := make(chan *GetResult, 1)
+	_, err := crud.Get(GetOptions{
+		Key:        []byte("test"),
+		WithExpiry: true,
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err, err)
+		waitCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	res := <-waitCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal([]memd.CmdCode{memd.CmdGet, memd.CmdGetMeta}, commands)
+	suite.Assert().True(expiry.Equal(res.Expiry))
+}
+
+func (suite *UnitTestSuite) TestGetWithExpiryModifiedBetweenReads() {
+	var commands []memd.CmdCode
+	var metaCas uint64 = 100
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		commands = append(commands, req.Command)
+
+		resp := &memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+			},
+		}
+		switch req.Command {
+		case memd.CmdGet:
+			resp.Cas = 123
+			resp.Extras = make([]byte, 4)
+			resp.Value = []byte("{}")
+		case memd.CmdGetMeta:
+			// The document is modified after the first Get only.
+			resp.Cas = metaCas
+			metaCas = 123
+			resp.Extras = make([]byte, 21)
+		}
+		req.Callback(resp, req, nil)
+	})
+
+	waitCh := make(chan error, 1)
+	op, err := crud.Get(GetOptions{
+		Key:        []byte("test"),
+		WithExpiry: true,
+	}, func(res *GetResult, err error) {
+		waitCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().Nil(<-waitCh)
+	suite.Assert().Equal([]memd.CmdCode{memd.CmdGet, memd.CmdGetMeta, memd.CmdGet, memd.CmdGetMeta}, commands)
+
+	// Every request sent is covered by the returned op, so cancelling it cancels all of them.
+	suite.Assert().Equal(4, op.(*multiPendingOp).Len())
+
+	// A document which keeps being modified eventually fails the operation.
+	commands = nil
+	crud = suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		commands = append(commands, req.Command)
+
+		resp := &memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    uint64(len(commands)),
+			},
+		}
+		if req.Command == memd.CmdGet {
+			resp.Extras = make([]byte, 4)
+		} else {
+			resp.Extras = make([]byte, 21)
+		}
+		req.Callback(resp, req, nil)
+	})
+
+	_, err = crud.Get(GetOptions{
+		Key:        []byte("test"),
+		WithExpiry: true,
+	}, func(res *GetResult, err error) {
+		waitCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().ErrorIs(<-waitCh, ErrCasMismatch)
+	suite.Assert().Len(commands, 2*getWithExpiryMaxAttempts)
+}
+
+func (suite *UnitTestSuite) TestGetWithExpiryPinnedReplica() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		suite.Fail("no request should be sent")
+	})
+
+	_, err := crud.Get(GetOptions{
+		Key:              []byte("test"),
+		WithExpiry:       true,
+		PinnedReplicaIdx: 1,
+	}, func(res *GetResult, err error) {
+		suite.Fail("callback should not be invoked")
+	})
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *UnitTestSuite) TestGetPinnedReplica() {
+	var dispatched *memdQRequest
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		dispatched = req
+
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    123,
+				Extras: make([]byte, 4),
+				Value:  []byte("{}"),
+			},
+		}, req, nil)
+	})
+
+	waitCh := make(chan *GetResult, 1)
+	_, err := crud.Get(GetOptions{
+		Key:              []byte("test"),
+		PinnedReplicaIdx: 2,
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err, err)
+		waitCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	res := <-waitCh
+	suite.Require().NotNil(res)
+	suite.Require().NotNil(dispatched)
+	suite.Assert().Equal(memd.CmdGetReplica, dispatched.Command)
+	suite.Assert().Equal(2, dispatched.ReplicaIdx)
+	suite.Assert().Equal([]byte("{}"), res.Value)
+	suite.Assert().Equal(Cas(123), res.Cas)
+}
+
+type testConfigSnapshotProvider struct {
+	snapshot *ConfigSnapshot
+}
+
+func (p *testConfigSnapshotProvider) WaitForConfigSnapshot(deadline time.Time, cb WaitForConfigSnapshotCallback) (PendingOp, error) {
+	cb(&WaitForConfigSnapshotResult{Snapshot: p.snapshot}, nil)
+	return &multiPendingOp{}, nil
+}
+
+func (suite *UnitTestSuite) TestGetAllReplicas() {
+	var lock sync.Mutex
+	var commands []memd.CmdCode
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		lock.Lock()
+		commands = append(commands, req.Command)
+		lock.Unlock()
+
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    uint64(100 + req.ReplicaIdx),
+				Extras: make([]byte, 4),
+				Value:  []byte("{}"),
+			},
+		}, req, nil)
+	})
+	crud.configSnapshotProvider = &testConfigSnapshotProvider{
+		snapshot: &ConfigSnapshot{
+			state: &kvMuxState{
+				routeCfg: routeConfig{
+					vbMap: newVbucketMap([][]int{{0, 1, 2}}, 2),
+				},
+			},
+		},
+	}
+
+	results := make(map[int]*GetReplicaResult)
+	_, err := crud.GetAllReplicas(GetAllReplicasOptions{
+		Key: []byte("test"),
+	}, func(replicaIdx int, res *GetReplicaResult, err error) {
+		suite.Assert().Nil(err, err)
+		lock.Lock()
+		results[replicaIdx] = res
+		lock.Unlock()
+	})
+	suite.Require().Nil(err, err)
+
+	suite.Require().Len(results, 3)
+	for replicaIdx, res := range results {
+		suite.Assert().Equal(Cas(100+replicaIdx), res.Cas)
+		suite.Assert().Equal([]byte("{}"), res.Value)
+	}
+	suite.Assert().ElementsMatch([]memd.CmdCode{memd.CmdGet, memd.CmdGetReplica, memd.CmdGetReplica}, commands)
+}
+
+func (suite *UnitTestSuite) TestGetAnyReplica() {
+	var lock sync.Mutex
+	var available map[int]bool
+	var numReads int
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		lock.Lock()
+		numReads++
+		replicaAvailable := available[req.ReplicaIdx]
+		lock.Unlock()
+
+		if !replicaAvailable {
+			req.Callback(nil, req, errDocumentNotFound)
+			return
+		}
+
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    uint64(100 + req.ReplicaIdx),
+				Extras: make([]byte, 4),
+				Value:  []byte("{}"),
+			},
+		}, req, nil)
+	})
+	crud.configSnapshotProvider = &testConfigSnapshotProvider{
+		snapshot: &ConfigSnapshot{
+			state: &kvMuxState{
+				routeCfg: routeConfig{
+					vbMap: newVbucketMap([][]int{{0, 1, 2}}, 2),
+				},
+			},
+		},
+	}
+
+	type result struct {
+		replicaIdx int
+		res        *GetReplicaResult
+		err        error
+	}
+	getAnyReplica := func() []result {
+		var results []result
+		_, err := crud.GetAnyReplica(GetAnyReplicaOptions{
+			Key: []byte("test"),
+		}, func(replicaIdx int, res *GetReplicaResult, err error) {
+			lock.Lock()
+			results = append(results, result{replicaIdx: replicaIdx, res: res, err: err})
+			lock.Unlock()
+		})
+		suite.Require().Nil(err, err)
+
+		return results
+	}
+
+	available = map[int]bool{1: true}
+	results := getAnyReplica()
+	suite.Require().Len(results, 1)
+	suite.Assert().Nil(results[0].err, results[0].err)
+	suite.Assert().Equal(1, results[0].replicaIdx)
+	suite.Require().NotNil(results[0].res)
+	suite.Assert().Equal(Cas(101), results[0].res.Cas)
+
+	available = nil
+	numReads = 0
+	results = getAnyReplica()
+	suite.Assert().Equal(3, numReads)
+	suite.Require().Len(results, 1)
+	suite.Assert().ErrorIs(results[0].err, ErrDocumentNotFound)
+	suite.Assert().Equal(-1, results[0].replicaIdx)
+	suite.Assert().Nil(results[0].res)
+}
+
+type testDurabilityCapabilityVerifier struct {
+	status CapabilityStatus
+}
+
+func (v *testDurabilityCapabilityVerifier) HasBucketCapabilityStatus(capability BucketCapability, status CapabilityStatus) bool {
+	return capability == BucketCapabilityDurableWrites && status == v.status
+}
+
+func (suite *UnitTestSuite) TestDurableWritesUnsupported() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		suite.T().Errorf("No request should have been sent")
+	})
+	crud.featureVerifier = &testDurabilityCapabilityVerifier{status: CapabilityStatusUnsupported}
+
+	level := memd.DurabilityLevelMajority
+	failCb := func(interface{}, error) {
+		suite.T().Errorf("Callback should not have been called")
+	}
+
+	_, err := crud.Set(SetOptions{Key: []byte("a"), DurabilityLevel: level}, func(res *StoreResult, err error) {
+		failCb(res, err)
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+
+	_, err = crud.Add(AddOptions{Key: []byte("a"), DurabilityLevel: level}, func(res *StoreResult, err error) {
+		failCb(res, err)
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+
+	_, err = crud.Replace(ReplaceOptions{Key: []byte("a"), DurabilityLevel: level}, func(res *StoreResult, err error) {
+		failCb(res, err)
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+
+	_, err = crud.Delete(DeleteOptions{Key: []byte("a"), DurabilityLevel: level}, func(res *DeleteResult, err error) {
+		failCb(res, err)
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+
+	_, err = crud.MutateIn(MutateInOptions{
+		Key:             []byte("a"),
+		DurabilityLevel: level,
+		Ops:             []SubDocOp{{Op: memd.SubDocOpDictSet, Path: "a", Value: []byte("1")}},
+	}, func(res *MutateInResult, err error) {
+		failCb(res, err)
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+
+	// Unknown durability levels are rejected regardless of support.
+	crud.featureVerifier = &testDurabilityCapabilityVerifier{status: CapabilityStatusSupported}
+	_, err = crud.Set(SetOptions{Key: []byte("a"), DurabilityLevel: 0x10}, func(res *StoreResult, err error) {
+		failCb(res, err)
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+}
+
+func (suite *UnitTestSuite) TestDurableWriteFrames() {
+	reqCh := make(chan *memdQRequest, 1)
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		reqCh <- req
+	})
+
+	_, err := crud.Delete(DeleteOptions{
+		Key:                    []byte("a"),
+		DurabilityLevel:        memd.DurabilityLevelPersistToMajority,
+		DurabilityLevelTimeout: 3 * time.Second,
+	}, func(res *DeleteResult, err error) {})
+	suite.Require().Nil(err, err)
+
+	req := <-reqCh
+	suite.Require().NotNil(req.DurabilityLevelFrame)
+	suite.Assert().Equal(memd.DurabilityLevelPersistToMajority, req.DurabilityLevelFrame.DurabilityLevel)
+	suite.Require().NotNil(req.DurabilityTimeoutFrame)
+	suite.Assert().Equal(3*time.Second, req.DurabilityTimeoutFrame.DurabilityTimeout)
+}
+
+func (suite *UnitTestSuite) TestGetAndLockLockTime() {
+	reqCh := make(chan *memdQRequest, 1)
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		reqCh <- req
+	})
+
+	_, err := crud.GetAndLock(GetAndLockOptions{
+		Key:      []byte("test"),
+		LockTime: MaxLockTime + 1,
+	}, func(res *GetAndLockResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+
+	_, err = crud.GetAndLock(GetAndLockOptions{
+		Key:           []byte("test"),
+		LockTime:      60,
+		ClampLockTime: true,
+	}, func(res *GetAndLockResult, err error) {})
+	suite.Require().Nil(err, err)
+
+	req := <-reqCh
+	suite.Assert().Equal(uint32(MaxLockTime), binary.BigEndian.Uint32(req.Extras))
+
+	_, err = crud.GetAndLock(GetAndLockOptions{
+		Key:      []byte("test"),
+		LockTime: MaxLockTime,
+	}, func(res *GetAndLockResult, err error) {})
+	suite.Require().Nil(err, err)
+
+	req = <-reqCh
+	suite.Assert().Equal(uint32(MaxLockTime), binary.BigEndian.Uint32(req.Extras))
+}
+
+func (suite *UnitTestSuite) TestUnlockCasMismatch() {
+	statusCh := make(chan memd.StatusCode, 1)
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		status := <-statusCh
+		err := translateMemdError(getKvStatusCodeError(status), req)
+		req.tryCallback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: status,
+			},
+		}, err)
+	})
+
+	_, err := crud.Unlock(UnlockOptions{
+		Key: []byte("test"),
+	}, func(res *UnlockResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+
+	// Depending on version the server responds to a cas mismatch with either of these.
+	for _, status := range []memd.StatusCode{memd.StatusLocked, memd.StatusKeyExists} {
+		statusCh <- status
+		errCh := make(chan error, 1)
+		_, err = crud.Unlock(UnlockOptions{
+			Key: []byte("test"),
+			Cas: 1234,
+		}, func(res *UnlockResult, err error) {
+			errCh <- err
+		})
+		suite.Require().Nil(err, err)
+		suite.Assert().True(errors.Is(<-errCh, ErrCasMismatch), status)
+	}
+}
+
+type testMetaOpsCapabilityVerifier struct {
+	status CapabilityStatus
+}
+
+func (v *testMetaOpsCapabilityVerifier) HasBucketCapabilityStatus(capability BucketCapability, status CapabilityStatus) bool {
+	return capability == BucketCapabilityXdcr && status == v.status
+}
+
+func (suite *UnitTestSuite) TestMetaOpsUnsupported() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		suite.T().Errorf("No request should have been sent")
+	})
+	crud.featureVerifier = &testMetaOpsCapabilityVerifier{status: CapabilityStatusUnsupported}
+
+	_, err := crud.GetMeta(GetMetaOptions{Key: []byte("a")}, func(res *GetMetaResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+
+	_, err = crud.SetMeta(SetMetaOptions{Key: []byte("a")}, func(res *SetMetaResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+
+	_, err = crud.DeleteMeta(DeleteMetaOptions{Key: []byte("a")}, func(res *DeleteMetaResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable), err)
+}
+
+func (suite *UnitTestSuite) TestGetMetaAndSetMeta() {
+	var setMetaReq *memdQRequest
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		resp := &memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    100,
+			},
+		}
+		if req.Command == memd.CmdGetMeta {
+			extras := make([]byte, 21)
+			binary.BigEndian.PutUint32(extras[0:], 1)
+			binary.BigEndian.PutUint32(extras[4:], 0x02000000)
+			binary.BigEndian.PutUint32(extras[8:], 1700000000)
+			binary.BigEndian.PutUint64(extras[12:], 7)
+			extras[20] = uint8(memd.DatatypeFlagJSON)
+			resp.Extras = extras
+		} else {
+			setMetaReq = req
+		}
+		req.Callback(resp, req, nil)
+	})
+	crud.featureVerifier = &testMetaOpsCapabilityVerifier{status: CapabilityStatusSupported}
+
+	var getRes *GetMetaResult
+	_, err := crud.GetMeta(GetMetaOptions{Key: []byte("a")}, func(res *GetMetaResult, err error) {
+		suite.Assert().Nil(err, err)
+		getRes = res
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().NotNil(getRes)
+	suite.Assert().Equal(Cas(100), getRes.Cas)
+	suite.Assert().Equal(uint32(1), getRes.Deleted)
+	suite.Assert().Equal(uint32(0x02000000), getRes.Flags)
+	suite.Assert().Equal(uint32(1700000000), getRes.Expiry)
+	suite.Assert().Equal(SeqNo(7), getRes.SeqNo)
+	suite.Assert().Equal(uint8(memd.DatatypeFlagJSON), getRes.Datatype)
+
+	_, err = crud.SetMeta(SetMetaOptions{
+		Key:     []byte("a"),
+		Value:   []byte("{}"),
+		Flags:   getRes.Flags,
+		Expiry:  getRes.Expiry,
+		RevNo:   uint64(getRes.SeqNo),
+		Cas:     getRes.Cas,
+		Options: uint32(memd.UseLwwConflictResolution),
+	}, func(res *SetMetaResult, err error) {
+		suite.Assert().Nil(err, err)
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().NotNil(setMetaReq)
+	suite.Require().Len(setMetaReq.Extras, 30)
+	suite.Assert().Equal(uint32(0x02000000), binary.BigEndian.Uint32(setMetaReq.Extras[0:]))
+	suite.Assert().Equal(uint32(1700000000), binary.BigEndian.Uint32(setMetaReq.Extras[4:]))
+	suite.Assert().Equal(uint64(7), binary.BigEndian.Uint64(setMetaReq.Extras[8:]))
+	suite.Assert().Equal(uint64(100), binary.BigEndian.Uint64(setMetaReq.Extras[16:]))
+	suite.Assert().Equal(uint32(memd.UseLwwConflictResolution), binary.BigEndian.Uint32(setMetaReq.Extras[24:]))
+}
+
+func (suite *UnitTestSuite) TestCasHLCTime() {
+	mutated := time.Date(2023, time.March, 14, 15, 9, 26, 0, time.UTC)
+	// The server replaces the low bits of the time with a logical counter.
+	cas := Cas(uint64(mutated.UnixNano())&^0xffff | 0x2a)
+
+	suite.Assert().WithinDuration(mutated, cas.HLCTime(), 0xffff*time.Nanosecond)
+	suite.Assert().False(cas.HLCTime().After(mutated))
+	suite.Assert().True(time.Unix(0, 0).Equal(Cas(0xffff).HLCTime()))
+}
+
+func (suite *UnitTestSuite) TestServerDuration() {
+	var serverDuration *memd.ServerDurationFrame
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status:              memd.StatusSuccess,
+				Cas:                 100,
+				Extras:              make([]byte, 4),
+				ServerDurationFrame: serverDuration,
+			},
+		}, req, nil)
+	})
+
+	var getRes *GetResult
+	_, err := crud.Get(GetOptions{Key: []byte("a")}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err, err)
+		getRes = res
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().NotNil(getRes)
+	suite.Assert().Zero(getRes.Internal.ServerDuration)
+
+	serverDuration = &memd.ServerDurationFrame{ServerDuration: 150 * time.Microsecond}
+	var setRes *StoreResult
+	_, err = crud.Set(SetOptions{Key: []byte("a"), Value: []byte("{}")}, func(res *StoreResult, err error) {
+		suite.Assert().Nil(err, err)
+		setRes = res
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().NotNil(setRes)
+	suite.Assert().Equal(150*time.Microsecond, setRes.Internal.ServerDuration)
+}
+
+func (suite *UnitTestSuite) TestIdempotentHint() {
+	var idempotent bool
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		idempotent = req.Idempotent()
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    100,
+			},
+		}, req, nil)
+	})
+
+	cb := func(res *StoreResult, err error) {
+		suite.Assert().Nil(err, err)
+	}
+
+	_, err := crud.Replace(ReplaceOptions{Key: []byte("a"), Value: []byte("{}"), Cas: 99}, cb)
+	suite.Require().Nil(err, err)
+	suite.Assert().False(idempotent)
+
+	_, err = crud.Replace(ReplaceOptions{Key: []byte("a"), Value: []byte("{}"), Cas: 99, Idempotent: true}, cb)
+	suite.Require().Nil(err, err)
+	suite.Assert().True(idempotent)
+
+	_, err = crud.Delete(DeleteOptions{Key: []byte("a"), Idempotent: true}, func(res *DeleteResult, err error) {
+		suite.Assert().Nil(err, err)
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().True(idempotent)
+}
+
+func (suite *UnitTestSuite) TestStoreIsJSON() {
+	var datatype uint8
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		datatype = req.Datatype
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    100,
+			},
+		}, req, nil)
+	})
+
+	cb := func(res *StoreResult, err error) {
+		suite.Assert().Nil(err, err)
+	}
+
+	_, err := crud.Set(SetOptions{Key: []byte("a"), Value: []byte("{}")}, cb)
+	suite.Require().Nil(err, err)
+	suite.Assert().Zero(datatype)
+
+	_, err = crud.Set(SetOptions{Key: []byte("a"), Value: []byte("{}"), IsJSON: true}, cb)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(uint8(memd.DatatypeFlagJSON), datatype)
+
+	_, err = crud.Add(AddOptions{Key: []byte("a"), Value: []byte("{}"), IsJSON: true}, cb)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(uint8(memd.DatatypeFlagJSON), datatype)
+
+	// A value which the caller has already compressed keeps its compressed flag.
+	_, err = crud.Replace(ReplaceOptions{
+		Key:      []byte("a"),
+		Value:    snappy.Encode(nil, []byte("{}")),
+		Datatype: uint8(memd.DatatypeFlagCompressed),
+		IsJSON:   true,
+	}, cb)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(uint8(memd.DatatypeFlagJSON|memd.DatatypeFlagCompressed), datatype)
+
+	// Without IsJSON the datatype is left exactly as the caller gave it.
+	_, err = crud.Replace(ReplaceOptions{Key: []byte("a"), Value: []byte("abc"), Datatype: uint8(memd.DatatypeFlagXattrs)}, cb)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(uint8(memd.DatatypeFlagXattrs), datatype)
+}
+
+func (suite *UnitTestSuite) TestAdjoin() {
+	var sent *memdQRequest
+	var status memd.StatusCode
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		sent = req
+		if status != memd.StatusSuccess {
+			req.tryCallback(&memdQResponse{
+				Packet: &memd.Packet{
+					Status: status,
+				},
+			}, translateMemdError(getKvStatusCodeError(status), req))
+			return
+		}
+
+		extras := make([]byte, 16)
+		binary.BigEndian.PutUint64(extras[0:], 0x1234)
+		binary.BigEndian.PutUint64(extras[8:], 12)
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    100,
+				Extras: extras,
+			},
+		}, req, nil)
+	})
+	crud.featureVerifier = &testDurabilityCapabilityVerifier{status: CapabilityStatusSupported}
+
+	var res *AdjoinResult
+	_, err := crud.Append(AdjoinOptions{
+		Key:             []byte("log"),
+		Value:           []byte("entry"),
+		Cas:             99,
+		DurabilityLevel: memd.DurabilityLevelMajority,
+	}, func(result *AdjoinResult, err error) {
+		suite.Assert().Nil(err, err)
+		res = result
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().NotNil(res)
+
+	suite.Assert().Equal(memd.CmdAppend, sent.Command)
+	suite.Assert().Equal(uint64(99), sent.Cas)
+	suite.Assert().Zero(sent.Datatype)
+	suite.Require().NotNil(sent.DurabilityLevelFrame)
+	suite.Assert().Equal(memd.DurabilityLevelMajority, sent.DurabilityLevelFrame.DurabilityLevel)
+
+	suite.Assert().Equal(Cas(100), res.Cas)
+	suite.Assert().Equal(VbUUID(0x1234), res.MutationToken.VbUUID)
+	suite.Assert().Equal(SeqNo(12), res.MutationToken.SeqNo)
+
+	// The document has to exist to be appended or prepended to.
+	status = memd.StatusNotStored
+	var cbErr error
+	_, err = crud.Prepend(AdjoinOptions{
+		Key:   []byte("missing"),
+		Value: []byte("entry"),
+	}, func(result *AdjoinResult, err error) {
+		cbErr = err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(memd.CmdPrepend, sent.Command)
+	suite.Assert().ErrorIs(cbErr, ErrNotStored)
+}
+
+func (suite *UnitTestSuite) TestCounterInitial() {
+	var sent *memdQRequest
+	var status memd.StatusCode
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		sent = req
+		if status != memd.StatusSuccess {
+			req.tryCallback(&memdQResponse{
+				Packet: &memd.Packet{
+					Status: status,
+				},
+			}, translateMemdError(getKvStatusCodeError(status), req))
+			return
+		}
+
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, 15)
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    100,
+				Value:  value,
+			},
+		}, req, nil)
+	})
+
+	var res *CounterResult
+	_, err := crud.Increment(CounterOptions{
+		Key:     []byte("limit"),
+		Delta:   5,
+		Initial: 10,
+		Expiry:  60,
+	}, func(result *CounterResult, err error) {
+		suite.Assert().Nil(err, err)
+		res = result
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().NotNil(res)
+	suite.Assert().Equal(uint64(15), res.Value)
+	suite.Assert().Equal(Cas(100), res.Cas)
+
+	suite.Assert().Equal(memd.CmdIncrement, sent.Command)
+	suite.Assert().Equal(uint64(5), binary.BigEndian.Uint64(sent.Extras[0:]))
+	suite.Assert().Equal(uint64(10), binary.BigEndian.Uint64(sent.Extras[8:]))
+	suite.Assert().Equal(uint32(60), binary.BigEndian.Uint32(sent.Extras[16:]))
+
+	// Without an initial value the document is not created.
+	status = memd.StatusKeyNotFound
+	var cbErr error
+	_, err = crud.Decrement(CounterOptions{
+		Key:     []byte("limit"),
+		Delta:   1,
+		Initial: CounterNoInitial,
+	}, func(result *CounterResult, err error) {
+		cbErr = err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(memd.CmdDecrement, sent.Command)
+	suite.Assert().Equal(uint32(0xFFFFFFFF), binary.BigEndian.Uint32(sent.Extras[16:]))
+	suite.Assert().ErrorIs(cbErr, ErrDocumentNotFound)
+
+	_, err = crud.Increment(CounterOptions{
+		Key:     []byte("limit"),
+		Delta:   1,
+		Initial: CounterNoInitial,
+		Expiry:  60,
+	}, func(result *CounterResult, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *UnitTestSuite) TestTouchExpiry() {
+	var sent *memdQRequest
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		sent = req
+		extras := make([]byte, 16)
+		binary.BigEndian.PutUint64(extras[0:], 0x1234)
+		binary.BigEndian.PutUint64(extras[8:], 7)
+		req.Callback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Cas:    100,
+				Extras: extras,
+			},
+		}, req, nil)
+	})
+
+	touch := func(expiry uint32) *TouchResult {
+		var res *TouchResult
+		_, err := crud.Touch(TouchOptions{Key: []byte("session"), Expiry: expiry}, func(result *TouchResult, err error) {
+			suite.Assert().Nil(err, err)
+			res = result
+		})
+		suite.Require().Nil(err, err)
+		suite.Require().NotNil(res)
+
+		suite.Assert().Equal(memd.CmdTouch, sent.Command)
+		suite.Assert().Nil(sent.Value)
+		suite.Assert().Equal(expiry, binary.BigEndian.Uint32(sent.Extras))
+
+		return res
+	}
+
+	res := touch(3600)
+	suite.Assert().Equal(Cas(100), res.Cas)
+	suite.Assert().Equal(VbUUID(0x1234), res.MutationToken.VbUUID)
+	suite.Assert().Equal(SeqNo(7), res.MutationToken.SeqNo)
+
+	// Absolute expiries are sent unchanged for the server to interpret.
+	touch(relativeExpiryLimit + 1)
+}
+
+func (suite *UnitTestSuite) TestExpiryDuration() {
+	var sent *memdQRequest
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		sent = req
+		req.Callback(nil, req, errTemporaryFailure)
+	})
+
+	longExpiry := 60 * 24 * time.Hour
+
+	type tCase struct {
+		name   string
+		send   func(expiry uint32, expiryDuration time.Duration) error
+		offset int
+	}
+
+	testCases := []tCase{
+		{
+			name: "set",
+			send: func(expiry uint32, expiryDuration time.Duration) error {
+				_, err := crud.Set(SetOptions{Key: []byte("a"), Expiry: expiry, ExpiryDuration: expiryDuration},
+					func(*StoreResult, error) {})
+				return err
+			},
+			offset: 4,
+		},
+		{
+			name: "replace",
+			send: func(expiry uint32, expiryDuration time.Duration) error {
+				_, err := crud.Replace(ReplaceOptions{Key: []byte("a"), Expiry: expiry, ExpiryDuration: expiryDuration},
+					func(*StoreResult, error) {})
+				return err
+			},
+			offset: 4,
+		},
+		{
+			name: "touch",
+			send: func(expiry uint32, expiryDuration time.Duration) error {
+				_, err := crud.Touch(TouchOptions{Key: []byte("a"), Expiry: expiry, ExpiryDuration: expiryDuration},
+					func(*TouchResult, error) {})
+				return err
+			},
+			offset: 0,
+		},
+		{
+			name: "get and touch",
+			send: func(expiry uint32, expiryDuration time.Duration) error {
+				_, err := crud.GetAndTouch(GetAndTouchOptions{Key: []byte("a"), Expiry: expiry, ExpiryDuration: expiryDuration},
+					func(*GetAndTouchResult, error) {})
+				return err
+			},
+			offset: 0,
+		},
+		{
+			name: "increment",
+			send: func(expiry uint32, expiryDuration time.Duration) error {
+				_, err := crud.Increment(CounterOptions{Key: []byte("a"), Expiry: expiry, ExpiryDuration: expiryDuration},
+					func(*CounterResult, error) {})
+				return err
+			},
+			offset: 16,
+		},
+		{
+			name: "mutate in",
+			send: func(expiry uint32, expiryDuration time.Duration) error {
+				_, err := crud.MutateIn(MutateInOptions{
+					Key:            []byte("a"),
+					Expiry:         expiry,
+					ExpiryDuration: expiryDuration,
+					Ops:            []SubDocOp{{Op: memd.SubDocOpDictSet, Path: "b", Value: []byte("1")}},
+				}, func(*MutateInResult, error) {})
+				return err
+			},
+			offset: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			sent = nil
+			suite.Require().Nil(tc.send(0, time.Hour))
+			suite.Require().NotNil(sent)
+			suite.Assert().Equal(uint32(3600), binary.BigEndian.Uint32(sent.Extras[tc.offset:]))
+
+			// Durations of over 30 days are sent as an absolute timestamp.
+			before := time.Now().Add(longExpiry).Unix()
+			suite.Require().Nil(tc.send(0, longExpiry))
+			sentExpiry := int64(binary.BigEndian.Uint32(sent.Extras[tc.offset:]))
+			suite.Assert().GreaterOrEqual(sentExpiry, before)
+			suite.Assert().LessOrEqual(sentExpiry, time.Now().Add(longExpiry).Unix())
+
+			// A duration which rounds down to 0 seconds still expires the document.
+			suite.Require().Nil(tc.send(0, time.Millisecond))
+			suite.Assert().Equal(uint32(1), binary.BigEndian.Uint32(sent.Extras[tc.offset:]))
+
+			sent = nil
+			err := tc.send(3600, time.Hour)
+			suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+			suite.Assert().Nil(sent)
+		})
+	}
+}
+
+func (suite *UnitTestSuite) TestCrudDefaultTimeout() {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		// Never respond so that the request times out.
+	})
+	crud.defaultTimeout = 50 * time.Millisecond
+
+	errCh := make(chan error, 1)
+	_, err := crud.Get(GetOptions{Key: []byte("key")}, func(result *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+
+	select {
+	case err := <-errCh:
+		suite.Assert().ErrorIs(err, ErrTimeout)
+	case <-time.After(5 * time.Second):
+		suite.Fail("request did not time out using the default timeout")
+	}
+
+	// The operation timeout and then an explicit deadline take precedence over the default.
+	start := time.Now()
+	suite.Assert().WithinDuration(start.Add(time.Hour), crud.deadline(time.Time{}, time.Hour), time.Second)
+	explicit := start.Add(time.Second)
+	suite.Assert().Equal(explicit, crud.deadline(explicit, time.Hour))
+
+	// Range scans are also subject to the default.
+	_, err = crud.RangeScanCreate(0, RangeScanCreateOptions{
+		Range: &RangeScanCreateRangeScanConfig{Start: []byte("a"), End: []byte("b")},
+	}, func(res RangeScanCreateResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+
+	select {
+	case err := <-errCh:
+		suite.Assert().ErrorIs(err, ErrTimeout)
+	case <-time.After(5 * time.Second):
+		suite.Fail("range scan did not time out using the default timeout")
+	}
+}
@@ -0,0 +1,75 @@
+package gocbcore
+
+// DCPStreamCheckpoint is the persisted position of a DCP stream for a single vbucket, from which the stream can be
+// resumed after a restart.
+type DCPStreamCheckpoint struct {
+	VbID           uint16 `json:"vb_id"`
+	VbUUID         VbUUID `json:"vb_uuid"`
+	SeqNo          SeqNo  `json:"seqno"`
+	SnapStartSeqNo SeqNo  `json:"snap_start_seqno"`
+	SnapEndSeqNo   SeqNo  `json:"snap_end_seqno"`
+}
+
+// DCPStreamResumeParams are the OpenStream parameters required to resume a stream from a checkpoint.
+type DCPStreamResumeParams struct {
+	VbID           uint16
+	VbUUID         VbUUID
+	StartSeqNo     SeqNo
+	SnapStartSeqNo SeqNo
+	SnapEndSeqNo   SeqNo
+
+	// Rollback indicates that the checkpoint has diverged from the current failover log, the stream will be resumed
+	// from StartSeqNo and any state persisted for mutations after StartSeqNo must be discarded.
+	Rollback bool
+}
+
+// ResumeDCPStreamFromCheckpoint validates a checkpoint against the current failover log for the vbucket, as returned
+// by GetFailoverLog, and returns the parameters to use with OpenStream to resume the stream. If the checkpoint is no
+// longer part of the history of the vbucket then the returned parameters will resume from the point at which the
+// histories diverged, and Rollback will be set.
+// Volatile: This API is subject to change at any time.
+func ResumeDCPStreamFromCheckpoint(checkpoint DCPStreamCheckpoint, failoverLog []FailoverEntry) (DCPStreamResumeParams, error) {
+	if len(failoverLog) == 0 {
+		return DCPStreamResumeParams{}, wrapError(errInvalidArgument, "failover log must not be empty")
+	}
+
+	if checkpoint.SnapStartSeqNo > checkpoint.SeqNo || checkpoint.SeqNo > checkpoint.SnapEndSeqNo {
+		return DCPStreamResumeParams{}, wrapError(errInvalidArgument,
+			"checkpoint seqno must be within its snapshot")
+	}
+
+	// The failover log is ordered newest first, with each entry recording the seqno at which that branch of history
+	// began. The branch of the checkpoint therefore ends where the next newest entry begins.
+	for i, entry := range failoverLog {
+		if entry.VbUUID != checkpoint.VbUUID {
+			continue
+		}
+
+		if i > 0 && checkpoint.SeqNo > failoverLog[i-1].SeqNo {
+			rollbackSeqNo := failoverLog[i-1].SeqNo
+			return DCPStreamResumeParams{
+				VbID:           checkpoint.VbID,
+				VbUUID:         entry.VbUUID,
+				StartSeqNo:     rollbackSeqNo,
+				SnapStartSeqNo: rollbackSeqNo,
+				SnapEndSeqNo:   rollbackSeqNo,
+				Rollback:       true,
+			}, nil
+		}
+
+		return DCPStreamResumeParams{
+			VbID:           checkpoint.VbID,
+			VbUUID:         checkpoint.VbUUID,
+			StartSeqNo:     checkpoint.SeqNo,
+			SnapStartSeqNo: checkpoint.SnapStartSeqNo,
+			SnapEndSeqNo:   checkpoint.SnapEndSeqNo,
+		}, nil
+	}
+
+	// The checkpoint is not from any branch of history that the vbucket knows about, so we have to start again.
+	return DCPStreamResumeParams{
+		VbID:     checkpoint.VbID,
+		VbUUID:   failoverLog[0].VbUUID,
+		Rollback: checkpoint.SeqNo > 0,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package gocbcore
+
+import (
+	"testing"
+)
+
+func (suite *UnitTestSuite) TestResumeDCPStreamFromCheckpoint() {
+	// Newest first, the vbucket moved to 0x3333 at seqno 200 after a failover.
+	failoverLog := []FailoverEntry{
+		{VbUUID: 0x3333, SeqNo: 200},
+		{VbUUID: 0x2222, SeqNo: 100},
+		{VbUUID: 0x1111, SeqNo: 0},
+	}
+
+	tests := []struct {
+		name       string
+		checkpoint DCPStreamCheckpoint
+		expected   DCPStreamResumeParams
+		wantErr    bool
+	}{
+		{
+			name:       "current branch",
+			checkpoint: DCPStreamCheckpoint{VbID: 12, VbUUID: 0x3333, SeqNo: 250, SnapStartSeqNo: 240, SnapEndSeqNo: 260},
+			expected: DCPStreamResumeParams{
+				VbID: 12, VbUUID: 0x3333, StartSeqNo: 250, SnapStartSeqNo: 240, SnapEndSeqNo: 260,
+			},
+		},
+		{
+			name:       "old branch before divergence",
+			checkpoint: DCPStreamCheckpoint{VbID: 12, VbUUID: 0x2222, SeqNo: 150, SnapStartSeqNo: 150, SnapEndSeqNo: 150},
+			expected: DCPStreamResumeParams{
+				VbID: 12, VbUUID: 0x2222, StartSeqNo: 150, SnapStartSeqNo: 150, SnapEndSeqNo: 150,
+			},
+		},
+		{
+			name:       "old branch after divergence",
+			checkpoint: DCPStreamCheckpoint{VbID: 12, VbUUID: 0x2222, SeqNo: 220, SnapStartSeqNo: 210, SnapEndSeqNo: 230},
+			expected: DCPStreamResumeParams{
+				VbID: 12, VbUUID: 0x2222, StartSeqNo: 200, SnapStartSeqNo: 200, SnapEndSeqNo: 200, Rollback: true,
+			},
+		},
+		{
+			name:       "unknown branch",
+			checkpoint: DCPStreamCheckpoint{VbID: 12, VbUUID: 0x9999, SeqNo: 50, SnapStartSeqNo: 50, SnapEndSeqNo: 50},
+			expected:   DCPStreamResumeParams{VbID: 12, VbUUID: 0x3333, Rollback: true},
+		},
+		{
+			name:       "seqno outside snapshot",
+			checkpoint: DCPStreamCheckpoint{VbID: 12, VbUUID: 0x3333, SeqNo: 300, SnapStartSeqNo: 240, SnapEndSeqNo: 260},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		suite.T().Run(tt.name, func(t *testing.T) {
+			params, err := ResumeDCPStreamFromCheckpoint(tt.checkpoint, failoverLog)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResumeDCPStreamFromCheckpoint() error = %v, wanted error = %t", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			suite.Assert().Equal(tt.expected, params)
+		})
+	}
+
+	_, err := ResumeDCPStreamFromCheckpoint(DCPStreamCheckpoint{}, nil)
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+}
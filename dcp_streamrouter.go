@@ -0,0 +1,141 @@
+package gocbcore
+
+import "sync"
+
+// DCPStreamRouter is a StreamObserver which delivers the events from streams opened with a stream ID to the
+// observer registered against that stream ID. This allows a single observer to be passed to OpenStreamWithID for
+// many streams whilst each stream ID has its own handling. Events for stream IDs with no registered observer are
+// dropped.
+// Volatile: This API is subject to change at any time.
+type DCPStreamRouter struct {
+	lock      sync.RWMutex
+	observers map[uint16]StreamObserver
+	logger    *scopedLogger
+}
+
+// NewDCPStreamRouter creates a new DCPStreamRouter with no registered observers. Dropped events are logged to logger,
+// or to the global logger if it is nil.
+// Volatile: This API is subject to change at any time.
+func NewDCPStreamRouter(logger Logger) *DCPStreamRouter {
+	return &DCPStreamRouter{
+		observers: make(map[uint16]StreamObserver),
+		logger:    newScopedLogger(logger),
+	}
+}
+
+// Register sets the observer to receive events for the given stream ID, replacing any existing observer.
+func (r *DCPStreamRouter) Register(streamID uint16, observer StreamObserver) {
+	r.lock.Lock()
+	r.observers[streamID] = observer
+	r.lock.Unlock()
+}
+
+// Unregister removes the observer for the given stream ID.
+func (r *DCPStreamRouter) Unregister(streamID uint16) {
+	r.lock.Lock()
+	delete(r.observers, streamID)
+	r.lock.Unlock()
+}
+
+func (r *DCPStreamRouter) observer(streamID uint16) StreamObserver {
+	r.lock.RLock()
+	observer := r.observers[streamID]
+	r.lock.RUnlock()
+
+	if observer == nil {
+		r.logger.debugf("DCP stream router dropping event for unregistered stream ID %d", streamID)
+	}
+
+	return observer
+}
+
+// SnapshotMarker routes a snapshot marker to the observer for its stream ID.
+func (r *DCPStreamRouter) SnapshotMarker(snapshotMarker DcpSnapshotMarker) {
+	if observer := r.observer(snapshotMarker.StreamID); observer != nil {
+		observer.SnapshotMarker(snapshotMarker)
+	}
+}
+
+// Mutation routes a mutation to the observer for its stream ID.
+func (r *DCPStreamRouter) Mutation(mutation DcpMutation) {
+	if observer := r.observer(mutation.StreamID); observer != nil {
+		observer.Mutation(mutation)
+	}
+}
+
+// Deletion routes a deletion to the observer for its stream ID.
+func (r *DCPStreamRouter) Deletion(deletion DcpDeletion) {
+	if observer := r.observer(deletion.StreamID); observer != nil {
+		observer.Deletion(deletion)
+	}
+}
+
+// Expiration routes an expiration to the observer for its stream ID.
+func (r *DCPStreamRouter) Expiration(expiration DcpExpiration) {
+	if observer := r.observer(expiration.StreamID); observer != nil {
+		observer.Expiration(expiration)
+	}
+}
+
+// End routes a stream end to the observer for its stream ID.
+func (r *DCPStreamRouter) End(end DcpStreamEnd, err error) {
+	if observer := r.observer(end.StreamID); observer != nil {
+		observer.End(end, err)
+	}
+}
+
+// CreateCollection routes a collection creation to the observer for its stream ID.
+func (r *DCPStreamRouter) CreateCollection(creation DcpCollectionCreation) {
+	if observer := r.observer(creation.StreamID); observer != nil {
+		observer.CreateCollection(creation)
+	}
+}
+
+// DeleteCollection routes a collection deletion to the observer for its stream ID.
+func (r *DCPStreamRouter) DeleteCollection(deletion DcpCollectionDeletion) {
+	if observer := r.observer(deletion.StreamID); observer != nil {
+		observer.DeleteCollection(deletion)
+	}
+}
+
+// FlushCollection routes a collection flush to the observer for its stream ID.
+func (r *DCPStreamRouter) FlushCollection(flush DcpCollectionFlush) {
+	if observer := r.observer(flush.StreamID); observer != nil {
+		observer.FlushCollection(flush)
+	}
+}
+
+// CreateScope routes a scope creation to the observer for its stream ID.
+func (r *DCPStreamRouter) CreateScope(creation DcpScopeCreation) {
+	if observer := r.observer(creation.StreamID); observer != nil {
+		observer.CreateScope(creation)
+	}
+}
+
+// DeleteScope routes a scope deletion to the observer for its stream ID.
+func (r *DCPStreamRouter) DeleteScope(deletion DcpScopeDeletion) {
+	if observer := r.observer(deletion.StreamID); observer != nil {
+		observer.DeleteScope(deletion)
+	}
+}
+
+// ModifyCollection routes a collection modification to the observer for its stream ID.
+func (r *DCPStreamRouter) ModifyCollection(modification DcpCollectionModification) {
+	if observer := r.observer(modification.StreamID); observer != nil {
+		observer.ModifyCollection(modification)
+	}
+}
+
+// OSOSnapshot routes an OSO snapshot marker to the observer for its stream ID.
+func (r *DCPStreamRouter) OSOSnapshot(snapshot DcpOSOSnapshot) {
+	if observer := r.observer(snapshot.StreamID); observer != nil {
+		observer.OSOSnapshot(snapshot)
+	}
+}
+
+// SeqNoAdvanced routes a seqno advanced event to the observer for its stream ID.
+func (r *DCPStreamRouter) SeqNoAdvanced(seqNoAdvanced DcpSeqNoAdvanced) {
+	if observer := r.observer(seqNoAdvanced.StreamID); observer != nil {
+		observer.SeqNoAdvanced(seqNoAdvanced)
+	}
+}
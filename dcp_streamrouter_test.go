@@ -0,0 +1,53 @@
+package gocbcore
+
+import (
+	"errors"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+func (suite *UnitTestSuite) TestDCPStreamRouter() {
+	newObserver := func() *TestStreamObserver {
+		so := &TestStreamObserver{
+			lastSeqno: make(map[uint16]uint64),
+			snapshots: make(map[uint16]DcpSnapshotMarker),
+		}
+		so.newCounter()
+		return so
+	}
+
+	router := NewDCPStreamRouter(nil)
+	first := newObserver()
+	second := newObserver()
+	router.Register(1, first)
+	router.Register(2, second)
+
+	router.Mutation(DcpMutation{StreamID: 1, Key: []byte("first")})
+	router.Mutation(DcpMutation{StreamID: 2, Key: []byte("second")})
+	router.Deletion(DcpDeletion{StreamID: 2, Key: []byte("deleted")})
+	router.Mutation(DcpMutation{StreamID: 3, Key: []byte("unknown")})
+
+	suite.Require().Len(first.counter.mutations, 1)
+	suite.Assert().Contains(first.counter.mutations, "first")
+	suite.Assert().Empty(first.counter.deletions)
+	suite.Require().Len(second.counter.mutations, 1)
+	suite.Assert().Contains(second.counter.mutations, "second")
+	suite.Assert().Contains(second.counter.deletions, "deleted")
+
+	router.Unregister(1)
+	router.Mutation(DcpMutation{StreamID: 1, Key: []byte("after")})
+	suite.Assert().Len(first.counter.mutations, 1)
+
+	second.endWg.Add(1)
+	router.End(DcpStreamEnd{StreamID: 2}, nil)
+	second.endWg.Wait()
+}
+
+func (suite *UnitTestSuite) TestDCPOpenStreamIDNotEnabled() {
+	dcp := newDcpComponent(nil, false)
+
+	_, err := dcp.OpenStream(0, memd.DcpStreamAddFlag(0), 0, 0, 0, 0, 0, NewDCPStreamRouter(nil), OpenStreamOptions{
+		StreamOptions: &OpenStreamStreamOptions{StreamID: 1},
+	}, func([]FailoverEntry, error) {})
+	suite.Require().True(errors.Is(err, ErrStreamIDNotEnabled), err)
+}
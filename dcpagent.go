@@ -31,6 +31,7 @@ type DCPAgent struct {
 	auth                   AuthProvider
 	authMechanisms         []AuthMechanism
 	tlsConfig              *dynTLSConfig
+	tlsSettings            tlsSettings
 
 	srvDetails *srvDetails
 
@@ -150,13 +151,18 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 		shutdownSig: make(chan struct{}),
 	}
 
-	tlsConfig, err := setupTLSConfig(config.SeedConfig.MemdAddrs, config.SecurityConfig)
+	if err := validateHTTPRetryJitter(config.ConfigPollerConfig.HTTPRetryJitter); err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := setupTLSConfig(config.SeedConfig.MemdAddrs, config.SecurityConfig, nil)
 	if err != nil {
 		return nil, err
 	}
 	c.tlsConfig = tlsConfig
+	c.tlsSettings = config.SecurityConfig.tlsSettings()
 
-	c.authMechanisms = authMechanismsFromConfig(config.SecurityConfig.AuthMechanisms, config.SecurityConfig.UseTLS)
+	c.authMechanisms = authMechanismsFromConfig(config.SecurityConfig.AuthMechanisms, config.SecurityConfig.UseTLS, nil)
 
 	circuitBreakerConfig := CircuitBreakerConfig{
 		Enabled: false,
@@ -243,6 +249,7 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			DCPQueueSize:         dcpQueueSize,
 			CompressionMinSize:   compressionMinSize,
 			CompressionMinRatio:  compressionMinRatio,
+			CompressionFilter:    config.CompressionConfig.Filter,
 			DisableDecompression: disableDecompression,
 			NoTLSSeedNode:        config.SecurityConfig.NoTLSSeedNode,
 			ConnBufSize:          kvBufferSize,
@@ -302,6 +309,7 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 		c.cfgManager,
 		&httpClientMux{tlsConfig: tlsConfig, auth: config.SecurityConfig.Auth},
 		config.SecurityConfig.NoTLSSeedNode,
+		nil,
 	)
 	c.http = newHTTPComponent(
 		httpComponentProps{
@@ -324,8 +332,10 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			httpPollerProperties{
 				httpComponent:        c.http,
 				confHTTPRetryDelay:   confHTTPRetryDelay,
+				confHTTPRetryJitter:  config.ConfigPollerConfig.HTTPRetryJitter,
 				confHTTPRedialPeriod: confHTTPRedialPeriod,
 				confHTTPMaxWait:      confHTTPMaxWait,
+				confHTTPMaxFailures:  config.ConfigPollerConfig.HTTPMaxConsecutiveFailures,
 			}, c.cfgManager)
 	} else {
 		var httpPoller *httpConfigController
@@ -335,8 +345,10 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 				httpPollerProperties{
 					httpComponent:        c.http,
 					confHTTPRetryDelay:   confHTTPRetryDelay,
+					confHTTPRetryJitter:  config.ConfigPollerConfig.HTTPRetryJitter,
 					confHTTPRedialPeriod: confHTTPRedialPeriod,
 					confHTTPMaxWait:      confHTTPMaxWait,
+					confHTTPMaxFailures:  config.ConfigPollerConfig.HTTPMaxConsecutiveFailures,
 				},
 				c.httpMux,
 				c.cfgManager,
@@ -347,8 +359,9 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			cccpFetcher := newCCCPConfigFetcher(confCccpMaxWait)
 			cccpPoller = newCCCPConfigController(
 				cccpPollerProperties{
-					cccpConfigFetcher:  cccpFetcher,
-					confCccpPollPeriod: confCccpPollPeriod,
+					cccpConfigFetcher:    cccpFetcher,
+					confCccpPollPeriod:   confCccpPollPeriod,
+					confCccpRetryBackoff: defaultCCCPRetryBackoff(confCccpPollPeriod, confCccpMaxWait),
 				},
 				c.kvMux,
 				c.cfgManager,
@@ -362,11 +375,12 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			httpPoller,
 			c.cfgManager,
 			c.isPollingFallbackError,
+			nil,
 		)
 	}
 	c.pollerController = poller
 
-	c.diagnostics = newDiagnosticsComponent(c.kvMux, nil, nil, c.bucketName, newFailFastRetryStrategy(), c.pollerController)
+	c.diagnostics = newDiagnosticsComponent(c.kvMux, nil, nil, c.bucketName, newFailFastRetryStrategy(), c.pollerController, nil)
 	c.dcp = newDcpComponent(c.kvMux, config.DCPConfig.UseStreamID)
 
 	c.dialer.AddBootstrapFailHandler(c.diagnostics)
@@ -436,6 +450,23 @@ func (agent *DCPAgent) OpenStream(vbID uint16, flags memd.DcpStreamAddFlag, vbUU
 	return agent.dcp.OpenStream(vbID, flags, vbUUID, startSeqNo, endSeqNo, snapStartSeqNo, snapEndSeqNo, evtHandler, opts, cb)
 }
 
+// OpenStreamWithID opens a stream with the given stream ID, filtered to the scope or collections in filter. This
+// allows multiple differently filtered streams to be open against the same vbucket, with the events for each
+// delivered to their own observer, see DCPStreamRouter for delivering events from many streams to one observer.
+// A nil filter opens an unfiltered stream. Stream IDs must be enabled via DCPConfig.UseStreamID and filtering
+// requires collections to be enabled via IoConfig.UseCollections.
+func (agent *DCPAgent) OpenStreamWithID(vbID uint16, streamID uint16, flags memd.DcpStreamAddFlag, vbUUID VbUUID,
+	startSeqNo, endSeqNo, snapStartSeqNo, snapEndSeqNo SeqNo, filter *OpenStreamFilterOptions,
+	evtHandler StreamObserver, cb OpenStreamCallback) (PendingOp, error) {
+	return agent.dcp.OpenStream(vbID, flags, vbUUID, startSeqNo, endSeqNo, snapStartSeqNo, snapEndSeqNo, evtHandler,
+		OpenStreamOptions{
+			FilterOptions: filter,
+			StreamOptions: &OpenStreamStreamOptions{
+				StreamID: streamID,
+			},
+		}, cb)
+}
+
 // CloseStream shuts down an open stream for the specified VBucket.
 func (agent *DCPAgent) CloseStream(vbID uint16, opts CloseStreamOptions, cb CloseStreamCallback) (PendingOp, error) {
 	return agent.dcp.CloseStream(vbID, opts, cb)
@@ -510,7 +541,7 @@ func (agent *DCPAgent) ReconfigureSecurity(opts ReconfigureSecurityOptions) erro
 		if opts.TLSRootCAProvider == nil {
 			return wrapError(errInvalidArgument, "must provide TLSRootCAProvider when UseTLS is true")
 		}
-		tlsConfig = createTLSConfig(auth, opts.TLSRootCAProvider)
+		tlsConfig = createTLSConfig(auth, opts.TLSRootCAProvider, agent.tlsSettings)
 	}
 
 	agent.auth = auth
@@ -557,7 +588,7 @@ func (agent *DCPAgent) resetConfig() {
 }
 
 func (agent *DCPAgent) onCCCPNoConfigFromAnyNode(err error) {
-	onCCCPNoConfigFromAnyNode(agent, err)
+	onCCCPNoConfigFromAnyNode(agent, nil, err)
 }
 
 func (agent *DCPAgent) stopped() <-chan struct{} {
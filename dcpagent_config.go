@@ -37,10 +37,13 @@ type DCPAgentConfig struct {
 type DCPConfig struct {
 	AgentPriority    DcpAgentPriority
 	UseChangeStreams bool
-	UseExpiryOpcode  bool
-	UseStreamID      bool
-	UseOSOBackfill   bool
-	BackfillOrder    DCPBackfillOrder
+	// UseExpiryOpcode enables expirations to be reported to StreamObserver.Expiration rather than as deletions.
+	UseExpiryOpcode bool
+	// UseStreamID enables stream IDs, allowing multiple streams to be opened against the same vbucket. Each stream
+	// is typically given a different collection or scope filter, which requires IoConfig.UseCollections.
+	UseStreamID    bool
+	UseOSOBackfill bool
+	BackfillOrder  DCPBackfillOrder
 
 	BufferSize                   int
 	DisableBufferAcknowledgement bool
@@ -114,6 +117,8 @@ func (config *DCPAgentConfig) redacted() interface{} {
 // Supported options are:
 //
 //	ca_cert_path (string) - Specifies the path to a CA certificate.
+//	tls_cipher_suites (string) - A comma separated list of IANA names of the TLS 1.2 cipher suites to allow.
+//	tls_min_version (string) - The minimum TLS version to negotiate (1.2, 1.3).
 //	network (string) - The network type to use.
 //	kv_connect_timeout (duration) - Maximum period to attempt to connect to cluster in ms.
 //	config_poll_interval (duration) - Period to wait between CCCP config polling in ms.
@@ -134,6 +139,7 @@ func (config *DCPAgentConfig) redacted() interface{} {
 //	idle_http_connection_timeout (duration) - Maximum length of time for an idle connection to stay in the pool in ms.
 //	http_redial_period (duration) - The maximum length of time for the HTTP poller to stay connected before reconnecting.
 //	http_retry_delay (duration) - The length of time to wait between HTTP poller retries if connecting fails.
+//	http_retry_jitter (float) - The fraction, between 0 and 1, by which to randomly vary the HTTP poller retry delay.
 func (config *DCPAgentConfig) FromConnStr(connStr string) error {
 	baseSpec, err := connstr.Parse(connStr)
 	if err != nil {
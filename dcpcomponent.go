@@ -25,6 +25,10 @@ func newDcpComponent(kvMux *kvMux, streamIDEnabled bool) *dcpComponent {
 func (dcp *dcpComponent) OpenStream(vbID uint16, flags memd.DcpStreamAddFlag, vbUUID VbUUID, startSeqNo,
 	endSeqNo, snapStartSeqNo, snapEndSeqNo SeqNo, evtHandler StreamObserver, opts OpenStreamOptions,
 	cb OpenStreamCallback) (PendingOp, error) {
+	if opts.StreamOptions != nil && !dcp.streamIDEnabled {
+		return nil, errStreamIDNotEnabled
+	}
+
 	var req *memdQRequest
 	var openHandled uint32
 	handler := func(resp *memdQResponse, _ *memdQRequest, err error) {
@@ -20,6 +20,9 @@ const (
 
 	// PingStateError indicates that the ping request to an endpoint encountered an error.
 	PingStateError PingState = 3
+
+	// PingStateAbsent indicates that the requested service is not present on the cluster.
+	PingStateAbsent PingState = 4
 )
 
 // EndpointState is the current connection state of an endpoint.
@@ -93,6 +96,9 @@ type PingOptions struct {
 	MgmtDeadline time.Time
 	ServiceTypes []ServiceType
 
+	// Deadline is used as the deadline for any service which does not have its own deadline set.
+	Deadline time.Time
+
 	// Internal: This should never be used and is not supported.
 	User string
 
@@ -120,12 +126,28 @@ type MemdConnInfo struct {
 	State        EndpointState
 }
 
+// MemdEndpointInfo summarises the connection pool for a single memcached endpoint.
+type MemdEndpointInfo struct {
+	// NumConns is the number of connections currently open in the pool.
+	NumConns int
+	// NumBusyConns is the number of open connections which have requests in flight.
+	NumBusyConns int
+	// LastActivity is the most recent activity seen on any connection in the pool.
+	LastActivity time.Time
+}
+
 // DiagnosticInfo is returned by the Diagnostics method and includes
 // information about the overall health of the clients connections.
 type DiagnosticInfo struct {
 	ConfigRev int64
 	MemdConns []MemdConnInfo
-	State     ClusterState
+	// MemdEndpoints summarises the connection pool for each memcached endpoint, keyed by endpoint address.
+	MemdEndpoints map[string]MemdEndpointInfo
+	State         ClusterState
+	// ClockSkew is the clock skew detected between the client and each server, keyed by server address.
+	// A positive value indicates that the client clock is ahead of the server. This is only populated
+	// when clock skew detection is enabled via KVConfig.ClockSkewCheckInterval.
+	ClockSkew map[string]time.Duration
 }
 
 // ClusterState is used to describe the state of a cluster.
@@ -151,6 +173,9 @@ type waitUntilOp struct {
 	httpCancel context.CancelFunc
 	closed     bool
 
+	readyServices  []ServiceType
+	absentServices []ServiceType
+
 	retryLock    sync.Mutex
 	retries      uint32
 	retryReasons []RetryReason
@@ -211,17 +236,31 @@ func (wuo *waitUntilOp) Cancel() {
 	wuo.cancel(errRequestCanceled)
 }
 
-func (wuo *waitUntilOp) handledOneLocked() {
+func (wuo *waitUntilOp) handledOneLocked(service ServiceType, absent bool) {
+	if absent {
+		wuo.absentServices = append(wuo.absentServices, service)
+	} else {
+		wuo.readyServices = append(wuo.readyServices, service)
+	}
+
 	remaining := atomic.AddInt32(&wuo.remaining, -1)
 	if remaining == 0 {
 		wuo.timer.Stop()
 		wuo.httpCancel()
-		wuo.callback(&WaitUntilReadyResult{}, nil)
+		wuo.callback(&WaitUntilReadyResult{
+			ReadyServices:  wuo.readyServices,
+			AbsentServices: wuo.absentServices,
+		}, nil)
 	}
 }
 
 // WaitUntilReadyResult encapsulates the result of a WaitUntilReady operation.
 type WaitUntilReadyResult struct {
+	// ReadyServices are the services which reached the desired state.
+	ReadyServices []ServiceType
+	// AbsentServices are the services which were not waited for because they are not present in the cluster, this
+	// only occurs when no services were specified in WaitUntilReadyOptions.
+	AbsentServices []ServiceType
 }
 
 // WaitUntilReadyOptions encapsulates the parameters for a WaitUntilReady operation.
@@ -21,6 +21,7 @@ type diagnosticsComponent struct {
 	bucket              string
 	defaultRetry        RetryStrategy
 	pollerErrorProvider pollerErrorProvider
+	logger              *scopedLogger
 
 	// preConfigBootstrapError must only be used for checking for bootstrap errors when a config has not yet been seen.
 	preConfigBootstrapError     error
@@ -28,7 +29,7 @@ type diagnosticsComponent struct {
 }
 
 func newDiagnosticsComponent(kvMux *kvMux, httpMux *httpMux, httpComponent *httpComponent, bucket string,
-	defaultRetry RetryStrategy, pollerErrorProvider pollerErrorProvider) *diagnosticsComponent {
+	defaultRetry RetryStrategy, pollerErrorProvider pollerErrorProvider, logger *scopedLogger) *diagnosticsComponent {
 	return &diagnosticsComponent{
 		kvMux:               kvMux,
 		httpMux:             httpMux,
@@ -36,6 +37,7 @@ func newDiagnosticsComponent(kvMux *kvMux, httpMux *httpMux, httpComponent *http
 		httpComponent:       httpComponent,
 		defaultRetry:        defaultRetry,
 		pollerErrorProvider: pollerErrorProvider,
+		logger:              logger,
 	}
 }
 
@@ -79,7 +81,7 @@ func (dc *diagnosticsComponent) pingKV(ctx context.Context, interval time.Durati
 				return
 			}
 
-			logErrorf("failed to get pipeline snapshot")
+			dc.logger.errorf("failed to get pipeline snapshot")
 
 			select {
 			case <-ctx.Done():
@@ -291,6 +293,7 @@ func (dc *diagnosticsComponent) pingHTTP(ctx context.Context, service ServiceTyp
 						Error: errServiceNotAvailable,
 						Scope: op.bucketName,
 						ID:    uuid.New().String(),
+						State: PingStateAbsent,
 					})
 				}
 				op.handledOneLocked(clientMux.revID)
@@ -329,7 +332,7 @@ func (dc *diagnosticsComponent) pingHTTP(ctx context.Context, service ServiceTyp
 							state = PingStateError
 							b, pErr := ioutil.ReadAll(resp.Body)
 							if pErr != nil {
-								logDebugf("Failed to read response body for ping: %v", pErr)
+								dc.logger.debugf("Failed to read response body for ping: %v", pErr)
 							}
 
 							err = errors.New(string(b))
@@ -396,6 +399,17 @@ func (dc *diagnosticsComponent) Ping(opts PingOptions, cb PingCallback) (Pending
 
 	ignoreMissingServices = ignoreMissingServices || opts.ignoreMissingServices
 
+	if !opts.Deadline.IsZero() {
+		for _, deadline := range []*time.Time{
+			&opts.KVDeadline, &opts.CapiDeadline, &opts.N1QLDeadline, &opts.FtsDeadline, &opts.CbasDeadline,
+			&opts.MgmtDeadline,
+		} {
+			if deadline.IsZero() {
+				*deadline = opts.Deadline
+			}
+		}
+	}
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 
 	op := &pingOp{
@@ -442,8 +456,11 @@ func (dc *diagnosticsComponent) Diagnostics(opts DiagnosticsOptions) (*Diagnosti
 		}
 
 		var conns []MemdConnInfo
+		endpoints := make(map[string]MemdEndpointInfo)
 
 		iter.Iterate(0, func(pipeline *memdPipeline) bool {
+			endpoint := endpoints[pipeline.Address()]
+
 			pipeline.clientsLock.Lock()
 			for _, pipecli := range pipeline.clients {
 				localAddr := ""
@@ -458,6 +475,14 @@ func (dc *diagnosticsComponent) Diagnostics(opts DiagnosticsOptions) (*Diagnosti
 					if lastActivityUs != 0 {
 						lastActivity = time.Unix(0, lastActivityUs)
 					}
+
+					endpoint.NumConns++
+					if pipecli.client.NumInFlightOps() > 0 {
+						endpoint.NumBusyConns++
+					}
+					if lastActivity.After(endpoint.LastActivity) {
+						endpoint.LastActivity = lastActivity
+					}
 				}
 				pipecli.lock.Unlock()
 
@@ -474,6 +499,10 @@ func (dc *diagnosticsComponent) Diagnostics(opts DiagnosticsOptions) (*Diagnosti
 				conns = append(conns, conn)
 			}
 			pipeline.clientsLock.Unlock()
+
+			if pipeline.Address() != "" {
+				endpoints[pipeline.Address()] = endpoint
+			}
 			return false
 		})
 
@@ -498,9 +527,10 @@ func (dc *diagnosticsComponent) Diagnostics(opts DiagnosticsOptions) (*Diagnosti
 		}
 		if iter.RevID() == endIter.RevID() {
 			return &DiagnosticInfo{
-				ConfigRev: iter.RevID(),
-				MemdConns: conns,
-				State:     state,
+				ConfigRev:     iter.RevID(),
+				MemdConns:     conns,
+				MemdEndpoints: endpoints,
+				State:         state,
 			}, nil
 		}
 	}
@@ -515,7 +545,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 				return
 			}
 
-			logErrorf("failed to get pipeline snapshot: %v", err)
+			dc.logger.errorf("failed to get pipeline snapshot: %v", err)
 
 			shouldRetry, until := retryOrchMaybeRetry(op, NoPipelineSnapshotRetryReason)
 			if !shouldRetry {
@@ -537,7 +567,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 			// We've not seen a config so let's see if we've been informed about any errors.
 			dc.preConfigBootstrapErrorLock.Lock()
 			connectErr = dc.preConfigBootstrapError
-			logDebugf("Bootstrap error found before config seen: %v", connectErr)
+			dc.logger.debugf("Bootstrap error found before config seen: %v", connectErr)
 			dc.preConfigBootstrapErrorLock.Unlock()
 
 			// If there's no error appearing from the pipeline client then let's check the poller
@@ -546,13 +576,13 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 
 				// We don't care about timeouts, they don't tell us anything we want to know.
 				if pollerErr != nil && !errors.Is(pollerErr, ErrTimeout) {
-					logDebugf("Error found in poller before config seen: %v", pollerErr)
+					dc.logger.debugf("Error found in poller before config seen: %v", pollerErr)
 					connectErr = pollerErr
 				}
 			}
 
 			if connectErr == nil {
-				logDebugf("No config seen yet in kv muxer but no errors found.")
+				dc.logger.debugf("No config seen yet in kv muxer but no errors found.")
 			}
 		} else if revID > -1 {
 			expected := iter.NumPipelines()
@@ -576,7 +606,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 
 					err := cli.Error()
 					if err != nil {
-						logDebugf("Error found in client after config seen: %v", err)
+						dc.logger.debugf("Error found in client after config seen: %v", err)
 						connectErr = err
 
 						// If the desired state is degraded then we need to keep trying as a different client or pipeline
@@ -596,7 +626,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 
 				// We don't care about timeouts, they don't tell us anything we want to know.
 				if pollerErr != nil && !errors.Is(pollerErr, ErrTimeout) {
-					logDebugf("Error found in poller after config seen: %v", pollerErr)
+					dc.logger.debugf("Error found in poller after config seen: %v", pollerErr)
 					connectErr = pollerErr
 				}
 			}
@@ -605,7 +635,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 			case ClusterStateDegraded:
 				if connected > 0 {
 					op.lock.Lock()
-					op.handledOneLocked()
+					op.handledOneLocked(MemdService, false)
 					op.lock.Unlock()
 
 					return
@@ -613,7 +643,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 			case ClusterStateOnline:
 				if connected == expected {
 					op.lock.Lock()
-					op.handledOneLocked()
+					op.handledOneLocked(MemdService, false)
 					op.lock.Unlock()
 
 					return
@@ -683,7 +713,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 			// We've not seen a config so let's see if we've been informed about any errors.
 			dc.preConfigBootstrapErrorLock.Lock()
 			connectErr = dc.preConfigBootstrapError
-			logDebugf("Bootstrap error found before config seen: %v", connectErr)
+			dc.logger.debugf("Bootstrap error found before config seen: %v", connectErr)
 			dc.preConfigBootstrapErrorLock.Unlock()
 
 			// If there's no error appearing from the pipeline client then let's check the poller
@@ -692,13 +722,13 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 
 				// We don't care about timeouts, they don't tell us anything we want to know.
 				if pollerErr != nil && !errors.Is(pollerErr, ErrTimeout) {
-					logDebugf("Error found in poller before config seen: %v", pollerErr)
+					dc.logger.debugf("Error found in poller before config seen: %v", pollerErr)
 					connectErr = pollerErr
 				}
 			}
 
 			if connectErr == nil {
-				logDebugf("No config seen yet in http muxer but no errors found.")
+				dc.logger.debugf("No config seen yet in http muxer but no errors found.")
 			}
 		} else {
 			var epList []routeEndpoint
@@ -741,7 +771,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 								return
 							}
 
-							logDebugf("Error returned for HTTP request for service %d: %v", service, err)
+							dc.logger.debugf("Error returned for HTTP request for service %d: %v", service, err)
 
 							if desiredState == ClusterStateOnline {
 								// Cancel this run entirely, we can't satisfy the requirements
@@ -751,10 +781,10 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 						}
 						err = resp.Body.Close()
 						if err != nil {
-							logDebugf("Failed to close response body: %s", err)
+							dc.logger.debugf("Failed to close response body: %s", err)
 						}
 						if resp.StatusCode != 200 {
-							logDebugf("Non-200 status code returned for HTTP request for service %d: %d", service, resp.StatusCode)
+							dc.logger.debugf("Non-200 status code returned for HTTP request for service %d: %d", service, resp.StatusCode)
 							if desiredState == ClusterStateOnline {
 								// Cancel this run entirely, we can't satisfy the requirements
 								cancel()
@@ -776,7 +806,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 			case ClusterStateDegraded:
 				if !forceWait && len(epList) == 0 {
 					op.lock.Lock()
-					op.handledOneLocked()
+					op.handledOneLocked(service, true)
 					op.lock.Unlock()
 
 					return
@@ -784,7 +814,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 				// If there are no entries in the epList then the service is not online and so cannot be ready.
 				if len(epList) > 0 && atomic.LoadUint32(&connected) > 0 {
 					op.lock.Lock()
-					op.handledOneLocked()
+					op.handledOneLocked(service, false)
 					op.lock.Unlock()
 
 					return
@@ -792,7 +822,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 			case ClusterStateOnline:
 				if !forceWait && len(epList) == 0 {
 					op.lock.Lock()
-					op.handledOneLocked()
+					op.handledOneLocked(service, true)
 					op.lock.Unlock()
 
 					return
@@ -800,7 +830,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 				// If there are no entries in the epList then the service is not online and so cannot be ready.
 				if len(epList) > 0 && atomic.LoadUint32(&connected) == uint32(len(epList)) {
 					op.lock.Lock()
-					op.handledOneLocked()
+					op.handledOneLocked(service, false)
 					op.lock.Unlock()
 
 					return
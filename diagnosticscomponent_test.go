@@ -0,0 +1,74 @@
+package gocbcore
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *UnitTestSuite) TestPingMissingServiceIsAbsent() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	mux := newHTTPMux(CircuitBreakerConfig{}, cfgMgr, &httpClientMux{
+		revID: 1,
+		mgmtEpList: []routeEndpoint{
+			{Address: "http://10.112.210.101:8091"},
+		},
+	}, false, nil)
+	dc := newDiagnosticsComponent(nil, mux, nil, "", nil, nil, nil)
+
+	resCh := make(chan *PingResult, 1)
+	_, err := dc.Ping(PingOptions{
+		ServiceTypes: []ServiceType{N1qlService},
+		Deadline:     time.Now().Add(time.Second),
+	}, func(res *PingResult, err error) {
+		suite.Assert().Nil(err, err)
+		resCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	var res *PingResult
+	select {
+	case res = <-resCh:
+	case <-time.After(time.Second):
+		suite.T().Fatalf("Timed out waiting for ping result")
+	}
+
+	suite.Assert().Equal(int64(1), res.ConfigRev)
+	suite.Require().Len(res.Services[N1qlService], 1)
+	suite.Assert().Equal(PingStateAbsent, res.Services[N1qlService][0].State)
+	suite.Assert().ErrorIs(res.Services[N1qlService][0].Error, ErrServiceNotAvailable)
+}
+
+func (suite *UnitTestSuite) TestWaitUntilReadyReportsAbsentServices() {
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	mux := newHTTPMux(CircuitBreakerConfig{}, cfgMgr, &httpClientMux{
+		revID: 1,
+		mgmtEpList: []routeEndpoint{
+			{Address: "http://10.112.210.101:8091"},
+		},
+	}, false, nil)
+	dc := newDiagnosticsComponent(nil, mux, nil, "", newFailFastRetryStrategy(), nil, nil)
+
+	resCh := make(chan *WaitUntilReadyResult, 1)
+	_, err := dc.WaitUntilReady(time.Now().Add(time.Second), false, WaitUntilReadyOptions{
+		ServiceTypes: []ServiceType{N1qlService, FtsService},
+	}, func(res *WaitUntilReadyResult, err error) {
+		suite.Assert().Nil(err, err)
+		resCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	var res *WaitUntilReadyResult
+	select {
+	case res = <-resCh:
+	case <-time.After(time.Second):
+		suite.T().Fatalf("Timed out waiting for wait until ready result")
+	}
+
+	suite.Assert().Empty(res.ReadyServices)
+	suite.Assert().ElementsMatch([]ServiceType{N1qlService, FtsService}, res.AbsentServices)
+}
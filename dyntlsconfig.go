@@ -1,20 +1,57 @@
 package gocbcore
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"net"
 )
 
+// tlsSettings are the user specified settings applied to every TLS connection, these are retained by the agents so
+// that they survive the TLS config being recreated.
+type tlsSettings struct {
+	CipherSuites []uint16
+	MinVersion   uint16
+
+	PinnedCertFingerprints [][32]byte
+}
+
+// verifyPinnedCertFn returns a function to verify that the leaf certificate presented by the server is one of the
+// pinned certificates, or nil if no certificates are pinned.
+func (settings tlsSettings) verifyPinnedCertFn() func([][]byte, [][]*x509.Certificate) error {
+	if len(settings.PinnedCertFingerprints) == 0 {
+		return nil
+	}
+
+	pinned := make(map[[32]byte]struct{}, len(settings.PinnedCertFingerprints))
+	for _, fingerprint := range settings.PinnedCertFingerprints {
+		pinned[fingerprint] = struct{}{}
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return wrapError(errInvalidCertificate, "server presented no certificate")
+		}
+
+		if _, ok := pinned[sha256.Sum256(rawCerts[0])]; !ok {
+			return wrapError(errInvalidCertificate, "server certificate does not match any pinned fingerprint")
+		}
+
+		return nil
+	}
+}
+
 type dynTLSConfig struct {
 	BaseConfig *tls.Config
 	Provider   func() *x509.CertPool
+	Auth       AuthProvider
 }
 
 func (config dynTLSConfig) Clone() *dynTLSConfig {
 	return &dynTLSConfig{
 		BaseConfig: config.BaseConfig.Clone(),
 		Provider:   config.Provider,
+		Auth:       config.Auth,
 	}
 }
 
@@ -36,11 +73,41 @@ func (config dynTLSConfig) MakeForHost(serverName string) (*tls.Config, error) {
 	return newConfig, nil
 }
 
-func (config dynTLSConfig) MakeForAddr(addr string) (*tls.Config, error) {
+// MakeForAddr creates a tls config for connecting to the given address, any client certificate is requested from
+// the auth provider with the service and address of the connection.
+func (config dynTLSConfig) MakeForAddr(addr string, service ServiceType) (*tls.Config, error) {
 	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
 	}
 
-	return config.MakeForHost(host)
+	newConfig, err := config.MakeForHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Auth != nil {
+		newConfig.GetClientCertificate = getClientCertificateFn(config.Auth, AuthCertRequest{
+			Service:  service,
+			Endpoint: addr,
+		})
+	}
+
+	return newConfig, nil
+}
+
+func getClientCertificateFn(auth AuthProvider,
+	req AuthCertRequest) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		cert, err := auth.Certificate(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if cert == nil {
+			return &tls.Certificate{}, nil
+		}
+
+		return cert, nil
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/couchbase/gocbcore/v10/memd"
 )
@@ -65,6 +66,17 @@ func (errMgr *errMapComponent) ShouldRetry(status memd.StatusCode) bool {
 	return false
 }
 
+// RetryDelay returns the delay which the error map specifies should be waited before retrying a request which
+// failed with the given status, or zero if there is no specification.
+func (errMgr *errMapComponent) RetryDelay(status memd.StatusCode, retryCount uint32) time.Duration {
+	kvErrData := errMgr.getKvErrMapData(status)
+	if kvErrData == nil {
+		return 0
+	}
+
+	return kvErrData.Retry.CalculateRetryDelay(retryCount)
+}
+
 func (errMgr *errMapComponent) EnhanceKvError(err error, resp *memdQResponse, req *memdQRequest) error {
 	enhErr := &KeyValueError{
 		InnerError: err,
@@ -88,7 +100,9 @@ func (errMgr *errMapComponent) EnhanceKvError(err error, resp *memdQResponse, re
 		enhErr.Internal.ResourceUnits = req.ResourceUnits()
 	}
 
-	if resp != nil {
+	// Without XERROR negotiated the server won't send us any error context, in which case we fall back
+	// to mapping the error from the status code alone.
+	if resp != nil && resp.Packet != nil {
 		enhErr.StatusCode = resp.Status
 		enhErr.Opaque = resp.Opaque
 
@@ -103,7 +117,7 @@ func (errMgr *errMapComponent) EnhanceKvError(err error, resp *memdQResponse, re
 			enhErr.ErrorDescription = errMapData.Description
 		}
 
-		if memd.DatatypeFlag(resp.Datatype)&memd.DatatypeFlagJSON != 0 {
+		if len(resp.Value) > 0 && memd.DatatypeFlag(resp.Datatype)&memd.DatatypeFlagJSON != 0 {
 			var enhancedData struct {
 				Error struct {
 					Context string `json:"context"`
@@ -141,7 +155,7 @@ func translateMemdError(err error, req *memdQRequest) error {
 		return errTemporaryFailure
 	case ErrMemdKeyExists:
 		if req.Command == memd.CmdReplace || (req.Command == memd.CmdDelete && req.Cas != 0) ||
-			(req.Command == memd.CmdSubDocMultiMutation && req.Cas != 0) {
+			(req.Command == memd.CmdSubDocMultiMutation && req.Cas != 0) || req.Command == memd.CmdUnlockKey {
 			return errCasMismatch
 		}
 		return errDocumentExists
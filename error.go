@@ -66,7 +66,9 @@ var (
 	// ErrShutdown occurs when operations are performed on a previously closed Agent.
 	ErrShutdown = errors.New("connection shut down")
 
-	// ErrOverload occurs when too many operations are dispatched and all queues are full.
+	// ErrOverload occurs when too many operations are dispatched and all queues are full. It is returned as soon as
+	// the operation is dispatched, rather than the operation being retried until it times out, so can be used to
+	// apply backpressure.
 	ErrOverload = errors.New("queue overflowed")
 
 	// ErrSocketClosed occurs when a socket closes while an operation is in flight.
@@ -90,6 +92,26 @@ var (
 	// vbucket id.
 	// Uncommitted: This API may change in the future.
 	ErrServerGroupMismatch = errors.New("vbucket id does not have any replica in requested server group")
+
+	// ErrKeyTooLong occurs when the length of a document key, including the encoded collection ID when collections
+	// are in use, exceeds the maximum permitted key length.
+	ErrKeyTooLong = errors.New("document key too long")
+
+	// ErrNoSeedAddresses occurs when an AgentConfig does not contain any memcached or http seed addresses.
+	ErrNoSeedAddresses = errors.New("no seed addresses provided")
+
+	// ErrInvalidTimeout occurs when an AgentConfig contains a negative timeout or interval.
+	ErrInvalidTimeout = errors.New("invalid timeout")
+
+	// ErrInvalidCompressionRatio occurs when an AgentConfig contains a compression minimum ratio outside of [0,1].
+	ErrInvalidCompressionRatio = errors.New("invalid compression ratio")
+
+	// ErrInvalidPoolSize occurs when an AgentConfig contains an invalid kv connection pool size.
+	ErrInvalidPoolSize = errors.New("invalid pool size")
+
+	// ErrAuthTransportMismatch occurs when the authenticator in an AgentConfig does not support the chosen
+	// transport, e.g. a certificate authenticator used without TLS.
+	ErrAuthTransportMismatch = errors.New("authenticator does not support the chosen transport")
 )
 
 // Shared Error Definitions RFC#58@15
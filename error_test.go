@@ -153,3 +153,91 @@ func (suite *UnitTestSuite) TestGetKvStatusCodeErrorUnknown() {
 
 	suite.Assert().Equal(code, unknownErr.code)
 }
+
+func (suite *UnitTestSuite) TestEnhanceKvErrorNoXError() {
+	req := &memdQRequest{
+		Packet: memd.Packet{
+			Magic:   memd.CmdMagicReq,
+			Command: memd.CmdAdd,
+			Key:     []byte("test"),
+		},
+	}
+
+	// No error map and no error context, as is the case when XERROR hasn't been negotiated.
+	errMapCmpt := newErrMapManager("testbucket")
+
+	type tCase struct {
+		name string
+		resp *memdQResponse
+	}
+	testCases := []tCase{
+		{
+			name: "plain text value",
+			resp: &memdQResponse{
+				Packet: &memd.Packet{
+					Status: memd.StatusKeyExists,
+					Value:  []byte("Data exists for key"),
+				},
+			},
+		},
+		{
+			name: "invalid json value",
+			resp: &memdQResponse{
+				Packet: &memd.Packet{
+					Status:   memd.StatusKeyExists,
+					Datatype: uint8(memd.DatatypeFlagJSON),
+					Value:    []byte("{\"error\":"),
+				},
+			},
+		},
+		{
+			name: "no value",
+			resp: &memdQResponse{
+				Packet: &memd.Packet{
+					Status:   memd.StatusKeyExists,
+					Datatype: uint8(memd.DatatypeFlagJSON),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			resErr := errMapCmpt.EnhanceKvError(errDocumentExists, tc.resp, req)
+
+			var kvErr *KeyValueError
+			suite.Require().ErrorAs(resErr, &kvErr)
+			suite.Assert().ErrorIs(resErr, ErrDocumentExists)
+			suite.Assert().Equal(memd.StatusKeyExists, kvErr.StatusCode)
+			suite.Assert().Empty(kvErr.Context)
+			suite.Assert().Empty(kvErr.Ref)
+			suite.Assert().Empty(kvErr.ErrorName)
+		})
+	}
+
+	resErr := errMapCmpt.EnhanceKvError(errDocumentExists, &memdQResponse{}, req)
+	suite.Assert().ErrorIs(resErr, ErrDocumentExists)
+}
+
+func (suite *UnitTestSuite) TestEnhanceKvErrorOverload() {
+	req := &memdQRequest{
+		Packet: memd.Packet{
+			Magic:   memd.CmdMagicReq,
+			Command: memd.CmdGet,
+			Key:     []byte("test"),
+		},
+	}
+
+	errMapCmpt := newErrMapManager("testbucket")
+
+	// The request was never sent, so there is no response to map, and the error must stay distinct from a timeout.
+	resErr := errMapCmpt.EnhanceKvError(errOverload, nil, req)
+
+	var kvErr *KeyValueError
+	suite.Require().ErrorAs(resErr, &kvErr)
+	suite.Assert().ErrorIs(resErr, ErrOverload)
+	suite.Assert().False(errors.Is(resErr, ErrTimeout))
+	suite.Assert().Equal(string(req.Key), kvErr.DocumentKey)
+	suite.Assert().Zero(kvErr.StatusCode)
+	suite.Assert().Empty(kvErr.ErrorName)
+}
@@ -48,6 +48,29 @@ func (err SubDocumentError) Unwrap() error {
 	return err.InnerError
 }
 
+// SubDocMutateError is returned from a MutateIn operation when one of the mutation specs fails, causing the
+// whole operation to fail.  InnerError is always a SubDocumentError.
+type SubDocMutateError struct {
+	InnerError error
+	// Index is the index of the failing spec within the ops provided to MutateIn.
+	Index int
+	// StatusCode is the status returned by the server for the failing spec.
+	StatusCode memd.StatusCode
+}
+
+// Error returns the string representation of this error.
+func (err SubDocMutateError) Error() string {
+	return fmt.Sprintf("sub-document mutation at index %d failed with status 0x%02x: %s",
+		err.Index,
+		uint16(err.StatusCode),
+		err.InnerError.Error())
+}
+
+// Unwrap returns the underlying error for the operation failing.
+func (err SubDocMutateError) Unwrap() error {
+	return err.InnerError
+}
+
 func serializeError(err error) string {
 	errBytes, serErr := json.Marshal(err)
 	if serErr != nil {
@@ -748,6 +771,12 @@ var (
 	errValueTooLarge                     = ncError{ErrValueTooLarge}
 	errDocumentExists                    = ncError{ErrDocumentExists}
 	errNotStored                         = ncError{ErrNotStored}
+	errKeyTooLong                        = ncError{ErrKeyTooLong}
+	errNoSeedAddresses                   = ncError{ErrNoSeedAddresses}
+	errInvalidTimeout                    = ncError{ErrInvalidTimeout}
+	errInvalidCompressionRatio           = ncError{ErrInvalidCompressionRatio}
+	errInvalidPoolSize                   = ncError{ErrInvalidPoolSize}
+	errAuthTransportMismatch             = ncError{ErrAuthTransportMismatch}
 	errValueNotJSON                      = ncError{ErrValueNotJSON}
 	errDurabilityLevelNotAvailable       = ncError{ErrDurabilityLevelNotAvailable}
 	errDurabilityImpossible              = ncError{ErrDurabilityImpossible}
@@ -0,0 +1,59 @@
+package gocbcore
+
+import (
+	"math"
+	"time"
+)
+
+// relativeExpiryLimit is the largest expiry, in seconds, that the server will treat as relative to now. Anything
+// larger is treated as an absolute unix timestamp.
+const relativeExpiryLimit = 30 * 24 * 60 * 60
+
+// ExpiryFromDuration converts a duration into an expiry for use with the Expiry of the KV operation options, such
+// as SetOptions, TouchOptions and MutateInOptions. The server treats expiries of up to 30 days as relative to now and
+// anything larger as an absolute unix timestamp, so longer durations are converted to a timestamp. A duration of 0
+// means no expiry, any other duration which would round down to 0 seconds is clamped to 1 second so that the
+// document still expires. A negative duration is treated as having already elapsed, and so is also clamped to 1
+// second rather than being rejected.
+//
+// This is how the ExpiryDuration of those options is encoded, callers setting Expiry directly can use it to do the
+// same.
+// Volatile: This API is subject to change at any time.
+func ExpiryFromDuration(d time.Duration) uint32 {
+	return expiryFromDuration(d, time.Now())
+}
+
+func expiryFromDuration(d time.Duration, now time.Time) uint32 {
+	if d == 0 {
+		return 0
+	}
+
+	// This also covers negative durations, which have already elapsed.
+	if d < time.Second {
+		return 1
+	}
+
+	secs := d / time.Second
+	if secs <= relativeExpiryLimit {
+		return uint32(secs)
+	}
+
+	expiry := now.Add(d).Unix()
+	if expiry > math.MaxUint32 {
+		return math.MaxUint32
+	}
+
+	return uint32(expiry)
+}
+
+// resolveExpiry returns the expiry to send to the server for the Expiry and ExpiryDuration of an operation's options.
+func resolveExpiry(expiry uint32, expiryDuration time.Duration) (uint32, error) {
+	if expiryDuration == 0 {
+		return expiry, nil
+	}
+	if expiry != 0 {
+		return 0, wrapError(errInvalidArgument, "expiry and expiry duration cannot both be set")
+	}
+
+	return ExpiryFromDuration(expiryDuration), nil
+}
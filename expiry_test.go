@@ -0,0 +1,39 @@
+package gocbcore
+
+import (
+	"math"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestExpiryFromDuration() {
+	now := time.Unix(1700000000, 0)
+	thirtyDays := 30 * 24 * time.Hour
+
+	type tCase struct {
+		name     string
+		duration time.Duration
+		expected uint32
+	}
+
+	testCases := []tCase{
+		{name: "zero", duration: 0, expected: 0},
+		{name: "sub-second", duration: time.Millisecond, expected: 1},
+		{name: "negative", duration: -time.Second, expected: 1},
+		{name: "large negative", duration: -365 * 24 * time.Hour, expected: 1},
+		{name: "one second", duration: time.Second, expected: 1},
+		{name: "truncated", duration: 1500 * time.Millisecond, expected: 1},
+		{name: "one hour", duration: time.Hour, expected: 3600},
+		{name: "thirty days", duration: thirtyDays, expected: relativeExpiryLimit},
+		{name: "thirty days and one second", duration: thirtyDays + time.Second, expected: uint32(now.Add(thirtyDays + time.Second).Unix())},
+		{name: "one year", duration: 365 * 24 * time.Hour, expected: uint32(now.Add(365 * 24 * time.Hour).Unix())},
+		{name: "beyond max timestamp", duration: time.Duration(math.MaxInt64), expected: math.MaxUint32},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.Assert().Equal(tc.expected, expiryFromDuration(tc.duration, now))
+		})
+	}
+
+	suite.Assert().Greater(ExpiryFromDuration(thirtyDays+time.Second), uint32(relativeExpiryLimit))
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net/http"
 	"sort"
+	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -33,6 +35,9 @@ type httpRequest struct {
 
 	retryCount   uint32
 	retryReasons []RetryReason
+
+	// serverRetryAfter is the delay requested by the server in the last response, in nanoseconds.
+	serverRetryAfter int64
 }
 
 func (hr *httpRequest) retryStrategy() RetryStrategy {
@@ -61,7 +66,19 @@ func (hr *httpRequest) RetryReasons() []RetryReason {
 	return hr.retryReasons
 }
 
+// ServerRetryAfter returns the delay requested by the server, via the Retry-After header, before the request is
+// retried.
+func (hr *httpRequest) ServerRetryAfter() time.Duration {
+	return time.Duration(atomic.LoadInt64(&hr.serverRetryAfter))
+}
+
+func (hr *httpRequest) setServerRetryAfter(resp *HTTPResponse) {
+	atomic.StoreInt64(&hr.serverRetryAfter, int64(parseRetryAfter(resp.Header)))
+}
+
 func (hr *httpRequest) recordRetryAttempt(reason RetryReason) {
+	// The server hint only applies to the retry that it was received for.
+	atomic.StoreInt64(&hr.serverRetryAfter, 0)
 	atomic.AddUint32(&hr.retryCount, 1)
 	idx := sort.Search(len(hr.retryReasons), func(i int) bool {
 		return hr.retryReasons[i] == reason
@@ -87,12 +104,14 @@ type HTTPRequest struct {
 	IsIdempotent  bool
 	UniqueID      string
 	Deadline      time.Time
+	Timeout       time.Duration
 	RetryStrategy RetryStrategy
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // HTTPResponse encapsulates the response from an HTTP request.
@@ -100,9 +119,33 @@ type HTTPResponse struct {
 	Endpoint      string
 	StatusCode    int
 	ContentLength int64
+	Header        http.Header
 	Body          io.ReadCloser
 }
 
+// parseRetryAfter parses the value of a Retry-After header, which can either be a number of seconds or a date.
+func parseRetryAfter(header http.Header) time.Duration {
+	val := header.Get("Retry-After")
+	if val == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(val); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if retryTime, err := http.ParseTime(val); err == nil {
+		if until := time.Until(retryTime); until > 0 {
+			return until
+		}
+	}
+
+	return 0
+}
+
 func wrapHTTPError(req *httpRequest, err error) HTTPError {
 	if err == nil {
 		err = errors.New("http error")
@@ -1,5 +1,14 @@
 package gocbcore
 
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type httpConfigController struct {
 	muxer     *httpMux
 	seenNodes map[string]uint64
@@ -19,9 +28,22 @@ func newHTTPConfigController(bucketName string, props httpPollerProperties, muxe
 }
 
 func (hcc *httpConfigController) GetEndpoint(iterNum uint64) string {
+	eps := hcc.muxer.MgmtEps()
+
+	var numAbandoned int
+	for _, srv := range eps {
+		if hcc.isAbandoned(srv) {
+			numAbandoned++
+		}
+	}
+	// If every endpoint has been abandoned then we have nowhere else to go, so give them all another chance.
+	if numAbandoned > 0 && numAbandoned == len(eps) {
+		hcc.resetAbandoned()
+	}
+
 	var pickedSrv string
-	for _, srv := range hcc.muxer.MgmtEps() {
-		if hcc.seenNodes[srv] >= iterNum {
+	for _, srv := range eps {
+		if hcc.seenNodes[srv] >= iterNum || hcc.isAbandoned(srv) {
 			continue
 		}
 		pickedSrv = srv
@@ -38,3 +60,55 @@ func (hcc *httpConfigController) GetEndpoint(iterNum uint64) string {
 func (hcc *httpConfigController) CanPoll() bool {
 	return len(hcc.muxer.MgmtEps()) > 0
 }
+
+// FetchConfig fetches the current config from the management endpoints outside of the streaming poll loop, trying
+// each endpoint in turn until one returns a config.
+func (hcc *httpConfigController) FetchConfig(deadline time.Time) (*cfgBucket, error) {
+	eps := hcc.muxer.MgmtEps()
+	if len(eps) == 0 {
+		return nil, wrapError(errServiceNotAvailable, "no management endpoints available to fetch config from")
+	}
+
+	var lastErr error
+	for _, ep := range eps {
+		cfg, err := hcc.fetchConfigFrom(ep, deadline)
+		if err != nil {
+			hcc.logger.debugf("Failed to fetch config from %s: %v", redactSystemData(ep), err)
+			lastErr = err
+			continue
+		}
+
+		return cfg, nil
+	}
+
+	return nil, lastErr
+}
+
+func (hcc *httpConfigController) fetchConfigFrom(endpoint string, deadline time.Time) (*cfgBucket, error) {
+	resp, err := hcc.httpComponent.DoInternalHTTPRequest(&httpRequest{
+		Service:  MgmtService,
+		Method:   "GET",
+		Path:     fmt.Sprintf("/pools/default/b/%s", url.PathEscape(hcc.bucketName)),
+		Endpoint: endpoint,
+		UniqueID: uuid.New().String(),
+		Deadline: deadline,
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	closeErr := resp.Body.Close()
+	if closeErr != nil {
+		hcc.logger.debugf("Failed to close config response body: %v", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, wrapError(errCliInternalError, fmt.Sprintf("unexpected status code fetching config: %d", resp.StatusCode))
+	}
+
+	return parseConfig(body, hostnameFromURI(endpoint))
+}
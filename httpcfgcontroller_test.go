@@ -0,0 +1,102 @@
+package gocbcore
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *UnitTestSuite) TestHTTPConfigControllerAbandonsFailingEndpoint() {
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	mux := newHTTPMux(CircuitBreakerConfig{}, cfgMgr, &httpClientMux{
+		mgmtEpList: []routeEndpoint{
+			{Address: "http://10.112.210.101:8091"},
+			{Address: "http://10.112.210.102:8091"},
+		},
+	}, false, nil)
+
+	ctrlr := newHTTPConfigController("default", httpPollerProperties{
+		confHTTPMaxFailures: 2,
+	}, mux, nil)
+
+	dead := "http://10.112.210.101:8091"
+	healthy := "http://10.112.210.102:8091"
+
+	suite.Assert().Equal(dead, ctrlr.GetEndpoint(1))
+	ctrlr.recordEndpointFailure(dead, errors.New("connection refused"))
+	suite.Assert().Equal(healthy, ctrlr.GetEndpoint(1))
+	suite.Assert().Equal("", ctrlr.GetEndpoint(1))
+
+	// The second consecutive failure should cause the dead endpoint to be abandoned.
+	suite.Assert().Equal(dead, ctrlr.GetEndpoint(2))
+	ctrlr.recordEndpointFailure(dead, errors.New("connection refused"))
+	suite.Assert().True(ctrlr.isAbandoned(dead))
+	suite.Assert().Equal(healthy, ctrlr.GetEndpoint(2))
+	suite.Assert().Equal(healthy, ctrlr.GetEndpoint(3))
+
+	// Once every endpoint has been abandoned they all become eligible again.
+	ctrlr.recordEndpointFailure(healthy, errors.New("connection refused"))
+	ctrlr.recordEndpointFailure(healthy, errors.New("connection refused"))
+	suite.Assert().Equal(dead, ctrlr.GetEndpoint(4))
+	suite.Assert().False(ctrlr.isAbandoned(healthy))
+}
+
+func (suite *UnitTestSuite) TestJitterDuration() {
+	suite.Assert().Equal(10*time.Second, jitterDuration(10*time.Second, 0))
+
+	for i := 0; i < 100; i++ {
+		d := jitterDuration(10*time.Second, 0.2)
+		suite.Assert().GreaterOrEqual(d, 8*time.Second)
+		suite.Assert().LessOrEqual(d, 12*time.Second)
+	}
+
+	// Jitter above 1 is clamped so that the delay is never negative.
+	for i := 0; i < 100; i++ {
+		d := jitterDuration(10*time.Second, 5)
+		suite.Assert().GreaterOrEqual(d, time.Duration(0))
+		suite.Assert().LessOrEqual(d, 20*time.Second)
+	}
+}
+
+func (suite *UnitTestSuite) TestHTTPConfigControllerFetchConfig() {
+	cfgBytes, err := suite.LoadRawTestDataset("bucket_config_with_rev_epoch")
+	suite.Require().Nil(err, err)
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, err := w.Write(cfgBytes)
+		suite.Assert().Nil(err, err)
+	}))
+	defer srv.Close()
+
+	cfgMgr := new(mockConfigManager)
+	cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+
+	mux := newHTTPMux(CircuitBreakerConfig{}, cfgMgr, &httpClientMux{
+		mgmtEpList: []routeEndpoint{
+			{Address: srv.URL},
+		},
+		auth: PasswordAuthProvider{Username: "user", Password: "pass"},
+	}, false, nil)
+	httpCpt := newHTTPComponentWithClient(httpComponentProps{
+		DefaultRetryStrategy: newFailFastRetryStrategy(),
+	}, srv.Client(), mux, newTracerComponent(noopTracer{}, "", true, &noopMeter{}, nil))
+
+	ctrlr := newHTTPConfigController("default", httpPollerProperties{
+		httpComponent: httpCpt,
+	}, mux, nil)
+
+	cfg, err := ctrlr.FetchConfig(time.Now().Add(time.Second))
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(int64(2), cfg.Rev)
+	suite.Assert().Equal([]string{"/pools/default/b/default"}, paths)
+}
@@ -8,6 +8,7 @@ func newHTTPComponentWithClient(props httpComponentProps, client *http.Client, m
 		muxer:                muxer,
 		userAgent:            props.UserAgent,
 		defaultRetryStrategy: props.DefaultRetryStrategy,
+		healthChecker:        props.HealthChecker,
 		tracer:               tracer,
 		cli:                  client,
 	}
@@ -57,3 +57,30 @@ func newHTTPClientMux(cfg *routeConfig, endpoints httpClientMuxEndpoints, tlsCon
 		auth:      auth,
 	}
 }
+
+// ServiceForAddress returns the service which the given host:port address belongs to, or zero if the address
+// is not known.
+func (mux *httpClientMux) ServiceForAddress(addr string) ServiceType {
+	epLists := []struct {
+		service ServiceType
+		eps     []routeEndpoint
+	}{
+		{MgmtService, mux.mgmtEpList},
+		{CapiService, mux.capiEpList},
+		{N1qlService, mux.n1qlEpList},
+		{FtsService, mux.ftsEpList},
+		{CbasService, mux.cbasEpList},
+		{EventingService, mux.eventingEpList},
+		{GSIService, mux.gsiEpList},
+		{BackupService, mux.backupEpList},
+	}
+	for _, list := range epLists {
+		for _, ep := range list.eps {
+			if trimSchemePrefix(ep.Address) == addr {
+				return list.service
+			}
+		}
+	}
+
+	return 0
+}
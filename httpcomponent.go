@@ -1,17 +1,21 @@
 package gocbcore
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"sync/atomic"
 	"syscall"
@@ -30,6 +34,9 @@ type httpComponent struct {
 	userAgent            string
 	tracer               *tracerComponent
 	defaultRetryStrategy RetryStrategy
+	healthChecker        HealthChecker
+	managementTimeout    time.Duration
+	logger               *scopedLogger
 
 	shutdownSig chan struct{}
 }
@@ -37,6 +44,9 @@ type httpComponent struct {
 type httpComponentProps struct {
 	UserAgent            string
 	DefaultRetryStrategy RetryStrategy
+	HealthChecker        HealthChecker
+	ManagementTimeout    time.Duration
+	Logger               *scopedLogger
 }
 
 type httpClientProps struct {
@@ -45,6 +55,7 @@ type httpClientProps struct {
 	maxIdleConnsPerHost int
 	maxConnsPerHost     int
 	idleTimeout         time.Duration
+	transportFactory    func() *http.Transport
 }
 
 func newHTTPComponent(props httpComponentProps, clientProps httpClientProps, muxer *httpMux, tracer *tracerComponent) *httpComponent {
@@ -52,12 +63,14 @@ func newHTTPComponent(props httpComponentProps, clientProps httpClientProps, mux
 		muxer:                muxer,
 		userAgent:            props.UserAgent,
 		defaultRetryStrategy: props.DefaultRetryStrategy,
+		healthChecker:        props.HealthChecker,
+		managementTimeout:    props.ManagementTimeout,
+		logger:               props.Logger,
 		tracer:               tracer,
 		shutdownSig:          make(chan struct{}),
 	}
 
-	hc.cli = hc.createHTTPClient(clientProps.maxIdleConns, clientProps.maxIdleConnsPerHost, clientProps.maxConnsPerHost, clientProps.idleTimeout,
-		clientProps.connectTimeout)
+	hc.cli = hc.createHTTPClient(clientProps)
 
 	return hc
 }
@@ -66,17 +79,28 @@ func (hc *httpComponent) Close() {
 	close(hc.shutdownSig)
 
 	if err := hc.muxer.Close(); err != nil {
-		logDebugf("Error closing http muxer: %s", err)
+		hc.logger.debugf("Error closing http muxer: %s", err)
 	}
 	if tsport, ok := hc.cli.Transport.(*http.Transport); ok {
 		tsport.CloseIdleConnections()
 	} else {
-		logDebugf("Could not close idle connections for transport")
+		hc.logger.debugf("Could not close idle connections for transport")
 	}
 }
 
+// requestDeadline returns the deadline for a request, which is derived from its timeout if no deadline was specified
+// or, for management requests, from the default management timeout if neither was.
+func (hc *httpComponent) requestDeadline(req *HTTPRequest) time.Time {
+	deadline := deadlineFromTimeout(req.Deadline, req.Timeout)
+	if req.Service == MgmtService {
+		deadline = deadlineFromTimeout(deadline, hc.managementTimeout)
+	}
+
+	return deadline
+}
+
 func (hc *httpComponent) DoHTTPRequest(req *HTTPRequest, cb DoHTTPRequestCallback) (PendingOp, error) {
-	tracer := hc.tracer.StartTelemeteryHandler(metricValueServiceHTTPValue, "http", req.TraceContext)
+	tracer := hc.tracer.StartTelemeteryHandler(metricValueServiceHTTPValue, "http", req.TraceContext, req.NoRootSpan)
 
 	retryStrategy := hc.defaultRetryStrategy
 	if req.RetryStrategy != nil {
@@ -97,7 +121,7 @@ func (hc *httpComponent) DoHTTPRequest(req *HTTPRequest, cb DoHTTPRequestCallbac
 		Body:             req.Body,
 		IsIdempotent:     req.IsIdempotent,
 		UniqueID:         req.UniqueID,
-		Deadline:         req.Deadline,
+		Deadline:         hc.requestDeadline(req),
 		RetryStrategy:    retryStrategy,
 		RootTraceContext: tracer.RootContext(),
 		Context:          ctx,
@@ -114,7 +138,7 @@ func (hc *httpComponent) DoHTTPRequest(req *HTTPRequest, cb DoHTTPRequestCallbac
 				return
 			}
 
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, wrapHTTPError(ireq, err))
 			return
 		}
@@ -206,8 +230,9 @@ func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck
 
 			var err error
 			creds, err = auth.Credentials(AuthCredsRequest{
-				Service:  req.Service,
-				Endpoint: endpoint,
+				Service:    req.Service,
+				Endpoint:   endpoint,
+				BucketName: hc.muxer.BucketName(),
 			})
 			if err != nil {
 				if err := hc.maybeWait(req, CredentialsFetchFailedRetryReason, err, start, endpoint); err != nil {
@@ -225,17 +250,17 @@ func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck
 		}
 
 		dSpan := hc.tracer.StartHTTPDispatchSpan(req, spanNameDispatchToServer)
-		logSchedf("Writing HTTP request to %s ID=%s", hreq.URL, req.UniqueID)
+		hc.logger.schedf("Writing HTTP request to %s ID=%s", hreq.URL, req.UniqueID)
 		// we can't close the body of this response as it's long-lived beyond the function
 		hresp, err := hc.cli.Do(hreq) // nolint: bodyclose
 		hc.tracer.StopHTTPDispatchSpan(dSpan, hreq, req.UniqueID, req.RetryAttempts())
 		if err != nil {
-			logDebugf("Received HTTP Response for ID=%s, errored: %v", req.UniqueID, err)
+			hc.logger.debugf("Received HTTP Response for ID=%s, errored: %v", req.UniqueID, err)
 			// Because we don't use the http request context itself to perform timeouts we need to do some translation
-			// of the error message here for better UX.
-			if errors.Is(err, context.Canceled) {
+			// of the error message here for better UX. A user supplied context can also expire its own deadline.
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				isTimeout := atomic.LoadUint32(&cancellationIsTimeout)
-				if isTimeout == 1 {
+				if isTimeout == 1 || errors.Is(err, context.DeadlineExceeded) {
 					if req.IsIdempotent {
 						err = &TimeoutError{
 							InnerError:       errUnambiguousTimeout,
@@ -291,7 +316,7 @@ func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck
 
 			continue
 		}
-		logSchedf("Received HTTP Response for ID=%s, status=%d", req.UniqueID, hresp.StatusCode)
+		hc.logger.schedf("Received HTTP Response for ID=%s, status=%d", req.UniqueID, hresp.StatusCode)
 
 		hresp = wrapHttpResponse(hresp) // nolint: bodyclose
 
@@ -299,6 +324,7 @@ func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck
 			Endpoint:      endpoint,
 			StatusCode:    hresp.StatusCode,
 			ContentLength: hresp.ContentLength,
+			Header:        hresp.Header,
 			Body:          hresp.Body,
 		}
 
@@ -323,9 +349,9 @@ func (hc *httpComponent) waitForConfig(ctx context.Context, isIdempotent bool, c
 		select {
 		case <-ctx.Done():
 			err := ctx.Err()
-			if errors.Is(err, context.Canceled) {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				isTimeout := atomic.LoadUint32(cancellationIsTimeout)
-				if isTimeout == 1 {
+				if isTimeout == 1 || errors.Is(err, context.DeadlineExceeded) {
 					if isIdempotent {
 						return errUnambiguousTimeout
 					}
@@ -342,6 +368,28 @@ func (hc *httpComponent) waitForConfig(ctx context.Context, isIdempotent bool, c
 }
 
 func (hc *httpComponent) randomEndpoint(service ServiceType, denylist []string) (string, error) {
+	var sawUnhealthy bool
+	for {
+		endpoint, err := hc.randomServiceEndpoint(service, denylist)
+		if err != nil {
+			if sawUnhealthy && errors.Is(err, errServiceNotAvailable) {
+				return "", errCircuitBreakerOpen
+			}
+			return "", err
+		}
+
+		if hc.healthChecker == nil || hc.healthChecker.IsHealthy(endpoint) {
+			return endpoint, nil
+		}
+
+		hc.logger.schedf("Health checker excluding endpoint %s", redactSystemData(endpoint))
+		sawUnhealthy = true
+		// Force a copy so that we don't modify the callers denylist.
+		denylist = append(denylist[:len(denylist):len(denylist)], endpoint)
+	}
+}
+
+func (hc *httpComponent) randomServiceEndpoint(service ServiceType, denylist []string) (string, error) {
 	var endpoint string
 	var err error
 	switch service {
@@ -468,70 +516,126 @@ func (hc *httpComponent) validateEndpoint(endpoint string, endpoints []string) e
 	return errInvalidServer
 }
 
-func createTLSConfig(auth AuthProvider, caProvider func() *x509.CertPool) *dynTLSConfig {
+func createTLSConfig(auth AuthProvider, caProvider func() *x509.CertPool, settings tlsSettings) *dynTLSConfig {
+	minVersion := settings.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
 	return &dynTLSConfig{
 		BaseConfig: &tls.Config{
-			GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
-				cert, err := auth.Certificate(AuthCertRequest{})
-				if err != nil {
-					return nil, err
-				}
-
-				if cert == nil {
-					return &tls.Certificate{}, nil
-				}
-
-				return cert, nil
-			},
-			MinVersion: tls.VersionTLS12,
+			GetClientCertificate:  getClientCertificateFn(auth, AuthCertRequest{}),
+			MinVersion:            minVersion,
+			CipherSuites:          settings.CipherSuites,
+			VerifyPeerCertificate: settings.verifyPinnedCertFn(),
 		},
 		Provider: caProvider,
+		Auth:     auth,
 	}
 }
 
-func (hc *httpComponent) createHTTPClient(maxIdleConns, maxIdleConnsPerHost, maxConnsPerHost int, idleTimeout time.Duration, connectTimeout time.Duration) *http.Client {
+func (hc *httpComponent) createHTTPClient(props httpClientProps) *http.Client {
 	httpDialer := &net.Dialer{
-		Timeout:   connectTimeout,
+		Timeout:   props.connectTimeout,
 		KeepAlive: 30 * time.Second,
 	}
+	dial := httpDialer.DialContext
+
+	httpTransport := &http.Transport{}
+	var proxy func(*http.Request) (*url.URL, error)
+	var proxyConnectHeader http.Header
+	if props.transportFactory != nil {
+		if userTransport := props.transportFactory(); userTransport != nil {
+			// The user transport is only used as a template, so that any TLS settings or TLS dialers on it can never
+			// take precedence over the TLS configuration of the agent.
+			httpTransport = &http.Transport{
+				DisableKeepAlives:      userTransport.DisableKeepAlives,
+				DisableCompression:     userTransport.DisableCompression,
+				MaxIdleConns:           userTransport.MaxIdleConns,
+				MaxIdleConnsPerHost:    userTransport.MaxIdleConnsPerHost,
+				MaxConnsPerHost:        userTransport.MaxConnsPerHost,
+				ResponseHeaderTimeout:  userTransport.ResponseHeaderTimeout,
+				ExpectContinueTimeout:  userTransport.ExpectContinueTimeout,
+				MaxResponseHeaderBytes: userTransport.MaxResponseHeaderBytes,
+				WriteBufferSize:        userTransport.WriteBufferSize,
+				ReadBufferSize:         userTransport.ReadBufferSize,
+			}
+			proxy = userTransport.Proxy
+			proxyConnectHeader = userTransport.ProxyConnectHeader
+
+			// If the user has provided their own dialer then we use it for the underlying connections, with TLS still
+			// applied on top.
+			if userTransport.DialContext != nil {
+				dial = userTransport.DialContext
+			} else if userTransport.Dial != nil {
+				userDial := userTransport.Dial
+				dial = func(_ context.Context, network, addr string) (net.Conn, error) {
+					return userDial(network, addr)
+				}
+			}
+		}
+	}
+
+	if proxy != nil {
+		// The transport would perform the TLS handshake for https requests sent through a proxy itself, without
+		// using DialTLS, so we establish the tunnel through the proxy ourselves instead.
+		httpTransport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if req.URL.Scheme == "https" {
+				return nil, nil
+			}
+
+			return proxy(req)
+		}
+		httpTransport.ProxyConnectHeader = proxyConnectHeader
+	}
 
 	// We set ForceAttemptHTTP2, which will update the base-config to support HTTP2
 	// automatically, so that all configs from it will look for that.
-	httpTransport := &http.Transport{
-		ForceAttemptHTTP2: true,
-
-		Dial: func(network, addr string) (net.Conn, error) {
-			return httpDialer.Dial(network, addr)
-		},
-		DialTLS: func(network, addr string) (net.Conn, error) {
-			tcpConn, err := httpDialer.Dial(network, addr)
-			if err != nil {
-				return nil, err
-			}
+	httpTransport.ForceAttemptHTTP2 = true
+	httpTransport.DialContext = dial
+	httpTransport.DialTLS = func(network, addr string) (net.Conn, error) {
+		var tcpConn net.Conn
+		var err error
+		if proxy != nil {
+			tcpConn, err = dialThroughHTTPProxy(dial, proxy, proxyConnectHeader, props.connectTimeout, network, addr)
+		} else {
+			tcpConn, err = dial(context.Background(), network, addr)
+		}
+		if err != nil {
+			return nil, err
+		}
 
-			// We set up the transport to point at the BaseConfig from the dynamic TLS system.
-			clientMux := hc.muxer.Get()
-			if clientMux == nil {
-				return nil, errShutdown
-			}
-			httpTLSConfig := clientMux.tlsConfig
-			if httpTLSConfig == nil {
-				return nil, errors.New("TLS is not configured on this Agent")
-			}
+		// We set up the transport to point at the BaseConfig from the dynamic TLS system.
+		clientMux := hc.muxer.Get()
+		if clientMux == nil {
+			_ = tcpConn.Close()
+			return nil, errShutdown
+		}
+		httpTLSConfig := clientMux.tlsConfig
+		if httpTLSConfig == nil {
+			_ = tcpConn.Close()
+			return nil, errors.New("TLS is not configured on this Agent")
+		}
 
-			srvTLSConfig, err := httpTLSConfig.MakeForAddr(addr)
-			if err != nil {
-				return nil, err
-			}
+		srvTLSConfig, err := httpTLSConfig.MakeForAddr(addr, clientMux.ServiceForAddress(addr))
+		if err != nil {
+			_ = tcpConn.Close()
+			return nil, err
+		}
 
-			tlsConn := tls.Client(tcpConn, srvTLSConfig)
-			return tlsConn, nil
-		},
-		MaxIdleConns:        maxIdleConns,
-		MaxIdleConnsPerHost: maxIdleConnsPerHost,
-		MaxConnsPerHost:     maxConnsPerHost,
-		IdleConnTimeout:     idleTimeout,
+		tlsConn := tls.Client(tcpConn, srvTLSConfig)
+		return tlsConn, nil
+	}
+	if props.transportFactory == nil || props.maxIdleConns > 0 {
+		httpTransport.MaxIdleConns = props.maxIdleConns
 	}
+	if props.transportFactory == nil || props.maxIdleConnsPerHost > 0 {
+		httpTransport.MaxIdleConnsPerHost = props.maxIdleConnsPerHost
+	}
+	if props.transportFactory == nil || props.maxConnsPerHost > 0 {
+		httpTransport.MaxConnsPerHost = props.maxConnsPerHost
+	}
+	httpTransport.IdleConnTimeout = props.idleTimeout
 
 	httpCli := &http.Client{
 		Transport: httpTransport,
@@ -555,6 +659,73 @@ func (hc *httpComponent) createHTTPClient(maxIdleConns, maxIdleConnsPerHost, max
 	return httpCli
 }
 
+// dialThroughHTTPProxy establishes a tunnel to addr through the proxy returned for it, if any, using a CONNECT
+// request. The returned connection has not yet had TLS applied to it.
+func dialThroughHTTPProxy(dial func(context.Context, string, string) (net.Conn, error),
+	proxy func(*http.Request) (*url.URL, error), connectHeader http.Header, timeout time.Duration, network,
+	addr string) (net.Conn, error) {
+	proxyURL, err := proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return dial(context.Background(), network, addr)
+	}
+	if proxyURL.Scheme != "http" && proxyURL.Scheme != "" {
+		return nil, fmt.Errorf("proxy scheme %s is not supported for TLS connections", proxyURL.Scheme)
+	}
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+
+	conn, err := dial(context.Background(), network, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	header := connectHeader.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+
+	connectReq := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: header,
+	}
+
+	if timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := connectReq.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// The proxy sends nothing further until we start the TLS handshake, so nothing can be lost in the buffer.
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connectReq)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy refused connection to %s: %s", redactSystemData(addr), resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
 /* #nosec G404 */
 func randFromServiceEndpoints(endpoints []string, denylist []string) (string, error) {
 	var allowList []string
@@ -0,0 +1,206 @@
+package gocbcore
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *UnitTestSuite) TestHTTPComponentTransportFactory() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	proxyURL, err := url.Parse("http://10.112.210.200:3128")
+	suite.Require().Nil(err, err)
+
+	var dials uint32
+	dialer := &net.Dialer{}
+	hc := &httpComponent{}
+	cli := hc.createHTTPClient(httpClientProps{
+		connectTimeout:  time.Second,
+		maxConnsPerHost: 5,
+		idleTimeout:     time.Second,
+		transportFactory: func() *http.Transport {
+			return &http.Transport{
+				Proxy:        http.ProxyURL(proxyURL),
+				MaxIdleConns: 7,
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					atomic.AddUint32(&dials, 1)
+					return dialer.DialContext(ctx, network, addr)
+				},
+			}
+		},
+	})
+	defer cli.CloseIdleConnections()
+
+	transport := cli.Transport.(*http.Transport)
+	suite.Assert().True(transport.ForceAttemptHTTP2)
+	suite.Assert().NotNil(transport.DialTLS)
+	suite.Assert().Equal(7, transport.MaxIdleConns)
+	suite.Assert().Equal(5, transport.MaxConnsPerHost)
+	suite.Assert().Equal(time.Second, transport.IdleConnTimeout)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	suite.Require().Nil(err, err)
+	proxied, err := transport.Proxy(req)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(proxyURL, proxied)
+
+	// Bypass the proxy so that we can check that the user's dialer is used.
+	transport.Proxy = nil
+	resp, err := cli.Do(req)
+	suite.Require().Nil(err, err)
+	suite.Require().Nil(resp.Body.Close())
+	suite.Assert().Equal(uint32(1), atomic.LoadUint32(&dials))
+}
+
+func (suite *UnitTestSuite) TestHTTPComponentTransportFactoryProxyTLS() {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	// A minimal proxy which only supports tunnelling using CONNECT.
+	proxyListener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().Nil(err, err)
+	var proxyWg sync.WaitGroup
+	connectsCh := make(chan string, 2)
+	proxyWg.Add(1)
+	go func() {
+		defer proxyWg.Done()
+		for {
+			conn, err := proxyListener.Accept()
+			if err != nil {
+				return
+			}
+
+			proxyWg.Add(1)
+			go func() {
+				defer proxyWg.Done()
+				defer conn.Close()
+
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+				connectsCh <- req.Host
+
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					return
+				}
+				defer target.Close()
+
+				_, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+				if err != nil {
+					return
+				}
+
+				go func() {
+					_, _ = io.Copy(target, conn)
+					_ = target.Close()
+				}()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+	defer func() {
+		_ = proxyListener.Close()
+		proxyWg.Wait()
+	}()
+
+	proxyURL, err := url.Parse("http://" + proxyListener.Addr().String())
+	suite.Require().Nil(err, err)
+
+	doRequest := func(roots *x509.CertPool) error {
+		cfgMgr := new(mockConfigManager)
+		cfgMgr.On("AddConfigWatcher", mock.Anything).Return()
+		tlsConfig := createTLSConfig(PasswordAuthProvider{}, func() *x509.CertPool {
+			return roots
+		}, tlsSettings{})
+		hc := &httpComponent{
+			muxer: newHTTPMux(CircuitBreakerConfig{}, cfgMgr, &httpClientMux{tlsConfig: tlsConfig}, false, nil),
+		}
+
+		cli := hc.createHTTPClient(httpClientProps{
+			connectTimeout: time.Second,
+			idleTimeout:    time.Second,
+			transportFactory: func() *http.Transport {
+				return &http.Transport{
+					Proxy: http.ProxyURL(proxyURL),
+					// This must never be used in place of the TLS configuration of the agent.
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402
+				}
+			},
+		})
+		defer cli.CloseIdleConnections()
+
+		resp, err := cli.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	suite.Require().Nil(doRequest(roots))
+	suite.Assert().Equal(srv.Listener.Addr().String(), <-connectsCh)
+
+	// The server certificate isn't trusted by the agent so the request must fail, even though the tunnel is made.
+	suite.Assert().NotNil(doRequest(x509.NewCertPool()))
+	suite.Assert().Equal(srv.Listener.Addr().String(), <-connectsCh)
+}
+
+func (suite *UnitTestSuite) TestHTTPComponentNoTransportFactory() {
+	hc := &httpComponent{}
+	cli := hc.createHTTPClient(httpClientProps{
+		connectTimeout: time.Second,
+		idleTimeout:    time.Second,
+	})
+
+	transport := cli.Transport.(*http.Transport)
+	suite.Assert().True(transport.ForceAttemptHTTP2)
+	suite.Assert().Nil(transport.Proxy)
+	suite.Assert().Zero(transport.MaxIdleConns)
+	suite.Assert().Equal(time.Second, transport.IdleConnTimeout)
+}
+
+func (suite *UnitTestSuite) TestHTTPComponentManagementTimeout() {
+	hc := &httpComponent{managementTimeout: time.Minute}
+
+	start := time.Now()
+	deadline := hc.requestDeadline(&HTTPRequest{Service: MgmtService})
+	suite.Assert().WithinDuration(start.Add(time.Minute), deadline, time.Second)
+
+	// A request timeout takes precedence over the default.
+	deadline = hc.requestDeadline(&HTTPRequest{Service: MgmtService, Timeout: time.Hour})
+	suite.Assert().WithinDuration(start.Add(time.Hour), deadline, time.Second)
+
+	// An explicit deadline always wins.
+	explicit := start.Add(time.Second)
+	deadline = hc.requestDeadline(&HTTPRequest{Service: MgmtService, Deadline: explicit, Timeout: time.Hour})
+	suite.Assert().Equal(explicit, deadline)
+
+	// The default only applies to the management service.
+	deadline = hc.requestDeadline(&HTTPRequest{Service: N1qlService})
+	suite.Assert().True(deadline.IsZero())
+}
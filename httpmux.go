@@ -13,14 +13,17 @@ type httpMux struct {
 	breakerCfg    CircuitBreakerConfig
 	cfgMgr        configManager
 	noSeedNodeTLS bool
+	logger        *scopedLogger
 }
 
-func newHTTPMux(breakerCfg CircuitBreakerConfig, cfgMgr configManager, muxState *httpClientMux, noSeedNodeTLS bool) *httpMux {
+func newHTTPMux(breakerCfg CircuitBreakerConfig, cfgMgr configManager, muxState *httpClientMux, noSeedNodeTLS bool,
+	logger *scopedLogger) *httpMux {
 	mux := &httpMux{
 		breakerCfg:    breakerCfg,
 		cfgMgr:        cfgMgr,
 		muxPtr:        unsafe.Pointer(muxState),
 		noSeedNodeTLS: noSeedNodeTLS,
+		logger:        logger,
 	}
 
 	cfgMgr.AddConfigWatcher(mux)
@@ -38,7 +41,7 @@ func (mux *httpMux) Get() *httpClientMux {
 
 func (mux *httpMux) Update(old, new *httpClientMux) bool {
 	if new == nil {
-		logErrorf("Attempted to update to nil httpClientMux")
+		mux.logger.errorf("Attempted to update to nil httpClientMux")
 		return false
 	}
 
@@ -47,7 +50,7 @@ func (mux *httpMux) Update(old, new *httpClientMux) bool {
 	}
 
 	if atomic.SwapPointer(&mux.muxPtr, unsafe.Pointer(new)) != nil {
-		logErrorf("Updated from nil attempted on initialized httpClientMux")
+		mux.logger.errorf("Updated from nil attempted on initialized httpClientMux")
 		return false
 	}
 
@@ -62,7 +65,7 @@ func (mux *httpMux) Clear() *httpClientMux {
 func (mux *httpMux) OnNewRouteConfig(cfg *routeConfig) {
 	oldHTTPMux := mux.Get()
 	if oldHTTPMux == nil {
-		logWarnf("HTTP mux received new route config after shutdown")
+		mux.logger.warnf("HTTP mux received new route config after shutdown")
 		return
 	}
 
@@ -87,19 +90,19 @@ func (mux *httpMux) OnNewRouteConfig(cfg *routeConfig) {
 	addEps("GSI", endpoints.gsiEpList)
 	addEps("Backup", endpoints.backupEpList)
 
-	logDebugf(buffer.String())
+	mux.logger.debugf(buffer.String())
 
 	newHTTPMux := newHTTPClientMux(cfg, endpoints, oldHTTPMux.tlsConfig, oldHTTPMux.auth, mux.breakerCfg)
 
 	if !mux.Update(oldHTTPMux, newHTTPMux) {
-		logDebugf("Failed to update HTTP mux")
+		mux.logger.debugf("Failed to update HTTP mux")
 	}
 }
 
 func (mux *httpMux) UpdateTLS(tlsConfig *dynTLSConfig, auth AuthProvider) {
 	oldMux := mux.Get()
 	if oldMux == nil {
-		logWarnf("HTTP mux received TLS update after shutdown")
+		mux.logger.warnf("HTTP mux received TLS update after shutdown")
 		return
 	}
 
@@ -220,6 +223,15 @@ func (mux *httpMux) Auth() AuthProvider {
 	return clientMux.auth
 }
 
+func (mux *httpMux) BucketName() string {
+	clientMux := mux.Get()
+	if clientMux == nil {
+		return ""
+	}
+
+	return clientMux.bucket
+}
+
 func (mux *httpMux) buildEndpoints(config *routeConfig, useTLS bool) httpClientMuxEndpoints {
 	var endpoints httpClientMuxEndpoints
 	if useTLS {
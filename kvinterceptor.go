@@ -0,0 +1,60 @@
+package gocbcore
+
+import (
+	"sync/atomic"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+// KVInterceptor observes KV requests as they are dispatched and completed, allowing cross-cutting behaviour such as
+// metrics, sampling or fault injection to be added to an agent. Interceptors see every request dispatched to the KV
+// service by the agent, including those it makes internally such as config fetches, but not those made whilst
+// bootstrapping a connection. The packets passed to an interceptor must not be modified or retained.
+// Volatile: This API is subject to change at any time.
+type KVInterceptor interface {
+	// BeforeSend is invoked each time a request is about to be dispatched, including when it is being retried.
+	// Returning an error fails the request with that error without sending it, and without any later interceptors
+	// being invoked. The error is returned from the operation call or, if the request is being retried, passed to
+	// its callback.
+	BeforeSend(req *memd.Packet) error
+
+	// AfterReceive is invoked when a request completes, immediately before its callback. resp is nil if no
+	// response was received. It is not invoked for requests which failed to dispatch.
+	AfterReceive(req *memd.Packet, resp *memd.Packet, err error)
+}
+
+// interceptRequest invokes the BeforeSend hooks of each interceptor in order, stopping at the first error.
+func (mux *kvMux) interceptRequest(req *memdQRequest) error {
+	for _, interceptor := range mux.interceptors {
+		if err := interceptor.BeforeSend(&req.Packet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// interceptResponse wraps the request callback to invoke the AfterReceive hooks of each interceptor in reverse
+// order, returning a function which reverts this if the request fails to dispatch.
+func (mux *kvMux) interceptResponse(req *memdQRequest) func() {
+	if len(mux.interceptors) == 0 || !atomic.CompareAndSwapUint32(&req.interceptorsApplied, 0, 1) {
+		return func() {}
+	}
+
+	cb := req.Callback
+	req.Callback = func(resp *memdQResponse, req *memdQRequest, err error) {
+		var respPak *memd.Packet
+		if resp != nil {
+			respPak = resp.Packet
+		}
+		for i := len(mux.interceptors) - 1; i >= 0; i-- {
+			mux.interceptors[i].AfterReceive(&req.Packet, respPak, err)
+		}
+		cb(resp, req, err)
+	}
+
+	return func() {
+		req.Callback = cb
+		atomic.StoreUint32(&req.interceptorsApplied, 0)
+	}
+}
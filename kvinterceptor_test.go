@@ -0,0 +1,143 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+	"github.com/couchbase/gocbcore/v10/memdmock"
+)
+
+type testKVInterceptorEvents struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (e *testKVInterceptorEvents) record(event string, req *memd.Packet) {
+	// Requests made internally by the agent, such as config polling, are also intercepted.
+	if req.Command != memd.CmdGet && req.Command != memd.CmdSet {
+		return
+	}
+	e.lock.Lock()
+	e.events = append(e.events, event+":"+req.Command.Name())
+	e.lock.Unlock()
+}
+
+func (e *testKVInterceptorEvents) Events() []string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.events
+}
+
+type testKVInterceptor struct {
+	name   string
+	events *testKVInterceptorEvents
+	failFn func(req *memd.Packet) error
+}
+
+func (i *testKVInterceptor) BeforeSend(req *memd.Packet) error {
+	i.events.record(i.name+":before", req)
+	if i.failFn != nil {
+		return i.failFn(req)
+	}
+	return nil
+}
+
+func (i *testKVInterceptor) AfterReceive(req *memd.Packet, resp *memd.Packet, err error) {
+	status := "nil"
+	if resp != nil {
+		status = resp.Status.String()
+	}
+	i.events.record(i.name+":after("+status+")", req)
+}
+
+func (suite *UnitTestSuite) TestKVInterceptors() {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		Username: "user",
+		Password: "pass",
+	})
+	suite.Require().Nil(err, err)
+	defer srv.Close()
+
+	events := &testKVInterceptorEvents{}
+	injectedErr := errors.New("injected")
+	first := &testKVInterceptor{name: "first", events: events}
+	second := &testKVInterceptor{
+		name:   "second",
+		events: events,
+		failFn: func(req *memd.Packet) error {
+			if req.Command == memd.CmdGet && string(req.Key) == "fail" {
+				return injectedErr
+			}
+			return nil
+		},
+	}
+
+	agent := suite.createMemdMockAgent(srv, &AgentConfig{
+		KVInterceptors: []KVInterceptor{first, second},
+	})
+	defer agent.Close()
+
+	setCh := make(chan error, 1)
+	_, err = agent.Set(SetOptions{
+		Key:   []byte("key"),
+		Value: []byte("value"),
+	}, func(res *StoreResult, err error) {
+		setCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Require().Nil(<-setCh)
+
+	getCh := make(chan error, 1)
+	_, err = agent.Get(GetOptions{
+		Key: []byte("missing"),
+	}, func(res *GetResult, err error) {
+		getCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().ErrorIs(<-getCh, ErrDocumentNotFound)
+
+	// Requests which are retried are passed to BeforeSend for each attempt but only to AfterReceive once.
+	var tmpFailed uint32
+	srv.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		if atomic.CompareAndSwapUint32(&tmpFailed, 0, 1) {
+			return memdmock.ErrorResponse(req, memd.StatusNotMyVBucket)
+		}
+		return nil
+	})
+
+	_, err = agent.Get(GetOptions{
+		Key: []byte("missing"),
+	}, func(res *GetResult, err error) {
+		getCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().ErrorIs(<-getCh, ErrDocumentNotFound)
+
+	_, err = agent.Get(GetOptions{
+		Key: []byte("fail"),
+	}, func(res *GetResult, err error) {
+		suite.Fail("callback should not be invoked")
+	})
+	suite.Assert().ErrorIs(err, injectedErr)
+
+	suite.Assert().Equal([]string{
+		"first:before:CMD_SET",
+		"second:before:CMD_SET",
+		"second:after(success):CMD_SET",
+		"first:after(success):CMD_SET",
+		"first:before:CMD_GET",
+		"second:before:CMD_GET",
+		"second:after(key not found):CMD_GET",
+		"first:after(key not found):CMD_GET",
+		"first:before:CMD_GET",
+		"second:before:CMD_GET",
+		"first:before:CMD_GET",
+		"second:before:CMD_GET",
+		"second:after(key not found):CMD_GET",
+		"first:after(key not found):CMD_GET",
+		"first:before:CMD_GET",
+		"second:before:CMD_GET",
+	}, events.Events())
+}
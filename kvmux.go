@@ -36,11 +36,14 @@ type clientProvider interface {
 
 type kvMux struct {
 	muxPtr unsafe.Pointer
+	logger *scopedLogger
 
 	bucketName         string
 	collectionsEnabled bool
 	queueSize          int
+	nodeQueueSize      int
 	poolSize           int
+	reservedPoolSize   int
 	cfgMgr             *configManagementComponent
 	errMapMgr          *errMapComponent
 
@@ -49,6 +52,10 @@ type kvMux struct {
 
 	postCompleteErrHandler postCompleteErrorHandler
 
+	interceptors []KVInterceptor
+
+	stats kvOpStats
+
 	// muxStateWriteLock is necessary for functions which update the muxPtr, due to the scenario where ForceReconnect and
 	// OnNewRouteConfig could race. ForceReconnect must succeed and cannot fail because OnNewRouteConfig has updated
 	// the mux state whilst force is attempting to update it. We could also end up in a situation where a full reconnect
@@ -69,15 +76,22 @@ type kvMux struct {
 type kvMuxProps struct {
 	CollectionsEnabled bool
 	QueueSize          int
+	NodeQueueSize      int
 	PoolSize           int
+	ReservedPoolSize   int
 	NoTLSSeedNode      bool
+	Logger             *scopedLogger
+	Interceptors       []KVInterceptor
 }
 
 func newKVMux(props kvMuxProps, cfgMgr *configManagementComponent, errMapMgr *errMapComponent, tracer *tracerComponent,
 	dialer *memdClientDialerComponent, muxState *kvMuxState) *kvMux {
 	mux := &kvMux{
 		queueSize:          props.QueueSize,
+		nodeQueueSize:      props.NodeQueueSize,
+		logger:             props.Logger,
 		poolSize:           props.PoolSize,
+		reservedPoolSize:   props.ReservedPoolSize,
 		collectionsEnabled: props.CollectionsEnabled,
 		cfgMgr:             cfgMgr,
 		errMapMgr:          errMapMgr,
@@ -88,6 +102,7 @@ func newKVMux(props kvMuxProps, cfgMgr *configManagementComponent, errMapMgr *er
 		muxPtr:             unsafe.Pointer(muxState),
 		hasSeenConfigCh:    make(chan struct{}),
 		bucketName:         muxState.expectedBucketName,
+		interceptors:       props.Interceptors,
 	}
 
 	cfgMgr.AddConfigWatcher(mux)
@@ -106,7 +121,7 @@ func (mux *kvMux) getState() *kvMuxState {
 
 func (mux *kvMux) updateState(old, new *kvMuxState) bool {
 	if new == nil {
-		logErrorf("Attempted to update to nil kvMuxState")
+		mux.logger.errorf("Attempted to update to nil kvMuxState")
 		return false
 	}
 
@@ -115,7 +130,7 @@ func (mux *kvMux) updateState(old, new *kvMuxState) bool {
 	}
 
 	if atomic.SwapPointer(&mux.muxPtr, unsafe.Pointer(new)) != nil {
-		logErrorf("Updated from nil attempted on initialized kvMuxState")
+		mux.logger.errorf("Updated from nil attempted on initialized kvMuxState")
 		return false
 	}
 
@@ -135,20 +150,20 @@ func (mux *kvMux) OnNewRouteConfig(cfg *routeConfig) {
 	oldMuxState := mux.getState()
 	if oldMuxState == nil {
 		// We can get here if we're shutting down and a NMVB comes in from an in flight request.
-		logWarnf("Received new config whilst shutting down kvmux")
+		mux.logger.warnf("Received new config whilst shutting down kvmux")
 		return
 	}
 	newMuxState := mux.newKVMuxState(cfg, oldMuxState.tlsConfig, oldMuxState.authMechanisms, oldMuxState.auth)
 
 	// Attempt to atomically update the routing data
 	if !mux.updateState(oldMuxState, newMuxState) {
-		logWarnf("Someone preempted the config update, skipping update")
+		mux.logger.warnf("Someone preempted the config update, skipping update")
 		return
 	}
 
 	if oldMuxState.RevID() == -1 && newMuxState.RevID() > -1 {
 		if cfg.name != "" && mux.collectionsEnabled && !newMuxState.collectionsSupported {
-			logDebugf("Collections disabled as unsupported")
+			mux.logger.debugf("Collections disabled as unsupported")
 		}
 
 		close(mux.hasSeenConfigCh)
@@ -182,6 +197,16 @@ func (mux *kvMux) ConfigRev() (int64, error) {
 	return clientMux.RevID(), nil
 }
 
+// ConfigRevAndEpoch returns the revision and epoch of the config currently used for routing, ok is false if there is
+// no config yet.
+func (mux *kvMux) ConfigRevAndEpoch() (rev int64, epoch int64, ok bool) {
+	clientMux := mux.getState()
+	if clientMux == nil || clientMux.RevID() < 0 {
+		return 0, 0, false
+	}
+	return clientMux.RevID(), clientMux.RevEpoch(), true
+}
+
 func (mux *kvMux) ConfigUUID() string {
 	clientMux := mux.getState()
 	if clientMux == nil {
@@ -192,13 +217,43 @@ func (mux *kvMux) ConfigUUID() string {
 
 func (mux *kvMux) KeyToVbucket(key []byte) (uint16, error) {
 	clientMux := mux.getState()
-	if clientMux == nil || clientMux.VBMap() == nil {
+	if clientMux == nil {
 		return 0, errShutdown
 	}
+	if clientMux.RevID() == -1 {
+		return 0, wrapError(errServiceNotAvailable, "no cluster config has been applied yet")
+	}
+	if clientMux.VBMap() == nil {
+		return 0, errUnsupportedOperation
+	}
 
 	return clientMux.VBMap().VbucketByKey(key), nil
 }
 
+// VbucketToServer returns the address of the node which hosts the given copy of the vbucket in the current config.
+func (mux *kvMux) VbucketToServer(vbID uint16, replicaIdx uint32) (string, error) {
+	clientMux := mux.getState()
+	if clientMux == nil {
+		return "", errShutdown
+	}
+	if clientMux.RevID() == -1 {
+		return "", wrapError(errServiceNotAvailable, "no cluster config has been applied yet")
+	}
+	if clientMux.VBMap() == nil {
+		return "", errUnsupportedOperation
+	}
+
+	srvIdx, err := clientMux.VBMap().NodeByVbucket(vbID, replicaIdx)
+	if err != nil {
+		return "", err
+	}
+	if srvIdx < 0 || srvIdx >= clientMux.NumPipelines() {
+		return "", wrapError(errInvalidServer, "vbucket copy is not currently assigned to a node")
+	}
+
+	return clientMux.GetPipeline(srvIdx).Address(), nil
+}
+
 func (mux *kvMux) NumReplicas() int {
 	clientMux := mux.getState()
 	if clientMux == nil {
@@ -248,6 +303,34 @@ func (mux *kvMux) IsSecure() bool {
 	return mux.getState().tlsConfig != nil
 }
 
+// SupportsFeature returns whether every connected client has negotiated the feature with the server. Clients which are
+// not currently connected are ignored, and false is returned if no clients are connected.
+func (mux *kvMux) SupportsFeature(feature memd.HelloFeature) bool {
+	clientMux := mux.getState()
+	if clientMux == nil {
+		return false
+	}
+
+	var numConnected int
+	for _, pipeline := range clientMux.pipelines {
+		for _, pipecli := range pipeline.Clients() {
+			pipecli.lock.Lock()
+			client := pipecli.client
+			pipecli.lock.Unlock()
+
+			if client == nil {
+				continue
+			}
+			if !client.SupportsFeature(feature) {
+				return false
+			}
+			numConnected++
+		}
+	}
+
+	return numConnected > 0
+}
+
 // SupportsCollections returns whether or not collections are enabled AND supported by the server.
 func (mux *kvMux) SupportsCollections() bool {
 	if !mux.collectionsEnabled {
@@ -341,13 +424,50 @@ func (mux *kvMux) RouteRequest(req *memdQRequest) (*memdPipeline, error) {
 	return clientMux.GetPipeline(srvIdx), nil
 }
 
+func (mux *kvMux) Stats() AgentStats {
+	return mux.stats.Snapshot()
+}
+
+// trackStats counts the request as issued and wraps its callback to count it as finished, returning a function
+// which reverts this if the request fails to dispatch, in which case the error is returned to the caller instead.
+func (mux *kvMux) trackStats(req *memdQRequest) func() {
+	// Persistent requests receive many responses so aren't counted, and requests can be dispatched more than once
+	// (e.g. after a collection ID has been resolved) so must only be counted the first time.
+	if req.Persistent || !atomic.CompareAndSwapUint32(&req.statsTracked, 0, 1) {
+		return func() {}
+	}
+
+	mux.stats.recordIssued()
+	cb := req.Callback
+	req.Callback = func(resp *memdQResponse, req *memdQRequest, err error) {
+		mux.stats.recordFinished(req.RetryAttempts(), err)
+		cb(resp, req, err)
+	}
+
+	return func() {
+		mux.stats.revertIssued()
+		req.Callback = cb
+		atomic.StoreUint32(&req.statsTracked, 0)
+	}
+}
+
 func (mux *kvMux) DispatchDirect(req *memdQRequest) (PendingOp, error) {
 	mux.tracer.StartCmdTrace(req)
 	req.dispatchTime = time.Now()
+	untrack := mux.trackStats(req)
+	unintercept := mux.interceptResponse(req)
+
+	if err := mux.interceptRequest(req); err != nil {
+		unintercept()
+		untrack()
+		return nil, err
+	}
 
 	for {
 		pipeline, err := mux.RouteRequest(req)
 		if err != nil {
+			unintercept()
+			untrack()
 			return nil, err
 		}
 
@@ -364,6 +484,8 @@ func (mux *kvMux) DispatchDirect(req *memdQRequest) (PendingOp, error) {
 				return req, nil
 			}
 
+			unintercept()
+			untrack()
 			return nil, routeErr
 		}
 
@@ -383,13 +505,26 @@ func (mux *kvMux) requeueDirect(pipeline *memdPipeline, req *memdQRequest, isRet
 	handleError := func(err error) {
 		// We only want to log an error on retries if the error isn't cancelled.
 		if !isRetry || (isRetry && !errors.Is(err, ErrRequestCanceled)) {
-			logErrorf("Reschedule failed, failing request, Opaque=%d, Opcode=0x%x, (%s)", req.Opaque, req.Command, err)
+			mux.logger.errorf("Reschedule failed, failing request, Opaque=%d, Opcode=0x%x, (%s)", req.Opaque, req.Command, err)
+		}
+
+		// If the request has already been retried then the caller needs to know why, otherwise the error they
+		// see won't tell them anything about the attempts made before rescheduling failed.
+		if retryCount, _ := req.Retries(); retryCount > 0 && !errors.Is(err, ErrRequestCanceled) {
+			err = mux.errMapMgr.EnhanceKvError(err, nil, req)
 		}
 
 		req.tryCallback(nil, err)
 	}
 
-	logDebugf("Request being requeued, Opaque=%d, Opcode=0x%x", req.Opaque, req.Command)
+	mux.logger.debugf("Request being requeued, Opaque=%d, Opcode=0x%x", req.Opaque, req.Command)
+
+	// Requests being requeued have normally already been dispatched, in which case this is a no-op.
+	mux.interceptResponse(req)
+	if err := mux.interceptRequest(req); err != nil {
+		req.tryCallback(nil, err)
+		return
+	}
 
 	if pipeline == nil {
 		var err error
@@ -454,9 +589,16 @@ func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, address string) (Pe
 	}
 	req.ReplicaIdx = -999999999
 
+	unintercept := mux.interceptResponse(req)
+	if err := mux.interceptRequest(req); err != nil {
+		unintercept()
+		return nil, err
+	}
+
 	for {
 		clientMux := mux.getState()
 		if clientMux == nil {
+			unintercept()
 			return nil, errShutdown
 		}
 
@@ -469,6 +611,7 @@ func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, address string) (Pe
 		}
 
 		if pipeline == nil {
+			unintercept()
 			return nil, errInvalidServer
 		}
 
@@ -485,6 +628,7 @@ func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, address string) (Pe
 				return req, nil
 			}
 
+			unintercept()
 			return nil, routeErr
 		}
 
@@ -495,7 +639,7 @@ func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, address string) (Pe
 }
 
 func (mux *kvMux) Close() error {
-	logInfof("KV Mux closing")
+	mux.logger.infof("KV Mux closing")
 
 	mux.cfgMgr.RemoveConfigWatcher(mux)
 	clientMux := mux.clear()
@@ -513,7 +657,7 @@ func (mux *kvMux) Close() error {
 	for _, pipeline := range clientMux.pipelines {
 		err := pipeline.Close()
 		if err != nil {
-			logErrorf("failed to shut down pipeline: %s", err)
+			mux.logger.errorf("failed to shut down pipeline: %s", err)
 			muxErr = errCliInternalError
 		}
 	}
@@ -521,7 +665,7 @@ func (mux *kvMux) Close() error {
 	if clientMux.deadPipe != nil {
 		err := clientMux.deadPipe.Close()
 		if err != nil {
-			logErrorf("failed to shut down deadpipe: %s", err)
+			mux.logger.errorf("failed to shut down deadpipe: %s", err)
 			muxErr = errCliInternalError
 		}
 	}
@@ -536,14 +680,14 @@ func (mux *kvMux) Close() error {
 
 	mux.clientCloseWg.Wait()
 
-	logInfof("KV Mux closed")
+	mux.logger.infof("KV Mux closed")
 
 	return muxErr
 }
 
 func (mux *kvMux) ForceReconnect(tlsConfig *dynTLSConfig, authMechanisms []AuthMechanism, auth AuthProvider,
 	reconnectLocal bool) {
-	logDebugf("Forcing reconnect of all connections")
+	mux.logger.debugf("Forcing reconnect of all connections")
 	mux.muxStateWriteLock.Lock()
 	muxState := mux.getState()
 	newMuxState := mux.newKVMuxState(muxState.RouteConfig(), tlsConfig, authMechanisms, auth)
@@ -681,7 +825,7 @@ func (mux *kvMux) handleOpRoutingResp(resp *memdQResponse, req *memdQRequest, or
 				return true, nil
 			}
 		} else if errors.Is(err, ErrMemdConfigOnly) {
-			logWarnf("Received config-only status, will attempt to refresh config map and retry operation")
+			mux.logger.warnf("Received config-only status, will attempt to refresh config map and retry operation")
 			if mux.handleConfigOnly(resp, req) {
 				return true, nil
 			}
@@ -689,6 +833,7 @@ func (mux *kvMux) handleOpRoutingResp(resp *memdQResponse, req *memdQRequest, or
 			// We don't know anything about this error so send it to the error map
 			shouldRetry := mux.errMapMgr.ShouldRetry(resp.Status)
 			if shouldRetry {
+				req.setServerRetryAfter(mux.errMapMgr.RetryDelay(resp.Status, req.RetryAttempts()))
 				if mux.waitAndRetryOperation(req, KVErrMapRetryReason) {
 					return true, nil
 				}
@@ -750,11 +895,11 @@ func (mux *kvMux) parseNotMyVbucketValue(value []byte, sourceAddr string) *cfgBu
 	// Grab just the hostname from the source address
 	sourceHost, err := hostFromHostPort(sourceAddr)
 	if err != nil {
-		logErrorf("NMV response source address was invalid, skipping config update")
+		mux.logger.errorf("NMV response source address was invalid, skipping config update")
 		return nil
 	}
 	// Try to parse the value as a bucket configuration
-	logDebugf("Got NMV Block: %v", string(value))
+	mux.logger.debugf("Got NMV Block: %v", string(value))
 	bk, err := parseConfig(value, sourceHost)
 	if err != nil {
 		return nil
@@ -764,13 +909,14 @@ func (mux *kvMux) parseNotMyVbucketValue(value []byte, sourceAddr string) *cfgBu
 }
 
 func (mux *kvMux) handleNotMyVbucket(resp *memdQResponse, req *memdQRequest) bool {
-	// For range scan continue we never want to retry, the range scan is now invalid.
-	isRetryableReq := req.Command != memd.CmdRangeScanContinue
+	// For range scan continue we never want to retry, the range scan is now invalid. Requests which have opted out of
+	// retries must also never be redispatched, although we still want to apply any config that we've been sent.
+	isRetryableReq := req.Command != memd.CmdRangeScanContinue && !req.NoRetry
 
-	logSchedf("Received NMV for request. OP=0x%x. Opaque=%d. Vbid: %d", req.Command, req.Opaque, req.Vbucket)
+	mux.logger.schedf("Received NMV for request. OP=0x%x. Opaque=%d. Vbid: %d", req.Command, req.Opaque, req.Vbucket)
 
 	if len(resp.Value) == 0 {
-		logDebugf("NMV response containing no new config")
+		mux.logger.debugf("NMV response containing no new config")
 		if !isRetryableReq {
 			return false
 		}
@@ -808,7 +954,10 @@ func (mux *kvMux) handleNotMyVbucket(resp *memdQResponse, req *memdQRequest) boo
 func (mux *kvMux) handleConfigOnly(resp *memdQResponse, req *memdQRequest) bool {
 	snapshot, err := mux.PipelineSnapshot()
 	if err != nil {
-		logInfof("Failed to get pipeline snapshot: %s", err)
+		mux.logger.infof("Failed to get pipeline snapshot: %s", err)
+		if req.NoRetry {
+			return false
+		}
 		// Not much we can do here, attempt a retry.
 		mux.RequeueDirect(req, true)
 		return true
@@ -820,14 +969,16 @@ func (mux *kvMux) handleConfigOnly(resp *memdQResponse, req *memdQRequest) bool
 		// calling RefreshConfig will end up blocking because we're holding the client read thread open
 		// whilst also trying to shutdown the client.
 		mux.cfgMgr.RefreshConfig(snapshot)
-		mux.RequeueDirect(req, true)
+		if !req.NoRetry {
+			mux.RequeueDirect(req, true)
+		}
 	}()
-	return true
+	return !req.NoRetry
 }
 
 func (mux *kvMux) drainPipelines(clientMux *kvMuxState, cb func(req *memdQRequest)) {
 	for _, pipeline := range clientMux.pipelines {
-		logDebugf("Draining queue. Address=`%s`. Num Clients=%d. Server Group=`%s`. Op Queue={%s}",
+		mux.logger.debugf("Draining queue. Address=`%s`. Num Clients=%d. Server Group=`%s`. Op Queue={%s}",
 			pipeline.Address(),
 			len(pipeline.Clients()),
 			pipeline.ServerGroup(),
@@ -842,8 +993,10 @@ func (mux *kvMux) drainPipelines(clientMux *kvMuxState, cb func(req *memdQReques
 func (mux *kvMux) newKVMuxState(cfg *routeConfig, tlsConfig *dynTLSConfig, authMechanisms []AuthMechanism,
 	auth AuthProvider) *kvMuxState {
 	poolSize := 1
+	var reservedPoolSize int
 	if !cfg.IsGCCCPConfig() {
 		poolSize = mux.poolSize
+		reservedPoolSize = mux.reservedPoolSize
 	}
 
 	useTls := tlsConfig != nil
@@ -879,7 +1032,7 @@ func (mux *kvMux) newKVMuxState(cfg *routeConfig, tlsConfig *dynTLSConfig, authM
 		buffer.WriteString(fmt.Sprintf("  - %s\n", ep.Address))
 	}
 
-	logDebugf(buffer.String())
+	mux.logger.debugf(buffer.String())
 
 	pipelines := make([]*memdPipeline, len(kvServerList))
 	for i, hostPort := range kvServerList {
@@ -893,13 +1046,14 @@ func (mux *kvMux) newKVMuxState(cfg *routeConfig, tlsConfig *dynTLSConfig, authM
 			return mux.dialer.SlowDialMemdClient(cancelSig, trimmedHostPort, tlsConfig, auth, authMechanisms,
 				mux.handleOpRoutingResp, mux.handleServerRequest)
 		}
-		pipeline := newPipeline(trimmedHostPort, poolSize, mux.queueSize, getCurClientFn)
+		pipeline := newPipeline(trimmedHostPort, poolSize, reservedPoolSize, mux.queueSize, mux.nodeQueueSize,
+			getCurClientFn, mux.logger)
 
 		pipelines[i] = pipeline
 	}
 
 	return newKVMuxState(cfg, kvServerList, tlsConfig, authMechanisms, auth, mux.bucketName, pipelines,
-		newDeadPipeline(mux.queueSize))
+		newDeadPipeline(mux.queueSize, mux.logger))
 }
 
 func (mux *kvMux) reconnectPipelines(oldMuxState *kvMuxState, newMuxState *kvMuxState, reconnectSeed bool) {
@@ -926,7 +1080,7 @@ func (mux *kvMux) reconnectPipelines(oldMuxState *kvMuxState, newMuxState *kvMux
 	for e := oldPipelines.Front(); e != nil; e = e.Next() {
 		pipeline, ok := e.Value.(*memdPipeline)
 		if !ok {
-			logErrorf("Failed to cast old pipeline")
+			mux.logger.errorf("Failed to cast old pipeline")
 			continue
 		}
 
@@ -974,16 +1128,16 @@ func (mux *kvMux) closeMemdClient(client *memdClient, err error) {
 	go func(client *memdClient) {
 		select {
 		case <-client.CloseNotify():
-			logDebugf("Memdclient %s/%p completed graceful shutdown", client.Address(), client)
+			mux.logger.debugf("Memdclient %s/%p completed graceful shutdown", client.Address(), client)
 		case <-mux.shutdownSig:
-			logDebugf("Memdclient %s/%p being forcibly shutdown", client.Address(), client)
+			mux.logger.debugf("Memdclient %s/%p being forcibly shutdown", client.Address(), client)
 			// Force the client to close even if there are requests in flight.
 			err := client.Close()
 			if err != nil {
-				logErrorf("failed to shutdown memdclient: %s", err)
+				mux.logger.errorf("failed to shutdown memdclient: %s", err)
 			}
 			<-client.CloseNotify()
-			logDebugf("Memdclient %s/%p completed shutdown", client.Address(), client)
+			mux.logger.debugf("Memdclient %s/%p completed shutdown", client.Address(), client)
 		}
 		mux.clientCloseWg.Done()
 	}(client)
@@ -993,7 +1147,7 @@ func (mux *kvMux) stealPipeline(address string, oldPipelines *list.List) *memdPi
 	for e := oldPipelines.Front(); e != nil; e = e.Next() {
 		pipeline, ok := e.Value.(*memdPipeline)
 		if !ok {
-			logErrorf("Failed to cast old pipeline")
+			mux.logger.errorf("Failed to cast old pipeline")
 			continue
 		}
 
@@ -1030,7 +1184,7 @@ func (mux *kvMux) pipelineTakeover(oldMux, newMux *kvMuxState) {
 	for e := oldPipelines.Front(); e != nil; e = e.Next() {
 		pipeline, ok := e.Value.(*memdPipeline)
 		if !ok {
-			logErrorf("Failed to cast old pipeline")
+			mux.logger.errorf("Failed to cast old pipeline")
 			continue
 		}
 
@@ -1043,7 +1197,7 @@ func (mux *kvMux) pipelineTakeover(oldMux, newMux *kvMuxState) {
 	if oldMux != nil && oldMux.deadPipe != nil {
 		err := oldMux.deadPipe.Close()
 		if err != nil {
-			logErrorf("Failed to properly close abandoned dead pipe (%s)", err)
+			mux.logger.errorf("Failed to properly close abandoned dead pipe (%s)", err)
 		}
 	}
 }
@@ -1058,7 +1212,7 @@ func (mux *kvMux) handleServerRequest(pak *memd.Packet) {
 		go func() {
 			snapshot, err := mux.PipelineSnapshot()
 			if err != nil {
-				logInfof("Failed to get pipeline snapshot: %s", err)
+				mux.logger.infof("Failed to get pipeline snapshot: %s", err)
 				return
 			}
 			mux.cfgMgr.OnNewConfigChangeNotifBrief(snapshot, extras)
@@ -1066,5 +1220,5 @@ func (mux *kvMux) handleServerRequest(pak *memd.Packet) {
 		return
 	}
 
-	logWarnf("Received an unknown command type for a server request: OP=0x%x", pak.Command)
+	mux.logger.warnf("Received an unknown command type for a server request: OP=0x%x", pak.Command)
 }
@@ -1,5 +1,13 @@
 package gocbcore
 
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
 func (suite *StandardTestSuite) TestKvMux_HasBucketCapabilityStatusNoState() {
 	// No mux state, shouldn't actually happen in practise.
 	mux := kvMux{}
@@ -73,3 +81,222 @@ func (suite *StandardTestSuite) TestKvMux_HasBucketCapabilityStatusSupported() {
 	suite.Assert().False(mux.HasBucketCapabilityStatus(9999, CapabilityStatusSupported))
 	suite.Assert().True(mux.HasBucketCapabilityStatus(9999, CapabilityStatusUnsupported))
 }
+
+func (suite *UnitTestSuite) TestKvMux_ConfigRevAndEpoch() {
+	mux := kvMux{}
+
+	_, _, ok := mux.ConfigRevAndEpoch()
+	suite.Assert().False(ok)
+
+	blankState := newKVMuxState(&routeConfig{revID: -1}, nil, nil, nil, nil, "", nil, nil)
+	mux.updateState(nil, blankState)
+
+	_, _, ok = mux.ConfigRevAndEpoch()
+	suite.Assert().False(ok)
+
+	mux.updateState(blankState, &kvMuxState{
+		routeCfg: routeConfig{
+			revID:    12,
+			revEpoch: 3,
+		},
+	})
+
+	rev, epoch, ok := mux.ConfigRevAndEpoch()
+	suite.Assert().True(ok)
+	suite.Assert().Equal(int64(12), rev)
+	suite.Assert().Equal(int64(3), epoch)
+}
+
+func (suite *UnitTestSuite) TestKvMux_SupportsFeature() {
+	newPipeline := func(features ...[]memd.HelloFeature) *memdPipeline {
+		pipeline := &memdPipeline{}
+		for _, f := range features {
+			pipeline.clients = append(pipeline.clients, &memdPipelineClient{
+				client: &memdClient{features: f},
+			})
+		}
+		return pipeline
+	}
+
+	mux := kvMux{}
+	suite.Assert().False(mux.SupportsFeature(memd.FeatureDurations))
+
+	// No clients are connected so nothing can be said to be supported.
+	mux.updateState(nil, &kvMuxState{
+		pipelines: []*memdPipeline{newPipeline(), {clients: []*memdPipelineClient{{}}}},
+	})
+	suite.Assert().False(mux.SupportsFeature(memd.FeatureDurations))
+
+	// One client has not negotiated the feature.
+	mixedState := &kvMuxState{
+		pipelines: []*memdPipeline{
+			newPipeline([]memd.HelloFeature{memd.FeatureDurations, memd.FeatureSnappy}),
+			newPipeline([]memd.HelloFeature{memd.FeatureSnappy}, []memd.HelloFeature{memd.FeatureDurations}),
+		},
+	}
+	mux.updateState(mux.getState(), mixedState)
+	suite.Assert().False(mux.SupportsFeature(memd.FeatureDurations))
+	suite.Assert().False(mux.SupportsFeature(memd.FeatureSnappy))
+
+	// Every connected client has negotiated the feature, clients which are not connected are ignored.
+	mux.updateState(mixedState, &kvMuxState{
+		pipelines: []*memdPipeline{
+			newPipeline([]memd.HelloFeature{memd.FeatureDurations}),
+			{clients: []*memdPipelineClient{{}, {client: &memdClient{features: []memd.HelloFeature{memd.FeatureDurations}}}}},
+		},
+	})
+	suite.Assert().True(mux.SupportsFeature(memd.FeatureDurations))
+	suite.Assert().False(mux.SupportsFeature(memd.FeatureSnappy))
+}
+
+func (suite *UnitTestSuite) TestKvMux_VbucketToServerErrors() {
+	mux := kvMux{}
+
+	_, err := mux.KeyToVbucket([]byte("key"))
+	suite.Assert().ErrorIs(err, ErrShutdown)
+	_, err = mux.VbucketToServer(0, 0)
+	suite.Assert().ErrorIs(err, ErrShutdown)
+
+	blankState := newKVMuxState(&routeConfig{revID: -1}, nil, nil, nil, nil, "", nil, nil)
+	mux.updateState(nil, blankState)
+
+	_, err = mux.KeyToVbucket([]byte("key"))
+	suite.Assert().ErrorIs(err, ErrServiceNotAvailable)
+	_, err = mux.VbucketToServer(0, 0)
+	suite.Assert().ErrorIs(err, ErrServiceNotAvailable)
+
+	memcachedState := &kvMuxState{
+		routeCfg: routeConfig{
+			revID:   1,
+			bktType: bktTypeMemcached,
+		},
+	}
+	mux.updateState(blankState, memcachedState)
+
+	_, err = mux.KeyToVbucket([]byte("key"))
+	suite.Assert().ErrorIs(err, ErrUnsupportedOperation)
+	_, err = mux.VbucketToServer(0, 0)
+	suite.Assert().ErrorIs(err, ErrUnsupportedOperation)
+
+	mux.updateState(memcachedState, &kvMuxState{
+		routeCfg: routeConfig{
+			revID:   2,
+			bktType: bktTypeCouchbase,
+			vbMap:   newVbucketMap([][]int{{0, -1}}, 1),
+		},
+	})
+
+	_, err = mux.VbucketToServer(1, 0)
+	suite.Assert().ErrorIs(err, ErrInvalidVBucket)
+	_, err = mux.VbucketToServer(0, 2)
+	suite.Assert().ErrorIs(err, ErrInvalidReplica)
+	_, err = mux.VbucketToServer(0, 1)
+	suite.Assert().ErrorIs(err, ErrInvalidServer)
+}
+
+func (suite *UnitTestSuite) TestKvMux_RequeueFailureIncludesRetries() {
+	// No mux state so rescheduling will always fail.
+	mux := &kvMux{
+		errMapMgr: newErrMapManager("default"),
+		tracer:    newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil),
+		logger: newScopedLogger(&defaultLogger{
+			GoLogger: log.New(ioutil.Discard, "", 0),
+			Level:    LogDebug,
+		}),
+	}
+
+	var cbErr error
+	req := &memdQRequest{
+		Packet: memd.Packet{
+			Key: []byte("key"),
+		},
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			cbErr = err
+		},
+	}
+	req.recordRetryAttempt(KVNotMyVBucketRetryReason)
+	req.recordRetryAttempt(KVTemporaryFailureRetryReason)
+
+	mux.RequeueDirect(req, true)
+
+	suite.Require().ErrorIs(cbErr, errShutdown)
+
+	var kvErr *KeyValueError
+	suite.Require().True(errors.As(cbErr, &kvErr))
+	suite.Assert().Equal("key", kvErr.DocumentKey)
+	suite.Assert().Equal(uint32(2), kvErr.RetryAttempts)
+	suite.Assert().Equal([]RetryReason{KVNotMyVBucketRetryReason, KVTemporaryFailureRetryReason}, kvErr.RetryReasons)
+
+	// A request which was never retried is failed with the error unchanged.
+	req = &memdQRequest{
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			cbErr = err
+		},
+	}
+
+	mux.RequeueDirect(req, false)
+
+	suite.Assert().Equal(errShutdown, cbErr)
+}
+
+func (suite *UnitTestSuite) TestKvMux_NoRetryNotRequeuedDirectly() {
+	cfgMgr := newConfigManager(configManagerProperties{
+		SrcMemdAddrs: []routeEndpoint{{Address: "127.0.0.1:11210"}},
+	})
+	// No mux state so any attempt to requeue the request fails it with errShutdown.
+	mux := &kvMux{
+		cfgMgr:    cfgMgr,
+		errMapMgr: newErrMapManager("default"),
+		tracer:    newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil),
+	}
+
+	newReq := func() *memdQRequest {
+		return &memdQRequest{
+			Packet: memd.Packet{
+				Command: memd.CmdSet,
+				Key:     []byte("key"),
+			},
+			NoRetry: true,
+			Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+				suite.Fail("request should not have been requeued")
+			},
+		}
+	}
+
+	cfg, err := suite.LoadRawTestDataset("bucket_config_with_rev_epoch")
+	suite.Require().Nil(err, err)
+
+	req := newReq()
+	resp := &memdQResponse{
+		Packet: &memd.Packet{
+			Magic:  memd.CmdMagicRes,
+			Status: memd.StatusNotMyVBucket,
+			Value:  cfg,
+		},
+		sourceAddr: "127.0.0.1:11210",
+	}
+	retried, err := mux.handleOpRoutingResp(resp, req, ErrMemdNotMyVBucket)
+	suite.Assert().False(retried)
+	suite.Assert().ErrorIs(err, ErrNotMyVBucket)
+	suite.Assert().Zero(req.RetryAttempts())
+
+	// The config from the response is still applied.
+	rev, _ := cfgMgr.CurrentRev()
+	suite.Assert().Equal(int64(2), rev)
+
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	req = newReq()
+	resp = &memdQResponse{
+		Packet: &memd.Packet{
+			Magic:  memd.CmdMagicRes,
+			Status: memd.StatusConfigOnly,
+		},
+		sourceAddr: "127.0.0.1:11210",
+	}
+	retried, err = mux.handleOpRoutingResp(resp, req, ErrMemdConfigOnly)
+	suite.Assert().False(retried)
+	suite.Assert().ErrorIs(err, ErrMemdConfigOnly)
+	suite.Assert().Zero(req.RetryAttempts())
+}
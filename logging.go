@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
 )
 
 // LogLevel specifies the severity of a log message.
@@ -119,6 +120,7 @@ var (
 
 	globalLogger            Logger
 	globalLogRedactionLevel LogRedactLevel
+	globalLogFilter         atomic.Value
 )
 
 // DefaultStdioLogger gets the default standard I/O logger.
@@ -142,12 +144,34 @@ func SetLogger(logger Logger) {
 	globalLogger = logger
 }
 
+// LogFilter decides whether a log message should be passed to the logger, returning false to drop it. It is passed the
+// format string of the message, before any formatting has been performed, so that it can be evaluated cheaply.
+type LogFilter func(level LogLevel, msg string) bool
+
+// SetLogFilter sets a filter which is consulted before each message is passed to the logger, a nil filter passes every
+// message. It is safe to call this whilst logging is in progress.
+func SetLogFilter(filter LogFilter) {
+	globalLogFilter.Store(filter)
+}
+
 type redactableLogValue interface {
 	redacted() interface{}
 }
 
 func logExf(level LogLevel, offset int, format string, v ...interface{}) {
-	if globalLogger != nil {
+	logExfTo(nil, level, offset+1, format, v...)
+}
+
+// logExfTo logs to the provided logger, or the global logger if it is nil.
+func logExfTo(logger Logger, level LogLevel, offset int, format string, v ...interface{}) {
+	if logger == nil {
+		logger = globalLogger
+	}
+	if logger != nil {
+		if filter, ok := globalLogFilter.Load().(LogFilter); ok && filter != nil && !filter(level, format) {
+			return
+		}
+
 		if level <= LogInfo && !isLogRedactionLevelNone() {
 			// We only redact at info level or below.
 			for i, iv := range v {
@@ -157,7 +181,7 @@ func logExf(level LogLevel, offset int, format string, v ...interface{}) {
 			}
 		}
 
-		err := globalLogger.Log(level, offset+1, format, v...)
+		err := logger.Log(level, offset+1, format, v...)
 		if err != nil {
 			log.Printf("Logger error occurred (%s)\n", err)
 		}
@@ -184,6 +208,46 @@ func logInfof(format string, v ...interface{}) {
 	logExf(LogInfo, 1, format, v...)
 }
 
+// scopedLogger logs to the logger of a single agent, falling back to the global logger when it has none. A nil
+// scopedLogger can be used and always logs to the global logger.
+type scopedLogger struct {
+	logger Logger
+}
+
+func newScopedLogger(logger Logger) *scopedLogger {
+	return &scopedLogger{
+		logger: logger,
+	}
+}
+
+func (l *scopedLogger) parent() Logger {
+	if l == nil {
+		return nil
+	}
+
+	return l.logger
+}
+
+func (l *scopedLogger) debugf(format string, v ...interface{}) {
+	logExfTo(l.parent(), LogDebug, 1, format, v...)
+}
+
+func (l *scopedLogger) schedf(format string, v ...interface{}) {
+	logExfTo(l.parent(), LogSched, 1, format, v...)
+}
+
+func (l *scopedLogger) warnf(format string, v ...interface{}) {
+	logExfTo(l.parent(), LogWarn, 1, format, v...)
+}
+
+func (l *scopedLogger) errorf(format string, v ...interface{}) {
+	logExfTo(l.parent(), LogError, 1, format, v...)
+}
+
+func (l *scopedLogger) infof(format string, v ...interface{}) {
+	logExfTo(l.parent(), LogInfo, 1, format, v...)
+}
+
 func reindentLog(indent, message string) string {
 	reindentedMessage := strings.Replace(message, "\n", "\n"+indent, -1)
 	return fmt.Sprintf("%s%s", indent, reindentedMessage)
@@ -3,6 +3,7 @@ package gocbcore
 import (
 	"bytes"
 	"log"
+	"strings"
 )
 
 func (suite *UnitTestSuite) TestLogRedaction() {
@@ -29,3 +30,63 @@ func (suite *UnitTestSuite) TestLogRedaction() {
 		suite.Assert().Equal("<sd>sensitive system data</sd>\n", logs.String())
 	}
 }
+
+func (suite *UnitTestSuite) TestLogFilter() {
+	var logs bytes.Buffer
+	oldLogger := globalLogger
+	SetLogger(&defaultLogger{
+		GoLogger: log.New(&logs, "", 0),
+		Level:    LogDebug,
+	})
+	defer SetLogger(oldLogger)
+
+	var filteredLevel LogLevel
+	SetLogFilter(func(level LogLevel, msg string) bool {
+		if strings.HasPrefix(msg, "Reconnecting") {
+			filteredLevel = level
+			return false
+		}
+		return true
+	})
+	defer SetLogFilter(nil)
+
+	logWarnf("Reconnecting to %s", "10.112.210.101")
+	logInfof("Connected to %s", "10.112.210.101")
+
+	suite.Assert().Equal("Connected to 10.112.210.101\n", logs.String())
+	suite.Assert().Equal(LogWarn, filteredLevel)
+
+	logs.Reset()
+	SetLogFilter(nil)
+
+	logInfof("Reconnecting to %s", "10.112.210.101")
+	suite.Assert().Equal("Reconnecting to 10.112.210.101\n", logs.String())
+}
+
+func (suite *UnitTestSuite) TestScopedLogger() {
+	var globalLogs bytes.Buffer
+	oldLogger := globalLogger
+	SetLogger(&defaultLogger{
+		GoLogger: log.New(&globalLogs, "", 0),
+		Level:    LogDebug,
+	})
+	defer SetLogger(oldLogger)
+
+	var agentLogs bytes.Buffer
+	logger := newScopedLogger(&defaultLogger{
+		GoLogger: log.New(&agentLogs, "", 0),
+		Level:    LogDebug,
+	})
+
+	logger.infof("Connected to %s", "10.112.210.101")
+	suite.Assert().Equal("Connected to 10.112.210.101\n", agentLogs.String())
+	suite.Assert().Empty(globalLogs.String())
+
+	agentLogs.Reset()
+
+	var nilLogger *scopedLogger
+	nilLogger.infof("Connected to %s", "10.112.210.102")
+	newScopedLogger(nil).infof("Connected to %s", "10.112.210.103")
+	suite.Assert().Equal("Connected to 10.112.210.102\nConnected to 10.112.210.103\n", globalLogs.String())
+	suite.Assert().Empty(agentLogs.String())
+}
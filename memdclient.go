@@ -34,6 +34,7 @@ type postCompleteErrorHandler func(resp *memdQResponse, req *memdQRequest, err e
 type serverRequestHandler func(pak *memd.Packet)
 
 type memdClient struct {
+	logger                *scopedLogger
 	lastActivity          int64
 	dcpAckSize            int
 	dcpFlowRecv           int
@@ -48,6 +49,7 @@ type memdClient struct {
 	lock                  sync.Mutex
 	streamEndNotSupported bool
 	breaker               circuitBreaker
+	healthChecker         HealthChecker
 	postErrHandler        postCompleteErrorHandler
 	serverRequestHandler  serverRequestHandler
 	tracer                *tracerComponent
@@ -66,9 +68,15 @@ type memdClient struct {
 
 	compressionMinSize   int
 	compressionMinRatio  float64
+	compressor           *adaptiveCompressor
+	compressionFilter    CompressionFilter
 	disableDecompression bool
 
 	gracefulCloseTriggered uint32
+
+	// closeHandler is invoked once the client has closed, before closeNotify is closed. Both fields are guarded by lock.
+	closeHandler func()
+	closeHandled bool
 }
 
 type dcpBuffer struct {
@@ -83,7 +91,11 @@ type memdClientProps struct {
 	DCPQueueSize         int
 	CompressionMinSize   int
 	CompressionMinRatio  float64
+	Compressor           *adaptiveCompressor
+	CompressionFilter    CompressionFilter
 	DisableDecompression bool
+	HealthChecker        HealthChecker
+	Logger               *scopedLogger
 }
 
 func newMemdClient(props memdClientProps, conn memdConn, breakerCfg CircuitBreakerConfig, postErrHandler postCompleteErrorHandler,
@@ -103,11 +115,15 @@ func newMemdClient(props memdClientProps, conn memdConn, breakerCfg CircuitBreak
 		dcpQueueSize:         props.DCPQueueSize,
 		compressionMinRatio:  props.CompressionMinRatio,
 		compressionMinSize:   props.CompressionMinSize,
+		compressor:           props.Compressor,
+		compressionFilter:    props.CompressionFilter,
 		disableDecompression: props.DisableDecompression,
+		healthChecker:        props.HealthChecker,
+		logger:               props.Logger,
 	}
 
 	if breakerCfg.Enabled {
-		client.breaker = newLazyCircuitBreaker(breakerCfg, client.sendCanary)
+		client.breaker = newLazyCircuitBreaker(breakerCfg, client.Address(), client.sendCanary)
 	} else {
 		client.breaker = newNoopCircuitBreaker()
 	}
@@ -150,7 +166,7 @@ func (client *memdClient) maybeSendDcpBufferAck(packetLen int) {
 		Extras:  extrasBuf,
 	})
 	if err != nil {
-		logWarnf("%p memdclient failed to dispatch DCP buffer ack: %s", client, err)
+		client.logger.warnf("%p memdclient failed to dispatch DCP buffer ack: %s", client, err)
 	}
 
 	client.dcpFlowRecv -= ackAmt
@@ -173,17 +189,17 @@ func (client *memdClient) takeRequestOwnership(req *memdQRequest) error {
 	defer client.lock.Unlock()
 
 	if client.closed {
-		logDebugf("%s memdclient attempted to put dispatched op OP=0x%x, Opaque=%d in drained opmap", client.loggerID(), req.Command, req.Opaque)
+		client.logger.debugf("%s memdclient attempted to put dispatched op OP=0x%x, Opaque=%d in drained opmap", client.loggerID(), req.Command, req.Opaque)
 		return errMemdClientClosed
 	}
 
 	if atomic.LoadUint32(&client.gracefulCloseTriggered) == 1 {
-		logDebugf("%s memdclient attempted to dispatch op OP=0x%x, Opaque=%d from gracefully closing memdclient", client.loggerID(), req.Command, req.Opaque)
+		client.logger.debugf("%s memdclient attempted to dispatch op OP=0x%x, Opaque=%d from gracefully closing memdclient", client.loggerID(), req.Command, req.Opaque)
 		return errMemdClientClosed
 	}
 
 	if !atomic.CompareAndSwapPointer(&req.waitingIn, nil, unsafe.Pointer(client)) {
-		logDebugf("%s memdclient attempted to put dispatched op OP=0x%x, Opaque=%d in new opmap", client.loggerID(), req.Command, req.Opaque)
+		client.logger.debugf("%s memdclient attempted to put dispatched op OP=0x%x, Opaque=%d in new opmap", client.loggerID(), req.Command, req.Opaque)
 		return errRequestAlreadyDispatched
 	}
 
@@ -208,13 +224,17 @@ func (client *memdClient) CancelRequest(req *memdQRequest, err error) bool {
 	defer client.lock.Unlock()
 
 	if client.closed {
-		logDebugf("%s memdclient attempted to remove op OP=0x%x, Opaque=%d from drained opmap", client.loggerID(), req.Command, req.Opaque)
+		client.logger.debugf("%s memdclient attempted to remove op OP=0x%x, Opaque=%d from drained opmap", client.loggerID(), req.Command, req.Opaque)
 		return false
 	}
 
 	removed := client.opList.Remove(req)
 	if removed {
 		atomic.CompareAndSwapPointer(&req.waitingIn, unsafe.Pointer(client), nil)
+
+		if client.zombieLogger != nil {
+			client.zombieLogger.RecordCancelledRequest(client.connID, req.Opaque, req.dispatchTime)
+		}
 	}
 
 	if client.breaker.CompletionCallback(err) {
@@ -226,9 +246,26 @@ func (client *memdClient) CancelRequest(req *memdQRequest, err error) bool {
 	return removed
 }
 
+// NumInFlightOps returns the number of requests which have been sent and are awaiting a response.
+func (client *memdClient) NumInFlightOps() int {
+	client.lock.Lock()
+	size := client.opList.Size()
+	client.lock.Unlock()
+
+	return size
+}
+
 func (client *memdClient) SendRequest(req *memdQRequest) error {
 	if !client.breaker.AllowsRequest() {
-		logSchedf("Circuit breaker interrupting request. %s to %s OP=0x%x. Opaque=%d", client.conn.LocalAddr(), client.Address(), req.Command, req.Opaque)
+		client.logger.schedf("Circuit breaker interrupting request. %s to %s OP=0x%x. Opaque=%d", client.conn.LocalAddr(), client.Address(), req.Command, req.Opaque)
+
+		req.cancelWithCallback(errCircuitBreakerOpen)
+
+		return nil
+	}
+
+	if client.healthChecker != nil && !client.healthChecker.IsHealthy(client.Address()) {
+		client.logger.schedf("Health checker interrupting request. %s to %s OP=0x%x. Opaque=%d", client.conn.LocalAddr(), client.Address(), req.Command, req.Opaque)
 
 		req.cancelWithCallback(errCircuitBreakerOpen)
 
@@ -247,8 +284,13 @@ func (client *memdClient) internalSendRequest(req *memdQRequest) error {
 	if client.SupportsFeature(memd.FeatureSnappy) {
 		isCompressed := (packet.Datatype & uint8(memd.DatatypeFlagCompressed)) != 0
 		packetSize := len(packet.Value)
-		if !isCompressed && packetSize > client.compressionMinSize && isCompressibleOp(packet.Command) {
+		if !isCompressed && client.shouldCompress(packetSize) && isCompressibleOp(packet.Command) &&
+			client.compressionAllowed(packet) {
+			compressStart := time.Now()
 			compressedValue := snappy.Encode(nil, packet.Value)
+			if client.compressor != nil {
+				client.compressor.Record(packetSize, len(compressedValue), time.Since(compressStart))
+			}
 			if float64(len(compressedValue))/float64(packetSize) <= client.compressionMinRatio {
 				newPacket := *packet
 				newPacket.Value = compressedValue
@@ -258,19 +300,35 @@ func (client *memdClient) internalSendRequest(req *memdQRequest) error {
 		}
 	}
 
-	logSchedf("Writing request. %s to %s OP=0x%x. Opaque=%d. Vbid=%d", client.conn.LocalAddr(), client.loggerID(), req.Command, req.Opaque, req.Vbucket)
+	client.logger.schedf("Writing request. %s to %s OP=0x%x. Opaque=%d. Vbid=%d", client.conn.LocalAddr(), client.loggerID(), req.Command, req.Opaque, req.Vbucket)
 
 	client.tracer.StartNetTrace(req)
 
 	err := client.conn.WritePacket(packet)
 	if err != nil {
-		logDebugf(" %s memdclient write failure: %v", client.loggerID(), err)
+		client.logger.debugf(" %s memdclient write failure: %v", client.loggerID(), err)
 		return err
 	}
 
 	return nil
 }
 
+func (client *memdClient) compressionAllowed(packet *memd.Packet) bool {
+	if client.compressionFilter == nil {
+		return true
+	}
+
+	return client.compressionFilter(packet.Value, packet.Datatype)
+}
+
+func (client *memdClient) shouldCompress(packetSize int) bool {
+	if client.compressor != nil {
+		return client.compressor.ShouldCompress(packetSize)
+	}
+
+	return packetSize > client.compressionMinSize
+}
+
 func (client *memdClient) classifyResponseStatusClass(status memd.StatusCode) statusClass {
 	switch status {
 	case memd.StatusSuccess:
@@ -288,12 +346,12 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 	defer memd.ReleasePacket(resp.Packet)
 
 	if resp.Magic == memd.CmdMagicServerReq {
-		logSchedf("Handling server request data on %s. OP=0x%x", client.loggerID(), resp.Command)
+		client.logger.schedf("Handling server request data on %s. OP=0x%x", client.loggerID(), resp.Command)
 		client.serverRequestHandler(resp.Packet)
 		return
 	}
 
-	logSchedf("Handling response data on %s. OP=0x%x. Opaque=%d. Status:%d", client.loggerID(), resp.Command, resp.Opaque, resp.Status)
+	client.logger.schedf("Handling response data on %s. OP=0x%x. Opaque=%d. Status:%d", client.loggerID(), resp.Command, resp.Opaque, resp.Status)
 
 	stClass := client.classifyResponseStatusClass(resp.Status)
 
@@ -315,7 +373,7 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 				// connection/client if someone else has already closed it.
 				err := client.Close()
 				if err != nil {
-					logDebugf("Failed to shutdown memdclient (%s) during graceful close: %s", client.loggerID(), err)
+					client.logger.debugf("Failed to shutdown memdclient (%s) during graceful close: %s", client.loggerID(), err)
 				}
 			}()
 		}
@@ -323,7 +381,7 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 
 	if req == nil {
 		// There is no known request that goes with this response.  Ignore it.
-		logDebugf("%s memdclient received response with no corresponding request.", client.loggerID())
+		client.logger.debugf("%s memdclient received response with no corresponding request.", client.loggerID())
 		if client.zombieLogger != nil {
 			client.zombieLogger.RecordZombieResponse(resp, client.connID, client.LocalAddress(), client.Address())
 		}
@@ -345,11 +403,11 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 	isCompressed := (resp.Datatype & uint8(memd.DatatypeFlagCompressed)) != 0
 	// We always want to decompress cluster configs if they've been compressed.
 	alwaysDecompress := req.Command == memd.CmdGetClusterConfig || resp.Status == memd.StatusNotMyVBucket
-	if isCompressed && (!client.disableDecompression || alwaysDecompress) {
+	if isCompressed && ((!client.disableDecompression && !req.DisableDecompression) || alwaysDecompress) {
 		newValue, err := snappy.Decode(nil, resp.Value)
 		if err != nil {
 			req.processingLock.Unlock()
-			logDebugf("%s memdclient failed to decompress value from the server for key `%s`.", client.loggerID(), req.Key)
+			client.logger.debugf("%s memdclient failed to decompress value from the server for key `%s`.", client.loggerID(), req.Key)
 			return
 		}
 
@@ -378,14 +436,14 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 	if err != nil {
 		shortCircuited, routeErr := client.postErrHandler(resp, req, err)
 		if shortCircuited {
-			logSchedf("Routing callback intercepted response")
+			client.logger.schedf("Routing callback intercepted response")
 			return
 		}
 		err = routeErr
 	}
 
 	// Call the requests callback handler...
-	logSchedf("Dispatching response callback. OP=0x%x. Opaque=%d", resp.Command, resp.Opaque)
+	client.logger.schedf("Dispatching response callback. OP=0x%x. Opaque=%d", resp.Command, resp.Opaque)
 	req.tryCallback(resp, err)
 }
 
@@ -413,7 +471,7 @@ func (client *memdClient) run() {
 				return
 			}
 
-			logSchedf("Resolving response OP=0x%x. Opaque=%d", q.resp.Command, q.resp.Opaque)
+			client.logger.schedf("Resolving response OP=0x%x. Opaque=%d", q.resp.Command, q.resp.Opaque)
 			client.resolveRequest(q.resp)
 
 			// See below for information on MB-26363 for why this is here.
@@ -429,7 +487,7 @@ func (client *memdClient) run() {
 			if err != nil {
 				client.lock.Lock()
 				if !client.closed {
-					logWarnf("%p memdClient read failure on conn `%v` : %v", client, client.connID, err)
+					client.logger.warnf("%p memdClient read failure on conn `%v` : %v", client, client.connID, err)
 				}
 				client.lock.Unlock()
 				break
@@ -452,7 +510,7 @@ func (client *memdClient) run() {
 					Opaque:  resp.Opaque,
 				})
 				if err != nil {
-					logWarnf("%p memdclient failed to dispatch DCP noop reply: %s", client, err)
+					client.logger.warnf("%p memdclient failed to dispatch DCP noop reply: %s", client, err)
 				}
 				continue
 			}
@@ -495,7 +553,7 @@ func (client *memdClient) run() {
 					packetLen: n,
 				}
 			default:
-				logSchedf("%s memdclient resolving response OP=0x%x. Opaque=%d", client.loggerID(), resp.Command, resp.Opaque)
+				client.logger.schedf("%s memdclient resolving response OP=0x%x. Opaque=%d", client.loggerID(), resp.Command, resp.Opaque)
 				client.resolveRequest(resp)
 			}
 		}
@@ -508,7 +566,7 @@ func (client *memdClient) run() {
 			err := client.closeConn(true)
 			if err != nil {
 				// Lets log a warning, as this is non-fatal
-				logWarnf("Failed to shut down client (%p) connection (%s)", client, err)
+				client.logger.warnf("Failed to shut down client (%p) connection (%s)", client, err)
 			}
 		} else {
 			client.lock.Unlock()
@@ -524,7 +582,7 @@ func (client *memdClient) run() {
 
 		client.opList.Drain(func(req *memdQRequest) {
 			if !atomic.CompareAndSwapPointer(&req.waitingIn, unsafe.Pointer(client), nil) {
-				logWarnf("Encountered an unowned request in a client (%p) opMap", client)
+				client.logger.warnf("Encountered an unowned request in a client (%p) opMap", client)
 			}
 
 			shortCircuited, routeErr := client.postErrHandler(nil, req, io.EOF)
@@ -537,10 +595,32 @@ func (client *memdClient) run() {
 
 		<-client.connReleasedNotify
 
+		client.lock.Lock()
+		closeHandler := client.closeHandler
+		client.closeHandled = true
+		client.lock.Unlock()
+		if closeHandler != nil {
+			closeHandler()
+		}
+
 		close(client.closeNotify)
 	}()
 }
 
+// SetCloseHandler registers fn to be invoked once the client has closed. It is invoked synchronously from the close
+// path, before CloseNotify is signalled, so it always happens before anything watching CloseNotify reacts to the close.
+// If the client has already closed then fn is invoked immediately.
+func (client *memdClient) SetCloseHandler(fn func()) {
+	client.lock.Lock()
+	if client.closeHandled {
+		client.lock.Unlock()
+		fn()
+		return
+	}
+	client.closeHandler = fn
+	client.lock.Unlock()
+}
+
 func (client *memdClient) LocalAddress() string {
 	return client.conn.LocalAddr()
 }
@@ -587,17 +667,17 @@ func (client *memdClient) GracefulClose(err error) {
 		err := client.closeConn(false)
 		if err != nil {
 			// Lets log a warning, as this is non-fatal
-			logWarnf("Failed to shut down client (%p) connection (%s)", client, err)
+			client.logger.warnf("Failed to shut down client (%p) connection (%s)", client, err)
 		}
 
 	}
 }
 
 func (client *memdClient) closeConn(internalTrigger bool) error {
-	logDebugf("%s memdclient closing connection, internal close: %t", client.loggerID(), internalTrigger)
+	client.logger.debugf("%s memdclient closing connection, internal close: %t", client.loggerID(), internalTrigger)
 	err := client.conn.Close()
 	if err != nil {
-		logDebugf("Failed to close memdconn: %v on memdclient %s", err, client.loggerID())
+		client.logger.debugf("Failed to close memdconn: %v on memdclient %s", err, client.loggerID())
 	}
 
 	// If this has been triggered by the read side failing a read before the client is closed then we
@@ -650,7 +730,7 @@ func (client *memdClient) sendCanary() {
 		RetryStrategy: newFailFastRetryStrategy(),
 	}
 
-	logDebugf("Sending NOOP request for %s", client.loggerID())
+	client.logger.debugf("Sending NOOP request for %s", client.loggerID())
 	err := client.internalSendRequest(req)
 	if err != nil {
 		client.breaker.MarkFailure()
@@ -662,15 +742,16 @@ func (client *memdClient) sendCanary() {
 		if !req.internalCancel(errRequestCanceled) {
 			err := <-errChan
 			if err == nil {
-				logDebugf("NOOP request successful for %s", client.loggerID())
+				client.logger.debugf("NOOP request successful for %s", client.loggerID())
 				client.breaker.MarkSuccessful()
 			} else {
-				logDebugf("NOOP request failed for %s", client.loggerID())
+				client.logger.debugf("NOOP request failed for %s", client.loggerID())
 				client.breaker.MarkFailure()
 			}
 		}
 		client.breaker.MarkFailure()
 	case err := <-errChan:
+		// The completion callback is not used for canaries, any error means that the node is still unhealthy.
 		if err == nil {
 			client.breaker.MarkSuccessful()
 		} else {
@@ -0,0 +1,245 @@
+package gocbcore
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+	"github.com/golang/snappy"
+)
+
+type testMemdConn struct {
+	written []*memd.Packet
+}
+
+func (conn *testMemdConn) LocalAddr() string                               { return "10.112.210.1:52000" }
+func (conn *testMemdConn) RemoteAddr() string                              { return "10.112.210.101:11210" }
+func (conn *testMemdConn) ReadPacket() (*memd.Packet, int, error)          { return nil, 0, nil }
+func (conn *testMemdConn) Close() error                                    { return nil }
+func (conn *testMemdConn) Release()                                        {}
+func (conn *testMemdConn) EnableFeature(feature memd.HelloFeature)         {}
+func (conn *testMemdConn) IsFeatureEnabled(feature memd.HelloFeature) bool { return true }
+
+func (conn *testMemdConn) WritePacket(packet *memd.Packet) error {
+	conn.written = append(conn.written, packet)
+	return nil
+}
+
+func (suite *UnitTestSuite) TestMemdClientCompressionFilter() {
+	conn := &testMemdConn{}
+	client := &memdClient{
+		conn:                conn,
+		opList:              newMemdOpMap(),
+		tracer:              newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil),
+		features:            []memd.HelloFeature{memd.FeatureSnappy},
+		compressionMinSize:  32,
+		compressionMinRatio: 0.83,
+		compressionFilter: func(value []byte, datatype uint8) bool {
+			return datatype&uint8(memd.DatatypeFlagJSON) == 0
+		},
+	}
+
+	value := bytes.Repeat([]byte("a"), 1024)
+	send := func(datatype uint8) *memd.Packet {
+		err := client.internalSendRequest(&memdQRequest{
+			Packet: memd.Packet{
+				Magic:    memd.CmdMagicReq,
+				Command:  memd.CmdSet,
+				Datatype: datatype,
+				Value:    value,
+			},
+		})
+		suite.Require().Nil(err, err)
+
+		return conn.written[len(conn.written)-1]
+	}
+
+	// The filter allows this value to be compressed.
+	packet := send(0)
+	suite.Assert().NotZero(packet.Datatype & uint8(memd.DatatypeFlagCompressed))
+	suite.Assert().Less(len(packet.Value), len(value))
+
+	// The filter vetoes compression even though the value meets the size and ratio requirements.
+	packet = send(uint8(memd.DatatypeFlagJSON))
+	suite.Assert().Zero(packet.Datatype & uint8(memd.DatatypeFlagCompressed))
+	suite.Assert().Equal(value, packet.Value)
+}
+
+func (suite *UnitTestSuite) TestMemdClientCompressionKeepsJSONFlag() {
+	conn := &testMemdConn{}
+	client := &memdClient{
+		conn:                conn,
+		opList:              newMemdOpMap(),
+		tracer:              newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil),
+		features:            []memd.HelloFeature{memd.FeatureSnappy},
+		compressionMinSize:  32,
+		compressionMinRatio: 0.83,
+	}
+
+	err := client.internalSendRequest(&memdQRequest{
+		Packet: memd.Packet{
+			Magic:    memd.CmdMagicReq,
+			Command:  memd.CmdSet,
+			Datatype: uint8(memd.DatatypeFlagJSON),
+			Value:    bytes.Repeat([]byte("a"), 1024),
+		},
+	})
+	suite.Require().Nil(err, err)
+
+	packet := conn.written[len(conn.written)-1]
+	suite.Assert().Equal(uint8(memd.DatatypeFlagJSON|memd.DatatypeFlagCompressed), packet.Datatype)
+}
+
+func (suite *UnitTestSuite) TestMemdClientPerRequestDisableDecompression() {
+	client := &memdClient{
+		conn:    &testMemdConn{},
+		opList:  newMemdOpMap(),
+		tracer:  newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil),
+		breaker: newNoopCircuitBreaker(),
+	}
+
+	value := bytes.Repeat([]byte("a"), 1024)
+	compressed := snappy.Encode(nil, value)
+
+	// The response packet is released once the callback returns, so copy out what we need.
+	get := func(disableDecompression bool) memd.Packet {
+		respCh := make(chan memd.Packet, 1)
+		req := &memdQRequest{
+			Packet: memd.Packet{
+				Magic:   memd.CmdMagicReq,
+				Command: memd.CmdGet,
+			},
+			DisableDecompression: disableDecompression,
+			Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+				suite.Assert().Nil(err, err)
+				respCh <- memd.Packet{
+					Datatype: resp.Datatype,
+					Value:    append([]byte(nil), resp.Value...),
+				}
+			},
+		}
+		suite.Require().Nil(client.internalSendRequest(req))
+
+		packet := memd.AcquirePacket()
+		packet.Magic = memd.CmdMagicRes
+		packet.Command = memd.CmdGet
+		packet.Opaque = req.Opaque
+		packet.Datatype = uint8(memd.DatatypeFlagCompressed)
+		packet.Value = compressed
+		client.resolveRequest(&memdQResponse{Packet: packet})
+
+		return <-respCh
+	}
+
+	resp := get(false)
+	suite.Assert().Zero(resp.Datatype & uint8(memd.DatatypeFlagCompressed))
+	suite.Assert().Equal(value, resp.Value)
+
+	resp = get(true)
+	suite.Assert().NotZero(resp.Datatype & uint8(memd.DatatypeFlagCompressed))
+	suite.Assert().Equal(compressed, resp.Value)
+}
+
+type canaryTestMemdConn struct {
+	testMemdConn
+	writtenCh chan *memd.Packet
+}
+
+func (conn *canaryTestMemdConn) WritePacket(packet *memd.Packet) error {
+	conn.writtenCh <- packet
+	return nil
+}
+
+func (suite *UnitTestSuite) TestMemdClientCanarySocketErrorKeepsBreakerOpen() {
+	conn := &canaryTestMemdConn{writtenCh: make(chan *memd.Packet, 1)}
+	client := &memdClient{
+		conn:   conn,
+		opList: newMemdOpMap(),
+		tracer: newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil),
+	}
+
+	canaryDoneCh := make(chan struct{})
+	breaker := newLazyCircuitBreaker(CircuitBreakerConfig{
+		VolumeThreshold:          1,
+		ErrorThresholdPercentage: 1,
+		SleepWindow:              time.Millisecond,
+		CanaryTimeout:            5 * time.Second,
+	}, "10.112.210.101:11210", func() {
+		client.sendCanary()
+		close(canaryDoneCh)
+	})
+	client.breaker = breaker
+
+	breaker.MarkFailure()
+	suite.Require().Equal(circuitBreakerStateOpen, breaker.State())
+
+	time.Sleep(5 * time.Millisecond)
+	// Moves the breaker to half open and sends the canary.
+	suite.Require().False(breaker.AllowsRequest())
+
+	packet := <-conn.writtenCh
+	suite.Require().Equal(memd.CmdNoop, packet.Command)
+
+	// The connection is lost before the canary gets a response, which the default completion callback would treat
+	// as a success as it is not a timeout.
+	req := client.opList.FindAndMaybeRemove(packet.Opaque, true)
+	suite.Require().NotNil(req)
+	req.tryCallback(nil, io.EOF)
+
+	<-canaryDoneCh
+	suite.Assert().Equal(circuitBreakerStateOpen, breaker.State())
+}
+
+type closeTestMemdConn struct {
+	testMemdConn
+	closeOnce sync.Once
+	closedCh  chan struct{}
+}
+
+func (conn *closeTestMemdConn) ReadPacket() (*memd.Packet, int, error) {
+	<-conn.closedCh
+	return nil, 0, io.EOF
+}
+
+func (conn *closeTestMemdConn) Close() error {
+	conn.closeOnce.Do(func() {
+		close(conn.closedCh)
+	})
+	return nil
+}
+
+func (suite *UnitTestSuite) TestMemdClientCloseHandler() {
+	conn := &closeTestMemdConn{closedCh: make(chan struct{})}
+	client := newMemdClient(memdClientProps{}, conn, CircuitBreakerConfig{}, nil,
+		newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, nil), nil, nil)
+
+	handledCh := make(chan bool, 1)
+	client.SetCloseHandler(func() {
+		// The handler must run before anything watching CloseNotify can react to the close.
+		select {
+		case <-client.CloseNotify():
+			handledCh <- false
+		default:
+			handledCh <- true
+		}
+	})
+
+	suite.Require().Nil(client.Close())
+	<-client.CloseNotify()
+
+	select {
+	case beforeNotify := <-handledCh:
+		suite.Assert().True(beforeNotify, "close handler was invoked after CloseNotify was signalled")
+	default:
+		suite.T().Fatalf("Close handler was not invoked")
+	}
+
+	// A handler registered once the client has closed is invoked immediately.
+	var lateCalled bool
+	client.SetCloseHandler(func() {
+		lateCalled = true
+	})
+	suite.Assert().True(lateCalled)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,15 +34,20 @@ type bootstrapProps struct {
 }
 
 type memdClientDialerComponent struct {
+	logger            *scopedLogger
 	kvConnectTimeout  time.Duration
 	serverWaitTimeout time.Duration
 	clientID          string
 	breakerCfg        CircuitBreakerConfig
+	healthChecker     HealthChecker
 
 	compressionMinSize   int
 	compressionMinRatio  float64
+	compressor           *adaptiveCompressor
+	compressionFilter    CompressionFilter
 	disableDecompression bool
 	connBufSize          uint
+	dialer               func(ctx context.Context, network, addr string) (net.Conn, error)
 
 	serverFailuresLock sync.Mutex
 	serverFailures     map[string]time.Time
@@ -59,12 +65,17 @@ type memdClientDialerComponent struct {
 
 	configApplied uint32
 
+	// xerrorUnavailableLogged is used to only log that enhanced error information is unavailable once.
+	xerrorUnavailableLogged uint32
+
 	noTLSSeedNode bool
 
 	dcpBootstrapProps *memdBootstrapDCPProps
 	dcpQueueSize      int
 
 	cfgManager *configManagementComponent
+
+	connStateNotifier *connectionStateNotifier
 }
 
 type memdBootstrapDCPProps struct {
@@ -86,9 +97,15 @@ type memdClientDialerProps struct {
 	ClientID             string
 	CompressionMinSize   int
 	CompressionMinRatio  float64
+	Compressor           *adaptiveCompressor
+	CompressionFilter    CompressionFilter
 	DisableDecompression bool
 	NoTLSSeedNode        bool
 	ConnBufSize          uint
+	HealthChecker        HealthChecker
+	Dialer               func(ctx context.Context, network, addr string) (net.Conn, error)
+	Logger               *scopedLogger
+	ConnStateNotifier    *connectionStateNotifier
 
 	DCPBootstrapProps *memdBootstrapDCPProps
 	DCPQueueSize      int
@@ -119,9 +136,15 @@ func newMemdClientDialerComponent(props memdClientDialerProps, bSettings bootstr
 		dcpQueueSize:         props.DCPQueueSize,
 		compressionMinSize:   props.CompressionMinSize,
 		compressionMinRatio:  props.CompressionMinRatio,
+		compressor:           props.Compressor,
+		compressionFilter:    props.CompressionFilter,
 		disableDecompression: props.DisableDecompression,
 		noTLSSeedNode:        props.NoTLSSeedNode,
 		connBufSize:          props.ConnBufSize,
+		healthChecker:        props.HealthChecker,
+		dialer:               props.Dialer,
+		logger:               props.Logger,
+		connStateNotifier:    props.ConnStateNotifier,
 
 		cfgManager: cfgManager,
 	}
@@ -212,7 +235,7 @@ func (mcc *memdClientDialerComponent) SlowDialMemdClient(cancelSig <-chan struct
 	if err != nil {
 		closeErr := client.Close()
 		if closeErr != nil {
-			logWarnf("Failed to close authentication client (%s)", closeErr)
+			mcc.logger.warnf("Failed to close authentication client (%s)", closeErr)
 		}
 		if !errors.Is(err, ErrForcedReconnect) {
 			mcc.serverFailuresLock.Lock()
@@ -231,6 +254,15 @@ func (mcc *memdClientDialerComponent) SlowDialMemdClient(cancelSig <-chan struct
 		return nil, err
 	}
 
+	if mcc.connStateNotifier != nil {
+		mcc.connStateNotifier.Connected(address.Address)
+		// The disconnect is reported before CloseNotify is signalled, and so before the pipeline can redial, so that
+		// it is always queued ahead of the event for any replacement connection.
+		client.SetCloseHandler(func() {
+			mcc.connStateNotifier.Disconnected(address.Address)
+		})
+	}
+
 	return client, nil
 }
 
@@ -240,7 +272,7 @@ func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{},
 	// server that we connect to so that the certificate can be validated properly.
 	var tlsConfig *tls.Config
 	if dynTls != nil && !(mcc.noTLSSeedNode && address.IsSeedNode) {
-		srvTLSConfig, err := dynTls.MakeForAddr(address.Address)
+		srvTLSConfig, err := dynTls.MakeForAddr(address.Address, MemdService)
 		if err != nil {
 			return nil, err
 		}
@@ -258,7 +290,7 @@ func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{},
 		}
 	}()
 
-	conn, err := dialMemdConn(ctx, address.Address, tlsConfig, deadline, mcc.connBufSize)
+	conn, err := dialMemdConn(ctx, address.Address, tlsConfig, deadline, mcc.connBufSize, mcc.dialer, mcc.logger)
 	cancel()
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
@@ -267,7 +299,7 @@ func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{},
 			err = wrapError(err, "check server ports and cluster encryption setting")
 		}
 
-		logDebugf("Failed to connect. %v", err)
+		mcc.logger.debugf("Failed to connect. %v", err)
 		return nil, err
 	}
 
@@ -278,6 +310,10 @@ func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{},
 			DisableDecompression: mcc.disableDecompression,
 			CompressionMinRatio:  mcc.compressionMinRatio,
 			CompressionMinSize:   mcc.compressionMinSize,
+			Compressor:           mcc.compressor,
+			CompressionFilter:    mcc.compressionFilter,
+			HealthChecker:        mcc.healthChecker,
+			Logger:               mcc.logger,
 		},
 		conn,
 		mcc.breakerCfg,
@@ -351,7 +387,7 @@ func (mcc *memdClientDialerComponent) dcpBootstrap(client *dcpBootstrapClient, d
 
 func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline time.Time,
 	authMechanisms []AuthMechanism, authProvider AuthProvider) error {
-	logDebugf("Memdclient %s Fetching cluster client data", client.LoggerID())
+	mcc.logger.debugf("Memdclient %s Fetching cluster client data", client.LoggerID())
 
 	bucket := mcc.bootstrapProps.Bucket
 	features := helloFeatures(mcc.bootstrapProps.HelloProps)
@@ -359,28 +395,32 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 
 	helloCh, err := client.ExecHello(clientInfoStr, features, deadline)
 	if err != nil {
-		logDebugf("Memdclient %s Failed to execute HELLO (%v)", client.LoggerID(), err)
+		mcc.logger.debugf("Memdclient %s Failed to execute HELLO (%v)", client.LoggerID(), err)
 		return err
 	}
 
 	errMapCh, err := client.ExecGetErrorMap(2, deadline)
 	if err != nil {
 		// GetErrorMap isn't integral to bootstrap succeeding
-		logDebugf("Memdclient %s Failed to execute Get error map (%v)", client.LoggerID(), err)
+		mcc.logger.debugf("Memdclient %s Failed to execute Get error map (%v)", client.LoggerID(), err)
 	}
 
 	var listMechsCh chan SaslListMechsCompleted
 	var completedAuthCh chan error
 	var continueAuthCh chan bool
 
-	firstAuthMethod := mcc.buildAuthHandler(client, authProvider, deadline, authMechanisms[0])
+	firstAuthMethod, err := mcc.buildAuthHandler(client, authProvider, deadline, authMechanisms[0])
+	if err != nil {
+		mcc.logger.debugf("Memdclient %s Failed to get credentials (%v)", client.LoggerID(), err)
+		return err
+	}
 
 	if firstAuthMethod != nil {
 		// If the auth method is nil then we don't actually need to do any auth so no need to Get the mechanisms.
 		listMechsCh = make(chan SaslListMechsCompleted, 1)
 		err = client.SaslListMechs(deadline, func(mechs []AuthMechanism, err error) {
 			if err != nil {
-				logDebugf("Memdclient %s Failed to fetch list auth mechs (%v)", client.LoggerID(), err)
+				mcc.logger.debugf("Memdclient %s Failed to fetch list auth mechs (%v)", client.LoggerID(), err)
 			}
 			listMechsCh <- SaslListMechsCompleted{
 				Err:   err,
@@ -388,12 +428,12 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 			}
 		})
 		if err != nil {
-			logDebugf("Memdclient %s Failed to execute list auth mechs (%v)", client.LoggerID(), err)
+			mcc.logger.debugf("Memdclient %s Failed to execute list auth mechs (%v)", client.LoggerID(), err)
 		}
 
 		completedAuthCh, continueAuthCh, err = firstAuthMethod()
 		if err != nil {
-			logDebugf("Memdclient %s Failed to execute auth (%v)", client.LoggerID(), err)
+			mcc.logger.debugf("Memdclient %s Failed to execute auth (%v)", client.LoggerID(), err)
 			return err
 		}
 	}
@@ -406,7 +446,7 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 		if bucket != "" {
 			selectCh, err = client.ExecSelectBucket([]byte(bucket), deadline)
 			if err != nil {
-				logDebugf("Memdclient %s Failed to execute select bucket (%v)", client.LoggerID(), err)
+				mcc.logger.debugf("Memdclient %s Failed to execute select bucket (%v)", client.LoggerID(), err)
 				return err
 			}
 		}
@@ -414,7 +454,7 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 			configCh, err = client.ExecGetConfig(deadline)
 			if err != nil {
 				// Getting a config isn't essential to bootstrap.
-				logDebugf("Memdclient %s Failed to execute get config (%v)", client.LoggerID(), err)
+				mcc.logger.debugf("Memdclient %s Failed to execute get config (%v)", client.LoggerID(), err)
 			}
 		}
 	} else {
@@ -423,7 +463,7 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 
 	helloResp := <-helloCh
 	if helloResp.Err != nil {
-		logDebugf("Memdclient %s Failed to hello with server (%v)", client.LoggerID(), helloResp.Err)
+		mcc.logger.debugf("Memdclient %s Failed to hello with server (%v)", client.LoggerID(), helloResp.Err)
 		return helloResp.Err
 	}
 
@@ -432,7 +472,7 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 		if errMapResp.Err == nil {
 			mcc.bootstrapProps.ErrMapManager.StoreErrorMap(errMapResp.Bytes)
 		} else {
-			logDebugf("Memdclient %s Failed to fetch kv error map (%s)", client.LoggerID(), errMapResp.Err)
+			mcc.logger.debugf("Memdclient %s Failed to fetch kv error map (%s)", client.LoggerID(), errMapResp.Err)
 		}
 	}
 
@@ -441,9 +481,9 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 		listMechsResp := <-listMechsCh
 		if listMechsResp.Err == nil {
 			serverAuthMechanisms = listMechsResp.Mechs
-			logDebugf("Memdclient %s Server supported auth mechanisms: %v", client.LoggerID(), serverAuthMechanisms)
+			mcc.logger.debugf("Memdclient %s Server supported auth mechanisms: %v", client.LoggerID(), serverAuthMechanisms)
 		} else {
-			logDebugf("Memdclient %s Failed to fetch auth mechs from server (%v)", client.LoggerID(), listMechsResp.Err)
+			mcc.logger.debugf("Memdclient %s Failed to fetch auth mechs from server (%v)", client.LoggerID(), listMechsResp.Err)
 		}
 	}
 
@@ -451,7 +491,7 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 	if completedAuthCh != nil {
 		authErr := <-completedAuthCh
 		if authErr != nil {
-			logDebugf("Memdclient %s Failed to perform auth against server (%v)", client.LoggerID(), authErr)
+			mcc.logger.debugf("Memdclient %s Failed to perform auth against server (%v)", client.LoggerID(), authErr)
 			if errors.Is(authErr, ErrRequestCanceled) {
 				// There's no point in us trying different mechanisms if something has cancelled bootstrapping.
 				return authErr
@@ -470,7 +510,7 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 
 				// If we've got here then the auth mechanism we tried is unsupported so let's keep trying with the next
 				// supported mechanism.
-				logInfof("Memdclient `%s` Unsupported authentication mechanism, will attempt to find next supported mechanism", client.ConnID())
+				mcc.logger.infof("Memdclient `%s` Unsupported authentication mechanism, will attempt to find next supported mechanism", client.ConnID())
 			}
 
 			for {
@@ -478,27 +518,31 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 				var mech AuthMechanism
 				found, mech, authMechanisms = findNextAuthMechanism(authMechanisms, serverAuthMechanisms)
 				if !found {
-					logDebugf("Memdclient %s Failed to authenticate, all options exhausted", client.LoggerID())
+					mcc.logger.debugf("Memdclient %s Failed to authenticate, all options exhausted", client.LoggerID())
 					return authErr
 				}
 
-				logDebugf("Memdclient %s Retrying authentication with found supported mechanism: %s", client.LoggerID(), mech)
-				nextAuthFunc := mcc.buildAuthHandler(client, authProvider, deadline, mech)
+				mcc.logger.debugf("Memdclient %s Retrying authentication with found supported mechanism: %s", client.LoggerID(), mech)
+				nextAuthFunc, err := mcc.buildAuthHandler(client, authProvider, deadline, mech)
+				if err != nil {
+					mcc.logger.debugf("Memdclient %s Failed to get credentials (%v)", client.LoggerID(), err)
+					return err
+				}
 				if nextAuthFunc == nil {
 					// This can't really happen but just in case it somehow does.
-					logInfof("Memdclient `%p` Failed to authenticate, no available credentials", client)
+					mcc.logger.infof("Memdclient `%p` Failed to authenticate, no available credentials", client)
 					return authErr
 				}
 				completedAuthCh, continueAuthCh, err = nextAuthFunc()
 				if err != nil {
-					logDebugf("Memdclient %s Failed to execute auth (%v)", client.LoggerID(), err)
+					mcc.logger.debugf("Memdclient %s Failed to execute auth (%v)", client.LoggerID(), err)
 					return err
 				}
 				if continueAuthCh == nil {
 					if bucket != "" {
 						selectCh, err = client.ExecSelectBucket([]byte(bucket), deadline)
 						if err != nil {
-							logDebugf("Memdclient %s Failed to execute select bucket (%v)", client.LoggerID(), err)
+							mcc.logger.debugf("Memdclient %s Failed to execute select bucket (%v)", client.LoggerID(), err)
 							return err
 						}
 					}
@@ -506,7 +550,7 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 						configCh, err = client.ExecGetConfig(deadline)
 						if err != nil {
 							// Getting a config isn't essential to bootstrap.
-							logDebugf("Memdclient %s Failed to execute get config (%v)", client.LoggerID(), err)
+							mcc.logger.debugf("Memdclient %s Failed to execute get config (%v)", client.LoggerID(), err)
 						}
 					}
 				} else {
@@ -517,19 +561,19 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 					break
 				}
 
-				logDebugf("Memdclient %s Failed to perform auth against server (%v)", client.LoggerID(), authErr)
+				mcc.logger.debugf("Memdclient %s Failed to perform auth against server (%v)", client.LoggerID(), authErr)
 				if errors.Is(authErr, ErrAuthenticationFailure) || errors.Is(err, ErrRequestCanceled) {
 					return authErr
 				}
 			}
 		}
-		logDebugf("Memdclient %s Authenticated successfully", client.LoggerID())
+		mcc.logger.debugf("Memdclient %s Authenticated successfully", client.LoggerID())
 	}
 
 	if selectCh != nil {
 		selectErr := <-selectCh
 		if selectErr != nil {
-			logDebugf("Memdclient %s Failed to perform select bucket against server (%v)", client.LoggerID(), selectErr)
+			mcc.logger.debugf("Memdclient %s Failed to perform select bucket against server (%v)", client.LoggerID(), selectErr)
 			return selectErr
 		}
 	}
@@ -541,9 +585,9 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 			// We don't want this to block us completing bootstrap.
 			go mcc.cfgManager.OnNewConfig(configResp.Config)
 		} else {
-			logDebugf("Memdclient %s Failed to perform config fetch against server (%v)", client.LoggerID(), err)
+			mcc.logger.debugf("Memdclient %s Failed to perform config fetch against server (%v)", client.LoggerID(), err)
 			if errors.Is(err, ErrDocumentNotFound) {
-				logDebugf("Memdclient %s detected that CCCP is unsupported, informing upstream", client.LoggerID())
+				mcc.logger.debugf("Memdclient %s detected that CCCP is unsupported, informing upstream", client.LoggerID())
 				mcc.sendErrorToCCCPUnsupportedHandlers()
 			}
 		}
@@ -551,8 +595,14 @@ func (mcc *memdClientDialerComponent) bootstrap(client bootstrapClient, deadline
 
 	client.Features(helloResp.SrvFeatures)
 
-	logDebugf("Memdclient %s Client Features: %+v", client.LoggerID(), features)
-	logDebugf("Memdclient %s Server Features: %+v", client.LoggerID(), helloResp.SrvFeatures)
+	if !checkSupportsFeature(helloResp.SrvFeatures, memd.FeatureXerror) &&
+		atomic.CompareAndSwapUint32(&mcc.xerrorUnavailableLogged, 0, 1) {
+		mcc.logger.infof("Extended error information (XERROR) was not negotiated, enhanced error context is unavailable " +
+			"and errors will be mapped by status code only")
+	}
+
+	mcc.logger.debugf("Memdclient %s Client Features: %+v", client.LoggerID(), features)
+	mcc.logger.debugf("Memdclient %s Server Features: %+v", client.LoggerID(), helloResp.SrvFeatures)
 
 	return nil
 }
@@ -586,7 +636,7 @@ func (mcc *memdClientDialerComponent) continueAfterAuth(client bootstrapClient,
 			var err error
 			execCh, err = client.ExecSelectBucket([]byte(bucketName), deadline)
 			if err != nil {
-				logDebugf("Memdclient %s Failed to execute select bucket (%v)", client.LoggerID(), err)
+				mcc.logger.debugf("Memdclient %s Failed to execute select bucket (%v)", client.LoggerID(), err)
 				selectCh <- err
 				return
 			}
@@ -598,7 +648,7 @@ func (mcc *memdClientDialerComponent) continueAfterAuth(client bootstrapClient,
 			execConfigCh, err = client.ExecGetConfig(deadline)
 			if err != nil {
 				// Getting a config isn't essential to bootstrap.
-				logDebugf("Memdclient %s Failed to execute get config (%v)", client.LoggerID(), err)
+				mcc.logger.debugf("Memdclient %s Failed to execute get config (%v)", client.LoggerID(), err)
 				close(configCh)
 				return
 			}
@@ -621,10 +671,10 @@ func (mcc *memdClientDialerComponent) continueAfterAuth(client bootstrapClient,
 type authFunc func() (continueCh chan error, completedCb chan bool, err error)
 
 func (mcc *memdClientDialerComponent) buildAuthHandler(client bootstrapClient, auth AuthProvider, deadline time.Time,
-	mechanism AuthMechanism) authFunc {
-	creds, err := getKvAuthCreds(auth, client.Address())
+	mechanism AuthMechanism) (authFunc, error) {
+	creds, err := getKvAuthCreds(auth, client.Address(), mcc.bootstrapProps.Bucket)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	if creds.Username != "" || creds.Password != "" {
@@ -651,10 +701,10 @@ func (mcc *memdClientDialerComponent) buildAuthHandler(client bootstrapClient, a
 				return nil, nil, callErr
 			}
 			return completedCh, continueCh, nil
-		}
+		}, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 func (mcc *memdClientDialerComponent) sendErrorToCCCPUnsupportedHandlers() {
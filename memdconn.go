@@ -85,6 +85,7 @@ type memdConnWrap struct {
 	conn       *memd.Conn
 	baseConn   *wrappedReadWriteCloser
 	bufSize    int
+	logger     *scopedLogger
 }
 
 func (s *memdConnWrap) LocalAddr() string {
@@ -118,42 +119,51 @@ func (s *memdConnWrap) Close() error {
 // Release is not thread safe and should not be called whilst there are pending calls, such as ReadPacket.
 func (s *memdConnWrap) Release() {
 	if s.baseConn == nil {
-		logWarnf("Release called on already released connection")
+		s.logger.warnf("Release called on already released connection")
 		return
 	}
 	releaseReadBuf(s.baseConn.Reader, s.bufSize)
 	s.baseConn = nil
 }
 
-func dialMemdConn(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time, bufSize uint) (memdConn, error) {
-	d := net.Dialer{
-		Deadline: deadline,
+func dialMemdConn(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time, bufSize uint,
+	dialer func(ctx context.Context, network, addr string) (net.Conn, error), logger *scopedLogger) (memdConn, error) {
+	if dialer == nil {
+		d := net.Dialer{
+			Deadline: deadline,
+		}
+		dialer = d.DialContext
+	} else if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
 	}
 
 	dialID := formatCbUID(randomCbUID())
-	logDebugf("Dialling new client connection for %s, dial id = %s", address, dialID)
+	logger.debugf("Dialling new client connection for %s, dial id = %s", address, dialID)
 
-	baseConn, err := d.DialContext(ctx, "tcp", address)
+	baseConn, err := dialer(ctx, "tcp", address)
 	if err != nil {
-		logDebugf("Failed to dial client connection for %s, dial id = %s", address, dialID)
+		logger.debugf("Failed to dial client connection for %s, dial id = %s", address, dialID)
 		return nil, err
 	}
-
-	logDebugf("Dialled new client connection for %s, dial id = %s", address, dialID)
-
-	tcpConn, isTCPConn := baseConn.(*net.TCPConn)
-	if !isTCPConn || tcpConn == nil {
+	if baseConn == nil {
 		return nil, errCliInternalError
 	}
 
-	err = tcpConn.SetNoDelay(false)
-	if err != nil {
-		logWarnf("Failed to disable TCP nodelay (%s)", err)
+	logger.debugf("Dialled new client connection for %s, dial id = %s", address, dialID)
+
+	// A user supplied dialer may not return a TCP connection, such as when going through a proxy.
+	if tcpConn, isTCPConn := baseConn.(*net.TCPConn); isTCPConn && tcpConn != nil {
+		err = tcpConn.SetNoDelay(false)
+		if err != nil {
+			logger.warnf("Failed to disable TCP nodelay (%s)", err)
+		}
 	}
 
-	var conn io.ReadWriteCloser = tcpConn
+	var conn io.ReadWriteCloser = baseConn
 	if tlsConfig != nil {
-		tlsConn := tls.Client(tcpConn, tlsConfig)
+		tlsConn := tls.Client(baseConn, tlsConfig)
 		err = tlsConn.Handshake()
 		if err != nil {
 			return nil, err
@@ -178,5 +188,6 @@ func dialMemdConn(ctx context.Context, address string, tlsConfig *tls.Config, de
 		localAddr:  baseConn.LocalAddr().String(),
 		remoteAddr: address,
 		bufSize:    int(bufSize),
+		logger:     logger,
 	}, nil
 }
@@ -0,0 +1,73 @@
+package gocbcore
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+func (suite *UnitTestSuite) testDialMemdConnCustomDialer(serverTLSConfig, clientTLSConfig *tls.Config) {
+	clientSide, serverSide := net.Pipe()
+
+	var dialedAddr string
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialedAddr = addr
+		return clientSide, nil
+	}
+
+	var serverConn net.Conn = serverSide
+	if serverTLSConfig != nil {
+		serverConn = tls.Server(serverSide, serverTLSConfig)
+	}
+	pktCh := make(chan *memd.Packet, 1)
+	go func() {
+		pkt, _, err := memd.NewConn(serverConn).ReadPacket()
+		suite.Assert().Nil(err, err)
+		pktCh <- pkt
+	}()
+
+	conn, err := dialMemdConn(context.Background(), "10.112.210.101:11210", clientTLSConfig,
+		time.Now().Add(time.Second), 1024, dialer, nil)
+	suite.Require().Nil(err, err)
+	defer func() {
+		// Closing a TLS conn sends an alert, which nothing would read from the pipe, so close the server side first.
+		_ = serverSide.Close()
+		_ = conn.Close()
+		conn.Release()
+	}()
+
+	suite.Assert().Equal("10.112.210.101:11210", dialedAddr)
+	suite.Assert().Equal("10.112.210.101:11210", conn.RemoteAddr())
+
+	err = conn.WritePacket(&memd.Packet{
+		Magic:   memd.CmdMagicReq,
+		Command: memd.CmdNoop,
+		Opaque:  5,
+	})
+	suite.Require().Nil(err, err)
+
+	pkt := <-pktCh
+	suite.Require().NotNil(pkt)
+	suite.Assert().Equal(memd.CmdNoop, pkt.Command)
+	suite.Assert().Equal(uint32(5), pkt.Opaque)
+}
+
+func (suite *UnitTestSuite) TestDialMemdConnCustomDialer() {
+	suite.testDialMemdConnCustomDialer(nil, nil)
+}
+
+func (suite *UnitTestSuite) TestDialMemdConnCustomDialerTLS() {
+	// The test server is only used for its certificate, and a client config which trusts it.
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	clientTLSConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	clientTLSConfig.ServerName = "example.com"
+
+	suite.testDialMemdConnCustomDialer(&tls.Config{Certificates: srv.TLS.Certificates}, clientTLSConfig)
+}
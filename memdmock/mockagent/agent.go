@@ -0,0 +1,71 @@
+// Package mockagent provides helpers for creating gocbcore agents which are connected to a memdmock server.
+//
+// Volatile: This package is subject to change at any time.
+package mockagent
+
+import (
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+	"github.com/couchbase/gocbcore/v10/memdmock"
+)
+
+// readyTimeout is the maximum time that NewMockAgent waits for an agent to become ready.
+const readyTimeout = 10 * time.Second
+
+// NewAgentConfig returns an AgentConfig which connects to the bucket served by srv, authenticating using the
+// credentials that the server was created with.
+func NewAgentConfig(srv *memdmock.Server) (*gocbcore.AgentConfig, error) {
+	config := &gocbcore.AgentConfig{}
+	if err := applyServer(config, srv); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// NewMockAgent creates an agent connected to srv and waits until it is ready to perform operations. The connection
+// and security settings of config are overwritten to connect to srv, if config is nil then the config returned by
+// NewAgentConfig is used. The caller is responsible for closing the agent.
+func NewMockAgent(srv *memdmock.Server, config *gocbcore.AgentConfig) (*gocbcore.Agent, error) {
+	if config == nil {
+		config = &gocbcore.AgentConfig{}
+	}
+	if err := applyServer(config, srv); err != nil {
+		return nil, err
+	}
+
+	agent, err := gocbcore.CreateAgent(config)
+	if err != nil {
+		return nil, err
+	}
+
+	waitCh := make(chan error, 1)
+	_, err = agent.WaitUntilReady(time.Now().Add(readyTimeout), gocbcore.WaitUntilReadyOptions{},
+		func(res *gocbcore.WaitUntilReadyResult, err error) {
+			waitCh <- err
+		})
+	if err == nil {
+		err = <-waitCh
+	}
+	if err != nil {
+		_ = agent.Close()
+		return nil, err
+	}
+
+	return agent, nil
+}
+
+func applyServer(config *gocbcore.AgentConfig, srv *memdmock.Server) error {
+	if err := config.FromConnStr(srv.ConnStr()); err != nil {
+		return err
+	}
+
+	username, password := srv.Credentials()
+	config.BucketName = srv.BucketName()
+	config.SecurityConfig.Auth = gocbcore.PasswordAuthProvider{Username: username, Password: password}
+	// The server only supports PLAIN, which is otherwise not used over non-TLS connections.
+	config.SecurityConfig.AuthMechanisms = []gocbcore.AuthMechanism{gocbcore.PlainAuthMechanism}
+
+	return nil
+}
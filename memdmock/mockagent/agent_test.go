@@ -0,0 +1,99 @@
+package mockagent
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+	"github.com/couchbase/gocbcore/v10/memdmock"
+)
+
+func TestNewMockAgent(t *testing.T) {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		BucketName: "mock",
+		Username:   "user",
+		Password:   "pass",
+	})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	agent, err := NewMockAgent(srv, nil)
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	defer agent.Close()
+
+	if agent.BucketName() != "mock" {
+		t.Fatalf("expected bucket mock, was %s", agent.BucketName())
+	}
+
+	setCh := make(chan error, 1)
+	_, err = agent.Set(gocbcore.SetOptions{
+		Key:      []byte("key"),
+		Value:    []byte("value"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *gocbcore.StoreResult, err error) {
+		setCh <- err
+	})
+	if err == nil {
+		err = <-setCh
+	}
+	if err != nil {
+		t.Fatalf("failed to set document: %v", err)
+	}
+
+	type getResult struct {
+		res *gocbcore.GetResult
+		err error
+	}
+	getCh := make(chan getResult, 1)
+	_, err = agent.Get(gocbcore.GetOptions{
+		Key:      []byte("key"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *gocbcore.GetResult, err error) {
+		getCh <- getResult{res, err}
+	})
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	got := <-getCh
+	if got.err != nil {
+		t.Fatalf("failed to get document: %v", got.err)
+	}
+	if !bytes.Equal(got.res.Value, []byte("value")) {
+		t.Fatalf("unexpected value: %s", got.res.Value)
+	}
+}
+
+func TestNewAgentConfig(t *testing.T) {
+	srv, err := memdmock.NewServer(memdmock.ServerOptions{
+		Username: "user",
+		Password: "pass",
+	})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	config, err := NewAgentConfig(srv)
+	if err != nil {
+		t.Fatalf("failed to create config: %v", err)
+	}
+	if config.BucketName != "default" {
+		t.Fatalf("expected bucket default, was %s", config.BucketName)
+	}
+	if len(config.SeedConfig.MemdAddrs) != 1 || config.SeedConfig.MemdAddrs[0] != srv.Address() {
+		t.Fatalf("unexpected memd addresses: %v", config.SeedConfig.MemdAddrs)
+	}
+
+	creds, err := config.SecurityConfig.Auth.Credentials(gocbcore.AuthCredsRequest{Service: gocbcore.MemdService})
+	if err != nil {
+		t.Fatalf("failed to get credentials: %v", err)
+	}
+	if len(creds) != 1 || creds[0].Username != "user" || creds[0].Password != "pass" {
+		t.Fatalf("unexpected credentials: %v", creds)
+	}
+}
@@ -0,0 +1,501 @@
+// Package memdmock provides an in-memory server which implements enough of the memcached binary protocol for an
+// agent to bootstrap against it and perform basic CRUD operations, allowing code built on gocbcore to be unit tested
+// without a Couchbase Server cluster.
+//
+// Volatile: This package is subject to change at any time.
+package memdmock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+const (
+	numVbuckets = 64
+	vbUUID      = uint64(0xdeadbeef)
+)
+
+// HandlerFunc handles a request sent to the server. Returning nil causes the request to be handled by the server as
+// it otherwise would be.
+type HandlerFunc func(req *memd.Packet) *memd.Packet
+
+// ServerOptions specifies how a Server should behave.
+type ServerOptions struct {
+	// BucketName is the name of the only bucket on the server, defaults to "default".
+	BucketName string
+
+	// Username and Password are the credentials which must be used to authenticate, using the PLAIN mechanism. If
+	// neither is set then any credentials are accepted.
+	Username string
+	Password string
+
+	// Features are the HELLO features which the server will negotiate, if requested by the client. Defaults to
+	// datatype, JSON, sequence numbers, select bucket and alternative requests.
+	Features []memd.HelloFeature
+}
+
+type document struct {
+	value    []byte
+	flags    uint32
+	datatype uint8
+	cas      uint64
+}
+
+// Server is an in-memory memcached server which serves a single couchbase bucket. Expiries are accepted but are
+// not applied.
+type Server struct {
+	opts     ServerOptions
+	listener net.Listener
+
+	lock     sync.Mutex
+	docs     map[string]*document
+	cas      uint64
+	seqNo    uint64
+	handlers map[memd.CmdCode]HandlerFunc
+	conns    map[net.Conn]struct{}
+	closed   bool
+
+	wg sync.WaitGroup
+}
+
+// NewServer starts a new Server listening on a random local port.
+func NewServer(opts ServerOptions) (*Server, error) {
+	if opts.BucketName == "" {
+		opts.BucketName = "default"
+	}
+	if opts.Features == nil {
+		opts.Features = []memd.HelloFeature{
+			memd.FeatureDatatype,
+			memd.FeatureJSON,
+			memd.FeatureSeqNo,
+			memd.FeatureSelectBucket,
+			memd.FeatureAltRequests,
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		opts:     opts,
+		listener: listener,
+		docs:     make(map[string]*document),
+		handlers: make(map[memd.CmdCode]HandlerFunc),
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.acceptLoop()
+
+	return s, nil
+}
+
+// Address returns the host and port that the server is listening on.
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// ConnStr returns a connection string which can be used to connect an agent to the server. The agent must be
+// configured to use the PLAIN auth mechanism if the server requires credentials.
+func (s *Server) ConnStr() string {
+	return "couchbase://" + s.Address()
+}
+
+// BucketName returns the name of the bucket served by the server.
+func (s *Server) BucketName() string {
+	return s.opts.BucketName
+}
+
+// Credentials returns the username and password which must be used to authenticate against the server.
+func (s *Server) Credentials() (string, string) {
+	return s.opts.Username, s.opts.Password
+}
+
+// Handle registers a handler for all requests with the given command, replacing any existing handler. This can be
+// used to inject canned responses or errors. Passing a nil handler removes the handler for the command.
+func (s *Server) Handle(cmd memd.CmdCode, handler HandlerFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if handler == nil {
+		delete(s.handlers, cmd)
+		return
+	}
+	s.handlers[cmd] = handler
+}
+
+// Close stops the server, closing any open connections.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.lock.Unlock()
+
+	err := s.listener.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+// ErrorResponse builds a response to req with the given status, for use by a HandlerFunc.
+func ErrorResponse(req *memd.Packet, status memd.StatusCode) *memd.Packet {
+	return &memd.Packet{
+		Magic:   memd.CmdMagicRes,
+		Command: req.Command,
+		Opaque:  req.Opaque,
+		Status:  status,
+	}
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.lock.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+type serverConn struct {
+	conn          *memd.Conn
+	features      []memd.HelloFeature
+	authenticated bool
+	bucket        string
+}
+
+func (sc *serverConn) supports(feature memd.HelloFeature) bool {
+	for _, f := range sc.features {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) serveConn(netConn net.Conn) {
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, netConn)
+		s.lock.Unlock()
+		_ = netConn.Close()
+		s.wg.Done()
+	}()
+
+	sc := &serverConn{
+		conn:          memd.NewConn(netConn),
+		authenticated: s.opts.Username == "" && s.opts.Password == "",
+	}
+
+	for {
+		req, _, err := sc.conn.ReadPacket()
+		if err != nil {
+			return
+		}
+
+		if req.Magic != memd.CmdMagicReq {
+			continue
+		}
+
+		resp := s.handle(sc, req)
+		resp.Magic = memd.CmdMagicRes
+		resp.Command = req.Command
+		resp.Opaque = req.Opaque
+
+		if err := sc.conn.WritePacket(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(sc *serverConn, req *memd.Packet) *memd.Packet {
+	s.lock.Lock()
+	handler := s.handlers[req.Command]
+	s.lock.Unlock()
+
+	if handler != nil {
+		if resp := handler(req); resp != nil {
+			return resp
+		}
+	}
+
+	switch req.Command {
+	case memd.CmdHello:
+		return s.handleHello(sc, req)
+	case memd.CmdNoop:
+		return &memd.Packet{}
+	case memd.CmdSASLListMechs:
+		return &memd.Packet{Value: []byte("PLAIN")}
+	case memd.CmdSASLAuth:
+		return s.handleSaslAuth(sc, req)
+	case memd.CmdGetErrorMap:
+		return ErrorResponse(req, memd.StatusUnknownCommand)
+	}
+
+	if !sc.authenticated {
+		return ErrorResponse(req, memd.StatusAuthError)
+	}
+
+	switch req.Command {
+	case memd.CmdSelectBucket:
+		if string(req.Key) != s.opts.BucketName {
+			return ErrorResponse(req, memd.StatusAccessError)
+		}
+		sc.bucket = string(req.Key)
+		return &memd.Packet{}
+	}
+
+	if sc.bucket == "" {
+		return ErrorResponse(req, memd.StatusNoBucket)
+	}
+
+	switch req.Command {
+	case memd.CmdGetClusterConfig:
+		return &memd.Packet{
+			Datatype: uint8(memd.DatatypeFlagJSON),
+			Value:    s.clusterConfig(),
+		}
+	case memd.CmdGet:
+		return s.handleGet(req)
+	case memd.CmdSet, memd.CmdAdd, memd.CmdReplace:
+		return s.handleStore(sc, req)
+	case memd.CmdDelete:
+		return s.handleDelete(sc, req)
+	}
+
+	return ErrorResponse(req, memd.StatusUnknownCommand)
+}
+
+func (s *Server) handleHello(sc *serverConn, req *memd.Packet) *memd.Packet {
+	sc.features = nil
+	var value []byte
+	for i := 0; i+1 < len(req.Value); i += 2 {
+		feature := memd.HelloFeature(binary.BigEndian.Uint16(req.Value[i:]))
+		for _, supported := range s.opts.Features {
+			if feature == supported {
+				sc.features = append(sc.features, feature)
+				value = append(value, req.Value[i:i+2]...)
+				break
+			}
+		}
+	}
+
+	resp := &memd.Packet{Value: value}
+	for _, feature := range sc.features {
+		sc.conn.EnableFeature(feature)
+	}
+
+	return resp
+}
+
+func (s *Server) handleSaslAuth(sc *serverConn, req *memd.Packet) *memd.Packet {
+	if string(req.Key) != "PLAIN" {
+		return ErrorResponse(req, memd.StatusAuthError)
+	}
+
+	parts := bytes.Split(req.Value, []byte{0})
+	if len(parts) != 3 {
+		return ErrorResponse(req, memd.StatusAuthError)
+	}
+
+	if s.opts.Username != "" || s.opts.Password != "" {
+		if string(parts[1]) != s.opts.Username || string(parts[2]) != s.opts.Password {
+			return ErrorResponse(req, memd.StatusAuthError)
+		}
+	}
+
+	sc.authenticated = true
+	return &memd.Packet{}
+}
+
+func (s *Server) handleGet(req *memd.Packet) *memd.Packet {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	doc, ok := s.docs[string(req.Key)]
+	if !ok {
+		return ErrorResponse(req, memd.StatusKeyNotFound)
+	}
+
+	extras := make([]byte, 4)
+	binary.BigEndian.PutUint32(extras, doc.flags)
+
+	return &memd.Packet{
+		Datatype: doc.datatype,
+		Cas:      doc.cas,
+		Extras:   extras,
+		Value:    doc.value,
+	}
+}
+
+func (s *Server) handleStore(sc *serverConn, req *memd.Packet) *memd.Packet {
+	if len(req.Extras) != 8 {
+		return ErrorResponse(req, memd.StatusInvalidArgs)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := string(req.Key)
+	existing, exists := s.docs[key]
+	switch {
+	case req.Command == memd.CmdAdd && exists:
+		return ErrorResponse(req, memd.StatusKeyExists)
+	case req.Command == memd.CmdReplace && !exists:
+		return ErrorResponse(req, memd.StatusKeyNotFound)
+	case req.Cas != 0 && !exists:
+		return ErrorResponse(req, memd.StatusKeyNotFound)
+	case req.Cas != 0 && existing.cas != req.Cas:
+		return ErrorResponse(req, memd.StatusKeyExists)
+	}
+
+	doc := &document{
+		value:    append([]byte(nil), req.Value...),
+		flags:    binary.BigEndian.Uint32(req.Extras),
+		datatype: req.Datatype,
+		cas:      s.nextCasLocked(),
+	}
+	s.docs[key] = doc
+
+	return s.mutationResponseLocked(sc, doc.cas)
+}
+
+func (s *Server) handleDelete(sc *serverConn, req *memd.Packet) *memd.Packet {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := string(req.Key)
+	existing, exists := s.docs[key]
+	if !exists {
+		return ErrorResponse(req, memd.StatusKeyNotFound)
+	}
+	if req.Cas != 0 && existing.cas != req.Cas {
+		return ErrorResponse(req, memd.StatusKeyExists)
+	}
+	delete(s.docs, key)
+
+	return s.mutationResponseLocked(sc, s.nextCasLocked())
+}
+
+func (s *Server) nextCasLocked() uint64 {
+	s.cas++
+	return s.cas
+}
+
+func (s *Server) mutationResponseLocked(sc *serverConn, cas uint64) *memd.Packet {
+	resp := &memd.Packet{Cas: cas}
+	if sc.supports(memd.FeatureSeqNo) {
+		s.seqNo++
+		resp.Extras = make([]byte, 16)
+		binary.BigEndian.PutUint64(resp.Extras[0:], vbUUID)
+		binary.BigEndian.PutUint64(resp.Extras[8:], s.seqNo)
+	}
+
+	return resp
+}
+
+type configNodeExt struct {
+	Services map[string]int `json:"services"`
+	Hostname string         `json:"hostname"`
+	ThisNode bool           `json:"thisNode"`
+}
+
+type configNode struct {
+	Hostname string         `json:"hostname"`
+	Ports    map[string]int `json:"ports"`
+}
+
+type configVBucketServerMap struct {
+	HashAlgorithm string   `json:"hashAlgorithm"`
+	NumReplicas   int      `json:"numReplicas"`
+	ServerList    []string `json:"serverList"`
+	VBucketMap    [][]int  `json:"vBucketMap"`
+}
+
+type config struct {
+	Rev              int                    `json:"rev"`
+	Name             string                 `json:"name"`
+	UUID             string                 `json:"uuid"`
+	NodeLocator      string                 `json:"nodeLocator"`
+	Capabilities     []string               `json:"bucketCapabilities"`
+	VBucketServerMap configVBucketServerMap `json:"vBucketServerMap"`
+	Nodes            []configNode           `json:"nodes"`
+	NodesExt         []configNodeExt        `json:"nodesExt"`
+}
+
+func (s *Server) clusterConfig() []byte {
+	host, portStr, err := net.SplitHostPort(s.Address())
+	if err != nil {
+		// The address comes from our own listener so this cannot happen.
+		panic(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		panic(err)
+	}
+
+	vbMap := make([][]int, numVbuckets)
+	for i := range vbMap {
+		vbMap[i] = []int{0}
+	}
+
+	cfg := config{
+		Rev:          1,
+		Name:         s.opts.BucketName,
+		UUID:         fmt.Sprintf("%x", vbUUID),
+		NodeLocator:  "vbucket",
+		Capabilities: []string{"couchapi", "xattr", "dcp", "cbhello", "touch", "cccp", "nodesExt"},
+		VBucketServerMap: configVBucketServerMap{
+			HashAlgorithm: "CRC",
+			ServerList:    []string{s.Address()},
+			VBucketMap:    vbMap,
+		},
+		Nodes: []configNode{
+			{
+				Hostname: s.Address(),
+				Ports:    map[string]int{"direct": port},
+			},
+		},
+		NodesExt: []configNodeExt{
+			{
+				// The server does not serve HTTP but routing requires a management endpoint, so we advertise
+				// our own port. Config polling happens over CCCP so it is never used.
+				Services: map[string]int{"kv": port, "mgmt": port},
+				Hostname: host,
+				ThisNode: true,
+			},
+		},
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
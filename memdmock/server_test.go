@@ -0,0 +1,151 @@
+package memdmock
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+type testClient struct {
+	t      *testing.T
+	conn   *memd.Conn
+	opaque uint32
+}
+
+func newTestClient(t *testing.T, srv *Server) *testClient {
+	netConn, err := net.Dial("tcp", srv.Address())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = netConn.Close()
+	})
+
+	return &testClient{t: t, conn: memd.NewConn(netConn)}
+}
+
+func (c *testClient) do(req *memd.Packet) *memd.Packet {
+	c.opaque++
+	req.Magic = memd.CmdMagicReq
+	req.Opaque = c.opaque
+	if err := c.conn.WritePacket(req); err != nil {
+		c.t.Fatalf("failed to write packet: %v", err)
+	}
+
+	resp, _, err := c.conn.ReadPacket()
+	if err != nil {
+		c.t.Fatalf("failed to read packet: %v", err)
+	}
+	if resp.Opaque != req.Opaque || resp.Command != req.Command {
+		c.t.Fatalf("response did not match request: %+v", resp)
+	}
+
+	return resp
+}
+
+func storeExtras(flags uint32) []byte {
+	extras := make([]byte, 8)
+	binary.BigEndian.PutUint32(extras, flags)
+	return extras
+}
+
+func TestServer(t *testing.T) {
+	srv, err := NewServer(ServerOptions{
+		Username: "user",
+		Password: "pass",
+	})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+
+	features := make([]byte, 4)
+	binary.BigEndian.PutUint16(features[0:], uint16(memd.FeatureSeqNo))
+	binary.BigEndian.PutUint16(features[2:], uint16(memd.FeatureCollections))
+	resp := c.do(&memd.Packet{Command: memd.CmdHello, Value: features})
+	if resp.Status != memd.StatusSuccess || len(resp.Value) != 2 ||
+		memd.HelloFeature(binary.BigEndian.Uint16(resp.Value)) != memd.FeatureSeqNo {
+		t.Fatalf("unexpected hello response: %+v", resp)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdSelectBucket, Key: []byte("default")})
+	if resp.Status != memd.StatusAuthError {
+		t.Fatalf("expected auth error before authenticating, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdSASLAuth, Key: []byte("PLAIN"), Value: []byte("\x00user\x00wrong")})
+	if resp.Status != memd.StatusAuthError {
+		t.Fatalf("expected auth error for bad password, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdSASLAuth, Key: []byte("PLAIN"), Value: []byte("\x00user\x00pass")})
+	if resp.Status != memd.StatusSuccess {
+		t.Fatalf("expected successful auth, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdSelectBucket, Key: []byte("missing")})
+	if resp.Status != memd.StatusAccessError {
+		t.Fatalf("expected access error for unknown bucket, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdSelectBucket, Key: []byte("default")})
+	if resp.Status != memd.StatusSuccess {
+		t.Fatalf("expected successful select bucket, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdAdd, Key: []byte("key"), Extras: storeExtras(1), Value: []byte("v1")})
+	if resp.Status != memd.StatusSuccess || resp.Cas == 0 || len(resp.Extras) != 16 {
+		t.Fatalf("unexpected add response: %+v", resp)
+	}
+	cas := resp.Cas
+
+	resp = c.do(&memd.Packet{Command: memd.CmdAdd, Key: []byte("key"), Extras: storeExtras(1), Value: []byte("v1")})
+	if resp.Status != memd.StatusKeyExists {
+		t.Fatalf("expected key exists for add, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdReplace, Key: []byte("key"), Cas: cas + 100, Extras: storeExtras(2),
+		Value: []byte("v2")})
+	if resp.Status != memd.StatusKeyExists {
+		t.Fatalf("expected key exists for cas mismatch, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdReplace, Key: []byte("key"), Cas: cas, Extras: storeExtras(2),
+		Value: []byte("v2")})
+	if resp.Status != memd.StatusSuccess || resp.Cas == cas {
+		t.Fatalf("unexpected replace response: %+v", resp)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdGet, Key: []byte("key")})
+	if resp.Status != memd.StatusSuccess || string(resp.Value) != "v2" || binary.BigEndian.Uint32(resp.Extras) != 2 {
+		t.Fatalf("unexpected get response: %+v", resp)
+	}
+
+	srv.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		return ErrorResponse(req, memd.StatusTmpFail)
+	})
+	resp = c.do(&memd.Packet{Command: memd.CmdGet, Key: []byte("key")})
+	if resp.Status != memd.StatusTmpFail {
+		t.Fatalf("expected injected tmpfail, got %s", resp.Status)
+	}
+	srv.Handle(memd.CmdGet, nil)
+
+	resp = c.do(&memd.Packet{Command: memd.CmdDelete, Key: []byte("key")})
+	if resp.Status != memd.StatusSuccess {
+		t.Fatalf("expected successful delete, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdGet, Key: []byte("key")})
+	if resp.Status != memd.StatusKeyNotFound {
+		t.Fatalf("expected key not found after delete, got %s", resp.Status)
+	}
+
+	resp = c.do(&memd.Packet{Command: memd.CmdIncrement, Key: []byte("key")})
+	if resp.Status != memd.StatusUnknownCommand {
+		t.Fatalf("expected unknown command, got %s", resp.Status)
+	}
+}
@@ -15,9 +15,15 @@ var (
 	errAlreadyQueued = errors.New("request was already queued somewhere else")
 )
 
+// memdOpQueueStarvationLimit is the number of times that a queued request of a given priority can be passed over in
+// favour of a higher priority request before a request of that priority is popped regardless.
+const memdOpQueueStarvationLimit = 16
+
 type memdOpConsumer struct {
 	parent   *memdOpQueue
 	isClosed bool
+	// highPriorityOnly indicates that this consumer is reserved for high priority requests.
+	highPriorityOnly bool
 }
 
 func (c *memdOpConsumer) Queue() *memdOpQueue {
@@ -35,25 +41,60 @@ func (c *memdOpConsumer) Close() {
 type memdOpQueue struct {
 	lock   sync.Mutex
 	signal *sync.Cond
-	items  *list.List
+	// items holds a list of requests for each priority, indexed by memdOpQueuePriorityIdx.
+	items  [3]*list.List
 	isOpen bool
+
+	// passedOver tracks, per priority, how many times requests have been passed over for higher priority requests.
+	passedOver [3]int
 }
 
 func newMemdOpQueue() *memdOpQueue {
 	q := memdOpQueue{
 		isOpen: true,
-		items:  list.New(),
+	}
+	for i := range q.items {
+		q.items[i] = list.New()
 	}
 	q.signal = sync.NewCond(&q.lock)
 	return &q
 }
 
+// memdOpQueuePriorityIdx returns the index into the queue lists for a priority, lower indexes are higher priority.
+func memdOpQueuePriorityIdx(priority OperationPriority) int {
+	switch priority {
+	case OperationPriorityHigh:
+		return 0
+	case OperationPriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (q *memdOpQueue) lenLocked() int {
+	var total int
+	for _, items := range q.items {
+		total += items.Len()
+	}
+	return total
+}
+
+// Len returns the total number of requests in the queue.
+func (q *memdOpQueue) Len() int {
+	q.lock.Lock()
+	total := q.lenLocked()
+	q.lock.Unlock()
+
+	return total
+}
+
 // nolint: unused
 func (q *memdOpQueue) debugString() string {
 	var outStr string
 	q.lock.Lock()
 
-	outStr += fmt.Sprintf("Num Items: %d\n", q.items.Len())
+	outStr += fmt.Sprintf("Num Items: %d\n", q.lenLocked())
 	outStr += fmt.Sprintf("Is Open: %t", q.isOpen)
 
 	q.lock.Unlock()
@@ -68,9 +109,10 @@ func (q *memdOpQueue) Remove(req *memdQRequest) bool {
 		return false
 	}
 
-	for e := q.items.Front(); e != nil; e = e.Next() {
+	items := q.items[memdOpQueuePriorityIdx(req.Priority)]
+	for e := items.Front(); e != nil; e = e.Next() {
 		if e.Value.(*memdQRequest) == req {
-			q.items.Remove(e)
+			items.Remove(e)
 			break
 		}
 	}
@@ -87,7 +129,7 @@ func (q *memdOpQueue) Push(req *memdQRequest, maxItems int) error {
 		return errOpQueueClosed
 	}
 
-	if maxItems > 0 && q.items.Len() >= maxItems {
+	if maxItems > 0 && q.lenLocked() >= maxItems {
 		q.lock.Unlock()
 		return errOpQueueFull
 	}
@@ -104,7 +146,7 @@ func (q *memdOpQueue) Push(req *memdQRequest, maxItems int) error {
 		return errRequestCanceled
 	}
 
-	q.items.PushBack(req)
+	q.items[memdOpQueuePriorityIdx(req.Priority)].PushBack(req)
 	q.lock.Unlock()
 
 	q.signal.Broadcast()
@@ -118,6 +160,15 @@ func (q *memdOpQueue) Consumer() *memdOpConsumer {
 	}
 }
 
+// HighPriorityConsumer returns a consumer which will only pop high priority requests.
+func (q *memdOpQueue) HighPriorityConsumer() *memdOpConsumer {
+	return &memdOpConsumer{
+		parent:           q,
+		isClosed:         false,
+		highPriorityOnly: true,
+	}
+}
+
 func (q *memdOpQueue) closeConsumer(c *memdOpConsumer) {
 	q.lock.Lock()
 	c.isClosed = true
@@ -126,10 +177,53 @@ func (q *memdOpQueue) closeConsumer(c *memdOpConsumer) {
 	q.signal.Broadcast()
 }
 
+// nextItemsLocked selects which list the next request should be popped from for the consumer, or nil if there
+// are no requests available to the consumer.
+func (q *memdOpQueue) nextItemsLocked(c *memdOpConsumer) *list.List {
+	if c.highPriorityOnly {
+		if q.items[0].Len() == 0 {
+			return nil
+		}
+		return q.items[0]
+	}
+
+	// If lower priority requests have been repeatedly passed over then let one through, this prevents a constant
+	// stream of higher priority requests from starving them entirely.
+	for i := len(q.items) - 1; i > 0; i-- {
+		if q.items[i].Len() > 0 && q.passedOver[i] >= memdOpQueueStarvationLimit {
+			q.passedOver[i] = 0
+			return q.items[i]
+		}
+	}
+
+	selected := -1
+	for i, items := range q.items {
+		if items.Len() == 0 {
+			continue
+		}
+		if selected < 0 {
+			selected = i
+		} else {
+			q.passedOver[i]++
+		}
+	}
+	if selected < 0 {
+		return nil
+	}
+
+	q.passedOver[selected] = 0
+	return q.items[selected]
+}
+
 func (q *memdOpQueue) pop(c *memdOpConsumer) *memdQRequest {
 	q.lock.Lock()
 
-	for q.isOpen && !c.isClosed && q.items.Len() == 0 {
+	var items *list.List
+	for q.isOpen && !c.isClosed {
+		items = q.nextItemsLocked(c)
+		if items != nil {
+			break
+		}
 		q.signal.Wait()
 	}
 
@@ -138,8 +232,8 @@ func (q *memdOpQueue) pop(c *memdOpConsumer) *memdQRequest {
 		return nil
 	}
 
-	e := q.items.Front()
-	q.items.Remove(e)
+	e := items.Front()
+	items.Remove(e)
 
 	req, ok := e.Value.(*memdQRequest)
 	if !ok {
@@ -165,16 +259,18 @@ func (q *memdOpQueue) Drain(cb drainCallback) {
 		return
 	}
 
-	for e := q.items.Front(); e != nil; e = e.Next() {
-		req, ok := e.Value.(*memdQRequest)
-		if !ok {
-			logErrorf("Encountered incorrect type in memdOpQueue")
-			continue
-		}
+	for _, items := range q.items {
+		for e := items.Front(); e != nil; e = e.Next() {
+			req, ok := e.Value.(*memdQRequest)
+			if !ok {
+				logErrorf("Encountered incorrect type in memdOpQueue")
+				continue
+			}
 
-		atomic.CompareAndSwapPointer(&req.queuedWith, unsafe.Pointer(q), nil)
+			atomic.CompareAndSwapPointer(&req.queuedWith, unsafe.Pointer(q), nil)
 
-		cb(req)
+			cb(req)
+		}
 	}
 
 	q.lock.Unlock()
@@ -0,0 +1,125 @@
+package gocbcore
+
+import (
+	"time"
+)
+
+func (suite *UnitTestSuite) TestMemdOpQueuePriorityOrdering() {
+	q := newMemdOpQueue()
+
+	low := &memdQRequest{Priority: OperationPriorityLow}
+	normal := &memdQRequest{Priority: OperationPriorityNormal}
+	high := &memdQRequest{Priority: OperationPriorityHigh}
+
+	suite.Require().Nil(q.Push(low, 0))
+	suite.Require().Nil(q.Push(normal, 0))
+	suite.Require().Nil(q.Push(high, 0))
+	suite.Assert().Equal(3, q.Len())
+
+	consumer := q.Consumer()
+	suite.Assert().Equal(high, consumer.Pop())
+	suite.Assert().Equal(normal, consumer.Pop())
+	suite.Assert().Equal(low, consumer.Pop())
+	suite.Assert().Zero(q.Len())
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueueHighPriorityConsumer() {
+	q := newMemdOpQueue()
+
+	normal := &memdQRequest{Priority: OperationPriorityNormal}
+	high := &memdQRequest{Priority: OperationPriorityHigh}
+	suite.Require().Nil(q.Push(normal, 0))
+
+	consumer := q.HighPriorityConsumer()
+	popCh := make(chan *memdQRequest, 1)
+	go func() {
+		popCh <- consumer.Pop()
+	}()
+
+	// The reserved consumer must not pick up the normal priority request.
+	select {
+	case req := <-popCh:
+		suite.T().Fatalf("High priority consumer popped unexpected request: %v", req)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	suite.Require().Nil(q.Push(high, 0))
+	select {
+	case req := <-popCh:
+		suite.Assert().Equal(high, req)
+	case <-time.After(1 * time.Second):
+		suite.T().Fatalf("Timed out waiting for high priority request")
+	}
+
+	suite.Assert().Equal(normal, q.Consumer().Pop())
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueueStarvationAvoidance() {
+	q := newMemdOpQueue()
+
+	low := &memdQRequest{Priority: OperationPriorityLow}
+	suite.Require().Nil(q.Push(low, 0))
+
+	consumer := q.Consumer()
+	var popped int
+	for {
+		// Keep the queue full of high priority requests, the low priority request must still get through.
+		suite.Require().Nil(q.Push(&memdQRequest{Priority: OperationPriorityHigh}, 0))
+
+		req := consumer.Pop()
+		popped++
+		if req == low {
+			break
+		}
+		if popped > memdOpQueueStarvationLimit+1 {
+			suite.T().Fatalf("Low priority request was starved")
+		}
+	}
+
+	suite.Assert().Equal(memdOpQueueStarvationLimit+1, popped)
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueueRemoveAndDrain() {
+	q := newMemdOpQueue()
+
+	low := &memdQRequest{Priority: OperationPriorityLow}
+	high := &memdQRequest{Priority: OperationPriorityHigh}
+	suite.Require().Nil(q.Push(low, 0))
+	suite.Require().Nil(q.Push(high, 0))
+
+	suite.Assert().Equal(errOpQueueFull, q.Push(&memdQRequest{}, 2))
+
+	suite.Assert().True(q.Remove(low))
+	suite.Assert().Equal(1, q.Len())
+
+	q.Close()
+	var drained []*memdQRequest
+	q.Drain(func(req *memdQRequest) {
+		drained = append(drained, req)
+	})
+	suite.Assert().Equal([]*memdQRequest{high}, drained)
+}
+
+func (suite *UnitTestSuite) TestMemdPipelineReservedClients() {
+	pipeline := newPipeline(routeEndpoint{Address: "localhost:11210"}, 4, 2, 0, 0, nil, nil)
+	suite.Assert().Equal(2, pipeline.reservedClients)
+
+	// At least one client must always be left to service all requests.
+	pipeline = newPipeline(routeEndpoint{Address: "localhost:11210"}, 2, 5, 0, 0, nil, nil)
+	suite.Assert().Equal(1, pipeline.reservedClients)
+
+	pipeline = newPipeline(routeEndpoint{Address: "localhost:11210"}, 1, 1, 0, 0, nil, nil)
+	suite.Assert().Equal(0, pipeline.reservedClients)
+}
+
+func (suite *UnitTestSuite) TestMemdPipelineMaxNodeItems() {
+	pipeline := newPipeline(routeEndpoint{Address: "localhost:11210"}, 1, 0, 0, 2, nil, nil)
+
+	suite.Require().Nil(pipeline.SendRequest(&memdQRequest{}))
+	suite.Require().Nil(pipeline.SendRequest(&memdQRequest{}))
+	suite.Assert().Equal(errPipelineFull, pipeline.SendRequest(&memdQRequest{}))
+
+	// Requeued requests have already been accepted so are not subject to the limit.
+	suite.Assert().Nil(pipeline.RequeueRequest(&memdQRequest{}))
+	suite.Assert().Equal(3, pipeline.numPendingOps())
+}
@@ -19,28 +19,46 @@ type memdPipeline struct {
 	address     string
 	getClientFn memdGetClientFn
 	maxItems    int
-	queue       *memdOpQueue
-	maxClients  int
-	clients     []*memdPipelineClient
-	clientsLock sync.Mutex
-	isSeedNode  bool
-	serverGroup string
+	// maxNodeItems is the maximum number of requests which can be pending against the node, whether queued or
+	// awaiting a response, before new requests are rejected.
+	maxNodeItems int
+	queue        *memdOpQueue
+	maxClients   int
+	// reservedClients is the number of clients which are reserved for high priority requests.
+	reservedClients int
+	clients         []*memdPipelineClient
+	clientsLock     sync.Mutex
+	isSeedNode      bool
+	serverGroup     string
+	logger          *scopedLogger
 }
 
-func newPipeline(endpoint routeEndpoint, maxClients, maxItems int, getClientFn memdGetClientFn) *memdPipeline {
+func newPipeline(endpoint routeEndpoint, maxClients, reservedClients, maxItems, maxNodeItems int,
+	getClientFn memdGetClientFn, logger *scopedLogger) *memdPipeline {
+	// We always need at least one client which can service requests of any priority.
+	if reservedClients >= maxClients {
+		reservedClients = maxClients - 1
+	}
+	if reservedClients < 0 {
+		reservedClients = 0
+	}
+
 	return &memdPipeline{
-		address:     endpoint.Address,
-		getClientFn: getClientFn,
-		maxClients:  maxClients,
-		maxItems:    maxItems,
-		queue:       newMemdOpQueue(),
-		isSeedNode:  endpoint.IsSeedNode,
-		serverGroup: endpoint.ServerGroup,
+		address:         endpoint.Address,
+		getClientFn:     getClientFn,
+		maxClients:      maxClients,
+		reservedClients: reservedClients,
+		maxItems:        maxItems,
+		maxNodeItems:    maxNodeItems,
+		queue:           newMemdOpQueue(),
+		isSeedNode:      endpoint.IsSeedNode,
+		serverGroup:     endpoint.ServerGroup,
+		logger:          logger,
 	}
 }
 
-func newDeadPipeline(maxItems int) *memdPipeline {
-	return newPipeline(routeEndpoint{}, 0, maxItems, nil)
+func newDeadPipeline(maxItems int, logger *scopedLogger) *memdPipeline {
+	return newPipeline(routeEndpoint{}, 0, 0, maxItems, 0, nil, logger)
 }
 
 // nolint: unused
@@ -102,6 +120,9 @@ func (pipeline *memdPipeline) StartClients() {
 
 	for len(pipeline.clients) < pipeline.maxClients {
 		client := newMemdPipelineClient(pipeline)
+		// The reserved clients are always the last ones created, so that any clients taken over from a previous
+		// pipeline, such as when moving from a cluster to a bucket config, remain available to all requests.
+		client.highPriorityOnly = len(pipeline.clients) >= pipeline.maxClients-pipeline.reservedClients
 		pipeline.clients = append(pipeline.clients, client)
 
 		go client.Run()
@@ -126,23 +147,38 @@ func (pipeline *memdPipeline) RequeueRequest(req *memdQRequest) error {
 }
 
 func (pipeline *memdPipeline) SendRequest(req *memdQRequest) error {
+	if pipeline.maxNodeItems > 0 && pipeline.numPendingOps() >= pipeline.maxNodeItems {
+		return errPipelineFull
+	}
+
 	return pipeline.sendRequest(req, pipeline.maxItems)
 }
 
+// numPendingOps returns the number of requests which are either queued to be sent to the node or have been sent and
+// are awaiting a response.
+func (pipeline *memdPipeline) numPendingOps() int {
+	numPending := pipeline.queue.Len()
+	for _, pipecli := range pipeline.Clients() {
+		numPending += pipecli.NumInFlightOps()
+	}
+
+	return numPending
+}
+
 // Performs a takeover of another pipeline.  Note that this does not
 //
 //	take over the requests queued in the old pipeline, and those must
 //	be drained and processed separately.
 func (pipeline *memdPipeline) Takeover(oldPipeline *memdPipeline) {
 	if oldPipeline.address != pipeline.address {
-		logErrorf("Attempted pipeline takeover for differing address")
+		pipeline.logger.errorf("Attempted pipeline takeover for differing address")
 
 		// We try to 'gracefully' error here by resolving all the requests as
 		//  errors, but allowing the application to continue.
 		err := oldPipeline.Close()
 		if err != nil {
 			// Log and continue with this non-fatal error.
-			logDebugf("Failed to shutdown old pipeline (%s)", err)
+			pipeline.logger.debugf("Failed to shutdown old pipeline (%s)", err)
 		}
 
 		// Drain all the requests as an internal error so they are not lost
@@ -184,7 +220,7 @@ func (pipeline *memdPipeline) GracefulClose() []*memdClient {
 	var memdClients []*memdClient
 	for _, pipecli := range clients {
 		client := pipecli.CloseAndTakeClient()
-		logDebugf("Pipeline %s/%p taking memdclient %p from client %p", pipeline.address, pipeline, client, pipecli)
+		pipeline.logger.debugf("Pipeline %s/%p taking memdclient %p from client %p", pipeline.address, pipeline, client, pipecli)
 		if client != nil {
 			memdClients = append(memdClients, client)
 		}
@@ -210,7 +246,7 @@ func (pipeline *memdPipeline) Close() error {
 
 			err := client.Close()
 			if err != nil {
-				logErrorf("failed to shutdown memdclient: %s", err)
+				pipeline.logger.errorf("failed to shutdown memdclient: %s", err)
 				hadErrors = true
 			}
 
@@ -25,7 +25,12 @@ type memdPipelineClient struct {
 	cancelDialSig  chan struct{}
 	state          uint32
 
+	// highPriorityOnly indicates that this client is reserved for high priority requests.
+	highPriorityOnly bool
+
 	connectError error
+
+	logger *scopedLogger
 }
 
 func newMemdPipelineClient(parent *memdPipeline) *memdPipelineClient {
@@ -36,6 +41,7 @@ func newMemdPipelineClient(parent *memdPipeline) *memdPipelineClient {
 		clientTakenSig: make(chan struct{}),
 		cancelDialSig:  make(chan struct{}),
 		state:          uint32(EndpointStateDisconnected),
+		logger:         parent.logger,
 	}
 }
 
@@ -64,12 +70,12 @@ func (pipecli *memdPipelineClient) ReassignTo(parent *memdPipeline) {
 func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 	pipecli.lock.Lock()
 	if pipecli.parent == nil {
-		logDebugf("Pipeline client ioLoop started with no parent pipeline")
+		pipecli.logger.debugf("Pipeline client ioLoop started with no parent pipeline")
 		pipecli.lock.Unlock()
 
 		err := client.Close()
 		if err != nil {
-			logErrorf("Failed to close client for shut down ioLoop (%s)", err)
+			pipecli.logger.errorf("Failed to close client for shut down ioLoop (%s)", err)
 		}
 
 		return
@@ -85,13 +91,13 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 	// shut down flow through this goroutine, even cases where we may already
 	// be aware that the client is shutdown, outside this scope.
 	go func() {
-		logDebugf("Pipeline client `%s/%p` client watcher starting...", pipecli.address, pipecli)
+		pipecli.logger.debugf("Pipeline client `%s/%p` client watcher starting...", pipecli.address, pipecli)
 
 		select {
 		case <-client.CloseNotify():
-			logDebugf("Pipeline client `%s/%p` client died", pipecli.address, pipecli)
+			pipecli.logger.debugf("Pipeline client `%s/%p` client died", pipecli.address, pipecli)
 		case <-pipecli.clientTakenSig:
-			logDebugf("Pipeline client `%s/%p` client taken", pipecli.address, pipecli)
+			pipecli.logger.debugf("Pipeline client `%s/%p` client taken", pipecli.address, pipecli)
 		}
 
 		pipecli.lock.Lock()
@@ -100,7 +106,7 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 		pipecli.consumer = nil
 		pipecli.lock.Unlock()
 
-		logDebugf("Pipeline client `%s/%p` closing consumer %p", pipecli.address, pipecli, activeConsumer)
+		pipecli.logger.debugf("Pipeline client `%s/%p` closing consumer %p", pipecli.address, pipecli, activeConsumer)
 
 		// If we have a consumer, we need to close it to signal the loop below that
 		// something has happened.  If there is no consumer, we don't need to signal
@@ -113,12 +119,12 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 		killSig <- struct{}{}
 	}()
 
-	logDebugf("Pipeline client `%s/%p` IO loop starting...", pipecli.address, pipecli)
+	pipecli.logger.debugf("Pipeline client `%s/%p` IO loop starting...", pipecli.address, pipecli)
 
 	var localConsumer *memdOpConsumer
 	for {
 		if localConsumer == nil {
-			logDebugf("Pipeline client `%s/%p` fetching new consumer", pipecli.address, pipecli)
+			pipecli.logger.debugf("Pipeline client `%s/%p` fetching new consumer", pipecli.address, pipecli)
 
 			pipecli.lock.Lock()
 
@@ -137,14 +143,18 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 
 			if pipecli.parent == nil {
 				// This pipelineClient has been shut down
-				logDebugf("Pipeline client `%s/%p` found no parent pipeline", pipecli.address, pipecli)
+				pipecli.logger.debugf("Pipeline client `%s/%p` found no parent pipeline", pipecli.address, pipecli)
 				pipecli.lock.Unlock()
 
 				break
 			}
 
 			// Fetch a new consumer to use for this iteration
-			localConsumer = pipecli.parent.queue.Consumer()
+			if pipecli.highPriorityOnly {
+				localConsumer = pipecli.parent.queue.HighPriorityConsumer()
+			} else {
+				localConsumer = pipecli.parent.queue.Consumer()
+			}
 			pipecli.consumer = localConsumer
 
 			pipecli.lock.Unlock()
@@ -164,7 +174,7 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 
 		err := client.SendRequest(req)
 		if err != nil {
-			logDebugf("Pipeline client `%s/%p` encountered a socket write error: %v", pipecli.address, pipecli, err)
+			pipecli.logger.debugf("Pipeline client `%s/%p` encountered a socket write error: %v", pipecli.address, pipecli, err)
 
 			if !errors.Is(err, io.EOF) && !errors.Is(err, ErrMemdClientClosed) {
 				// If we errored the write, and the client was not already closed,
@@ -175,7 +185,7 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 				// did shutdown or is gracefully shutting down.
 				err := client.Close()
 				if err != nil {
-					logErrorf("Pipeline client `%s/%p` failed to shut down errored client socket (%s)", pipecli.address, pipecli, err)
+					pipecli.logger.errorf("Pipeline client `%s/%p` failed to shut down errored client socket (%s)", pipecli.address, pipecli, err)
 				}
 			}
 
@@ -197,12 +207,12 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 	// We must wait for the close wait goroutine to die as well before we can continue.
 	<-killSig
 
-	logDebugf("Pipeline client `%s/%p` received client shutdown notification", pipecli.address, pipecli)
+	pipecli.logger.debugf("Pipeline client `%s/%p` received client shutdown notification", pipecli.address, pipecli)
 }
 
 func (pipecli *memdPipelineClient) Run() {
 	for {
-		logDebugf("Pipeline Client `%s/%p` preparing for new client loop", pipecli.address, pipecli)
+		pipecli.logger.debugf("Pipeline Client `%s/%p` preparing for new client loop", pipecli.address, pipecli)
 		atomic.StoreUint32(&pipecli.state, uint32(EndpointStateConnecting))
 
 		pipecli.lock.Lock()
@@ -211,11 +221,11 @@ func (pipecli *memdPipelineClient) Run() {
 
 		if pipeline == nil {
 			// If our pipeline is nil, it indicates that we need to shut down.
-			logDebugf("Pipeline Client `%s/%p` is shutting down", pipecli.address, pipecli)
+			pipecli.logger.debugf("Pipeline Client `%s/%p` is shutting down", pipecli.address, pipecli)
 			break
 		}
 
-		logDebugf("Pipeline Client `%s/%p` retrieving new client connection for parent %p", pipecli.address, pipecli, pipeline)
+		pipecli.logger.debugf("Pipeline Client `%s/%p` retrieving new client connection for parent %p", pipecli.address, pipecli, pipeline)
 		wait := make(chan clientWait, 1)
 		go func() {
 			client, err := pipeline.getClientFn(pipecli.cancelDialSig)
@@ -231,7 +241,7 @@ func (pipecli *memdPipelineClient) Run() {
 			pipecli.lock.Lock()
 			if pipecli.parent != nil {
 				// If we know that we're shutting then don't log the error, it isn't unexpected.
-				logWarnf("Pipeline Client %p failed to bootstrap: %s", pipecli, cli.err)
+				pipecli.logger.warnf("Pipeline Client %p failed to bootstrap: %s", pipecli, cli.err)
 			}
 			pipecli.connectError = cli.err
 			pipecli.lock.Unlock()
@@ -244,7 +254,7 @@ func (pipecli *memdPipelineClient) Run() {
 		atomic.StoreUint32(&pipecli.state, uint32(EndpointStateConnected))
 
 		// Runs until the connection has died (for whatever reason)
-		logDebugf("Pipeline Client `%s/%p` starting new client loop for %p", pipecli.address, pipecli, cli.client)
+		pipecli.logger.debugf("Pipeline Client `%s/%p` starting new client loop for %p", pipecli.address, pipecli, cli.client)
 		pipecli.ioLoop(cli.client)
 	}
 
@@ -255,7 +265,7 @@ func (pipecli *memdPipelineClient) Run() {
 // CloseAndTakeClient will close this pipeline client, yielding the memdClient. Note that this method will not wait for
 // everything to be cleaned up before returning.
 func (pipecli *memdPipelineClient) CloseAndTakeClient() *memdClient {
-	logDebugf("Pipeline Client `%s/%p` received close request", pipecli.address, pipecli)
+	pipecli.logger.debugf("Pipeline Client `%s/%p` received close request", pipecli.address, pipecli)
 	atomic.StoreUint32(&pipecli.state, uint32(EndpointStateDisconnecting))
 
 	// To shut down the client, we remove our reference to the parent. This
@@ -271,7 +281,7 @@ func (pipecli *memdPipelineClient) CloseAndTakeClient() *memdClient {
 
 	close(pipecli.clientTakenSig)
 
-	logDebugf("Pipeline client `%s/%p` closing consumer %p", pipecli.address, pipecli, activeConsumer)
+	pipecli.logger.debugf("Pipeline client `%s/%p` closing consumer %p", pipecli.address, pipecli, activeConsumer)
 
 	// If we have a consumer, we need to close it to signal the loop below that
 	// something has happened.  If there is no consumer, we don't need to signal
@@ -297,11 +307,22 @@ func (pipecli *memdPipelineClient) CloseAndTakeClient() *memdClient {
 	<-pipecli.closedSig
 	atomic.StoreUint32(&pipecli.state, uint32(EndpointStateDisconnected))
 
-	logDebugf("Pipeline Client `%s/%p` has exited", pipecli.address, pipecli)
+	pipecli.logger.debugf("Pipeline Client `%s/%p` has exited", pipecli.address, pipecli)
 
 	return client
 }
 
+// NumInFlightOps returns the number of requests which have been sent by the client and are awaiting a response.
+func (pipecli *memdPipelineClient) NumInFlightOps() int {
+	pipecli.lock.Lock()
+	defer pipecli.lock.Unlock()
+	if pipecli.client == nil {
+		return 0
+	}
+
+	return pipecli.client.NumInFlightOps()
+}
+
 func (pipecli *memdPipelineClient) SupportsFeature(feature memd.HelloFeature) bool {
 	pipecli.lock.Lock()
 	defer pipecli.lock.Unlock()
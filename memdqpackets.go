@@ -22,6 +22,15 @@ type memdQResponse struct {
 	sourceConnID string
 }
 
+// ServerDuration returns the time taken by the server to process the request, or zero if the server did not report it.
+func (resp *memdQResponse) ServerDuration() time.Duration {
+	if resp.ServerDurationFrame == nil {
+		return 0
+	}
+
+	return resp.ServerDurationFrame.ServerDuration
+}
+
 type callback func(*memdQResponse, *memdQRequest, error)
 
 // The data for a request that can be queued with a memdqueueconn,
@@ -36,6 +45,9 @@ type memdQRequest struct {
 	Persistent  bool
 	ServerGroup string
 
+	// DisableDecompression prevents a compressed response value from being decompressed for this request.
+	DisableDecompression bool
+
 	// This tracks when the request was dispatched so that we can
 	//  properly prioritize older requests to try and meet timeout
 	//  requirements.
@@ -56,6 +68,12 @@ type memdQRequest struct {
 	//  This is an integer to allow us to atomically control it.
 	isCompleted uint32
 
+	// This is used to ensure that the request is only counted once in the agent stats.
+	statsTracked uint32
+
+	// This is used to ensure that the request callback is only wrapped by the KV interceptors once.
+	interceptorsApplied uint32
+
 	// This is used to lock access to the request when processing
 	// a timeout, a response or spans
 	processingLock sync.Mutex
@@ -70,9 +88,24 @@ type memdQRequest struct {
 	// any back-off time period.
 	RetryStrategy RetryStrategy
 
+	// This is used to indicate that the request must never be retried, regardless of
+	// the retry strategy or the reason for the failure.
+	NoRetry bool
+
+	// This is used to indicate that the request is safe to retry in cases where only idempotent
+	// requests would otherwise be retried, regardless of the command.
+	IsIdempotent bool
+
+	// This is used to determine which connections the request can be dispatched on,
+	// and in which order queued requests are dispatched.
+	Priority OperationPriority
+
 	// This is the set of reasons why this request has been retried.
 	retryReasons []RetryReason
 
+	// This is the delay requested by the server, via the error map, before the request is retried.
+	serverRetryAfter time.Duration
+
 	// This is used to lock access to the request when processing
 	// retry reasons or attempts.
 	retryLock sync.Mutex
@@ -171,11 +204,19 @@ func (req *memdQRequest) retryStrategy() RetryStrategy {
 	return req.RetryStrategy
 }
 
+func (req *memdQRequest) noRetry() bool {
+	return req.NoRetry
+}
+
 func (req *memdQRequest) Identifier() string {
 	return fmt.Sprintf("%d", atomic.LoadUint32(&req.Opaque))
 }
 
 func (req *memdQRequest) Idempotent() bool {
+	if req.IsIdempotent {
+		return true
+	}
+
 	_, ok := idempotentOps[req.Command]
 	return ok
 }
@@ -205,9 +246,24 @@ func (req *memdQRequest) Timer() *time.Timer {
 	return t.(*time.Timer)
 }
 
+// ServerRetryAfter returns the delay requested by the server, via the error map, before the request is retried.
+func (req *memdQRequest) ServerRetryAfter() time.Duration {
+	req.retryLock.Lock()
+	defer req.retryLock.Unlock()
+	return req.serverRetryAfter
+}
+
+func (req *memdQRequest) setServerRetryAfter(retryAfter time.Duration) {
+	req.retryLock.Lock()
+	req.serverRetryAfter = retryAfter
+	req.retryLock.Unlock()
+}
+
 func (req *memdQRequest) recordRetryAttempt(retryReason RetryReason) {
 	req.retryLock.Lock()
 	defer req.retryLock.Unlock()
+	// The server hint only applies to the retry that it was received for.
+	req.serverRetryAfter = 0
 	req.retryCount++
 	found := false
 	for i := 0; i < len(req.retryReasons); i++ {
@@ -298,7 +354,7 @@ func (req *memdQRequest) cancelWithCallbackAndFinishTracer(err error, tracer *op
 	// callback immediately on the users behalf.
 	// Only if cancel succeeds we also finish the tracer.
 	if req.internalCancel(err) {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		req.Callback(nil, req, err)
 	}
 }
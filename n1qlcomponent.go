@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"regexp"
 	"strings"
@@ -65,6 +66,30 @@ func (q *N1QLRowReader) MetaData() ([]byte, error) {
 	return q.streamer.MetaData()
 }
 
+// Profile returns the raw profile section of the meta-data, as requested by setting Profile in the query options.
+// Like MetaData this is only available once the rows have been read. If the response did not contain a profile then
+// nil is returned.
+// Volatile: This API is subject to change at any time.
+func (q *N1QLRowReader) Profile() (json.RawMessage, error) {
+	meta, err := q.streamer.MetaData()
+	if err != nil {
+		return nil, err
+	}
+
+	var metaData struct {
+		Profile json.RawMessage `json:"profile"`
+	}
+	err = json.Unmarshal(meta, &metaData)
+	if err != nil {
+		return nil, wrapN1QLError(nil, q.statement, wrapError(err, "failed to parse meta-data"), "", 0)
+	}
+	if string(metaData.Profile) == "null" {
+		return nil, nil
+	}
+
+	return metaData.Profile, nil
+}
+
 // Close immediately shuts down the connection
 func (q *N1QLRowReader) Close() error {
 	return q.streamer.Close()
@@ -94,11 +119,41 @@ func (q *N1QLRowReader) Endpoint() string {
 	return q.endpoint
 }
 
+// N1QLProfileMode specifies the profiling information returned by the query service.
+type N1QLProfileMode string
+
+const (
+	// N1QLProfileModeOff disables profiling.
+	N1QLProfileModeOff = N1QLProfileMode("off")
+
+	// N1QLProfileModePhases includes the phase times and counts in the profile.
+	N1QLProfileModePhases = N1QLProfileMode("phases")
+
+	// N1QLProfileModeTimings includes the phase information and the timings of each operator in the profile.
+	N1QLProfileModeTimings = N1QLProfileMode("timings")
+)
+
 // N1QLQueryOptions represents the various options available for a n1ql query.
 type N1QLQueryOptions struct {
 	Payload       []byte
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
+
+	// PositionalParameters, if set, are JSON encoded and sent as the positional ($1, $2, ...) parameters of the query.
+	// The Payload must not also contain "args".
+	PositionalParameters []interface{}
+	// NamedParameters, if set, are JSON encoded and sent as the named parameters of the query. The names may be given
+	// with or without the leading "$", and the Payload must not also contain any of them.
+	NamedParameters map[string]interface{}
+	// Profile, if set, requests that the query service includes profiling information in the response, which can be
+	// read using N1QLRowReader.Profile. The Payload must not also contain "profile".
+	// Volatile: This API is subject to change at any time.
+	Profile N1QLProfileMode
+
+	// Context, if set, cancels the query when it is done. If Deadline is not set then the deadline of the context
+	// is used instead.
+	Context context.Context
 
 	// Internal: This should never be used and is not supported.
 	User string
@@ -106,6 +161,7 @@ type N1QLQueryOptions struct {
 	Endpoint string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 func wrapN1QLError(req *httpRequest, statement string, err error, errBody string, statusCode int) *N1QLError {
@@ -326,6 +382,9 @@ type n1qlQueryComponent struct {
 	cfgMgr        configManager
 	tracer        *tracerComponent
 
+	defaultTimeout time.Duration
+	logger         *scopedLogger
+
 	queryCache *n1qlQueryCache
 
 	enhancedPreparedSupported uint32
@@ -389,12 +448,15 @@ type n1qlJSONPrepData struct {
 	Name        string `json:"name"`
 }
 
-func newN1QLQueryComponent(httpComponent httpComponentInterface, cfgMgr configManager, tracer *tracerComponent) *n1qlQueryComponent {
+func newN1QLQueryComponent(httpComponent httpComponentInterface, cfgMgr configManager, tracer *tracerComponent,
+	defaultTimeout time.Duration, logger *scopedLogger) *n1qlQueryComponent {
 	nqc := &n1qlQueryComponent{
-		httpComponent: httpComponent,
-		cfgMgr:        cfgMgr,
-		queryCache:    newN1qlQueryCache(),
-		tracer:        tracer,
+		httpComponent:  httpComponent,
+		cfgMgr:         cfgMgr,
+		queryCache:     newN1qlQueryCache(),
+		tracer:         tracer,
+		defaultTimeout: defaultTimeout,
+		logger:         logger,
 	}
 	cfgMgr.AddConfigWatcher(nqc)
 
@@ -404,7 +466,7 @@ func newN1QLQueryComponent(httpComponent httpComponentInterface, cfgMgr configMa
 func (nqc *n1qlQueryComponent) OnNewRouteConfig(cfg *routeConfig) {
 	if atomic.LoadUint32(&nqc.enhancedPreparedSupported) == 0 &&
 		cfg.ContainsClusterCapability(1, "n1ql", "enhancedPreparedStatements") {
-		logDebugf("Enabling enhanced prepared statement support")
+		nqc.logger.debugf("Enabling enhanced prepared statement support")
 		// Once supported this can't be unsupported
 		nqc.queryCache.Invalidate()
 		atomic.StoreUint32(&nqc.enhancedPreparedSupported, 1)
@@ -416,18 +478,72 @@ func (nqc *n1qlQueryComponent) OnNewRouteConfig(cfg *routeConfig) {
 	}
 }
 
+// applyN1QLOptions adds the parameters and profile mode from the options into the payload.
+func applyN1QLOptions(payloadMap map[string]interface{}, opts N1QLQueryOptions) error {
+	if opts.Profile != "" {
+		if _, ok := payloadMap["profile"]; ok {
+			return wrapError(errInvalidArgument, "profile cannot be specified in both the payload and options")
+		}
+		payloadMap["profile"] = string(opts.Profile)
+	}
+
+	if len(opts.PositionalParameters) > 0 {
+		if _, ok := payloadMap["args"]; ok {
+			return wrapError(errInvalidArgument, "positional parameters cannot be specified in both the payload and options")
+		}
+
+		args := make([]json.RawMessage, len(opts.PositionalParameters))
+		for i, param := range opts.PositionalParameters {
+			val, err := json.Marshal(param)
+			if err != nil {
+				return wrapError(errInvalidArgument, fmt.Sprintf("failed to encode positional parameter %d: %v", i+1, err))
+			}
+			args[i] = val
+		}
+		payloadMap["args"] = args
+	}
+
+	for name, param := range opts.NamedParameters {
+		if !strings.HasPrefix(name, "$") {
+			name = "$" + name
+		}
+		if _, ok := payloadMap[name]; ok {
+			return wrapError(errInvalidArgument,
+				fmt.Sprintf("named parameter %s cannot be specified in both the payload and options", name))
+		}
+
+		val, err := json.Marshal(param)
+		if err != nil {
+			return wrapError(errInvalidArgument, fmt.Sprintf("failed to encode named parameter %s: %v", name, err))
+		}
+		payloadMap[name] = json.RawMessage(val)
+	}
+
+	return nil
+}
+
 // N1QLQuery executes a N1QL query
 func (nqc *n1qlQueryComponent) N1QLQuery(opts N1QLQueryOptions, cb N1QLQueryCallback) (PendingOp, error) {
+	opts.Deadline = deadlineFromContext(opts.Deadline, opts.Context)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, opts.Timeout)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, nqc.defaultTimeout)
+
 	tracer := nqc.tracer.StartTelemeteryHandler(metricValueServiceQueryValue, "N1QLQuery",
-		opts.TraceContext)
+		opts.TraceContext, opts.NoRootSpan)
 
 	var payloadMap map[string]interface{}
 	err := json.Unmarshal(opts.Payload, &payloadMap)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, wrapN1QLError(nil, "", wrapError(err, "expected a JSON payload"), "", 0)
 	}
 
+	err = applyN1QLOptions(payloadMap, opts)
+	if err != nil {
+		tracer.FinishWithError(err)
+		return nil, wrapN1QLError(nil, getMapValueString(payloadMap, "statement", ""), err, "", 0)
+	}
+
 	statement := getMapValueString(payloadMap, "statement", "")
 	clientContextID := getMapValueString(payloadMap, "client_context_id", "")
 	readOnly := getMapValueBool(payloadMap, "readonly", false)
@@ -437,7 +553,7 @@ func (nqc *n1qlQueryComponent) N1QLQuery(opts N1QLQueryOptions, cb N1QLQueryCall
 		}
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(contextOrBackground(opts.Context))
 	ireq := &httpRequest{
 		Service:          N1qlService,
 		Method:           "POST",
@@ -456,7 +572,7 @@ func (nqc *n1qlQueryComponent) N1QLQuery(opts N1QLQueryOptions, cb N1QLQueryCall
 	go func() {
 		resp, err := nqc.execute(ireq, payloadMap, statement, time.Now())
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -470,9 +586,13 @@ func (nqc *n1qlQueryComponent) N1QLQuery(opts N1QLQueryOptions, cb N1QLQueryCall
 
 // PreparedN1QLQuery executes a prepared N1QL query
 func (nqc *n1qlQueryComponent) PreparedN1QLQuery(opts N1QLQueryOptions, cb N1QLQueryCallback) (PendingOp, error) {
-	tracer := nqc.tracer.StartTelemeteryHandler(metricValueServiceQueryValue, "PreparedN1QLQuery", opts.TraceContext)
+	opts.Deadline = deadlineFromContext(opts.Deadline, opts.Context)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, opts.Timeout)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, nqc.defaultTimeout)
+
+	tracer := nqc.tracer.StartTelemeteryHandler(metricValueServiceQueryValue, "PreparedN1QLQuery", opts.TraceContext, opts.NoRootSpan)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(contextOrBackground(opts.Context))
 	parentReqForCancel := &httpRequest{
 		Context:    ctx,
 		CancelFunc: cancel,
@@ -482,7 +602,7 @@ func (nqc *n1qlQueryComponent) PreparedN1QLQuery(opts N1QLQueryOptions, cb N1QLQ
 		res, err := nqc.executePrepared(ctx, cancel, tracer.RootContext(), opts)
 		if err != nil {
 			cancel()
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -503,6 +623,11 @@ func (nqc *n1qlQueryComponent) executePrepared(ctx context.Context, cancel conte
 		return nil, wrapN1QLError(nil, "", wrapError(err, "expected a JSON payload"), "", 0)
 	}
 
+	err = applyN1QLOptions(payloadMap, opts)
+	if err != nil {
+		return nil, wrapN1QLError(nil, getMapValueString(payloadMap, "statement", ""), err, "", 0)
+	}
+
 	statement := getMapValueString(payloadMap, "statement", "")
 	clientContextID := getMapValueString(payloadMap, "client_context_id", "")
 	readOnly := getMapValueBool(payloadMap, "readonly", false)
@@ -555,7 +680,7 @@ func (nqc *n1qlQueryComponent) executePrepared(ctx context.Context, cancel conte
 			return nil, retryErr
 		}
 
-		logDebugf("Prepared statement execution failed, will attempt reprepare: %v", err)
+		nqc.logger.debugf("Prepared statement execution failed, will attempt reprepare: %v", err)
 	}
 
 	delete(payloadMap, "prepared")
@@ -663,7 +788,7 @@ func (nqc *n1qlQueryComponent) executeEnhPrepared(ireq *httpRequest, payloadMap
 
 	preparedName, err := cacheRes.PreparedName()
 	if err != nil {
-		logWarnf("Failed to read prepared name from result: %s", err)
+		nqc.logger.warnf("Failed to read prepared name from result: %s", err)
 		return cacheRes, nil
 	}
 
@@ -794,6 +919,7 @@ func (nqc *n1qlQueryComponent) execute(ireq *httpRequest, payloadMap map[string]
 				return nil, n1qlErr
 			}
 
+			ireq.setServerRetryAfter(resp)
 			shouldRetry, retryTime := retryOrchMaybeRetry(ireq, retryReason)
 			if !shouldRetry {
 				// n1qlErr is already wrapped here
@@ -821,7 +947,7 @@ func (nqc *n1qlQueryComponent) execute(ireq *httpRequest, payloadMap map[string]
 		if err != nil {
 			respBody, readErr := ioutil.ReadAll(resp.Body)
 			if readErr != nil {
-				logDebugf("Failed to read response body: %v", readErr)
+				nqc.logger.debugf("Failed to read response body: %v", readErr)
 			}
 			return nil, wrapN1QLError(ireq, statementForErr, err, string(respBody), resp.StatusCode)
 		}
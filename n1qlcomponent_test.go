@@ -347,7 +347,7 @@ func (suite *StandardTestSuite) TestN1QLCancel() {
 		agent.httpMux,
 		agent.tracer,
 	)
-	n1qlCpt := newN1QLQueryComponent(httpCpt, &configManagementComponent{}, &tracerComponent{tracer: suite.tracer, metrics: suite.meter})
+	n1qlCpt := newN1QLQueryComponent(httpCpt, &configManagementComponent{}, &tracerComponent{tracer: suite.tracer, metrics: suite.meter}, 0, nil)
 
 	resCh := make(chan *N1QLRowReader)
 	errCh := make(chan error)
@@ -491,7 +491,7 @@ func (suite *StandardTestSuite) TestN1QLPreparedCancel() {
 		agent.httpMux,
 		agent.tracer,
 	)
-	n1qlCpt := newN1QLQueryComponent(httpCpt, &configManagementComponent{}, &tracerComponent{tracer: suite.tracer, metrics: suite.meter})
+	n1qlCpt := newN1QLQueryComponent(httpCpt, &configManagementComponent{}, &tracerComponent{tracer: suite.tracer, metrics: suite.meter}, 0, nil)
 
 	resCh := make(chan *N1QLRowReader)
 	errCh := make(chan error)
@@ -680,7 +680,7 @@ func (suite *UnitTestSuite) TestN1QLErrorsAndResults() {
 	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
 		Return(resp, nil)
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	test := map[string]interface{}{
 		"statement":         "SELECT 1=1",
@@ -739,7 +739,7 @@ func (suite *UnitTestSuite) TestN1QLOldPreparedErrorsAndResults() {
 	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
 		Return(resp, nil)
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	test := map[string]interface{}{
 		"statement":         "SELECT 1=1",
@@ -785,7 +785,7 @@ func (suite *UnitTestSuite) TestN1QLOldPreparedUnknownErrorsAndResults() {
 	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
 		Return(resp, nil)
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	test := map[string]interface{}{
 		"statement":         "SELECT 1=1",
@@ -831,7 +831,7 @@ func (suite *UnitTestSuite) TestN1QLErrUnknownErrorsAndResults() {
 	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
 		Return(resp, nil)
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	test := map[string]interface{}{
 		"statement":         "SELECT 1=1",
@@ -900,7 +900,7 @@ func (suite *UnitTestSuite) doN1QLRequest(respData []byte, statusCode int, retry
 		Body:       respData,
 	}
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	test := map[string]interface{}{
 		"statement":         "SELECT 1=1",
@@ -973,7 +973,7 @@ func (suite *UnitTestSuite) TestN1QLEnhPreparedKnownQueryRetryPrepare4050() {
 		suite.Assert().True(autoExec.(bool))
 	})
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	n1qlC.enhancedPreparedSupported = 1
 	n1qlC.queryCache.Put(n1qlQueryCacheStatementContext{Statement: "SELECT 1=1"}, &n1qlQueryCacheEntry{
@@ -1026,7 +1026,7 @@ func (suite *UnitTestSuite) TestN1QLEnhPreparedKnownQueryFailReprepare() {
 	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
 		Return(resp2, nil).Once()
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	n1qlC.enhancedPreparedSupported = 1
 	n1qlC.queryCache.Put(n1qlQueryCacheStatementContext{Statement: "SELECT 1=1"}, &n1qlQueryCacheEntry{
@@ -1549,7 +1549,7 @@ func (suite *UnitTestSuite) TestN1QLEnhPreparedDifferentiatesQueryContext() {
 		suite.Assert().NotContains(body, "auto_execute")
 	})
 
-	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 
 	n1qlC.enhancedPreparedSupported = 1
 	n1qlC.queryCache.Put(n1qlQueryCacheStatementContext{Statement: "SELECT 1=1"}, &n1qlQueryCacheEntry{
@@ -1595,3 +1595,154 @@ func (suite *UnitTestSuite) TestN1QLEnhPreparedDifferentiatesQueryContext() {
 	suite.Require().NoError(err, err)
 	suite.Require().NoError(<-waitCh)
 }
+
+func (suite *UnitTestSuite) TestN1QLDefaultTimeout() {
+	configC := new(mockConfigManager)
+	configC.On("AddConfigWatcher", mock.Anything)
+
+	deadlineCh := make(chan time.Time, 1)
+	httpC := new(mockHttpComponentInterface)
+	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
+		Return(nil, errRequestCanceled).
+		Run(func(args mock.Arguments) {
+			deadlineCh <- args[0].(*httpRequest).Deadline
+		})
+
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC),
+		75*time.Second, nil)
+
+	runQuery := func(opts N1QLQueryOptions) time.Time {
+		opts.Payload = []byte(`{"statement":"SELECT 1=1"}`)
+		errCh := make(chan error, 1)
+		_, err := n1qlC.N1QLQuery(opts, func(reader *N1QLRowReader, err error) {
+			errCh <- err
+		})
+		suite.Require().Nil(err, err)
+		<-errCh
+
+		return <-deadlineCh
+	}
+
+	start := time.Now()
+	deadline := runQuery(N1QLQueryOptions{})
+	suite.Assert().True(deadline.After(start.Add(70*time.Second)), "default timeout not applied: %v", deadline.Sub(start))
+
+	// An explicit timeout should take precedence over the default.
+	deadline = runQuery(N1QLQueryOptions{Timeout: 5 * time.Second})
+	suite.Assert().True(deadline.Before(start.Add(10*time.Second)), "explicit timeout not applied: %v", deadline.Sub(start))
+}
+
+func (suite *UnitTestSuite) TestN1QLParameters() {
+	configC := new(mockConfigManager)
+	configC.On("AddConfigWatcher", mock.Anything)
+
+	bodyCh := make(chan []byte, 1)
+	httpC := new(mockHttpComponentInterface)
+	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
+		Return(nil, errRequestCanceled).
+		Run(func(args mock.Arguments) {
+			bodyCh <- args[0].(*httpRequest).Body
+		})
+
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
+
+	errCh := make(chan error, 1)
+	_, err := n1qlC.N1QLQuery(N1QLQueryOptions{
+		Payload:              []byte(`{"statement":"SELECT * FROM default WHERE a=$1 AND b=$2 AND c=$c AND d=$d"}`),
+		PositionalParameters: []interface{}{"one", 2},
+		NamedParameters: map[string]interface{}{
+			"c":  []string{"x", "y"},
+			"$d": map[string]bool{"z": true},
+		},
+		Deadline: time.Now().Add(time.Second),
+	}, func(reader *N1QLRowReader, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+	<-errCh
+
+	var body map[string]json.RawMessage
+	suite.Require().Nil(json.Unmarshal(<-bodyCh, &body))
+	suite.Assert().JSONEq(`["one",2]`, string(body["args"]))
+	suite.Assert().JSONEq(`["x","y"]`, string(body["$c"]))
+	suite.Assert().JSONEq(`{"z":true}`, string(body["$d"]))
+
+	// Parameters in both the payload and options conflict.
+	_, err = n1qlC.N1QLQuery(N1QLQueryOptions{
+		Payload:              []byte(`{"statement":"SELECT $1","args":["one"]}`),
+		PositionalParameters: []interface{}{"two"},
+	}, func(reader *N1QLRowReader, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+
+	_, err = n1qlC.N1QLQuery(N1QLQueryOptions{
+		Payload:         []byte(`{"statement":"SELECT $c","$c":"one"}`),
+		NamedParameters: map[string]interface{}{"c": "two"},
+	}, func(reader *N1QLRowReader, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+
+	_, err = n1qlC.N1QLQuery(N1QLQueryOptions{
+		Payload:              []byte(`{"statement":"SELECT $1"}`),
+		PositionalParameters: []interface{}{make(chan int)},
+	}, func(reader *N1QLRowReader, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+}
+
+func (suite *UnitTestSuite) TestN1QLProfile() {
+	configC := new(mockConfigManager)
+	configC.On("AddConfigWatcher", mock.Anything)
+
+	respBody := []byte(`{"requestID":"1","results":[{"a":1}],"status":"success",` +
+		`"profile":{"phaseTimes":{"run":"1.2ms"},"phaseCounts":{"fetch":1}}}`)
+	bodyCh := make(chan []byte, 1)
+	httpC := new(mockHttpComponentInterface)
+	httpC.On("DoInternalHTTPRequest", mock.AnythingOfType("*gocbcore.httpRequest"), false).
+		Return(&HTTPResponse{
+			Endpoint:      "whatever",
+			StatusCode:    200,
+			Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+		}, nil).
+		Run(func(args mock.Arguments) {
+			bodyCh <- args[0].(*httpRequest).Body
+		})
+
+	n1qlC := newN1QLQueryComponent(httpC, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
+
+	waitCh := make(chan readerAndError, 1)
+	_, err := n1qlC.N1QLQuery(N1QLQueryOptions{
+		Payload:  []byte(`{"statement":"SELECT 1=1"}`),
+		Profile:  N1QLProfileModeTimings,
+		Deadline: time.Now().Add(time.Second),
+	}, func(reader *N1QLRowReader, err error) {
+		waitCh <- readerAndError{reader: reader, err: err}
+	})
+	suite.Require().Nil(err, err)
+
+	var body map[string]interface{}
+	suite.Require().Nil(json.Unmarshal(<-bodyCh, &body))
+	suite.Assert().Equal("timings", body["profile"])
+
+	res := <-waitCh
+	suite.Require().Nil(res.err, res.err)
+	for res.reader.NextRow() != nil {
+	}
+	suite.Require().Nil(res.reader.Err())
+
+	profile, err := res.reader.Profile()
+	suite.Require().Nil(err, err)
+	suite.Assert().JSONEq(`{"phaseTimes":{"run":"1.2ms"},"phaseCounts":{"fetch":1}}`, string(profile))
+
+	_, err = n1qlC.N1QLQuery(N1QLQueryOptions{
+		Payload: []byte(`{"statement":"SELECT 1=1","profile":"phases"}`),
+		Profile: N1QLProfileModeTimings,
+	}, func(reader *N1QLRowReader, err error) {
+		suite.T().Errorf("Callback should not have been called")
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument), err)
+}
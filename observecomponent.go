@@ -13,46 +13,55 @@ type bucketUtilsProvider interface {
 }
 
 type observeComponent struct {
-	cidMgr               *collectionsComponent
-	defaultRetryStrategy RetryStrategy
-	tracer               *tracerComponent
-	bucketUtils          bucketUtilsProvider
+	cidMgr                 *collectionsComponent
+	defaultRetryStrategy   RetryStrategy
+	tracer                 *tracerComponent
+	bucketUtils            bucketUtilsProvider
+	configSnapshotProvider configSnapshotProvider
+	defaultTimeout         time.Duration
+	logger                 *scopedLogger
 }
 
 func newObserveComponent(cidMgr *collectionsComponent, defaultRetryStrategy RetryStrategy, tracerCmpt *tracerComponent,
-	bucketUtils bucketUtilsProvider) *observeComponent {
+	bucketUtils bucketUtilsProvider, configSnapshotProvider configSnapshotProvider, defaultTimeout time.Duration,
+	logger *scopedLogger) *observeComponent {
 	return &observeComponent{
-		cidMgr:               cidMgr,
-		defaultRetryStrategy: defaultRetryStrategy,
-		tracer:               tracerCmpt,
-		bucketUtils:          bucketUtils,
+		cidMgr:                 cidMgr,
+		defaultRetryStrategy:   defaultRetryStrategy,
+		tracer:                 tracerCmpt,
+		bucketUtils:            bucketUtils,
+		configSnapshotProvider: configSnapshotProvider,
+		defaultTimeout:         defaultTimeout,
+		logger:                 logger,
 	}
 }
 
 func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (PendingOp, error) {
-	tracer := oc.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Observe", opts.TraceContext)
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, oc.defaultTimeout)
+
+	tracer := oc.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Observe", opts.TraceContext, opts.NoRootSpan)
 
 	if oc.bucketUtils.BucketType() != bktTypeCouchbase {
-		tracer.Finish()
+		tracer.FinishWithError(errFeatureNotAvailable)
 		return nil, errFeatureNotAvailable
 	}
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Value) < 4 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
 		keyLen := int(binary.BigEndian.Uint16(resp.Value[2:]))
 
 		if len(resp.Value) != 2+2+keyLen+1+8 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -63,6 +72,7 @@ func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (Pe
 			Cas:      Cas(cas),
 		}
 		res.Internal.ResourceUnits = req.ResourceUnits()
+		res.Internal.ServerDuration = resp.ServerDuration()
 
 		tracer.Finish()
 		cb(res, nil)
@@ -77,7 +87,7 @@ func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (Pe
 
 	vbID, err := oc.bucketUtils.KeyToVbucket(opts.Key)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 	keyLen := len(opts.Key)
@@ -114,7 +124,7 @@ func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (Pe
 
 	op, err := oc.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -125,7 +135,7 @@ func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (Pe
 			count, reasons := req.Retries()
 			req.cancelWithCallbackAndFinishTracer(&TimeoutError{
 				InnerError:         errUnambiguousTimeout,
-				OperationID:        "Unlock",
+				OperationID:        "Observe",
 				Opaque:             req.Identifier(),
 				TimeObserved:       time.Since(start),
 				RetryReasons:       reasons,
@@ -141,22 +151,24 @@ func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (Pe
 }
 
 func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallback) (PendingOp, error) {
-	tracer := oc.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "ObserveVb", opts.TraceContext)
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, oc.defaultTimeout)
+
+	tracer := oc.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "ObserveVb", opts.TraceContext, opts.NoRootSpan)
 
 	if oc.bucketUtils.BucketType() != bktTypeCouchbase {
-		tracer.Finish()
+		tracer.FinishWithError(errFeatureNotAvailable)
 		return nil, errFeatureNotAvailable
 	}
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
 		if err != nil {
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
 		if len(resp.Value) < 1 {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -165,7 +177,7 @@ func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallbac
 		if formatType == 0 {
 			// Normal
 			if len(resp.Value) < 27 {
-				tracer.Finish()
+				tracer.FinishWithError(errProtocol)
 				cb(nil, errProtocol)
 				return
 			}
@@ -182,6 +194,7 @@ func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallbac
 				CurrentSeqNo: SeqNo(currentSeqNo),
 			}
 			res.Internal.ResourceUnits = req.ResourceUnits()
+			res.Internal.ServerDuration = resp.ServerDuration()
 
 			tracer.Finish()
 			cb(res, nil)
@@ -209,12 +222,13 @@ func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallbac
 				LastSeqNo:    SeqNo(lastSeqNo),
 			}
 			res.Internal.ResourceUnits = req.ResourceUnits()
+			res.Internal.ServerDuration = resp.ServerDuration()
 
 			tracer.Finish()
 			cb(res, nil)
 			return
 		} else {
-			tracer.Finish()
+			tracer.FinishWithError(errProtocol)
 			cb(nil, errProtocol)
 			return
 		}
@@ -254,7 +268,7 @@ func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallbac
 
 	op, err := oc.cidMgr.Dispatch(req)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -265,7 +279,7 @@ func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallbac
 			count, reasons := req.Retries()
 			req.cancelWithCallbackAndFinishTracer(&TimeoutError{
 				InnerError:         errUnambiguousTimeout,
-				OperationID:        "Unlock",
+				OperationID:        "ObserveVb",
 				Opaque:             req.Identifier(),
 				TimeObserved:       time.Since(start),
 				RetryReasons:       reasons,
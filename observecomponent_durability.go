@@ -0,0 +1,243 @@
+package gocbcore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+const defaultObserveDurabilityPollInterval = 10 * time.Millisecond
+
+type observeDurabilityOp struct {
+	oc          *observeComponent
+	opts        ObserveDurabilityOptions
+	callback    ObserveDurabilityCallback
+	start       time.Time
+	numReplicas int
+
+	lock       sync.Mutex
+	done       bool
+	ops        []PendingOp
+	pollTimer  *time.Timer
+	timeoutTmr *time.Timer
+}
+
+// ObserveDurability polls observe against the active and replicas for a key until the mutation identified by the
+// options has reached the requested replication and persistence, or the deadline is reached. A deadline must be
+// specified, either directly or using a timeout, unless the agent has a default KV timeout.
+func (oc *observeComponent) ObserveDurability(opts ObserveDurabilityOptions, cb ObserveDurabilityCallback) (PendingOp, error) {
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, oc.defaultTimeout)
+
+	// Without a deadline polling would continue forever if the requirements can never be met.
+	if opts.Deadline.IsZero() {
+		return nil, wrapError(errInvalidArgument, "a deadline or timeout must be specified")
+	}
+	if opts.ReplicateTo == 0 && opts.PersistTo == 0 {
+		return nil, wrapError(errInvalidArgument, "at least one of replicate to and persist to must be specified")
+	}
+	if oc.bucketUtils.BucketType() != bktTypeCouchbase {
+		return nil, wrapError(errFeatureNotAvailable, "observe is only supported by couchbase buckets")
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultObserveDurabilityPollInterval
+	}
+
+	op := &observeDurabilityOp{
+		oc:       oc,
+		opts:     opts,
+		callback: cb,
+		start:    time.Now(),
+	}
+
+	if !opts.Deadline.IsZero() {
+		op.lock.Lock()
+		op.timeoutTmr = time.AfterFunc(opts.Deadline.Sub(op.start), func() {
+			op.finish(nil, &TimeoutError{
+				InnerError:   errAmbiguousTimeout,
+				OperationID:  "ObserveDurability",
+				TimeObserved: time.Since(op.start),
+			})
+		})
+		op.lock.Unlock()
+	}
+
+	snapshotOp, err := oc.configSnapshotProvider.WaitForConfigSnapshot(opts.Deadline, func(result *WaitForConfigSnapshotResult, err error) {
+		if err != nil {
+			op.finish(nil, err)
+			return
+		}
+
+		numReplicas, err := result.Snapshot.NumReplicas()
+		if err != nil {
+			op.finish(nil, err)
+			return
+		}
+
+		if int(opts.ReplicateTo) > numReplicas || int(opts.PersistTo) > numReplicas+1 {
+			op.finish(nil, wrapError(errDurabilityImpossible,
+				fmt.Sprintf("the bucket only has %d replicas", numReplicas)))
+			return
+		}
+
+		op.numReplicas = numReplicas
+		op.poll()
+	})
+	if err != nil {
+		op.lock.Lock()
+		op.done = true
+		if op.timeoutTmr != nil {
+			op.timeoutTmr.Stop()
+		}
+		op.lock.Unlock()
+		return nil, err
+	}
+	op.addOps([]PendingOp{snapshotOp})
+
+	return op, nil
+}
+
+func (op *observeDurabilityOp) Cancel() {
+	op.finish(nil, errRequestCanceled)
+}
+
+// addOps records operations which must be cancelled if the durability operation finishes before they do, cancelling
+// them immediately if it already has.
+func (op *observeDurabilityOp) addOps(ops []PendingOp) {
+	op.lock.Lock()
+	if op.done {
+		op.lock.Unlock()
+		for _, subOp := range ops {
+			subOp.Cancel()
+		}
+		return
+	}
+	op.ops = append(op.ops, ops...)
+	op.lock.Unlock()
+}
+
+// poll sends an observe to every node holding the key, and evaluates the results once they have all responded.
+func (op *observeDurabilityOp) poll() {
+	op.lock.Lock()
+	if op.done {
+		op.lock.Unlock()
+		return
+	}
+	// Any previous round has completed, so there is nothing left to cancel.
+	op.ops = nil
+	op.lock.Unlock()
+
+	var lock sync.Mutex
+	var numReplicated, numPersisted uint
+	remaining := op.numReplicas + 1
+	handleResult := func(replicaIdx int, res *ObserveResult, err error) {
+		lock.Lock()
+		if err == nil {
+			replicated, persisted := op.evaluate(res)
+			if replicated && replicaIdx > 0 {
+				numReplicated++
+			}
+			if persisted {
+				numPersisted++
+			}
+		} else {
+			op.oc.logger.debugf("Observe against replica %d failed during durability polling: %v", replicaIdx, err)
+		}
+		remaining--
+		done := remaining == 0
+		lock.Unlock()
+
+		if !done {
+			return
+		}
+
+		if numReplicated >= op.opts.ReplicateTo && numPersisted >= op.opts.PersistTo {
+			op.finish(&ObserveDurabilityResult{
+				NumReplicated: numReplicated,
+				NumPersisted:  numPersisted,
+			}, nil)
+			return
+		}
+
+		op.lock.Lock()
+		if !op.done {
+			op.pollTimer = time.AfterFunc(op.opts.PollInterval, op.poll)
+		}
+		op.lock.Unlock()
+	}
+
+	subOps := make([]PendingOp, 0, op.numReplicas+1)
+	for replicaIdx := 0; replicaIdx <= op.numReplicas; replicaIdx++ {
+		replicaIdx := replicaIdx
+		subOp, err := op.oc.Observe(ObserveOptions{
+			Key:            op.opts.Key,
+			ReplicaIdx:     replicaIdx,
+			CollectionName: op.opts.CollectionName,
+			ScopeName:      op.opts.ScopeName,
+			CollectionID:   op.opts.CollectionID,
+			RetryStrategy:  op.opts.RetryStrategy,
+			Deadline:       op.opts.Deadline,
+			User:           op.opts.User,
+			TraceContext:   op.opts.TraceContext,
+			NoRootSpan:     op.opts.NoRootSpan,
+		}, func(res *ObserveResult, err error) {
+			handleResult(replicaIdx, res, err)
+		})
+		if err != nil {
+			handleResult(replicaIdx, nil, err)
+			continue
+		}
+		subOps = append(subOps, subOp)
+	}
+	op.addOps(subOps)
+}
+
+// evaluate returns whether an observe result shows the mutation as being in memory and persisted on that node.
+func (op *observeDurabilityOp) evaluate(res *ObserveResult) (bool, bool) {
+	if op.opts.IsDelete {
+		switch res.KeyState {
+		case memd.KeyStateDeleted:
+			return true, true
+		case memd.KeyStateNotFound:
+			return true, false
+		}
+		return false, false
+	}
+
+	if res.Cas != op.opts.Cas {
+		return false, false
+	}
+
+	switch res.KeyState {
+	case memd.KeyStatePersisted:
+		return true, true
+	case memd.KeyStateNotPersisted:
+		return true, false
+	}
+	return false, false
+}
+
+func (op *observeDurabilityOp) finish(res *ObserveDurabilityResult, err error) {
+	op.lock.Lock()
+	if op.done {
+		op.lock.Unlock()
+		return
+	}
+	op.done = true
+	if op.pollTimer != nil {
+		op.pollTimer.Stop()
+	}
+	if op.timeoutTmr != nil {
+		op.timeoutTmr.Stop()
+	}
+	ops := op.ops
+	op.ops = nil
+	op.lock.Unlock()
+
+	for _, subOp := range ops {
+		subOp.Cancel()
+	}
+
+	op.callback(res, err)
+}
@@ -0,0 +1,208 @@
+package gocbcore
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+)
+
+type testObserveBucketUtils struct{}
+
+func (b *testObserveBucketUtils) KeyToVbucket(key []byte) (uint16, error) {
+	return 0, nil
+}
+
+func (b *testObserveBucketUtils) BucketType() bucketType {
+	return bktTypeCouchbase
+}
+
+func (suite *UnitTestSuite) newTestObserveComponent(numReplicas int,
+	respond func(req *memdQRequest) (memd.KeyState, Cas)) *observeComponent {
+	crud := suite.newTestSubdocCrudComponent(func(req *memdQRequest) {
+		keyState, cas := respond(req)
+
+		keyLen := int(binary.BigEndian.Uint16(req.Value[2:]))
+		value := make([]byte, 2+2+keyLen+1+8)
+		copy(value, req.Value)
+		value[4+keyLen] = byte(keyState)
+		binary.BigEndian.PutUint64(value[4+keyLen+1:], uint64(cas))
+
+		req.tryCallback(&memdQResponse{
+			Packet: &memd.Packet{
+				Status: memd.StatusSuccess,
+				Value:  value,
+			},
+		}, nil)
+	})
+
+	servers := make([]int, numReplicas+1)
+	for i := range servers {
+		servers[i] = i
+	}
+
+	return newObserveComponent(crud.cidMgr, &failFastRetryStrategy{}, crud.tracer, &testObserveBucketUtils{},
+		&testConfigSnapshotProvider{
+			snapshot: &ConfigSnapshot{
+				state: &kvMuxState{
+					routeCfg: routeConfig{
+						vbMap: newVbucketMap([][]int{servers}, numReplicas),
+					},
+				},
+			},
+		}, 0, nil)
+}
+
+func (suite *UnitTestSuite) TestObserveDurability() {
+	var lock sync.Mutex
+	rounds := make(map[int]int)
+	oc := suite.newTestObserveComponent(2, func(req *memdQRequest) (memd.KeyState, Cas) {
+		lock.Lock()
+		rounds[req.ReplicaIdx]++
+		round := rounds[req.ReplicaIdx]
+		lock.Unlock()
+
+		switch {
+		case req.ReplicaIdx == 0:
+			return memd.KeyStatePersisted, 10
+		case req.ReplicaIdx == 2:
+			// This replica has a different version of the document so never counts.
+			return memd.KeyStatePersisted, 9
+		case round == 1:
+			return memd.KeyStateNotPersisted, 10
+		default:
+			return memd.KeyStatePersisted, 10
+		}
+	})
+
+	resCh := make(chan *ObserveDurabilityResult, 1)
+	_, err := oc.ObserveDurability(ObserveDurabilityOptions{
+		Key:          []byte("test"),
+		Cas:          10,
+		ReplicateTo:  1,
+		PersistTo:    2,
+		PollInterval: time.Millisecond,
+		Timeout:      time.Second,
+	}, func(res *ObserveDurabilityResult, err error) {
+		suite.Assert().Nil(err, err)
+		resCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	res := <-resCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal(uint(1), res.NumReplicated)
+	suite.Assert().Equal(uint(2), res.NumPersisted)
+
+	lock.Lock()
+	suite.Assert().Equal(2, rounds[1])
+	lock.Unlock()
+}
+
+func (suite *UnitTestSuite) TestObserveDurabilityDelete() {
+	oc := suite.newTestObserveComponent(1, func(req *memdQRequest) (memd.KeyState, Cas) {
+		if req.ReplicaIdx == 0 {
+			return memd.KeyStateDeleted, 0
+		}
+		return memd.KeyStateNotFound, 0
+	})
+
+	resCh := make(chan *ObserveDurabilityResult, 1)
+	_, err := oc.ObserveDurability(ObserveDurabilityOptions{
+		Key:         []byte("test"),
+		IsDelete:    true,
+		ReplicateTo: 1,
+		PersistTo:   1,
+		Timeout:     time.Second,
+	}, func(res *ObserveDurabilityResult, err error) {
+		suite.Assert().Nil(err, err)
+		resCh <- res
+	})
+	suite.Require().Nil(err, err)
+
+	res := <-resCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal(uint(1), res.NumReplicated)
+	suite.Assert().Equal(uint(1), res.NumPersisted)
+}
+
+func (suite *UnitTestSuite) TestObserveDurabilityImpossible() {
+	oc := suite.newTestObserveComponent(1, func(req *memdQRequest) (memd.KeyState, Cas) {
+		suite.T().Errorf("No observe should have been sent")
+		return memd.KeyStateNotFound, 0
+	})
+
+	errCh := make(chan error, 1)
+	_, err := oc.ObserveDurability(ObserveDurabilityOptions{
+		Key:       []byte("test"),
+		Cas:       10,
+		PersistTo: 3,
+		Timeout:   time.Second,
+	}, func(res *ObserveDurabilityResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().True(errors.Is(<-errCh, ErrDurabilityImpossible))
+}
+
+func (suite *UnitTestSuite) TestObserveDurabilityTimeout() {
+	oc := suite.newTestObserveComponent(1, func(req *memdQRequest) (memd.KeyState, Cas) {
+		return memd.KeyStateNotPersisted, 10
+	})
+
+	errCh := make(chan error, 1)
+	_, err := oc.ObserveDurability(ObserveDurabilityOptions{
+		Key:          []byte("test"),
+		Cas:          10,
+		PersistTo:    1,
+		PollInterval: time.Millisecond,
+		Timeout:      50 * time.Millisecond,
+	}, func(res *ObserveDurabilityResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().True(errors.Is(<-errCh, ErrAmbiguousTimeout))
+}
+
+func (suite *UnitTestSuite) TestObserveDurabilityDefaultTimeout() {
+	oc := suite.newTestObserveComponent(1, func(req *memdQRequest) (memd.KeyState, Cas) {
+		return memd.KeyStateNotPersisted, 10
+	})
+	oc.defaultTimeout = 50 * time.Millisecond
+
+	errCh := make(chan error, 1)
+	_, err := oc.ObserveDurability(ObserveDurabilityOptions{
+		Key:          []byte("test"),
+		Cas:          10,
+		PersistTo:    1,
+		PollInterval: time.Millisecond,
+	}, func(res *ObserveDurabilityResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err, err)
+
+	select {
+	case err := <-errCh:
+		suite.Assert().ErrorIs(err, ErrAmbiguousTimeout)
+	case <-time.After(5 * time.Second):
+		suite.Fail("observe did not time out using the default timeout")
+	}
+}
+
+func (suite *UnitTestSuite) TestObserveDurabilityNoDeadline() {
+	oc := suite.newTestObserveComponent(1, func(req *memdQRequest) (memd.KeyState, Cas) {
+		suite.Fail("no observe should be sent")
+		return memd.KeyStateNotPersisted, 10
+	})
+
+	_, err := oc.ObserveDurability(ObserveDurabilityOptions{
+		Key:       []byte("test"),
+		Cas:       10,
+		PersistTo: 1,
+	}, func(res *ObserveDurabilityResult, err error) {
+		suite.Fail("callback should not be invoked")
+	})
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+}
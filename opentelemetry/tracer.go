@@ -0,0 +1,113 @@
+// Package opentelemetry provides an OpenTelemetry implementation of the gocbcore RequestTracer interface.
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "com.couchbase.client/go"
+
+	spanAttribDBOperationKey = "db.operation"
+	spanNameDispatchToServer = "dispatch_to_server"
+)
+
+// OpenTelemetryTracer is an implementation of gocbcore.RequestTracer which creates OpenTelemetry spans.
+//
+// The parent context supplied in an operation's TraceContext can be a context.Context containing a span, a
+// trace.SpanContext or a trace.Span. Spans created by this tracer return a context.Context from Context(), so
+// dispatch spans are correctly parented under the operation span. When NoRootTraceSpans is set the SDK does not
+// create operation spans and dispatch spans are parented directly under the supplied context.
+type OpenTelemetryTracer struct {
+	tracer trace.Tracer
+}
+
+// NewOpenTelemetryTracer creates a new OpenTelemetryTracer using the given TracerProvider. If tp is nil then the
+// global TracerProvider is used.
+func NewOpenTelemetryTracer(tp trace.TracerProvider) *OpenTelemetryTracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return &OpenTelemetryTracer{
+		tracer: tp.Tracer(tracerName, trace.WithInstrumentationVersion(gocbcore.Version())),
+	}
+}
+
+// RequestSpan creates a new span as a child of the parent context.
+func (tracer *OpenTelemetryTracer) RequestSpan(parentContext gocbcore.RequestSpanContext, operationName string) gocbcore.RequestSpan {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+	}
+	if operationName != spanNameDispatchToServer {
+		opts = append(opts, trace.WithAttributes(attribute.String(spanAttribDBOperationKey, operationName)))
+	}
+
+	ctx, span := tracer.tracer.Start(contextFromParent(parentContext), operationName, opts...)
+
+	return &openTelemetrySpan{
+		ctx:  ctx,
+		span: span,
+	}
+}
+
+func contextFromParent(parentContext gocbcore.RequestSpanContext) context.Context {
+	switch parent := parentContext.(type) {
+	case context.Context:
+		return parent
+	case trace.SpanContext:
+		return trace.ContextWithSpanContext(context.Background(), parent)
+	case trace.Span:
+		return trace.ContextWithSpan(context.Background(), parent)
+	}
+
+	return context.Background()
+}
+
+type openTelemetrySpan struct {
+	ctx  context.Context
+	span trace.Span
+}
+
+func (span *openTelemetrySpan) End() {
+	span.span.End()
+}
+
+// Context returns a context.Context containing the span, for use as the parent of child spans.
+func (span *openTelemetrySpan) Context() gocbcore.RequestSpanContext {
+	return span.ctx
+}
+
+func (span *openTelemetrySpan) AddEvent(name string, timestamp time.Time) {
+	span.span.AddEvent(name, trace.WithTimestamp(timestamp))
+}
+
+func (span *openTelemetrySpan) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		span.span.SetAttributes(attribute.String(key, v))
+	case bool:
+		span.span.SetAttributes(attribute.Bool(key, v))
+	case int:
+		span.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		span.span.SetAttributes(attribute.Int64(key, v))
+	case uint32:
+		span.span.SetAttributes(attribute.Int64(key, int64(v)))
+	case uint64:
+		span.span.SetAttributes(attribute.Int64(key, int64(v)))
+	case float64:
+		span.span.SetAttributes(attribute.Float64(key, v))
+	case time.Duration:
+		span.span.SetAttributes(attribute.Int64(key, v.Microseconds()))
+	default:
+		span.span.SetAttributes(attribute.String(key, fmt.Sprintf("%v", v)))
+	}
+}
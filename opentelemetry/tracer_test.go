@@ -0,0 +1,87 @@
+package opentelemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOpenTelemetryTracerParenting(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewOpenTelemetryTracer(tp)
+
+	parentCtx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+
+	opSpan := tracer.RequestSpan(parentCtx, "Get")
+	opSpan.SetAttribute("db.system", "couchbase")
+	opSpan.SetAttribute("net.peer.name", "10.112.210.101")
+	opSpan.SetAttribute("db.couchbase.retries", uint32(2))
+
+	dispatchSpan := tracer.RequestSpan(opSpan.Context(), "dispatch_to_server")
+	dispatchSpan.SetAttribute("db.couchbase.server_duration", 150*time.Microsecond)
+	dispatchSpan.AddEvent("sent", time.Now())
+	dispatchSpan.End()
+	opSpan.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans but got %d", len(spans))
+	}
+
+	dispatch, op := spans[0], spans[1]
+	if dispatch.Parent().SpanID() != op.SpanContext().SpanID() {
+		t.Fatalf("Dispatch span was not parented under the operation span")
+	}
+	if op.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("Operation span was not parented under the supplied parent span")
+	}
+
+	attribs := make(map[string]string)
+	for _, attrib := range op.Attributes() {
+		attribs[string(attrib.Key)] = attrib.Value.Emit()
+	}
+	expected := map[string]string{
+		"db.operation":         "Get",
+		"db.system":            "couchbase",
+		"net.peer.name":        "10.112.210.101",
+		"db.couchbase.retries": "2",
+	}
+	for key, val := range expected {
+		if attribs[key] != val {
+			t.Fatalf("Expected attribute %s to be %s but was %s", key, val, attribs[key])
+		}
+	}
+
+	for _, attrib := range dispatch.Attributes() {
+		if attrib.Key == "db.operation" {
+			t.Fatalf("Dispatch span should not have a db.operation attribute")
+		}
+		if attrib.Key == "db.couchbase.server_duration" && attrib.Value.AsInt64() != 150 {
+			t.Fatalf("Expected server duration to be recorded in microseconds but was %d", attrib.Value.AsInt64())
+		}
+	}
+	if len(dispatch.Events()) != 1 {
+		t.Fatalf("Expected 1 event on dispatch span but got %d", len(dispatch.Events()))
+	}
+}
+
+func TestOpenTelemetryTracerNoParent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewOpenTelemetryTracer(tp)
+
+	tracer.RequestSpan(nil, "Get").End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span but got %d", len(spans))
+	}
+	if spans[0].Parent().IsValid() {
+		t.Fatalf("Span should not have a parent")
+	}
+}
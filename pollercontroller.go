@@ -7,6 +7,7 @@ import (
 )
 
 type pollerController struct {
+	logger           *scopedLogger
 	activeController configPoller
 	controllerLock   sync.Mutex
 	stopped          bool
@@ -34,8 +35,9 @@ type configPoller interface {
 }
 
 func newPollerController(cccpPoller *cccpConfigController, httpPoller *httpConfigController, cfgMgr configManager,
-	errorFn func(error) bool) *pollerController {
+	errorFn func(error) bool, logger *scopedLogger) *pollerController {
 	pc := &pollerController{
+		logger:            logger,
 		cccpPoller:        cccpPoller,
 		httpPoller:        httpPoller,
 		cfgMgr:            cfgMgr,
@@ -65,7 +67,7 @@ func (pc *pollerController) OnNewRouteConfig(cfg *routeConfig) {
 			return
 		}
 		if pc.activeController == pc.httpPoller {
-			logInfof("Found couchbase bucket and HTTP poller in use. Restarting poller run loop to start cccp.")
+			pc.logger.infof("Found couchbase bucket and HTTP poller in use. Restarting poller run loop to start cccp.")
 			pc.activeController = nil
 
 			// Stopping the poller will trigger the run loop to loop again.
@@ -80,11 +82,11 @@ func (pc *pollerController) OnNewRouteConfig(cfg *routeConfig) {
 
 func (pc *pollerController) runSinglePoller(doLoop func()) {
 	for {
-		logInfof("Starting poller controller loop")
+		pc.logger.infof("Starting poller controller loop")
 		pc.controllerLock.Lock()
 		if pc.stopped {
 			pc.controllerLock.Unlock()
-			logInfof("Poller controller stopped, exiting")
+			pc.logger.infof("Poller controller stopped, exiting")
 			return
 		}
 
@@ -98,11 +100,11 @@ func (pc *pollerController) runSinglePoller(doLoop func()) {
 
 func (pc *pollerController) runDualPollers() {
 	for {
-		logInfof("Starting poller controller loop")
+		pc.logger.infof("Starting poller controller loop")
 		pc.controllerLock.Lock()
 		if pc.stopped {
 			pc.controllerLock.Unlock()
-			logInfof("Poller controller stopped, exiting")
+			pc.logger.infof("Poller controller stopped, exiting")
 			return
 		}
 
@@ -116,10 +118,10 @@ func (pc *pollerController) runDualPollers() {
 
 		err := pc.cccpPoller.DoLoop()
 		if err != nil {
-			logDebugf("CCCP poller has exited with err: %v", err)
+			pc.logger.debugf("CCCP poller has exited with err: %v", err)
 		}
 		if atomic.LoadUint32(&pc.bucketConfigSeen) == 1 {
-			logInfof("Config seen but CCCP poller exited, restarting CCCP poller.")
+			pc.logger.infof("Config seen but CCCP poller exited, restarting CCCP poller.")
 			// CCCP managed to fetch a config whilst we were waiting for shutdown, in this case we want to just
 			// start CCCP again as the bucket must exist and be a couchbase bucket.
 			continue
@@ -127,7 +129,7 @@ func (pc *pollerController) runDualPollers() {
 		pc.controllerLock.Lock()
 		if pc.stopped {
 			pc.controllerLock.Unlock()
-			logDebugf("Poller controller stopped, exiting")
+			pc.logger.debugf("Poller controller stopped, exiting")
 			return
 		}
 
@@ -140,7 +142,7 @@ func (pc *pollerController) runDualPollers() {
 func (pc *pollerController) Run() {
 	defer close(pc.stoppedSig)
 	if pc.cccpPoller == nil && pc.httpPoller == nil {
-		logInfof("No cccp or http pollers registered, will not run poller controller loop")
+		pc.logger.infof("No cccp or http pollers registered, will not run poller controller loop")
 		return
 	}
 
@@ -159,8 +161,8 @@ func (pc *pollerController) Run() {
 		pc.runSinglePoller(func() {
 			err := pc.cccpPoller.DoLoop()
 			if err != nil {
-				logDebugf("CCCP poller has exited with err: %v", err)
-				logWarnf("CCCP poller has exited for http fallback but no http poller is configured, retrying CCCP")
+				pc.logger.debugf("CCCP poller has exited with err: %v", err)
+				pc.logger.warnf("CCCP poller has exited for http fallback but no http poller is configured, retrying CCCP")
 			}
 		})
 		return
@@ -171,7 +173,7 @@ func (pc *pollerController) Run() {
 
 // Stop should never be called more than once.
 func (pc *pollerController) Stop() {
-	logInfof("Stopping poller controller")
+	pc.logger.infof("Stopping poller controller")
 	pc.controllerLock.Lock()
 	pc.stopped = true
 	controller := pc.activeController
@@ -203,16 +205,16 @@ func (pc *pollerController) PollerError() error {
 
 func (pc *pollerController) ForceHTTPPoller() {
 	if pc.httpPoller == nil {
-		logErrorf("Attempting to force http poller but no http poller is configured")
+		pc.logger.errorf("Attempting to force http poller but no http poller is configured")
 		return
 	}
 	if !pc.httpPoller.CanPoll() {
-		logDebugf("Attempting to force http poller but there are no http endpoints to poll")
+		pc.logger.debugf("Attempting to force http poller but there are no http endpoints to poll")
 		return
 	}
 	go func() {
 		if atomic.LoadUint32(&pc.bucketConfigSeen) == 1 {
-			logInfof("Config already seen, not forcing HTTP")
+			pc.logger.infof("Config already seen, not forcing HTTP")
 			// If we've seen a config already then either cccp or http polling have managed to fetch a config and
 			// bucket type can't have changed so there's no reason to fallback.
 			return
@@ -228,7 +230,7 @@ func (pc *pollerController) ForceHTTPPoller() {
 			return
 		}
 		if pc.activeController == pc.cccpPoller {
-			logInfof("Stopping CCCP poller for HTTP polling takeover")
+			pc.logger.infof("Stopping CCCP poller for HTTP polling takeover")
 			pc.activeController = nil
 			pc.cccpPoller.Stop()
 			pc.controllerLock.Unlock()
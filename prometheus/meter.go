@@ -0,0 +1,209 @@
+// Package prometheus provides a Prometheus implementation of the gocbcore Meter interface.
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/couchbase/gocbcore/v10"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricAttribServiceKey   = "db.couchbase.service"
+	metricAttribOperationKey = "db.operation"
+	metricAttribOutcomeKey   = "outcome"
+)
+
+// labelTags are the tags which are exposed as labels, and the names of those labels. Any other tags are dropped so
+// that every series of a metric has the same set of labels and label cardinality remains bounded.
+var labelTags = []struct {
+	tag   string
+	label string
+}{
+	{tag: metricAttribServiceKey, label: "service"},
+	{tag: metricAttribOperationKey, label: "operation"},
+	{tag: metricAttribOutcomeKey, label: "outcome"},
+}
+
+// DefaultBuckets are the histogram bucket upper bounds, in seconds, used when no buckets are specified. They range
+// from sub-millisecond, for typical KV operations, up to 10 seconds.
+var DefaultBuckets = []float64{
+	0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// MeterOptions are the options available when creating a PrometheusMeter.
+type MeterOptions struct {
+	// Namespace, if set, is prefixed to the name of every metric.
+	Namespace string
+
+	// Buckets are the histogram bucket upper bounds, in seconds, applied to value recorders.
+	// If empty then DefaultBuckets is used.
+	Buckets []float64
+}
+
+// PrometheusMeter is an implementation of gocbcore.Meter which registers its metrics with a prometheus.Registerer.
+//
+// Value recorders are exposed as histograms, with the recorded microsecond values converted into seconds. Metrics
+// are labelled by service, operation and outcome, which are derived from the tags supplied by the SDK and never
+// include per-document values.
+type PrometheusMeter struct {
+	registerer prom.Registerer
+	namespace  string
+	buckets    []float64
+
+	lock       sync.Mutex
+	counters   map[string]*prom.CounterVec
+	histograms map[string]*prom.HistogramVec
+}
+
+// NewPrometheusMeter creates a new PrometheusMeter which registers its metrics with registerer. If registerer is
+// nil then prometheus.DefaultRegisterer is used.
+func NewPrometheusMeter(registerer prom.Registerer, opts MeterOptions) *PrometheusMeter {
+	if registerer == nil {
+		registerer = prom.DefaultRegisterer
+	}
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	return &PrometheusMeter{
+		registerer: registerer,
+		namespace:  opts.Namespace,
+		buckets:    buckets,
+		counters:   make(map[string]*prom.CounterVec),
+		histograms: make(map[string]*prom.HistogramVec),
+	}
+}
+
+// Counter returns the counter for the given metric name and tags, registering the metric if required.
+func (pm *PrometheusMeter) Counter(name string, tags map[string]string) (gocbcore.Counter, error) {
+	name = sanitizeName(name)
+
+	pm.lock.Lock()
+	vec, ok := pm.counters[name]
+	if !ok {
+		vec = prom.NewCounterVec(prom.CounterOpts{
+			Namespace: pm.namespace,
+			Name:      name,
+			Help:      "Count of " + name + ".",
+		}, labelNames())
+		registered, err := pm.register(vec)
+		if err != nil {
+			pm.lock.Unlock()
+			return nil, err
+		}
+		vec, ok = registered.(*prom.CounterVec)
+		if !ok {
+			pm.lock.Unlock()
+			return nil, fmt.Errorf("a different collector is already registered as %s", name)
+		}
+		pm.counters[name] = vec
+	}
+	pm.lock.Unlock()
+
+	counter, err := vec.GetMetricWithLabelValues(labelValues(tags)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &prometheusCounter{counter: counter}, nil
+}
+
+// ValueRecorder returns the value recorder for the given metric name and tags, registering the metric if required.
+func (pm *PrometheusMeter) ValueRecorder(name string, tags map[string]string) (gocbcore.ValueRecorder, error) {
+	name = sanitizeName(name) + "_seconds"
+
+	pm.lock.Lock()
+	vec, ok := pm.histograms[name]
+	if !ok {
+		vec = prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: pm.namespace,
+			Name:      name,
+			Help:      "Duration of " + name + " in seconds.",
+			Buckets:   pm.buckets,
+		}, labelNames())
+		registered, err := pm.register(vec)
+		if err != nil {
+			pm.lock.Unlock()
+			return nil, err
+		}
+		vec, ok = registered.(*prom.HistogramVec)
+		if !ok {
+			pm.lock.Unlock()
+			return nil, fmt.Errorf("a different collector is already registered as %s", name)
+		}
+		pm.histograms[name] = vec
+	}
+	pm.lock.Unlock()
+
+	observer, err := vec.GetMetricWithLabelValues(labelValues(tags)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &prometheusValueRecorder{observer: observer}, nil
+}
+
+// register registers collector, returning the collector which was already registered if there is one, as is the
+// case when more than one meter shares a registerer.
+func (pm *PrometheusMeter) register(collector prom.Collector) (prom.Collector, error) {
+	err := pm.registerer.Register(collector)
+	if err != nil {
+		var alreadyErr prom.AlreadyRegisteredError
+		if errors.As(err, &alreadyErr) {
+			return alreadyErr.ExistingCollector, nil
+		}
+		return nil, err
+	}
+
+	return collector, nil
+}
+
+type prometheusCounter struct {
+	counter prom.Counter
+}
+
+func (pc *prometheusCounter) IncrementBy(num uint64) {
+	pc.counter.Add(float64(num))
+}
+
+type prometheusValueRecorder struct {
+	observer prom.Observer
+}
+
+// RecordValue records a value, in microseconds.
+func (pvr *prometheusValueRecorder) RecordValue(val uint64) {
+	pvr.observer.Observe(float64(val) / 1e6)
+}
+
+func labelNames() []string {
+	names := make([]string, len(labelTags))
+	for i, lt := range labelTags {
+		names[i] = lt.label
+	}
+	return names
+}
+
+func labelValues(tags map[string]string) []string {
+	values := make([]string, len(labelTags))
+	for i, lt := range labelTags {
+		values[i] = tags[lt.tag]
+	}
+	return values
+}
+
+func sanitizeName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || r == ':' || (i > 0 && r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
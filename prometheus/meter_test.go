@@ -0,0 +1,114 @@
+package prometheus
+
+import (
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPrometheusMeterValueRecorder(t *testing.T) {
+	registry := prom.NewRegistry()
+	meter := NewPrometheusMeter(registry, MeterOptions{
+		Buckets: []float64{0.001, 0.01},
+	})
+
+	recorder, err := meter.ValueRecorder("db.couchbase.operations", map[string]string{
+		"db.couchbase.service":      "kv",
+		"db.operation":              "get",
+		"outcome":                   "Success",
+		"db.couchbase.cluster_uuid": "c0ffee",
+	})
+	if err != nil {
+		t.Fatalf("Failed to get value recorder: %v", err)
+	}
+	recorder.RecordValue(500)
+	recorder.RecordValue(5000)
+	recorder.RecordValue(50000)
+
+	recorder, err = meter.ValueRecorder("db.couchbase.operations", map[string]string{
+		"db.couchbase.service": "kv",
+		"db.operation":         "get",
+		"outcome":              "Timeout",
+	})
+	if err != nil {
+		t.Fatalf("Failed to get value recorder: %v", err)
+	}
+	recorder.RecordValue(100)
+
+	count, err := testutil.GatherAndCount(registry, "db_couchbase_operations_seconds")
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 series but got %d", count)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, metric := range families[0].GetMetric() {
+		labels := make(map[string]string)
+		for _, pair := range metric.GetLabel() {
+			labels[pair.GetName()] = pair.GetValue()
+		}
+		if len(labels) != 3 || labels["service"] != "kv" || labels["operation"] != "get" {
+			t.Fatalf("Unexpected labels: %v", labels)
+		}
+
+		histogram := metric.GetHistogram()
+		switch labels["outcome"] {
+		case "Success":
+			if histogram.GetSampleCount() != 3 {
+				t.Fatalf("Expected 3 samples but got %d", histogram.GetSampleCount())
+			}
+			if histogram.GetBucket()[0].GetCumulativeCount() != 1 || histogram.GetBucket()[1].GetCumulativeCount() != 2 {
+				t.Fatalf("Unexpected bucket counts: %v", histogram.GetBucket())
+			}
+			if histogram.GetSampleSum() != 0.0555 {
+				t.Fatalf("Expected a sum of 0.0555 but got %v", histogram.GetSampleSum())
+			}
+		case "Timeout":
+			if histogram.GetSampleCount() != 1 {
+				t.Fatalf("Expected 1 sample but got %d", histogram.GetSampleCount())
+			}
+		default:
+			t.Fatalf("Unexpected outcome label: %s", labels["outcome"])
+		}
+	}
+}
+
+func TestPrometheusMeterCounter(t *testing.T) {
+	registry := prom.NewRegistry()
+	meter := NewPrometheusMeter(registry, MeterOptions{Namespace: "app"})
+
+	counter, err := meter.Counter("db.couchbase.retries", map[string]string{
+		"db.couchbase.service": "kv",
+	})
+	if err != nil {
+		t.Fatalf("Failed to get counter: %v", err)
+	}
+	counter.IncrementBy(3)
+
+	// A second meter sharing the registerer must reuse the registered metric rather than fail.
+	counter, err = NewPrometheusMeter(registry, MeterOptions{Namespace: "app"}).Counter("db.couchbase.retries",
+		map[string]string{
+			"db.couchbase.service": "kv",
+		})
+	if err != nil {
+		t.Fatalf("Failed to get counter from second meter: %v", err)
+	}
+	counter.IncrementBy(2)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "app_db_couchbase_retries" {
+		t.Fatalf("Unexpected metric families: %v", families)
+	}
+	if value := families[0].GetMetric()[0].GetCounter().GetValue(); value != 5 {
+		t.Fatalf("Expected a count of 5 but got %v", value)
+	}
+}
@@ -69,6 +69,16 @@ func (r *queryStreamer) EarlyMetadata(key string) json.RawMessage {
 	return r.streamer.EarlyAttrib(key)
 }
 
+// PartialMetaData returns the non-row attributes which have been read from the stream so far, without consuming any
+// rows. Once the rows have been exhausted this is the same as MetaData.
+func (r *queryStreamer) PartialMetaData() ([]byte, error) {
+	if r.streamer == nil {
+		return r.MetaData()
+	}
+
+	return json.Marshal(r.streamer.attribs)
+}
+
 func (r *queryStreamer) finishWithoutError() {
 	// Lets finalize the streamer so we Get the meta-data
 	metaDataBytes, err := r.streamer.Finalize()
@@ -19,6 +19,11 @@ type RetryRequest interface {
 	recordRetryAttempt(reason RetryReason)
 }
 
+// noRetryRequest is implemented by requests which can opt out of being retried entirely.
+type noRetryRequest interface {
+	noRetry() bool
+}
+
 // RetryReason represents the reason for an operation possibly being retried.
 type RetryReason interface {
 	AllowsNonIdempotentRetry() bool
@@ -175,9 +180,61 @@ type RetryStrategy interface {
 	RetryAfter(req RetryRequest, reason RetryReason) RetryAction
 }
 
+// RetryDecision describes a single decision made by a RetryStrategy.
+// Volatile: This API is subject to change at any time.
+type RetryDecision struct {
+	Request RetryRequest
+	Reason  RetryReason
+	// Action is the action returned by the strategy, which may be nil.
+	Action RetryAction
+	// Duration is the delay computed from Action, a value of 0 indicates that the request will not be retried.
+	Duration time.Duration
+}
+
+// RetryStrategyWithTrace is a RetryStrategy which is told about every decision that it makes. This is intended
+// for debugging retry strategies and is only used by strategies which implement it, so has no cost otherwise.
+// Reasons which always retry do not consult the strategy and so are not traced.
+// Volatile: This API is subject to change at any time.
+type RetryStrategyWithTrace interface {
+	RetryStrategy
+	TraceRetryDecision(decision RetryDecision)
+}
+
+type tracingRetryStrategy struct {
+	strategy RetryStrategy
+	traceFn  func(decision RetryDecision)
+}
+
+// NewTracingRetryStrategy returns a RetryStrategyWithTrace which makes decisions using strategy and calls traceFn
+// with each of them.
+// Volatile: This API is subject to change at any time.
+func NewTracingRetryStrategy(strategy RetryStrategy, traceFn func(decision RetryDecision)) RetryStrategyWithTrace {
+	return &tracingRetryStrategy{
+		strategy: strategy,
+		traceFn:  traceFn,
+	}
+}
+
+// RetryAfter calculates and returns a RetryAction describing how long to wait before retrying an operation.
+func (rs *tracingRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
+	return rs.strategy.RetryAfter(req, reason)
+}
+
+// TraceRetryDecision is called with each decision made by the strategy.
+func (rs *tracingRetryStrategy) TraceRetryDecision(decision RetryDecision) {
+	if rs.traceFn != nil {
+		rs.traceFn(decision)
+	}
+}
+
 // retryOrchMaybeRetry will possibly retry an operation according to the strategy belonging to the request.
 // It will use the reason to determine whether or not the failure reason is one that can be retried.
 func retryOrchMaybeRetry(req RetryRequest, reason RetryReason) (bool, time.Time) {
+	if nrReq, ok := req.(noRetryRequest); ok && nrReq.noRetry() {
+		logDebugf("Won't retry request, retries are disabled.  OperationID=%s. Reason=%s", req.Identifier(), reason)
+		return false, time.Time{}
+	}
+
 	if reason.AlwaysRetry() {
 		duration := ControlledBackoff(req.RetryAttempts())
 		logDebugf("Will retry request. Backoff=%s, OperationID=%s. Reason=%s", duration, req.Identifier(), reason)
@@ -193,12 +250,26 @@ func retryOrchMaybeRetry(req RetryRequest, reason RetryReason) (bool, time.Time)
 	}
 
 	action := retryStrategy.RetryAfter(req, reason)
+
+	// The duration is only read once, so that the traced decision always matches the wait which is used, even if the
+	// action computes a different duration on each call.
+	var duration time.Duration
+	if action != nil {
+		duration = action.Duration()
+	}
+	if tracer, ok := retryStrategy.(RetryStrategyWithTrace); ok {
+		tracer.TraceRetryDecision(RetryDecision{
+			Request:  req,
+			Reason:   reason,
+			Action:   action,
+			Duration: duration,
+		})
+	}
 	if action == nil {
 		logDebugf("Won't retry request.  OperationID=%s. Reason=%s", req.Identifier(), reason)
 		return false, time.Time{}
 	}
 
-	duration := action.Duration()
 	if duration == 0 {
 		logDebugf("Won't retry request.  OperationID=%s. Reason=%s", req.Identifier(), reason)
 		return false, time.Time{}
@@ -252,6 +323,47 @@ func (rs *BestEffortRetryStrategy) RetryAfter(req RetryRequest, reason RetryReas
 	return &NoRetryRetryAction{}
 }
 
+// ServerRetryHintRequest is implemented by requests which can carry a hint from the server of how long to wait
+// before retrying, such as the Retry-After header of an HTTP response or the retry specification for a KV status
+// in the error map.
+type ServerRetryHintRequest interface {
+	ServerRetryAfter() time.Duration
+}
+
+// ServerAwareRetryStrategy represents a strategy which retries using the delay requested by the server when the
+// request carries one, see ServerRetryHintRequest, and otherwise behaves as the embedded BestEffortRetryStrategy.
+type ServerAwareRetryStrategy struct {
+	*BestEffortRetryStrategy
+}
+
+// NewServerAwareRetryStrategy returns a new ServerAwareRetryStrategy which will use the supplied calculator function
+// to calculate retry durations when the server has not requested one. If calculator is nil then an ExponentialBackoff
+// with the default values will be used.
+func NewServerAwareRetryStrategy(calculator BackoffCalculator) *ServerAwareRetryStrategy {
+	if calculator == nil {
+		calculator = ExponentialBackoff(0, 0, 0)
+	}
+
+	return &ServerAwareRetryStrategy{
+		BestEffortRetryStrategy: NewBestEffortRetryStrategy(calculator),
+	}
+}
+
+// RetryAfter calculates and returns a RetryAction describing how long to wait before retrying an operation.
+func (rs *ServerAwareRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
+	if !req.Idempotent() && !reason.AllowsNonIdempotentRetry() {
+		return &NoRetryRetryAction{}
+	}
+
+	if hintReq, ok := req.(ServerRetryHintRequest); ok {
+		if retryAfter := hintReq.ServerRetryAfter(); retryAfter > 0 {
+			return &WithDurationRetryAction{WithDuration: retryAfter}
+		}
+	}
+
+	return rs.BestEffortRetryStrategy.RetryAfter(req, reason)
+}
+
 // ExponentialBackoff calculates a backoff time duration from the retry attempts on a given request.
 func ExponentialBackoff(min, max time.Duration, backoffFactor float64) BackoffCalculator {
 	var minBackoff float64 = 1000000   // 1 Millisecond
@@ -2,9 +2,12 @@ package gocbcore
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
 )
 
 type mockRetryRequest struct {
@@ -404,3 +407,132 @@ func (suite *StandardTestSuite) TestExponentialBackoffNonDefaults() {
 		}
 	}
 }
+
+func (suite *UnitTestSuite) TestRetryOrchestratorNoRetry() {
+	strategy := &mockRetryStrategy{action: &WithDurationRetryAction{WithDuration: time.Millisecond}}
+	req := &memdQRequest{
+		RetryStrategy: strategy,
+		NoRetry:       true,
+	}
+
+	// Even reasons which are always retried should not be retried.
+	shouldRetry, _ := retryOrchMaybeRetry(req, KVNotMyVBucketRetryReason)
+	suite.Assert().False(shouldRetry)
+
+	shouldRetry, _ = retryOrchMaybeRetry(req, KVTemporaryFailureRetryReason)
+	suite.Assert().False(shouldRetry)
+	suite.Assert().False(strategy.retried)
+
+	count, reasons := req.Retries()
+	suite.Assert().Zero(count)
+	suite.Assert().Empty(reasons)
+
+	req.NoRetry = false
+	shouldRetry, _ = retryOrchMaybeRetry(req, KVTemporaryFailureRetryReason)
+	suite.Assert().True(shouldRetry)
+	suite.Assert().True(strategy.retried)
+}
+
+func (suite *UnitTestSuite) TestServerAwareRetryStrategy() {
+	rs := NewServerAwareRetryStrategy(mockBackoffCalculator)
+
+	req := &httpRequest{IsIdempotent: true}
+	req.setServerRetryAfter(&HTTPResponse{
+		Header: http.Header{"Retry-After": []string{"3"}},
+	})
+	suite.Assert().Equal(3*time.Second, rs.RetryAfter(req, SearchTooManyRequestsRetryReason).Duration())
+
+	// The hint only applies to a single retry, after which the fallback calculator is used.
+	req.recordRetryAttempt(SearchTooManyRequestsRetryReason)
+	req.recordRetryAttempt(SearchTooManyRequestsRetryReason)
+	suite.Assert().Equal(2*time.Millisecond, rs.RetryAfter(req, SearchTooManyRequestsRetryReason).Duration())
+
+	req.setServerRetryAfter(&HTTPResponse{
+		Header: http.Header{"Retry-After": []string{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}},
+	})
+	duration := rs.RetryAfter(req, SearchTooManyRequestsRetryReason).Duration()
+	suite.Assert().True(duration > 59*time.Minute && duration <= time.Hour, "unexpected duration %s", duration)
+
+	memdReq := &memdQRequest{Packet: memd.Packet{Command: memd.CmdSet}}
+	memdReq.setServerRetryAfter(250 * time.Millisecond)
+	suite.Assert().Equal(250*time.Millisecond, rs.RetryAfter(memdReq, KVErrMapRetryReason).Duration())
+
+	// Non idempotent requests must not be retried for reasons which don't allow it, regardless of any hint.
+	memdReq.setServerRetryAfter(250 * time.Millisecond)
+	suite.Assert().Zero(rs.RetryAfter(memdReq, SocketCloseInFlightRetryReason).Duration())
+}
+
+func (suite *UnitTestSuite) TestParseRetryAfter() {
+	suite.Assert().Zero(parseRetryAfter(http.Header{}))
+	suite.Assert().Zero(parseRetryAfter(http.Header{"Retry-After": []string{"-1"}}))
+	suite.Assert().Zero(parseRetryAfter(http.Header{"Retry-After": []string{"soon"}}))
+	suite.Assert().Equal(120*time.Second, parseRetryAfter(http.Header{"Retry-After": []string{"120"}}))
+	suite.Assert().Zero(parseRetryAfter(http.Header{
+		"Retry-After": []string{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)},
+	}))
+}
+
+func (suite *UnitTestSuite) TestRetryOrchestratorTrace() {
+	var decisions []RetryDecision
+	strategy := NewTracingRetryStrategy(NewBestEffortRetryStrategy(mockBackoffCalculator), func(decision RetryDecision) {
+		decisions = append(decisions, decision)
+	})
+
+	req := &mockRetryRequest{attempts: 1, strategy: strategy}
+	shouldRetry, _ := retryOrchMaybeRetry(req, KVLockedRetryReason)
+	suite.Assert().True(shouldRetry)
+
+	shouldRetry, _ = retryOrchMaybeRetry(req, SocketCloseInFlightRetryReason)
+	suite.Assert().False(shouldRetry)
+
+	// Always retry reasons do not consult the strategy.
+	shouldRetry, _ = retryOrchMaybeRetry(req, KVNotMyVBucketRetryReason)
+	suite.Assert().True(shouldRetry)
+
+	suite.Require().Len(decisions, 2)
+
+	suite.Assert().Equal(req, decisions[0].Request)
+	suite.Assert().Equal(KVLockedRetryReason, decisions[0].Reason)
+	suite.Assert().Equal(&WithDurationRetryAction{WithDuration: time.Millisecond}, decisions[0].Action)
+	suite.Assert().Equal(time.Millisecond, decisions[0].Duration)
+
+	suite.Assert().Equal(SocketCloseInFlightRetryReason, decisions[1].Reason)
+	suite.Assert().Equal(&NoRetryRetryAction{}, decisions[1].Action)
+	suite.Assert().Zero(decisions[1].Duration)
+}
+
+// changingRetryAction returns a longer duration each time it is asked.
+type changingRetryAction struct {
+	calls int
+}
+
+func (a *changingRetryAction) Duration() time.Duration {
+	a.calls++
+	return time.Duration(a.calls) * time.Hour
+}
+
+type changingActionRetryStrategy struct {
+	action *changingRetryAction
+}
+
+func (s *changingActionRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
+	return s.action
+}
+
+func (suite *UnitTestSuite) TestRetryOrchestratorTraceMatchesWait() {
+	action := &changingRetryAction{}
+	var decisions []RetryDecision
+	strategy := NewTracingRetryStrategy(&changingActionRetryStrategy{action: action}, func(decision RetryDecision) {
+		decisions = append(decisions, decision)
+	})
+
+	req := &mockRetryRequest{attempts: 1, strategy: strategy}
+	start := time.Now()
+	shouldRetry, retryTime := retryOrchMaybeRetry(req, KVLockedRetryReason)
+	suite.Require().True(shouldRetry)
+
+	suite.Assert().Equal(1, action.calls)
+	suite.Require().Len(decisions, 1)
+	suite.Assert().Equal(time.Hour, decisions[0].Duration)
+	suite.Assert().True(retryTime.Before(start.Add(2*time.Hour)), retryTime)
+}
@@ -42,6 +42,9 @@ type routeConfig struct {
 
 	clusterUUID string
 	clusterName string
+
+	// collectionsManifestUID is the uid of the bucket's current collections manifest, 0 if it is not known.
+	collectionsManifestUID uint64
 }
 
 func (config *routeConfig) DebugString() string {
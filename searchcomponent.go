@@ -12,14 +12,25 @@ import (
 	"time"
 )
 
-// SearchRowReader providers access to the rows of a view query
+// SearchRowReader providers access to the rows of a search query. Hits are parsed from the response as they are
+// read, so the whole result set is never held in memory.
 type SearchRowReader struct {
 	streamer *queryStreamer
+
+	from    int
+	numRows int
+	lastRow []byte
 }
 
 // NextRow reads the next rows bytes from the stream
 func (q *SearchRowReader) NextRow() []byte {
-	return q.streamer.NextRow()
+	row := q.streamer.NextRow()
+	if row != nil {
+		q.numRows++
+		q.lastRow = row
+	}
+
+	return row
 }
 
 // Err returns any errors that occurred during streaming.
@@ -32,11 +43,91 @@ func (q *SearchRowReader) MetaData() ([]byte, error) {
 	return q.streamer.MetaData()
 }
 
+// Facets returns the raw facets section of the meta-data. Like MetaData this is only available once all rows have
+// been read. If the response did not contain any facets then nil is returned.
+// Volatile: This API is subject to change at any time.
+func (q *SearchRowReader) Facets() (json.RawMessage, error) {
+	meta, err := q.streamer.MetaData()
+	if err != nil {
+		return nil, err
+	}
+
+	var metaData struct {
+		Facets json.RawMessage `json:"facets"`
+	}
+	if err := json.Unmarshal(meta, &metaData); err != nil {
+		return nil, wrapError(err, "failed to parse meta-data")
+	}
+	if string(metaData.Facets) == "null" {
+		return nil, nil
+	}
+
+	return metaData.Facets, nil
+}
+
 // Close immediately shuts down the connection
 func (q *SearchRowReader) Close() error {
 	return q.streamer.Close()
 }
 
+// NextPageCursor returns an opaque cursor which can be passed as SearchQueryOptions.SearchAfter to fetch the page of
+// results following this one. It must only be called once all rows have been read and returns nil if this page
+// contained no rows.
+func (q *SearchRowReader) NextPageCursor() ([]byte, error) {
+	if q.lastRow == nil {
+		return nil, nil
+	}
+
+	var hit struct {
+		Sort json.RawMessage `json:"sort"`
+	}
+	if err := json.Unmarshal(q.lastRow, &hit); err != nil {
+		return nil, wrapError(err, "failed to parse sort values from last hit")
+	}
+
+	return json.Marshal(searchCursor{
+		SearchAfter: hit.Sort,
+		From:        q.from + q.numRows,
+	})
+}
+
+// searchCursor is the content of the opaque cursor used for search pagination. The from offset is always
+// tracked so that the cursor can still be used against clusters which do not support search_after.
+type searchCursor struct {
+	SearchAfter json.RawMessage `json:"search_after,omitempty"`
+	From        int             `json:"from"`
+}
+
+// applySearchCursor updates the payload to start from the position identified by the cursor, returning the offset
+// of the first hit which will be returned.
+func applySearchCursor(payloadMap map[string]interface{}, cursorBytes []byte, searchAfterSupported bool) (int, error) {
+	if len(cursorBytes) == 0 {
+		if from, ok := payloadMap["from"].(float64); ok {
+			return int(from), nil
+		}
+		return 0, nil
+	}
+
+	if _, ok := payloadMap["search_before"]; ok {
+		return 0, wrapError(errInvalidArgument, "search_before cannot be used with a pagination cursor")
+	}
+
+	var cursor searchCursor
+	if err := json.Unmarshal(cursorBytes, &cursor); err != nil {
+		return 0, wrapError(errInvalidArgument, "invalid pagination cursor")
+	}
+
+	if searchAfterSupported && len(cursor.SearchAfter) > 0 {
+		payloadMap["search_after"] = cursor.SearchAfter
+		delete(payloadMap, "from")
+	} else {
+		payloadMap["from"] = cursor.From
+		delete(payloadMap, "search_after")
+	}
+
+	return cursor.From, nil
+}
+
 // SearchQueryOptions represents the various options available for a search query.
 type SearchQueryOptions struct {
 	BucketName    string
@@ -45,11 +136,23 @@ type SearchQueryOptions struct {
 	Payload       []byte
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
+
+	// Context, if set, cancels the query when it is done. If Deadline is not set then the deadline of the context
+	// is used instead.
+	Context context.Context
+
+	// SearchAfter is an opaque cursor, as returned by SearchRowReader.NextPageCursor, identifying where the page of
+	// results should start. The query should specify a sort which uniquely orders hits, e.g. ending with "_id".
+	// Against clusters that do not support search_after the cursor falls back to a from offset, which can miss or
+	// duplicate results if documents are mutated between pages.
+	SearchAfter []byte
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 type jsonSearchErrorResponse struct {
@@ -122,26 +225,33 @@ type SearchCapability uint32
 const (
 	SearchCapabilityScopedIndexes SearchCapability = iota
 	SearchCapabilityVectorSearch
+	SearchCapabilitySearchAfter
 )
 
 type searchQueryComponent struct {
-	httpComponent *httpComponent
-	cfgMgr        configManager
-	tracer        *tracerComponent
+	httpComponent  *httpComponent
+	cfgMgr         configManager
+	tracer         *tracerComponent
+	defaultTimeout time.Duration
+	logger         *scopedLogger
 
 	caps     map[SearchCapability]CapabilityStatus
 	capsLock sync.RWMutex
 }
 
-func newSearchQueryComponent(httpComponent *httpComponent, cfgMgr configManager, tracer *tracerComponent) *searchQueryComponent {
+func newSearchQueryComponent(httpComponent *httpComponent, cfgMgr configManager, tracer *tracerComponent,
+	defaultTimeout time.Duration, logger *scopedLogger) *searchQueryComponent {
 	sqc := &searchQueryComponent{
-		httpComponent: httpComponent,
-		cfgMgr:        cfgMgr,
-		tracer:        tracer,
+		httpComponent:  httpComponent,
+		cfgMgr:         cfgMgr,
+		tracer:         tracer,
+		defaultTimeout: defaultTimeout,
+		logger:         logger,
 
 		caps: map[SearchCapability]CapabilityStatus{
 			SearchCapabilityVectorSearch:  CapabilityStatusUnknown,
 			SearchCapabilityScopedIndexes: CapabilityStatusUnknown,
+			SearchCapabilitySearchAfter:   CapabilityStatusUnknown,
 		},
 	}
 	cfgMgr.AddConfigWatcher(sqc)
@@ -164,6 +274,14 @@ func (sqc *searchQueryComponent) OnNewRouteConfig(cfg *routeConfig) {
 	} else {
 		sqc.caps[SearchCapabilityScopedIndexes] = CapabilityStatusUnsupported
 	}
+
+	// There is no capability advertised for search_after, which was added in 6.6.1. Any cluster which advertises
+	// search capabilities at all is new enough.
+	if len(cfg.clusterCapabilities["search"]) > 0 {
+		sqc.caps[SearchCapabilitySearchAfter] = CapabilityStatusSupported
+	} else {
+		sqc.caps[SearchCapabilitySearchAfter] = CapabilityStatusUnsupported
+	}
 }
 
 func (sqc *searchQueryComponent) capabilityStatus(cap SearchCapability) CapabilityStatus {
@@ -180,12 +298,16 @@ func (sqc *searchQueryComponent) capabilityStatus(cap SearchCapability) Capabili
 
 // SearchQuery executes a Search query
 func (sqc *searchQueryComponent) SearchQuery(opts SearchQueryOptions, cb SearchQueryCallback) (PendingOp, error) {
-	tracer := sqc.tracer.StartTelemeteryHandler(metricValueServiceSearchValue, "SearchQuery", opts.TraceContext)
+	opts.Deadline = deadlineFromContext(opts.Deadline, opts.Context)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, opts.Timeout)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, sqc.defaultTimeout)
+
+	tracer := sqc.tracer.StartTelemeteryHandler(metricValueServiceSearchValue, "SearchQuery", opts.TraceContext, opts.NoRootSpan)
 
 	var payloadMap map[string]interface{}
 	err := json.Unmarshal(opts.Payload, &payloadMap)
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, wrapSearchError(nil, "", nil, wrapError(err, "expected a JSON payload"), 0)
 	}
 
@@ -194,7 +316,7 @@ func (sqc *searchQueryComponent) SearchQuery(opts SearchQueryOptions, cb SearchQ
 		if coercedCtlMap, ok := foundCtlMap.(map[string]interface{}); ok {
 			ctlMap = coercedCtlMap
 		} else {
-			tracer.Finish()
+			tracer.FinishWithError(errInvalidArgument)
 			return nil, wrapSearchError(nil, "", nil,
 				wrapError(errInvalidArgument, "expected ctl to be a map"), 0)
 		}
@@ -216,10 +338,24 @@ func (sqc *searchQueryComponent) SearchQuery(opts SearchQueryOptions, cb SearchQ
 		}
 	}
 
+	from, err := applySearchCursor(payloadMap, opts.SearchAfter,
+		sqc.capabilityStatus(SearchCapabilitySearchAfter) == CapabilityStatusSupported)
+	if err != nil {
+		tracer.FinishWithError(err)
+		return nil, wrapSearchError(nil, "", nil, err, 0)
+	}
+	if len(opts.SearchAfter) > 0 {
+		opts.Payload, err = json.Marshal(payloadMap)
+		if err != nil {
+			tracer.FinishWithError(err)
+			return nil, wrapSearchError(nil, "", nil, wrapError(err, "failed to produce payload"), 0)
+		}
+	}
+
 	indexName := opts.IndexName
 	query := payloadMap["query"]
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(contextOrBackground(opts.Context))
 	var reqURI string
 	if opts.BucketName != "" && opts.ScopeName != "" {
 		reqURI = fmt.Sprintf("/api/bucket/%s/scope/%s/index/%s/query",
@@ -245,11 +381,13 @@ func (sqc *searchQueryComponent) SearchQuery(opts SearchQueryOptions, cb SearchQ
 		res, err := sqc.searchQuery(ireq, indexName, query, payloadMap, ctlMap, tracer.StartTime())
 		if err != nil {
 			cancel()
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
 
+		res.from = from
+
 		tracer.Finish()
 		cb(res, nil)
 	}()
@@ -311,6 +449,7 @@ func (sqc *searchQueryComponent) searchQuery(ireq *httpRequest, indexName string
 				return nil, searchErr
 			}
 
+			ireq.setServerRetryAfter(resp)
 			shouldRetry, retryTime := retryOrchMaybeRetry(ireq, retryReason)
 			if !shouldRetry {
 				// searchErr is already wrapped here
@@ -338,7 +477,7 @@ func (sqc *searchQueryComponent) searchQuery(ireq *httpRequest, indexName string
 		if err != nil {
 			respBody, readErr := ioutil.ReadAll(resp.Body)
 			if readErr != nil {
-				logDebugf("Failed to read response body: %v", readErr)
+				sqc.logger.debugf("Failed to read response body: %v", readErr)
 			}
 			sErr := wrapSearchError(ireq, indexName, query, err, resp.StatusCode)
 			sErr.ErrorText = string(respBody)
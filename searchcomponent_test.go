@@ -3,7 +3,9 @@ package gocbcore
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/mock"
+	"io"
 	"io/ioutil"
 )
 
@@ -45,7 +47,7 @@ func (suite *UnitTestSuite) TestSearchComponentRouteConfigHandling() {
 	configC := new(mockConfigManager)
 	configC.On("AddConfigWatcher", mock.AnythingOfType("*gocbcore.searchQueryComponent"))
 
-	sqc := newSearchQueryComponent(nil, configC, nil)
+	sqc := newSearchQueryComponent(nil, configC, nil, 0, nil)
 
 	suite.Assert().Equal(CapabilityStatusUnknown, sqc.capabilityStatus(SearchCapabilityVectorSearch))
 	suite.Assert().Equal(CapabilityStatusUnknown, sqc.capabilityStatus(SearchCapabilityScopedIndexes))
@@ -58,6 +60,7 @@ func (suite *UnitTestSuite) TestSearchComponentRouteConfigHandling() {
 
 	suite.Assert().Equal(CapabilityStatusUnsupported, sqc.capabilityStatus(SearchCapabilityVectorSearch))
 	suite.Assert().Equal(CapabilityStatusUnsupported, sqc.capabilityStatus(SearchCapabilityScopedIndexes))
+	suite.Assert().Equal(CapabilityStatusUnsupported, sqc.capabilityStatus(SearchCapabilitySearchAfter))
 
 	cfg = &routeConfig{
 		clusterCapabilitiesVer: []int{1},
@@ -69,13 +72,14 @@ func (suite *UnitTestSuite) TestSearchComponentRouteConfigHandling() {
 
 	suite.Assert().Equal(CapabilityStatusSupported, sqc.capabilityStatus(SearchCapabilityVectorSearch))
 	suite.Assert().Equal(CapabilityStatusSupported, sqc.capabilityStatus(SearchCapabilityScopedIndexes))
+	suite.Assert().Equal(CapabilityStatusSupported, sqc.capabilityStatus(SearchCapabilitySearchAfter))
 }
 
 func (suite *UnitTestSuite) TestSearchComponentVectorSearchUnsupported() {
 	configC := new(mockConfigManager)
 	configC.On("AddConfigWatcher", mock.Anything)
 
-	sqc := newSearchQueryComponent(nil, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	sqc := newSearchQueryComponent(nil, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 	sqc.caps[SearchCapabilityVectorSearch] = CapabilityStatusUnsupported
 	sqc.caps[SearchCapabilityScopedIndexes] = CapabilityStatusSupported
 
@@ -95,7 +99,7 @@ func (suite *UnitTestSuite) TestSearchComponentScopedIndexUnsupported() {
 	configC := new(mockConfigManager)
 	configC.On("AddConfigWatcher", mock.Anything)
 
-	sqc := newSearchQueryComponent(nil, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC))
+	sqc := newSearchQueryComponent(nil, configC, newTracerComponent(&noopTracer{}, "", true, &noopMeter{}, configC), 0, nil)
 	sqc.caps[SearchCapabilityScopedIndexes] = CapabilityStatusUnsupported
 
 	opts := SearchQueryOptions{
@@ -109,3 +113,119 @@ func (suite *UnitTestSuite) TestSearchComponentScopedIndexUnsupported() {
 	suite.Assert().ErrorIs(err, ErrFeatureNotAvailable)
 	suite.Assert().Contains(err.Error(), "scoped search indexes are not supported by this cluster version")
 }
+
+func (suite *UnitTestSuite) TestSearchComponentNextPageCursor() {
+	d := []byte(`{"status":{"total":1,"failed":0,"successful":1},"hits":[` +
+		`{"index":"idx","id":"doc1","score":1.5,"sort":["a","doc1"]},` +
+		`{"index":"idx","id":"doc2","score":1.2,"sort":["b","doc2"]}` +
+		`],"total_hits":10}`)
+
+	qStreamer, err := newQueryStreamer(ioutil.NopCloser(bytes.NewBuffer(d)), "hits")
+	suite.Require().Nil(err, err)
+
+	reader := SearchRowReader{
+		streamer: qStreamer,
+		from:     4,
+	}
+	numRows := 0
+	for reader.NextRow() != nil {
+		numRows++
+	}
+	suite.Require().Nil(reader.Err())
+	suite.Assert().Equal(2, numRows)
+
+	cursor, err := reader.NextPageCursor()
+	suite.Require().Nil(err, err)
+
+	payloadMap := map[string]interface{}{"from": float64(4), "size": float64(2)}
+	from, err := applySearchCursor(payloadMap, cursor, true)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(6, from)
+	suite.Assert().Equal(json.RawMessage(`["b","doc2"]`), payloadMap["search_after"])
+	suite.Assert().NotContains(payloadMap, "from")
+
+	// Without search_after support the cursor falls back to an offset.
+	payloadMap = map[string]interface{}{"size": float64(2)}
+	from, err = applySearchCursor(payloadMap, cursor, false)
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(6, from)
+	suite.Assert().Equal(6, payloadMap["from"])
+	suite.Assert().NotContains(payloadMap, "search_after")
+
+	_, err = applySearchCursor(map[string]interface{}{}, []byte("notacursor"), true)
+	suite.Assert().ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *UnitTestSuite) TestSearchComponentNextPageCursorNoRows() {
+	qStreamer, err := newQueryStreamer(ioutil.NopCloser(bytes.NewBufferString(`{"hits":[]}`)), "hits")
+	suite.Require().Nil(err, err)
+
+	reader := SearchRowReader{
+		streamer: qStreamer,
+	}
+	suite.Assert().Nil(reader.NextRow())
+
+	cursor, err := reader.NextPageCursor()
+	suite.Require().Nil(err, err)
+	suite.Assert().Nil(cursor)
+}
+
+func (suite *UnitTestSuite) TestSearchRowReaderStreamsHitsAndFacets() {
+	const numHits = 10000
+
+	pr, pw := io.Pipe()
+	written := make(chan int, numHits)
+	go func() {
+		_, _ = pw.Write([]byte(`{"status":{"total":1,"failed":0,"successful":1},"hits":[`))
+		for i := 0; i < numHits; i++ {
+			if i > 0 {
+				_, _ = pw.Write([]byte(","))
+			}
+			_, _ = pw.Write([]byte(fmt.Sprintf(`{"id":"doc-%d","score":1}`, i)))
+			written <- i
+		}
+		_, _ = pw.Write([]byte(`],"total_hits":10000,"facets":{"type":{"field":"type","total":10000}}}`))
+		_ = pw.Close()
+	}()
+
+	qStreamer, err := newQueryStreamer(pr, "hits")
+	suite.Require().Nil(err, err)
+
+	reader := SearchRowReader{
+		streamer: qStreamer,
+	}
+
+	row := reader.NextRow()
+	suite.Require().NotNil(row)
+	suite.Assert().JSONEq(`{"id":"doc-0","score":1}`, string(row))
+	// The first hit must be available well before the whole response has been sent.
+	suite.Assert().Less(len(written), numHits)
+
+	_, err = reader.Facets()
+	suite.Assert().NotNil(err)
+
+	numRows := 1
+	for reader.NextRow() != nil {
+		numRows++
+	}
+	suite.Assert().Equal(numHits, numRows)
+	suite.Require().Nil(reader.Err())
+
+	facets, err := reader.Facets()
+	suite.Require().Nil(err, err)
+	suite.Assert().JSONEq(`{"type":{"field":"type","total":10000}}`, string(facets))
+}
+
+func (suite *UnitTestSuite) TestSearchRowReaderNoFacets() {
+	qStreamer, err := newQueryStreamer(ioutil.NopCloser(bytes.NewBufferString(`{"hits":[],"facets":null}`)), "hits")
+	suite.Require().Nil(err, err)
+
+	reader := SearchRowReader{
+		streamer: qStreamer,
+	}
+	suite.Assert().Nil(reader.NextRow())
+
+	facets, err := reader.Facets()
+	suite.Require().Nil(err, err)
+	suite.Assert().Nil(facets)
+}
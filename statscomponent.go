@@ -1,6 +1,10 @@
 package gocbcore
 
 import (
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,22 +15,29 @@ type statsComponent struct {
 	kvMux                *kvMux
 	tracer               *tracerComponent
 	defaultRetryStrategy RetryStrategy
+	defaultTimeout       time.Duration
+	logger               *scopedLogger
 }
 
-func newStatsComponent(kvMux *kvMux, defaultRetry RetryStrategy, tracer *tracerComponent) *statsComponent {
+func newStatsComponent(kvMux *kvMux, defaultRetry RetryStrategy, tracer *tracerComponent,
+	defaultTimeout time.Duration, logger *scopedLogger) *statsComponent {
 	return &statsComponent{
 		kvMux:                kvMux,
 		tracer:               tracer,
 		defaultRetryStrategy: defaultRetry,
+		defaultTimeout:       defaultTimeout,
+		logger:               logger,
 	}
 }
 
 func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp, error) {
-	tracer := sc.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Stats", opts.TraceContext)
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, sc.defaultTimeout)
+
+	tracer := sc.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "Stats", opts.TraceContext, opts.NoRootSpan)
 
 	iter, err := sc.kvMux.PipelineSnapshot()
 	if err != nil {
-		tracer.Finish()
+		tracer.FinishWithError(err)
 		return nil, err
 	}
 
@@ -103,7 +114,7 @@ func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp,
 				if curStats.Error == nil {
 					curStats.Error = err
 				} else {
-					logDebugf("Got additional error for stats: %s: %v", serverAddress, err)
+					sc.logger.debugf("Got additional error for stats: %s: %v", serverAddress, err)
 				}
 
 				opHandledLocked()
@@ -190,6 +201,229 @@ func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp,
 	return op, nil
 }
 
+func (sc *statsComponent) GetAllVBucketSeqnos(opts GetAllVBucketSeqnosOptions, cb GetAllVBucketSeqnosCallback) (PendingOp, error) {
+	opts.Deadline = deadlineFromTimeouts(opts.Deadline, opts.Timeout, sc.defaultTimeout)
+
+	tracer := sc.tracer.StartTelemeteryHandler(metricValueServiceKeyValue, "GetAllVBucketSeqnos", opts.TraceContext, opts.NoRootSpan)
+
+	extraBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(extraBuf[0:], uint32(memd.VbucketStateActive))
+	if opts.FilterOptions != nil {
+		if !sc.kvMux.SupportsCollections() {
+			tracer.FinishWithError(errCollectionsUnsupported)
+			return nil, errCollectionsUnsupported
+		}
+
+		extraBuf = append(extraBuf, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(extraBuf[4:], opts.FilterOptions.CollectionID)
+	}
+
+	iter, err := sc.kvMux.PipelineSnapshot()
+	if err != nil {
+		tracer.FinishWithError(err)
+		return nil, err
+	}
+
+	var addresses []string
+	iter.Iterate(0, func(pipeline *memdPipeline) bool {
+		addresses = append(addresses, pipeline.Address())
+		return false
+	})
+
+	var userFrame *memd.UserImpersonationFrame
+	if len(opts.User) > 0 {
+		userFrame = &memd.UserImpersonationFrame{
+			User: []byte(opts.User),
+		}
+	}
+
+	if opts.RetryStrategy == nil {
+		opts.RetryStrategy = sc.defaultRetryStrategy
+	}
+
+	op := new(multiPendingOp)
+	op.isIdempotent = true
+	expected := uint32(len(addresses))
+
+	var seqnos []VBucketSeqno
+	var firstErr error
+	var lock sync.Mutex
+
+	complete := func() {
+		if firstErr != nil {
+			tracer.FinishWithError(firstErr)
+			cb(nil, firstErr)
+			return
+		}
+
+		sort.Slice(seqnos, func(i, j int) bool {
+			return seqnos[i].VbID < seqnos[j].VbID
+		})
+
+		if !opts.IncludeVbUUID {
+			tracer.Finish()
+			cb(seqnos, nil)
+			return
+		}
+
+		statsOp, err := sc.Stats(StatsOptions{
+			Key:           "vbucket-seqno",
+			RetryStrategy: opts.RetryStrategy,
+			Deadline:      opts.Deadline,
+			User:          opts.User,
+			TraceContext:  tracer.RootContext(),
+			NoRootSpan:    opts.NoRootSpan,
+		}, func(res *StatsResult, err error) {
+			tracer.FinishWithError(err)
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			uuids, err := parseVbUUIDStats(res)
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			for i, seqno := range seqnos {
+				seqnos[i].VbUUID = uuids[seqno.VbID]
+			}
+
+			cb(seqnos, nil)
+		})
+		if err != nil {
+			tracer.FinishWithError(err)
+			cb(nil, err)
+			return
+		}
+
+		op.AddOp(statsOp)
+	}
+
+	handleResult := func(entries []VBucketSeqno, err error) {
+		lock.Lock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else {
+			seqnos = append(seqnos, entries...)
+		}
+		completed := op.IncrementCompletedOps()
+		lock.Unlock()
+
+		if expected-completed == 0 {
+			complete()
+		}
+	}
+
+	if expected == 0 {
+		tracer.FinishWithError(errServiceNotAvailable)
+		return nil, errServiceNotAvailable
+	}
+
+	for _, address := range addresses {
+		handler := func(resp *memdQResponse, req *memdQRequest, err error) {
+			if err != nil {
+				handleResult(nil, err)
+				return
+			}
+
+			if len(resp.Value)%10 != 0 {
+				handleResult(nil, errProtocol)
+				return
+			}
+
+			numVbs := len(resp.Value) / 10
+			entries := make([]VBucketSeqno, numVbs)
+			for i := 0; i < numVbs; i++ {
+				entries[i] = VBucketSeqno{
+					VbID:  binary.BigEndian.Uint16(resp.Value[i*10:]),
+					SeqNo: SeqNo(binary.BigEndian.Uint64(resp.Value[i*10+2:])),
+				}
+			}
+
+			handleResult(entries, nil)
+		}
+
+		req := &memdQRequest{
+			Packet: memd.Packet{
+				Magic:                  memd.CmdMagicReq,
+				Command:                memd.CmdGetAllVBSeqnos,
+				Datatype:               0,
+				Cas:                    0,
+				Extras:                 extraBuf,
+				Key:                    nil,
+				Value:                  nil,
+				UserImpersonationFrame: userFrame,
+			},
+			Callback:         handler,
+			RootTraceContext: tracer.RootContext(),
+			RetryStrategy:    opts.RetryStrategy,
+		}
+
+		curOp, err := sc.kvMux.DispatchDirectToAddress(req, address)
+		if err != nil {
+			handleResult(nil, err)
+			continue
+		}
+
+		if !opts.Deadline.IsZero() {
+			start := time.Now()
+			req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
+				connInfo := req.ConnectionInfo()
+				count, reasons := req.Retries()
+				req.cancelWithCallback(&TimeoutError{
+					InnerError:         errUnambiguousTimeout,
+					OperationID:        "GetAllVBucketSeqnos",
+					Opaque:             req.Identifier(),
+					TimeObserved:       time.Since(start),
+					RetryReasons:       reasons,
+					RetryAttempts:      count,
+					LastDispatchedTo:   connInfo.lastDispatchedTo,
+					LastDispatchedFrom: connInfo.lastDispatchedFrom,
+					LastConnectionID:   connInfo.lastConnectionID,
+				})
+			}))
+		}
+
+		op.AddOp(curOp)
+	}
+
+	return op, nil
+}
+
+// parseVbUUIDStats extracts the vbucket uuids from the vb_<id>:uuid keys of vbucket-seqno stats.
+func parseVbUUIDStats(res *StatsResult) (map[uint16]VbUUID, error) {
+	uuids := make(map[uint16]VbUUID)
+	for _, server := range res.Servers {
+		if server.Error != nil {
+			return nil, server.Error
+		}
+
+		for key, value := range server.Stats {
+			if !strings.HasPrefix(key, "vb_") || !strings.HasSuffix(key, ":uuid") {
+				continue
+			}
+
+			vbID, err := strconv.ParseUint(key[3:len(key)-5], 10, 16)
+			if err != nil {
+				continue
+			}
+
+			uuid, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, wrapError(errProtocol, "failed to parse vbucket uuid")
+			}
+
+			uuids[uint16(vbID)] = VbUUID(uuid)
+		}
+	}
+
+	return uuids, nil
+}
+
 // SingleServerStats represents the stats returned from a single server.
 type SingleServerStats struct {
 	Stats map[string]string
@@ -218,14 +452,41 @@ type StatsOptions struct {
 	Target        StatsTarget
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Timeout       time.Duration
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 // StatsResult encapsulates the result of a Stats operation.
 type StatsResult struct {
 	Servers map[string]SingleServerStats
 }
+
+// GetAllVBucketSeqnosOptions encapsulates the parameters for a GetAllVBucketSeqnos operation.
+type GetAllVBucketSeqnosOptions struct {
+	// FilterOptions restricts the sequence numbers returned to those of a specific collection.
+	FilterOptions *GetVbucketSeqnoFilterOptions
+	// IncludeVbUUID specifies that the uuid of each vbucket should also be fetched. The uuid is not returned by
+	// the sequence numbers command so this requires an additional stats request to each node.
+	IncludeVbUUID bool
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+	Timeout       time.Duration
+
+	// Internal: This should never be used and is not supported.
+	User string
+
+	TraceContext RequestSpanContext
+	NoRootSpan   bool
+}
+
+// VBucketSeqno represents the high sequence number of a single active vbucket.
+type VBucketSeqno struct {
+	VbID   uint16
+	VbUUID VbUUID
+	SeqNo  SeqNo
+}
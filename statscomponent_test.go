@@ -0,0 +1,36 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestParseVbUUIDStats() {
+	uuids, err := parseVbUUIDStats(&StatsResult{
+		Servers: map[string]SingleServerStats{
+			"10.112.210.101:11210": {
+				Stats: map[string]string{
+					"vb_0:uuid":        "123456789",
+					"vb_0:high_seqno":  "12",
+					"vb_1023:uuid":     "987654321",
+					"vb_1023:abs_high": "4",
+				},
+			},
+			"10.112.210.102:11210": {
+				Stats: map[string]string{
+					"vb_512:uuid": "42",
+				},
+			},
+		},
+	})
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(map[uint16]VbUUID{
+		0:    123456789,
+		512:  42,
+		1023: 987654321,
+	}, uuids)
+
+	_, err = parseVbUUIDStats(&StatsResult{
+		Servers: map[string]SingleServerStats{
+			"10.112.210.101:11210": {
+				Error: errServiceNotAvailable,
+			},
+		},
+	})
+	suite.Assert().ErrorIs(err, ErrServiceNotAvailable)
+}
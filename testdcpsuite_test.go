@@ -531,6 +531,25 @@ func (suite *DCPTestSuite) TestBasic() {
 	}
 }
 
+func (suite *DCPTestSuite) TestExpirationsDistinctFromDeletions() {
+	suite.EnsureSupportsFeature(TestFeatureDCPExpiry)
+
+	_, deletionKeys := suite.runMutations("", "")
+
+	suite.runDCPStream(suite.dcpAgent)
+
+	// Compaction can run and cause expirations to be hidden from us, but an expiry must never be reported as a deletion.
+	for i := 0; i < suite.NumExpirations; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		suite.Assert().NotContains(suite.so.counter.deletions, key)
+	}
+
+	for _, key := range deletionKeys {
+		suite.Assert().Contains(suite.so.counter.deletions, key)
+		suite.Assert().NotContains(suite.so.counter.expirations, key)
+	}
+}
+
 func (suite *DCPTestSuite) TestScopesBasic() {
 	suite.EnsureSupportsFeature(TestFeatureCollections)
 
@@ -1,6 +1,7 @@
 package gocbcore
 
 import (
+	"errors"
 	"net"
 	"net/http"
 	"strconv"
@@ -110,8 +111,8 @@ func newTracerComponent(tracer RequestTracer, bucket string, noRootTraceSpans bo
 	return tc
 }
 
-func (tc *tracerComponent) CreateOpTrace(operationName string, parentContext RequestSpanContext) *opTracer {
-	if tc.noRootTraceSpans {
+func (tc *tracerComponent) CreateOpTrace(operationName string, parentContext RequestSpanContext, noRootSpan bool) *opTracer {
+	if tc.noRootTraceSpans || noRootSpan {
 		return &opTracer{
 			parentContext: parentContext,
 			opSpan:        nil,
@@ -185,6 +186,15 @@ func (tc *tracerComponent) StartCmdTrace(req *memdQRequest) {
 	if labels.ClusterUUID != "" {
 		req.cmdTraceSpan.SetAttribute(spanAttribClusterUUIDKey, labels.ClusterUUID)
 	}
+	if tc.bucket != "" {
+		req.cmdTraceSpan.SetAttribute(spanAttribDBNameKey, tc.bucket)
+	}
+	if req.ScopeName != "" {
+		req.cmdTraceSpan.SetAttribute(spanAttribScopeNameKey, req.ScopeName)
+	}
+	if req.CollectionName != "" {
+		req.cmdTraceSpan.SetAttribute(spanAttribCollectionNameKey, req.CollectionName)
+	}
 	req.processingLock.Unlock()
 }
 
@@ -213,17 +223,19 @@ func (tc *tracerComponent) StartNetTrace(req *memdQRequest) {
 	req.processingLock.Unlock()
 }
 
-func (tc *tracerComponent) ResponseValueRecord(service, operation string, start time.Time) {
+func (tc *tracerComponent) ResponseValueRecord(service, operation string, start time.Time, err error) {
 	if tc.metrics == nil {
 		return
 	}
-	key := service + "." + operation
+	outcome := metricOutcome(err)
+	key := service + "." + operation + "." + outcome
 	attribs, ok := tc.valueRecorderAttribsCache.Load(key)
 	if !ok {
 		// It doesn't really matter if we end up storing the attribs against the same key multiple times. We just need
 		// to have a read efficient cache that doesn't cause actual data races.
 		attribs = map[string]string{
 			metricAttribServiceKey: service,
+			metricAttribOutcomeKey: outcome,
 		}
 		if operation != "" {
 			attribs.(map[string]string)[metricAttribOperationKey] = operation
@@ -252,6 +264,21 @@ func (tc *tracerComponent) ResponseValueRecord(service, operation string, start
 	recorder.RecordValue(duration)
 }
 
+// metricOutcome classifies the result of an operation for the outcome metric tag, using a small fixed set of values
+// so that the cardinality of the tag remains bounded.
+func metricOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metricValueOutcomeSuccess
+	case errors.Is(err, ErrTimeout):
+		return metricValueOutcomeTimeout
+	case errors.Is(err, ErrRequestCanceled):
+		return metricValueOutcomeCanceled
+	default:
+		return metricValueOutcomeError
+	}
+}
+
 func (tc *tracerComponent) OnNewRouteConfig(cfg *routeConfig) {
 	tc.clusterLabels.Store(ClusterLabels{
 		ClusterUUID: cfg.clusterUUID,
@@ -335,12 +362,13 @@ type opTelemetryHandler struct {
 	service           string
 	operation         string
 	start             time.Time
-	metricsCompleteFn func(string, string, time.Time)
+	metricsCompleteFn func(string, string, time.Time, error)
 }
 
-func (tc *tracerComponent) StartTelemeteryHandler(service, operation string, traceContext RequestSpanContext) *opTelemetryHandler {
+func (tc *tracerComponent) StartTelemeteryHandler(service, operation string, traceContext RequestSpanContext,
+	noRootSpan bool) *opTelemetryHandler {
 	return &opTelemetryHandler{
-		tracer:            tc.CreateOpTrace(operation, traceContext),
+		tracer:            tc.CreateOpTrace(operation, traceContext, noRootSpan),
 		service:           service,
 		operation:         operation,
 		start:             time.Now(),
@@ -356,7 +384,13 @@ func (oth *opTelemetryHandler) StartTime() time.Time {
 	return oth.start
 }
 
+// Finish completes the telemetry for an operation which succeeded.
 func (oth *opTelemetryHandler) Finish() {
+	oth.FinishWithError(nil)
+}
+
+// FinishWithError completes the telemetry for an operation, which failed if err is not nil.
+func (oth *opTelemetryHandler) FinishWithError(err error) {
 	oth.tracer.Finish()
-	oth.metricsCompleteFn(oth.service, oth.operation, oth.start)
+	oth.metricsCompleteFn(oth.service, oth.operation, oth.start, err)
 }
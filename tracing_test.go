@@ -3,6 +3,7 @@ package gocbcore
 import (
 	"github.com/couchbase/gocbcore/v10/memd"
 	"github.com/stretchr/testify/mock"
+	"sync"
 	"time"
 )
 
@@ -113,9 +114,9 @@ func (suite *StandardTestSuite) AssertCmdSpansGE(parents map[RequestSpanContext]
 
 func (suite *StandardTestSuite) AssertCmdSpan(span *testSpan, expectedName string) {
 	suite.Assert().Equal(expectedName, span.Name)
-	suite.Assert().Equal(2, len(span.Tags))
 	suite.Assert().True(span.Finished)
 	suite.Assert().Equal("couchbase", span.Tags["db.system"])
+	suite.Assert().Equal(globalTestConfig.BucketName, span.Tags["db.name"])
 	suite.Assert().Contains(span.Tags, "db.couchbase.retries")
 
 	suite.AssertNetSpansEq(span.Spans, 1)
@@ -265,3 +266,75 @@ func (suite *StandardTestSuite) TestTracerComponentTracksClusterLabels() {
 	suite.Assert().Equal("test-cluster", tc.ClusterLabels().ClusterName)
 	suite.Assert().Equal("48d5d855660452102a8c279dc6155e01", tc.ClusterLabels().ClusterUUID)
 }
+
+func (suite *UnitTestSuite) TestTracerComponentCmdSpanKeyspaceAttributes() {
+	tracer := newTestTracer()
+	tc := newTracerComponent(tracer, "default", true, &noopMeter{}, nil)
+
+	req := &memdQRequest{
+		Packet: memd.Packet{
+			Command: memd.CmdGet,
+		},
+		RootTraceContext: "parent",
+		ScopeName:        "inventory",
+		CollectionName:   "airline",
+	}
+	tc.StartCmdTrace(req)
+
+	spans := tracer.Spans["parent"]
+	suite.Require().Len(spans, 1)
+	suite.Assert().Equal(memd.CmdGet.Name(), spans[0].Name)
+	suite.Assert().Equal("default", spans[0].Tags["db.name"])
+	suite.Assert().Equal("inventory", spans[0].Tags["db.couchbase.scope"])
+	suite.Assert().Equal("airline", spans[0].Tags["db.couchbase.collection"])
+}
+
+func (suite *UnitTestSuite) TestTracerComponentPerOperationNoRootSpan() {
+	tracer := newTestTracer()
+	tc := newTracerComponent(tracer, "default", false, &noopMeter{}, nil)
+
+	handler := tc.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", "parent", true)
+	suite.Assert().Equal("parent", handler.RootContext())
+	handler.Finish()
+	suite.Assert().Empty(tracer.Spans)
+
+	handler = tc.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", "parent", false)
+	handler.Finish()
+
+	spans := tracer.Spans["parent"]
+	suite.Require().Len(spans, 1)
+	suite.Assert().Equal("Get", spans[0].Name)
+	suite.Assert().True(spans[0].Finished)
+}
+
+type testOutcomeMeter struct {
+	noopMeter
+	lock     sync.Mutex
+	outcomes []string
+}
+
+func (tm *testOutcomeMeter) ValueRecorder(name string, tags map[string]string) (ValueRecorder, error) {
+	tm.lock.Lock()
+	tm.outcomes = append(tm.outcomes, tags[metricAttribOutcomeKey])
+	tm.lock.Unlock()
+	return defaultNoopValueRecorder, nil
+}
+
+func (suite *UnitTestSuite) TestTracerComponentOutcome() {
+	meter := &testOutcomeMeter{}
+	tc := newTracerComponent(noopTracer{}, "default", true, meter, nil)
+
+	tc.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", nil, false).Finish()
+	tc.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", nil, false).FinishWithError(nil)
+	tc.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", nil, false).FinishWithError(errUnambiguousTimeout)
+	tc.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", nil, false).FinishWithError(errRequestCanceled)
+	tc.StartTelemeteryHandler(metricValueServiceKeyValue, "Get", nil, false).FinishWithError(errDocumentNotFound)
+
+	suite.Assert().Equal([]string{
+		metricValueOutcomeSuccess,
+		metricValueOutcomeSuccess,
+		metricValueOutcomeTimeout,
+		metricValueOutcomeCanceled,
+		metricValueOutcomeError,
+	}, meter.outcomes)
+}
@@ -1,10 +1,12 @@
 package gocbcore
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 func getMapValueString(dict map[string]interface{}, key string, def string) string {
@@ -72,3 +74,41 @@ func trimSchemePrefix(address string) string {
 
 	return address[idx+len("://"):]
 }
+
+// deadlineFromContext returns the deadline of ctx if no deadline has been set.
+func deadlineFromContext(deadline time.Time, ctx context.Context) time.Time {
+	if !deadline.IsZero() || ctx == nil {
+		return deadline
+	}
+
+	if ctxDeadline, ok := ctx.Deadline(); ok {
+		return ctxDeadline
+	}
+
+	return deadline
+}
+
+// contextOrBackground returns ctx, or context.Background if ctx is nil.
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+
+	return ctx
+}
+
+// deadlineFromTimeout returns the deadline to apply to an operation. An explicitly provided deadline always wins,
+// otherwise if a timeout is provided then the deadline is computed relative to now.
+func deadlineFromTimeout(deadline time.Time, timeout time.Duration) time.Time {
+	if !deadline.IsZero() || timeout <= 0 {
+		return deadline
+	}
+
+	return time.Now().Add(timeout)
+}
+
+// deadlineFromTimeouts returns the deadline to apply to an operation, falling back to the default timeout configured
+// for the service when the operation provides neither a deadline nor a timeout.
+func deadlineFromTimeouts(deadline time.Time, timeout, defaultTimeout time.Duration) time.Time {
+	return deadlineFromTimeout(deadlineFromTimeout(deadline, timeout), defaultTimeout)
+}
@@ -2,6 +2,7 @@ package gocbcore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -40,3 +41,15 @@ func TestTrimSchemePrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestDeadlineFromTimeout(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+	require.Equal(t, deadline, deadlineFromTimeout(deadline, time.Second))
+	require.Equal(t, deadline, deadlineFromTimeout(deadline, 0))
+	require.True(t, deadlineFromTimeout(time.Time{}, 0).IsZero())
+
+	before := time.Now()
+	computed := deadlineFromTimeout(time.Time{}, time.Minute)
+	require.False(t, computed.Before(before.Add(time.Minute)))
+	require.False(t, computed.After(time.Now().Add(time.Minute)))
+}
@@ -14,6 +14,9 @@ import (
 // ViewQueryRowReader providers access to the rows of a view query
 type ViewQueryRowReader struct {
 	streamer *queryStreamer
+	ddoc     string
+	view     string
+	endpoint string
 }
 
 // NextRow reads the next rows bytes from the stream
@@ -21,9 +24,41 @@ func (q *ViewQueryRowReader) NextRow() []byte {
 	return q.streamer.NextRow()
 }
 
-// Err returns any errors that occurred during streaming.
+// Err returns any errors that occurred during streaming. This includes any errors which were returned by the
+// server alongside the rows, such as when some nodes were unable to produce their results.
 func (q ViewQueryRowReader) Err() error {
-	return q.streamer.Err()
+	err := q.streamer.Err()
+	if err != nil {
+		return err
+	}
+
+	// The meta-data, and so any errors embedded in it, is only available once the stream has been read to completion.
+	// Until then, or if the reader was closed early, there is nothing further to report.
+	meta, metaErr := q.streamer.MetaData()
+	if metaErr != nil {
+		return nil
+	}
+
+	return parseViewQueryMetaDataError(q.ddoc, q.view, q.endpoint, meta)
+}
+
+// TotalRows returns the total number of rows in the view, as reported by the server. Like MetaData this is only
+// available once all rows have been read.
+// Volatile: This API is subject to change at any time.
+func (q *ViewQueryRowReader) TotalRows() (uint64, error) {
+	meta, err := q.streamer.MetaData()
+	if err != nil {
+		return 0, err
+	}
+
+	var metaData struct {
+		TotalRows uint64 `json:"total_rows"`
+	}
+	if err := json.Unmarshal(meta, &metaData); err != nil {
+		return 0, wrapViewQueryError(nil, q.ddoc, q.view, wrapError(err, "failed to parse meta-data"), "", 0)
+	}
+
+	return metaData.TotalRows, nil
 }
 
 // MetaData fetches the non-row bytes streamed in the response.
@@ -44,11 +79,17 @@ type ViewQueryOptions struct {
 	Options            url.Values
 	RetryStrategy      RetryStrategy
 	Deadline           time.Time
+	Timeout            time.Duration
+
+	// Context, if set, cancels the query when it is done. If Deadline is not set then the deadline of the context
+	// is used instead.
+	Context context.Context
 
 	// Internal: This should never be used and is not supported.
 	User string
 
 	TraceContext RequestSpanContext
+	NoRootSpan   bool
 }
 
 func wrapViewQueryError(req *httpRequest, ddoc, view string, err error, errBody string, statusCode int) *ViewError {
@@ -125,26 +166,78 @@ func parseViewQueryError(req *httpRequest, ddoc, view string, resp *HTTPResponse
 	return errOut
 }
 
+// parseViewQueryMetaDataError returns an error for any errors which were sent in the meta-data of a successful
+// view query response.
+func parseViewQueryMetaDataError(ddoc, view, endpoint string, meta []byte) error {
+	var metaData struct {
+		Errors []struct {
+			From   string `json:"from"`
+			Reason string `json:"reason"`
+		} `json:"errors"`
+		Error  string `json:"error"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(meta, &metaData); err != nil {
+		return wrapViewQueryError(nil, ddoc, view, wrapError(err, "failed to parse meta-data"), string(meta), 200)
+	}
+
+	var errorDescs []ViewQueryErrorDesc
+	for _, desc := range metaData.Errors {
+		errorDescs = append(errorDescs, ViewQueryErrorDesc{
+			SourceNode: desc.From,
+			Message:    desc.Reason,
+		})
+	}
+	if metaData.Error != "" {
+		errorDescs = append(errorDescs, ViewQueryErrorDesc{
+			Message: metaData.Error + ": " + metaData.Reason,
+		})
+	}
+
+	if len(errorDescs) == 0 {
+		return nil
+	}
+
+	var err error
+	if strings.Contains(errorDescs[0].Message, "not_found") {
+		err = errViewNotFound
+	}
+
+	errOut := wrapViewQueryError(nil, ddoc, view, err, string(meta), 200)
+	errOut.Endpoint = endpoint
+	errOut.Errors = errorDescs
+	return errOut
+}
+
 type viewQueryComponent struct {
-	httpComponent *httpComponent
-	tracer        *tracerComponent
+	httpComponent  *httpComponent
+	tracer         *tracerComponent
+	defaultTimeout time.Duration
+	logger         *scopedLogger
 }
 
-func newViewQueryComponent(httpComponent *httpComponent, tracer *tracerComponent) *viewQueryComponent {
+func newViewQueryComponent(httpComponent *httpComponent, tracer *tracerComponent, defaultTimeout time.Duration,
+	logger *scopedLogger) *viewQueryComponent {
 	return &viewQueryComponent{
-		httpComponent: httpComponent,
-		tracer:        tracer,
+		httpComponent:  httpComponent,
+		tracer:         tracer,
+		defaultTimeout: defaultTimeout,
+		logger:         logger,
 	}
 }
 
 // ViewQuery executes a view query
 func (vqc *viewQueryComponent) ViewQuery(opts ViewQueryOptions, cb ViewQueryCallback) (PendingOp, error) {
-	tracer := vqc.tracer.StartTelemeteryHandler(metricValueServiceViewsValue, "ViewQuery", opts.TraceContext)
+	opts.Deadline = deadlineFromContext(opts.Deadline, opts.Context)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, opts.Timeout)
+	opts.Deadline = deadlineFromTimeout(opts.Deadline, vqc.defaultTimeout)
+
+	tracer := vqc.tracer.StartTelemeteryHandler(metricValueServiceViewsValue, "ViewQuery", opts.TraceContext, opts.NoRootSpan)
 
 	reqURI := fmt.Sprintf("/_design/%s/%s/%s?%s",
 		opts.DesignDocumentName, opts.ViewType, opts.ViewName, opts.Options.Encode())
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(contextOrBackground(opts.Context))
 	ireq := &httpRequest{
 		Service:          CapiService,
 		Method:           "GET",
@@ -165,7 +258,7 @@ func (vqc *viewQueryComponent) ViewQuery(opts ViewQueryOptions, cb ViewQueryCall
 		res, err := vqc.viewQuery(ireq, ddoc, view)
 		if err != nil {
 			cancel()
-			tracer.Finish()
+			tracer.FinishWithError(err)
 			cb(nil, err)
 			return
 		}
@@ -199,12 +292,15 @@ func (vqc *viewQueryComponent) viewQuery(ireq *httpRequest, ddoc, view string) (
 	if err != nil {
 		respBody, readErr := ioutil.ReadAll(resp.Body)
 		if readErr != nil {
-			logDebugf("Failed to read response body: %v", readErr)
+			vqc.logger.debugf("Failed to read response body: %v", readErr)
 		}
 		return nil, wrapViewQueryError(ireq, ddoc, view, err, string(respBody), resp.StatusCode)
 	}
 
 	return &ViewQueryRowReader{
 		streamer: streamer,
+		ddoc:     ddoc,
+		view:     view,
+		endpoint: resp.Endpoint,
 	}, nil
 }
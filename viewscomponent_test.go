@@ -0,0 +1,65 @@
+package gocbcore
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+)
+
+func (suite *UnitTestSuite) newTestViewQueryRowReader(body string) *ViewQueryRowReader {
+	streamer, err := newQueryStreamer(ioutil.NopCloser(bytes.NewBufferString(body)), "rows")
+	suite.Require().Nil(err, err)
+
+	return &ViewQueryRowReader{
+		streamer: streamer,
+		ddoc:     "ddoc",
+		view:     "view",
+		endpoint: "http://10.112.210.101:8092",
+	}
+}
+
+func (suite *UnitTestSuite) TestViewQueryRowReader() {
+	reader := suite.newTestViewQueryRowReader(`{"total_rows":3,"rows":[` +
+		`{"id":"a","key":"a","value":1},{"id":"b","key":"b","value":2}]}`)
+
+	var rows []string
+	for row := reader.NextRow(); row != nil; row = reader.NextRow() {
+		rows = append(rows, string(row))
+		suite.Require().Nil(reader.Err())
+	}
+	suite.Assert().Equal([]string{`{"id":"a","key":"a","value":1}`, `{"id":"b","key":"b","value":2}`}, rows)
+	suite.Require().Nil(reader.Err())
+
+	totalRows, err := reader.TotalRows()
+	suite.Require().Nil(err, err)
+	suite.Assert().Equal(uint64(3), totalRows)
+}
+
+func (suite *UnitTestSuite) TestViewQueryRowReaderEmbeddedErrors() {
+	reader := suite.newTestViewQueryRowReader(`{"total_rows":1,"rows":[{"id":"a","key":"a","value":1}],` +
+		`"errors":[{"from":"10.112.210.102:8092","reason":"timeout"}]}`)
+
+	suite.Assert().NotNil(reader.NextRow())
+	suite.Assert().Nil(reader.NextRow())
+
+	err := reader.Err()
+	var viewErr *ViewError
+	suite.Require().True(errors.As(err, &viewErr), err)
+	suite.Assert().Equal("ddoc", viewErr.DesignDocumentName)
+	suite.Assert().Equal("view", viewErr.ViewName)
+	suite.Assert().Equal("http://10.112.210.101:8092", viewErr.Endpoint)
+	suite.Assert().Equal([]ViewQueryErrorDesc{{SourceNode: "10.112.210.102:8092", Message: "timeout"}}, viewErr.Errors)
+
+	reader = suite.newTestViewQueryRowReader(`{"rows":[],"error":"not_found","reason":"missing"}`)
+	suite.Assert().Nil(reader.NextRow())
+	suite.Assert().True(errors.Is(reader.Err(), ErrViewNotFound))
+}
+
+func (suite *UnitTestSuite) TestViewQueryRowReaderClosedEarly() {
+	reader := suite.newTestViewQueryRowReader(`{"total_rows":2,"rows":[` +
+		`{"id":"a","key":"a","value":1},{"id":"b","key":"b","value":2}]}`)
+
+	suite.Require().NotNil(reader.NextRow())
+	suite.Require().Nil(reader.Close())
+	suite.Assert().Nil(reader.Err())
+}
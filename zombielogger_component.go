@@ -8,15 +8,41 @@ import (
 	"time"
 )
 
+// zombieLoggerMaxCancelledOps is the maximum number of cancelled requests which are tracked so that the total
+// duration of their responses can be reported.
+const zombieLoggerMaxCancelledOps = 1024
+
 type zombieLogEntry struct {
 	connectionID  string
 	operationID   string
 	remoteSocket  string
 	localSocket   string
 	duration      time.Duration
+	totalDuration time.Duration
 	operationName string
 }
 
+// ZombieLogEntry represents a single orphaned response which has been recorded by the orphan reporter.
+type ZombieLogEntry struct {
+	ConnectionID   string
+	OperationID    string
+	RemoteSocket   string
+	LocalSocket    string
+	ServerDuration time.Duration
+	// TotalDuration is the time between the request being dispatched and the orphaned response being received,
+	// this is zero if the dispatch time of the request is not known.
+	TotalDuration time.Duration
+	OperationName string
+}
+
+// ZombieLoggerCallback is invoked by the orphan reporter with each sampled batch of orphaned responses, slowest first.
+type ZombieLoggerCallback func([]ZombieLogEntry)
+
+type zombieCancelledOpKey struct {
+	connectionID string
+	opaque       uint32
+}
+
 type zombieLogItem struct {
 	ConnectionID     string `json:"last_local_id"`
 	OperationID      string `json:"operation_id"`
@@ -34,50 +60,75 @@ type zombieLogJsonEntry struct {
 type zombieLogService map[string]zombieLogJsonEntry
 
 type zombieLoggerComponent struct {
-	zombieLock sync.RWMutex
-	zombieOps  []*zombieLogEntry
-	interval   time.Duration
-	sampleSize int
-	stopSig    chan struct{}
+	zombieLock  sync.RWMutex
+	zombieOps   []*zombieLogEntry
+	interval    time.Duration
+	sampleSize  int
+	callback    ZombieLoggerCallback
+	stopSig     chan struct{}
+	reconfigSig chan struct{}
+	logger      *scopedLogger
+
+	cancelledLock sync.Mutex
+	cancelledOps  map[zombieCancelledOpKey]time.Time
 }
 
-func newZombieLoggerComponent(interval time.Duration, sampleSize int) *zombieLoggerComponent {
+func newZombieLoggerComponent(interval time.Duration, sampleSize int, callback ZombieLoggerCallback,
+	logger *scopedLogger) *zombieLoggerComponent {
 	return &zombieLoggerComponent{
-		// zombieOps must have a static capacity for its lifetime, the capacity should
-		// never be altered so that it is consistent across the zombieLogger and
-		// recordZombieResponse.
-		zombieOps:  make([]*zombieLogEntry, 0, sampleSize),
-		interval:   interval,
-		sampleSize: sampleSize,
-		stopSig:    make(chan struct{}),
+		// zombieOps must have a static capacity, the capacity should only ever be
+		// altered under the write lock so that it is consistent across the zombieLogger
+		// and recordZombieResponse.
+		zombieOps:    make([]*zombieLogEntry, 0, sampleSize),
+		interval:     interval,
+		sampleSize:   sampleSize,
+		callback:     callback,
+		stopSig:      make(chan struct{}),
+		reconfigSig:  make(chan struct{}, 1),
+		cancelledOps: make(map[zombieCancelledOpKey]time.Time),
+		logger:       logger,
 	}
 }
 
 func (zlc *zombieLoggerComponent) Start() {
-	lastTick := time.Now()
-
 	for {
+		zlc.zombieLock.RLock()
+		interval := zlc.interval
+		zlc.zombieLock.RUnlock()
+
+		// A zero interval means that emission is disabled until we are reconfigured.
+		var tick <-chan time.Time
+		if interval > 0 {
+			tick = time.After(interval)
+		}
+
 		select {
 		case <-zlc.stopSig:
 			return
-		case <-time.After(zlc.interval):
+		case <-zlc.reconfigSig:
+			continue
+		case <-tick:
 		}
 
-		lastTick = lastTick.Add(zlc.interval)
+		if zlc.callback != nil {
+			entries := zlc.Drain()
+			if len(entries) > 0 {
+				zlc.callback(entries)
+			}
+			continue
+		}
 
 		jsonBytes := zlc.createOutput()
 		if len(jsonBytes) == 0 {
 			continue
 		}
 
-		logWarnf("Orphaned responses observed:\n %s", jsonBytes)
+		zlc.logger.warnf("Orphaned responses observed:\n %s", jsonBytes)
 	}
 }
 
-func (zlc *zombieLoggerComponent) createOutput() []byte {
-	// Preallocate space to copy the ops into...
-	oldOps := make([]*zombieLogEntry, zlc.sampleSize)
-
+// takeEntries removes all currently buffered entries, returning them ordered from slowest to fastest.
+func (zlc *zombieLoggerComponent) takeEntries() []*zombieLogEntry {
 	zlc.zombieLock.Lock()
 	// Escape early if we have no ops to log...
 	if len(zlc.zombieOps) == 0 {
@@ -88,20 +139,32 @@ func (zlc *zombieLoggerComponent) createOutput() []byte {
 	// Copy out our ops so we can cheaply print them out without blocking
 	// our ops from actually being recorded in other goroutines (which would
 	// effectively slow down the op pipeline for logging).
-	oldOps = oldOps[0:len(zlc.zombieOps)]
+	oldOps := make([]*zombieLogEntry, len(zlc.zombieOps))
 	copy(oldOps, zlc.zombieOps)
 	zlc.zombieOps = zlc.zombieOps[:0]
 
 	zlc.zombieLock.Unlock()
 
+	// zombieOps is stored fastest first, reverse it so that the slowest ops come first.
+	for i, j := 0, len(oldOps)-1; i < j; i, j = i+1, j-1 {
+		oldOps[i], oldOps[j] = oldOps[j], oldOps[i]
+	}
+
+	return oldOps
+}
+
+func (zlc *zombieLoggerComponent) createOutput() []byte {
+	oldOps := zlc.takeEntries()
+	if len(oldOps) == 0 {
+		return nil
+	}
+
 	entries := zombieLogJsonEntry{
 		Top: make([]zombieLogItem, len(oldOps)),
 	}
 
-	for i := 0; i < len(oldOps); i++ {
-		op := oldOps[i]
-
-		entries.Top[len(oldOps)-i-1] = zombieLogItem{
+	for i, op := range oldOps {
+		entries.Top[i] = zombieLogItem{
 			OperationID:      op.operationID,
 			ConnectionID:     op.connectionID,
 			RemoteSocket:     op.remoteSocket,
@@ -117,16 +180,91 @@ func (zlc *zombieLoggerComponent) createOutput() []byte {
 		"kv": entries,
 	})
 	if err != nil {
-		logDebugf("Failed to generate zombie logging JSON: %s", err)
+		zlc.logger.debugf("Failed to generate zombie logging JSON: %s", err)
 	}
 
 	return jsonBytes
 }
 
+// Drain removes and returns all currently buffered orphaned responses, slowest first.
+func (zlc *zombieLoggerComponent) Drain() []ZombieLogEntry {
+	oldOps := zlc.takeEntries()
+	if len(oldOps) == 0 {
+		return nil
+	}
+
+	entries := make([]ZombieLogEntry, len(oldOps))
+	for i, op := range oldOps {
+		entries[i] = ZombieLogEntry{
+			ConnectionID:   op.connectionID,
+			OperationID:    op.operationID,
+			RemoteSocket:   op.remoteSocket,
+			LocalSocket:    op.localSocket,
+			ServerDuration: op.duration,
+			TotalDuration:  op.totalDuration,
+			OperationName:  op.operationName,
+		}
+	}
+
+	return entries
+}
+
 func (zlc *zombieLoggerComponent) Stop() {
 	close(zlc.stopSig)
 }
 
+// Reconfigure swaps the interval and sample size used by the running logger. A zero interval disables emission
+// until the logger is reconfigured with a non-zero interval.
+func (zlc *zombieLoggerComponent) Reconfigure(interval time.Duration, sampleSize int) {
+	zlc.zombieLock.Lock()
+	zlc.interval = interval
+	if sampleSize != zlc.sampleSize {
+		// zombieOps is stored fastest first, so keep the slowest of the currently buffered ops.
+		ops := make([]*zombieLogEntry, 0, sampleSize)
+		if len(zlc.zombieOps) > sampleSize {
+			ops = append(ops, zlc.zombieOps[len(zlc.zombieOps)-sampleSize:]...)
+		} else {
+			ops = append(ops, zlc.zombieOps...)
+		}
+		zlc.zombieOps = ops
+		zlc.sampleSize = sampleSize
+	}
+	zlc.zombieLock.Unlock()
+
+	// Wake the logger so that the new interval takes effect immediately.
+	select {
+	case zlc.reconfigSig <- struct{}{}:
+	default:
+	}
+}
+
+// RecordCancelledRequest records the dispatch time of a request which has been cancelled whilst in flight, so that the
+// total duration can be reported if a response for it is later received.
+func (zlc *zombieLoggerComponent) RecordCancelledRequest(connID string, opaque uint32, dispatchTime time.Time) {
+	if dispatchTime.IsZero() {
+		return
+	}
+
+	zlc.cancelledLock.Lock()
+	if len(zlc.cancelledOps) >= zombieLoggerMaxCancelledOps {
+		// Responses are never received for some cancelled requests, start again rather than growing unbounded.
+		zlc.cancelledOps = make(map[zombieCancelledOpKey]time.Time)
+	}
+	zlc.cancelledOps[zombieCancelledOpKey{connectionID: connID, opaque: opaque}] = dispatchTime
+	zlc.cancelledLock.Unlock()
+}
+
+func (zlc *zombieLoggerComponent) takeCancelledRequest(connID string, opaque uint32) time.Time {
+	key := zombieCancelledOpKey{connectionID: connID, opaque: opaque}
+
+	zlc.cancelledLock.Lock()
+	dispatchTime := zlc.cancelledOps[key]
+	delete(zlc.cancelledOps, key)
+	zlc.cancelledLock.Unlock()
+
+	return dispatchTime
+}
+
 func (zlc *zombieLoggerComponent) RecordZombieResponse(resp *memdQResponse, connID, localAddr, remoteAddr string) {
 	entry := &zombieLogEntry{
 		connectionID:  connID,
@@ -141,6 +279,10 @@ func (zlc *zombieLoggerComponent) RecordZombieResponse(resp *memdQResponse, conn
 		entry.duration = resp.Packet.ServerDurationFrame.ServerDuration
 	}
 
+	if dispatchTime := zlc.takeCancelledRequest(connID, resp.Opaque); !dispatchTime.IsZero() {
+		entry.totalDuration = time.Since(dispatchTime)
+	}
+
 	zlc.zombieLock.RLock()
 
 	if cap(zlc.zombieOps) == 0 || (len(zlc.zombieOps) == cap(zlc.zombieOps) &&
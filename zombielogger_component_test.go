@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/couchbase/gocbcore/v10/memd"
+	"sync"
 	"time"
 )
 
@@ -66,7 +67,7 @@ func (suite *UnitTestSuite) TestZombieLoggerComponent() {
 		},
 	}
 
-	z := newZombieLoggerComponent(1*time.Second, 4)
+	z := newZombieLoggerComponent(1*time.Second, 4, nil, nil)
 	go z.Start()
 	for _, r := range responses {
 		z.RecordZombieResponse(r, "9a1e99041b33322b/54cf79f08d852738", "10.112.210.1", "10.112.210.101")
@@ -139,3 +140,121 @@ func (suite *UnitTestSuite) TestZombieLoggerComponent() {
 
 	suite.Assert().Equal(expectedJsonOutput, []byte(mapInnerOutput["top_requests"]), fmt.Sprintf("Expected output to be %s but was %s", string(expectedJsonOutput), string(mapInnerOutput["top_requests"])))
 }
+
+func (suite *UnitTestSuite) TestZombieLoggerComponentDrain() {
+	z := newZombieLoggerComponent(1*time.Second, 2, nil, nil)
+	durations := []time.Duration{1100 * time.Microsecond, 3000 * time.Microsecond, 2000 * time.Microsecond}
+	for i, d := range durations {
+		z.RecordZombieResponse(&memdQResponse{
+			Packet: &memd.Packet{
+				Command: memd.CmdGet,
+				Opaque:  uint32(i),
+				ServerDurationFrame: &memd.ServerDurationFrame{
+					ServerDuration: d,
+				},
+			},
+		}, "9a1e99041b33322b/54cf79f08d852738", "10.112.210.1", "10.112.210.101")
+	}
+
+	entries := z.Drain()
+	suite.Require().Len(entries, 2)
+	suite.Assert().Equal(ZombieLogEntry{
+		ConnectionID:   "9a1e99041b33322b/54cf79f08d852738",
+		OperationID:    "0x1",
+		RemoteSocket:   "10.112.210.101",
+		LocalSocket:    "10.112.210.1",
+		ServerDuration: 3000 * time.Microsecond,
+		OperationName:  memd.CmdGet.Name(),
+	}, entries[0])
+	suite.Assert().Equal(2000*time.Microsecond, entries[1].ServerDuration)
+
+	// Drained entries must not be reported again.
+	suite.Assert().Empty(z.Drain())
+	suite.Assert().Empty(z.createOutput())
+}
+
+func (suite *UnitTestSuite) TestZombieLoggerComponentCallback() {
+	entriesCh := make(chan []ZombieLogEntry, 1)
+	z := newZombieLoggerComponent(10*time.Millisecond, 4, func(entries []ZombieLogEntry) {
+		entriesCh <- entries
+	}, nil)
+
+	z.RecordCancelledRequest("9a1e99041b33322b/54cf79f08d852738", 23, time.Now().Add(-50*time.Millisecond))
+	z.RecordZombieResponse(&memdQResponse{
+		Packet: &memd.Packet{
+			Command: memd.CmdReplace,
+			Opaque:  23,
+			ServerDurationFrame: &memd.ServerDurationFrame{
+				ServerDuration: 2100 * time.Microsecond,
+			},
+		},
+	}, "9a1e99041b33322b/54cf79f08d852738", "10.112.210.1", "10.112.210.101")
+
+	go z.Start()
+	defer z.Stop()
+
+	var entries []ZombieLogEntry
+	select {
+	case entries = <-entriesCh:
+	case <-time.After(time.Second):
+		suite.T().Fatalf("Timed out waiting for callback")
+	}
+
+	suite.Require().Len(entries, 1)
+	suite.Assert().Equal("9a1e99041b33322b/54cf79f08d852738", entries[0].ConnectionID)
+	suite.Assert().Equal(memd.CmdReplace.Name(), entries[0].OperationName)
+	suite.Assert().Equal(2100*time.Microsecond, entries[0].ServerDuration)
+	suite.Assert().GreaterOrEqual(int64(entries[0].TotalDuration), int64(50*time.Millisecond))
+}
+
+func (suite *UnitTestSuite) TestZombieLoggerComponentReconfigure() {
+	entriesCh := make(chan []ZombieLogEntry, 10)
+	z := newZombieLoggerComponent(0, 4, func(entries []ZombieLogEntry) {
+		entriesCh <- entries
+	}, nil)
+	go z.Start()
+	defer z.Stop()
+
+	record := func(opaque uint32, d time.Duration) {
+		z.RecordZombieResponse(&memdQResponse{
+			Packet: &memd.Packet{
+				Command: memd.CmdGet,
+				Opaque:  opaque,
+				ServerDurationFrame: &memd.ServerDurationFrame{
+					ServerDuration: d,
+				},
+			},
+		}, "9a1e99041b33322b/54cf79f08d852738", "10.112.210.1", "10.112.210.101")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			record(uint32(i), time.Duration(i+1)*time.Millisecond)
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	// A zero interval must not emit anything.
+	select {
+	case <-entriesCh:
+		suite.T().Fatalf("Callback should not have been invoked with a zero interval")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Shrinking the sample size must keep the slowest ops.
+	z.Reconfigure(10*time.Millisecond, 2)
+
+	var entries []ZombieLogEntry
+	select {
+	case entries = <-entriesCh:
+	case <-time.After(time.Second):
+		suite.T().Fatalf("Timed out waiting for callback")
+	}
+
+	suite.Require().Len(entries, 2)
+	suite.Assert().Equal(4*time.Millisecond, entries[0].ServerDuration)
+	suite.Assert().Equal(3*time.Millisecond, entries[1].ServerDuration)
+}